@@ -0,0 +1,48 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/labstack/echo"
+	"github.com/sirupsen/logrus"
+)
+
+// commandReply collects response of a command handled without http request
+type commandReply struct {
+	header http.Header
+	body   bytes.Buffer
+}
+
+func (w *commandReply) Header() http.Header         { return w.header }
+func (w *commandReply) Write(b []byte) (int, error) { return w.body.Write(b) }
+func (w *commandReply) WriteHeader(int)             {}
+
+// HandleCommand handles slash command which was sent as a chat message on platform,
+// e.g. in Telegram. Form has the same fields as Slack payload, reply text is returned
+func (r *REST) HandleCommand(platform string, form url.Values) (text string) {
+	// net/http is not there to recover a panic of the handler, it would stop the chat loop
+	defer func() {
+		if p := recover(); p != nil {
+			logrus.Errorf("rest: command %v panicked: %v\n", form.Get("command"), p)
+			text = fmt.Sprintf("%v failed", form.Get("command"))
+		}
+	}()
+	req, err := http.NewRequest(echo.POST, "/commands", strings.NewReader(form.Encode()))
+	if err != nil {
+		logrus.Errorf("rest: NewRequest failed: %v\n", err)
+		return ""
+	}
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+	reply := &commandReply{header: http.Header{}}
+	c := r.echo.NewContext(req, reply)
+	c.Set("platform", platform)
+	if err := r.handleCommands(c); err != nil {
+		logrus.Errorf("rest: handleCommands failed: %v\n", err)
+		return err.Error()
+	}
+	return reply.body.String()
+}
@@ -0,0 +1,546 @@
+package api
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/maddevsio/comedian/calendar"
+	"github.com/maddevsio/comedian/collector"
+	"github.com/maddevsio/comedian/model"
+	"github.com/nlopes/slack"
+	"github.com/sirupsen/logrus"
+)
+
+// Dashboard sessions
+const (
+	sessionCookie = "comedian_session"
+	stateCookie   = "comedian_oauth_state"
+	sessionTTL    = 12 * time.Hour
+	monthFormat   = "2006-01"
+)
+
+// Statuses of days in channel calendar
+const (
+	dayDone    = "done"
+	dayMissed  = "missed"
+	dayAbsent  = "absent"
+	dayOff     = "off"
+	dayFuture  = "future"
+	dayOutside = "outside"
+)
+
+// slackAuthorizeURL is the page where users allow Comedian to know who they are
+var slackAuthorizeURL = "https://slack.com/oauth/authorize"
+
+// diffToken splits text into words and runs of whitespace
+var diffToken = regexp.MustCompile(`\s+|\S+`)
+
+type (
+	// dashboardMetrics is collector data shown on dashboard, Unavailable is set
+	// if collector failed and pages are shown without its data
+	dashboardMetrics struct {
+		Commits     int
+		Merges      int
+		Reviews     int
+		Hours       int
+		Unavailable bool
+	}
+
+	// calendarRow is a month of standuper in channel calendar
+	calendarRow struct {
+		User   model.StandupUser
+		Days   []calendarDay
+		Done   int
+		Missed int
+	}
+
+	// calendarDay is a day of calendar row, Status is one of day* constants
+	calendarDay struct {
+		Date   string
+		Status string
+	}
+
+	// standupVersions is a standup with its edits, the oldest first
+	standupVersions struct {
+		Standup  model.Standup
+		Original string
+		Edits    []standupEdit
+	}
+
+	// standupEdit is a change of standup text made at Created
+	standupEdit struct {
+		Created time.Time
+		Parts   []diffPart
+	}
+
+	// diffPart is a piece of text kept, deleted ("del") or inserted ("ins") by an edit
+	diffPart struct {
+		Op   string
+		Text string
+	}
+
+	// monthPage is common data of channel and user pages
+	monthPage struct {
+		UserID   string
+		Channel  apiChannel
+		Month    time.Time
+		Previous string
+		Next     string
+		Metrics  dashboardMetrics
+	}
+)
+
+// initDashboard mounts web dashboard for the manager and channel admins who sign in with Slack,
+// it is not mounted if there is no key to sign sessions with
+func (r *REST) initDashboard() error {
+	key := []byte(r.conf.DashboardSecret)
+	if len(key) == 0 {
+		logrus.Warning("rest: dashboard secret is not set, dashboard sessions end when Comedian restarts")
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return err
+		}
+	}
+	r.sessionKey = key
+	r.echo.GET("/dashboard/login", r.dashboardLogin)
+	r.echo.GET("/dashboard/oauth", r.dashboardOAuth)
+	r.echo.GET("/dashboard/logout", r.dashboardLogout)
+	r.echo.GET("/dashboard", r.dashboardChannels, r.authorizeSession)
+	r.echo.GET("/dashboard/channels/:channel_id", r.dashboardChannel, r.authorizeSession)
+	r.echo.GET("/dashboard/channels/:channel_id/users/:user_id", r.dashboardUser, r.authorizeSession)
+	return nil
+}
+
+// authorizeSession lets in users with a valid session cookie, others are sent to sign in
+func (r *REST) authorizeSession(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		cookie, err := c.Cookie(sessionCookie)
+		if err == nil {
+			if userID, ok := r.checkSession(cookie.Value, time.Now()); ok {
+				c.Set("user_id", userID)
+				return next(c)
+			}
+		}
+		return c.Redirect(http.StatusFound, "/dashboard/login")
+	}
+}
+
+// dashboardLogin sends user to Slack to sign in
+func (r *REST) dashboardLogin(c echo.Context) error {
+	state, err := newToken()
+	if err != nil {
+		logrus.Errorf("rest: newToken failed: %v\n", err)
+		return r.dashboardError(c, http.StatusInternalServerError, "Sign in failed, try again")
+	}
+	c.SetCookie(&http.Cookie{
+		Name:     stateCookie,
+		Value:    state,
+		Path:     "/dashboard",
+		Expires:  time.Now().Add(10 * time.Minute),
+		HttpOnly: true,
+		Secure:   r.secureCookies(c),
+	})
+	q := url.Values{
+		"client_id":    {r.conf.SlackClientID},
+		"scope":        {"identity.basic"},
+		"redirect_uri": {r.redirectURI(c)},
+		"state":        {state},
+	}
+	return c.Redirect(http.StatusFound, slackAuthorizeURL+"?"+q.Encode())
+}
+
+// dashboardOAuth finishes sign in with Slack, only the manager and channel admins get a session
+func (r *REST) dashboardOAuth(c echo.Context) error {
+	state, err := c.Cookie(stateCookie)
+	if err != nil || state.Value == "" || state.Value != c.QueryParam("state") {
+		return r.dashboardError(c, http.StatusBadRequest, "Sign in failed, try again")
+	}
+	c.SetCookie(&http.Cookie{Name: stateCookie, Path: "/dashboard", MaxAge: -1})
+	if c.QueryParam("error") != "" {
+		return r.dashboardError(c, http.StatusForbidden, "Sign in with Slack was cancelled")
+	}
+	resp, err := slack.GetOAuthResponse(r.conf.SlackClientID, r.conf.SlackClientSecret, c.QueryParam("code"), r.redirectURI(c), false)
+	if err != nil {
+		logrus.Errorf("rest: GetOAuthResponse failed: %v\n", err)
+		return r.dashboardError(c, http.StatusBadGateway, "Sign in with Slack failed")
+	}
+	identity, err := slack.New(resp.AccessToken).GetUserIdentity()
+	if err != nil {
+		logrus.Errorf("rest: GetUserIdentity failed: %v\n", err)
+		return r.dashboardError(c, http.StatusBadGateway, "Sign in with Slack failed")
+	}
+	channels, err := r.visibleChannels(identity.User.ID)
+	if err != nil {
+		return r.dashboardError(c, http.StatusInternalServerError, "Channels are unavailable")
+	}
+	if identity.User.ID != r.conf.ManagerSlackUserID && len(channels) == 0 {
+		return r.dashboardError(c, http.StatusForbidden, "Dashboard is only for the manager and channel admins")
+	}
+	expiry := time.Now().Add(sessionTTL)
+	c.SetCookie(&http.Cookie{
+		Name:     sessionCookie,
+		Value:    r.session(identity.User.ID, expiry),
+		Path:     "/dashboard",
+		Expires:  expiry,
+		HttpOnly: true,
+		Secure:   r.secureCookies(c),
+	})
+	return c.Redirect(http.StatusFound, "/dashboard")
+}
+
+// dashboardLogout ends session
+func (r *REST) dashboardLogout(c echo.Context) error {
+	c.SetCookie(&http.Cookie{Name: sessionCookie, Path: "/dashboard", MaxAge: -1})
+	return r.render(c, http.StatusOK, "logout", nil)
+}
+
+// dashboardChannels lists channels of signed in user
+func (r *REST) dashboardChannels(c echo.Context) error {
+	userID := c.Get("user_id").(string)
+	channels, err := r.visibleChannels(userID)
+	if err != nil {
+		return r.dashboardError(c, http.StatusInternalServerError, "Channels are unavailable")
+	}
+	return r.render(c, http.StatusOK, "channels", struct {
+		UserID   string
+		Channels []apiChannel
+	}{userID, channels})
+}
+
+// dashboardChannel shows calendar of standups submitted in channel in a month
+func (r *REST) dashboardChannel(c echo.Context) error {
+	page, st, ok, err := r.monthPage(c)
+	if !ok {
+		return err
+	}
+	from, to := page.Month, page.Month.AddDate(0, 1, 0)
+	page.Metrics = metricsOf(r.collector.ProjectData(c.Request().Context(), page.Channel.ID, page.Channel.Name, from, to.AddDate(0, 0, -1)))
+	rows, err := r.channelCalendar(page.Channel.ID, st, from, time.Now())
+	if err != nil {
+		return r.dashboardError(c, http.StatusInternalServerError, "Standups are unavailable")
+	}
+	days := []int{}
+	for d := from; d.Before(to); d = d.AddDate(0, 0, 1) {
+		days = append(days, d.Day())
+	}
+	return r.render(c, http.StatusOK, "channel", struct {
+		monthPage
+		Days []int
+		Rows []calendarRow
+	}{page, days, rows})
+}
+
+// dashboardUser shows standups of user in channel in a month with their edits
+func (r *REST) dashboardUser(c echo.Context) error {
+	page, _, ok, err := r.monthPage(c)
+	if !ok {
+		return err
+	}
+	user := model.StandupUser{SlackUserID: c.Param("user_id"), SlackName: c.Param("user_id")}
+	users, err := r.db.ListStandupUsersByChannelID(page.Channel.ID)
+	if err != nil {
+		logrus.Errorf("rest: ListStandupUsersByChannelID failed: %v\n", err)
+		return r.dashboardError(c, http.StatusInternalServerError, "Standupers are unavailable")
+	}
+	for _, u := range users {
+		if u.SlackUserID == user.SlackUserID {
+			user = u
+		}
+	}
+	from, to := page.Month, page.Month.AddDate(0, 1, 0)
+	page.Metrics = metricsOf(r.collector.ProjectUserData(c.Request().Context(), page.Channel.ID, page.Channel.Name, user.SlackUserID, from, to.AddDate(0, 0, -1)))
+	standups, err := r.db.SelectStandupsFiltered(user.SlackUserID, page.Channel.ID, from, to)
+	if err != nil {
+		logrus.Errorf("rest: SelectStandupsFiltered failed: %v\n", err)
+		return r.dashboardError(c, http.StatusInternalServerError, "Standups are unavailable")
+	}
+	sort.Slice(standups, func(i, j int) bool { return standups[i].Created.After(standups[j].Created) })
+	versions := []standupVersions{}
+	for _, s := range standups {
+		history, err := r.db.ListStandupHistory(s.ID)
+		if err != nil {
+			logrus.Errorf("rest: ListStandupHistory failed: %v\n", err)
+			return r.dashboardError(c, http.StatusInternalServerError, "Standups are unavailable")
+		}
+		versions = append(versions, editsOf(s, history))
+	}
+	return r.render(c, http.StatusOK, "user", struct {
+		monthPage
+		User     model.StandupUser
+		Standups []standupVersions
+	}{page, user, versions})
+}
+
+// monthPage finds channel of request among channels user may see and month of its month parameter,
+// ok is false if the error page is already sent
+func (r *REST) monthPage(c echo.Context) (monthPage, model.StandupTime, bool, error) {
+	page := monthPage{UserID: c.Get("user_id").(string)}
+	channels, err := r.visibleChannels(page.UserID)
+	if err != nil {
+		return page, model.StandupTime{}, false, r.dashboardError(c, http.StatusInternalServerError, "Channels are unavailable")
+	}
+	found := false
+	for _, ch := range channels {
+		if ch.ID == c.Param("channel_id") {
+			page.Channel = ch
+			found = true
+		}
+	}
+	if !found {
+		return page, model.StandupTime{}, false, r.dashboardError(c, http.StatusNotFound, "Channel not found")
+	}
+	st := model.StandupTime{ChannelID: page.Channel.ID}
+	if page.Channel.StandupTime != nil {
+		st = *page.Channel.StandupTime
+	}
+	now := time.Now().In(st.Location())
+	page.Month = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	if m := c.QueryParam("month"); m != "" {
+		page.Month, err = time.ParseInLocation(monthFormat, m, now.Location())
+		if err != nil {
+			return page, st, false, r.dashboardError(c, http.StatusBadRequest, "Month must look like 2018-06")
+		}
+	}
+	page.Previous = page.Month.AddDate(0, -1, 0).Format(monthFormat)
+	page.Next = page.Month.AddDate(0, 1, 0).Format(monthFormat)
+	return page, st, true, nil
+}
+
+// visibleChannels returns all channels to the manager and channels where user is admin to others
+func (r *REST) visibleChannels(userID string) ([]apiChannel, error) {
+	channels, err := r.apiChannels()
+	if err != nil {
+		return nil, err
+	}
+	if userID == r.conf.ManagerSlackUserID {
+		return channels, nil
+	}
+	visible := []apiChannel{}
+	for _, ch := range channels {
+		if r.db.IsAdmin(userID, ch.ID) {
+			visible = append(visible, ch)
+		}
+	}
+	return visible, nil
+}
+
+// channelCalendar marks days of month starting at from for every standuper of channel.
+// Days after now are future unless a standup is already there
+func (r *REST) channelCalendar(channelID string, st model.StandupTime, from, now time.Time) ([]calendarRow, error) {
+	to := from.AddDate(0, 1, 0)
+	users, err := r.db.ListStandupUsersByChannelID(channelID)
+	if err != nil {
+		logrus.Errorf("rest: ListStandupUsersByChannelID failed: %v\n", err)
+		return nil, err
+	}
+	holidays, err := r.db.ListHolidays(channelID)
+	if err != nil {
+		logrus.Errorf("rest: ListHolidays failed: %v\n", err)
+		return nil, err
+	}
+	standups, err := r.db.SelectStandupsByChannelIDForPeriod(channelID, from, to)
+	if err != nil {
+		logrus.Errorf("rest: SelectStandupsByChannelIDForPeriod failed: %v\n", err)
+		return nil, err
+	}
+	cal := calendar.New(st, holidays)
+	done := map[string]bool{}
+	for _, s := range standups {
+		done[s.UsernameID+"|"+s.Created.In(cal.Location).Format(calendar.DateFormat)] = true
+	}
+	today := now.In(cal.Location).Format(calendar.DateFormat)
+	sort.Slice(users, func(i, j int) bool { return users[i].SlackName < users[j].SlackName })
+	rows := []calendarRow{}
+	for _, u := range users {
+		absences, err := r.db.ListAbsences(u.SlackUserID)
+		if err != nil {
+			logrus.Errorf("rest: ListAbsences failed: %v\n", err)
+			return nil, err
+		}
+		joined := u.Created.In(cal.Location).Format(calendar.DateFormat)
+		row := calendarRow{User: u}
+		for d := from; d.Before(to); d = d.AddDate(0, 0, 1) {
+			day := calendarDay{Date: d.Format(calendar.DateFormat)}
+			switch {
+			case done[u.SlackUserID+"|"+day.Date]:
+				day.Status = dayDone
+				row.Done++
+			case !cal.IsWorkday(d):
+				day.Status = dayOff
+			case absentOn(absences, day.Date):
+				day.Status = dayAbsent
+			case day.Date >= today:
+				day.Status = dayFuture
+			case day.Date < joined:
+				day.Status = dayOutside
+			default:
+				day.Status = dayMissed
+				row.Missed++
+			}
+			row.Days = append(row.Days, day)
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// absentOn checks if one of absences covers date
+func absentOn(absences []model.Absence, date string) bool {
+	for _, a := range absences {
+		if a.DateFrom <= date && date <= a.DateTo {
+			return true
+		}
+	}
+	return false
+}
+
+// editsOf compares every text of standup with the next one, history keeps
+// texts standup had before edits and the last text is its comment
+func editsOf(s model.Standup, history []model.StandupEditHistory) standupVersions {
+	v := standupVersions{Standup: s, Original: s.Comment}
+	if len(history) == 0 {
+		return v
+	}
+	v.Original = history[0].StandupText
+	for i, h := range history {
+		next := s.Comment
+		if i+1 < len(history) {
+			next = history[i+1].StandupText
+		}
+		v.Edits = append(v.Edits, standupEdit{Created: h.Created, Parts: diffWords(h.StandupText, next)})
+	}
+	return v
+}
+
+// diffWords compares texts word by word with the longest common subsequence,
+// whitespace is kept so the new text is the result without deleted parts
+func diffWords(old, new string) []diffPart {
+	a := diffToken.FindAllString(old, -1)
+	b := diffToken.FindAllString(new, -1)
+	// common[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	common := make([][]int, len(a)+1)
+	for i := range common {
+		common[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			switch {
+			case a[i] == b[j]:
+				common[i][j] = common[i+1][j+1] + 1
+			case common[i+1][j] >= common[i][j+1]:
+				common[i][j] = common[i+1][j]
+			default:
+				common[i][j] = common[i][j+1]
+			}
+		}
+	}
+	parts := []diffPart{}
+	add := func(op, text string) {
+		if n := len(parts); n > 0 && parts[n-1].Op == op {
+			parts[n-1].Text += text
+			return
+		}
+		parts = append(parts, diffPart{Op: op, Text: text})
+	}
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			add("", a[i])
+			i++
+			j++
+		case common[i+1][j] >= common[i][j+1]:
+			add("del", a[i])
+			i++
+		default:
+			add("ins", b[j])
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		add("del", a[i])
+	}
+	for ; j < len(b); j++ {
+		add("ins", b[j])
+	}
+	return parts
+}
+
+// metricsOf converts collector data, worklogs are in seconds
+func metricsOf(data collector.Data, err error) dashboardMetrics {
+	if err != nil {
+		logrus.Errorf("rest: collector failed: %v\n", err)
+		return dashboardMetrics{Unavailable: true}
+	}
+	return dashboardMetrics{
+		Commits: data.TotalCommits,
+		Merges:  data.TotalMerges,
+		Reviews: data.TotalReviews,
+		Hours:   data.Worklogs / 3600,
+	}
+}
+
+// redirectURI is where Slack sends users after sign in, DashboardURL is used if
+// Comedian is behind a proxy which changes host or scheme of requests
+func (r *REST) redirectURI(c echo.Context) string {
+	base := strings.TrimRight(r.conf.DashboardURL, "/")
+	if base == "" {
+		base = c.Scheme() + "://" + c.Request().Host
+	}
+	return base + "/dashboard/oauth"
+}
+
+// secureCookies tells if cookies are sent only over https, which is the case when dashboard is served over it
+func (r *REST) secureCookies(c echo.Context) bool {
+	return strings.HasPrefix(r.redirectURI(c), "https://")
+}
+
+// session returns session cookie value "userID|expiry|signature"
+func (r *REST) session(userID string, expiry time.Time) string {
+	value := userID + "|" + strconv.FormatInt(expiry.Unix(), 10)
+	return value + "|" + r.sign(value)
+}
+
+// checkSession returns user of session cookie value if it is signed and not expired at now
+func (r *REST) checkSession(value string, now time.Time) (string, bool) {
+	parts := strings.Split(value, "|")
+	if len(parts) != 3 || !hmac.Equal([]byte(r.sign(parts[0]+"|"+parts[1])), []byte(parts[2])) {
+		return "", false
+	}
+	expiry, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil || now.Unix() > expiry {
+		return "", false
+	}
+	return parts[0], true
+}
+
+func (r *REST) sign(value string) string {
+	mac := hmac.New(sha256.New, r.sessionKey)
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// render executes dashboard template
+func (r *REST) render(c echo.Context, status int, name string, data interface{}) error {
+	var b bytes.Buffer
+	if err := dashboardTemplates.ExecuteTemplate(&b, name, data); err != nil {
+		logrus.Errorf("rest: ExecuteTemplate failed: %v\n", err)
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+	return c.HTMLBlob(status, b.Bytes())
+}
+
+func (r *REST) dashboardError(c echo.Context, status int, message string) error {
+	return r.render(c, status, "error", message)
+}
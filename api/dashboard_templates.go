@@ -0,0 +1,100 @@
+package api
+
+import (
+	"html/template"
+	"time"
+
+	"github.com/maddevsio/comedian/calendar"
+	"github.com/maddevsio/comedian/model"
+)
+
+// dashboardTemplates are pages of web dashboard, every page is a template named after it
+var dashboardTemplates = template.Must(template.New("dashboard").Funcs(template.FuncMap{
+	"standupTime": standupTime,
+}).Parse(`
+{{define "header"}}<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Comedian</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ddd; padding: 4px 6px; text-align: center; }
+td.name { text-align: left; }
+.done { background: #a5d6a7; }
+.missed { background: #ef9a9a; }
+.absent { background: #90caf9; }
+.off { background: #eeeeee; }
+.future, .outside { background: #ffffff; }
+.legend span { display: inline-block; padding: 2px 8px; margin-right: 4px; border: 1px solid #ddd; }
+.metrics span { margin-right: 1.5em; }
+pre { white-space: pre-wrap; background: #f7f7f7; padding: 8px; }
+del { background: #ffcdd2; }
+ins { background: #c8e6c9; text-decoration: none; }
+nav { margin-bottom: 1.5em; }
+</style>
+</head>
+<body>
+{{end}}
+
+{{define "footer"}}</body>
+</html>
+{{end}}
+
+{{define "nav"}}<nav><a href="/dashboard">Channels</a> | signed in as {{.}} | <a href="/dashboard/logout">Sign out</a></nav>
+{{end}}
+
+{{define "metrics"}}<p class="metrics">{{if .Unavailable}}Collector data is unavailable{{else}}<span>Commits: {{.Commits}}</span><span>Merges: {{.Merges}}</span><span>Reviews: {{.Reviews}}</span><span>Hours logged: {{.Hours}}</span>{{end}}</p>
+{{end}}
+
+{{define "error"}}{{template "header"}}<h1>Comedian</h1>
+<p>{{.}}</p>
+<p><a href="/dashboard/login">Sign in with Slack</a></p>
+{{template "footer"}}{{end}}
+
+{{define "logout"}}{{template "header"}}<h1>Comedian</h1>
+<p>You are signed out.</p>
+<p><a href="/dashboard/login">Sign in with Slack</a></p>
+{{template "footer"}}{{end}}
+
+{{define "channels"}}{{template "header"}}{{template "nav" .UserID}}<h1>Channels</h1>
+{{if .Channels}}<table>
+<tr><th>Channel</th><th>Platform</th><th>Standupers</th><th>Standup time</th></tr>
+{{range .Channels}}<tr><td class="name"><a href="/dashboard/channels/{{.ID}}">{{if .Name}}{{.Name}}{{else}}{{.ID}}{{end}}</a></td><td>{{.Platform}}</td><td>{{.Standupers}}</td><td>{{with .StandupTime}}{{standupTime .}}{{end}}</td></tr>
+{{end}}</table>{{else}}<p>No channels yet.</p>{{end}}
+{{template "footer"}}{{end}}
+
+{{define "month"}}<p><a href="?month={{.Previous}}">&larr; {{.Previous}}</a> <b>{{.Month.Format "January 2006"}}</b> <a href="?month={{.Next}}">{{.Next}} &rarr;</a></p>
+{{end}}
+
+{{define "channel"}}{{template "header"}}{{template "nav" .UserID}}<h1>{{if .Channel.Name}}{{.Channel.Name}}{{else}}{{.Channel.ID}}{{end}}</h1>
+{{template "month" .}}{{template "metrics" .Metrics}}
+<p class="legend"><span class="done">submitted</span><span class="missed">missed</span><span class="absent">absent</span><span class="off">day off</span></p>
+{{if .Rows}}<table>
+<tr><th>Standuper</th>{{range .Days}}<th>{{.}}</th>{{end}}<th>Submitted</th><th>Missed</th></tr>
+{{$channel := .Channel.ID}}{{$month := .Month.Format "2006-01"}}{{range .Rows}}<tr><td class="name"><a href="/dashboard/channels/{{$channel}}/users/{{.User.SlackUserID}}?month={{$month}}">{{.User.SlackName}}</a></td>{{range .Days}}<td class="{{.Status}}" title="{{.Date}}"></td>{{end}}<td>{{.Done}}</td><td>{{.Missed}}</td></tr>
+{{end}}</table>{{else}}<p>Channel has no standupers.</p>{{end}}
+{{template "footer"}}{{end}}
+
+{{define "user"}}{{template "header"}}{{template "nav" .UserID}}<h1>{{.User.SlackName}} in <a href="/dashboard/channels/{{.Channel.ID}}?month={{.Month.Format "2006-01"}}">{{if .Channel.Name}}{{.Channel.Name}}{{else}}{{.Channel.ID}}{{end}}</a></h1>
+{{template "month" .}}{{template "metrics" .Metrics}}
+{{range .Standups}}<h3>{{.Standup.Created.Format "2006-01-02 15:04"}}</h3>
+<pre>{{.Original}}</pre>
+{{range .Edits}}<p>edited {{.Created.Format "2006-01-02 15:04"}}</p>
+<pre>{{range .Parts}}{{if eq .Op "del"}}<del>{{.Text}}</del>{{else if eq .Op "ins"}}<ins>{{.Text}}</ins>{{else}}{{.Text}}{{end}}{{end}}</pre>
+{{end}}{{else}}<p>No standups in this month.</p>{{end}}
+{{template "footer"}}{{end}}
+`))
+
+// standupTime describes when channel has standups
+func standupTime(st *model.StandupTime) string {
+	if st.Schedule != "" {
+		return st.Schedule
+	}
+	days := st.WorkDays
+	if days == "" {
+		days = calendar.DefaultWorkDays
+	}
+	return time.Unix(st.Time, 0).In(st.Location()).Format("15:04 MST") + ", " + days
+}
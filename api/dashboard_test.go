@@ -0,0 +1,297 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bouk/monkey"
+	"github.com/maddevsio/comedian/collector"
+	"github.com/maddevsio/comedian/config"
+	"github.com/maddevsio/comedian/model"
+	"github.com/maddevsio/comedian/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/jarcoal/httpmock.v1"
+)
+
+// collectorStub returns data or fails with err
+type collectorStub struct {
+	data collector.Data
+	err  error
+}
+
+func (c collectorStub) UserData(ctx context.Context, userID string, dateFrom, dateTo time.Time) (collector.Data, error) {
+	return c.data, c.err
+}
+
+func (c collectorStub) ProjectData(ctx context.Context, channelID, channelName string, dateFrom, dateTo time.Time) (collector.Data, error) {
+	return c.data, c.err
+}
+
+func (c collectorStub) ProjectUserData(ctx context.Context, channelID, channelName, userID string, dateFrom, dateTo time.Time) (collector.Data, error) {
+	return c.data, c.err
+}
+
+func newDashboard(t *testing.T) (*REST, storage.Storage) {
+	c, err := config.Get()
+	require.NoError(t, err)
+	c.SlackClientID = "client"
+	c.SlackClientSecret = "client secret"
+	c.DashboardURL = "https://comedian.example.com/"
+	c.DashboardSecret = "dashboard secret"
+	db := storage.NewMemory()
+	rest, err := NewRESTAPI(c, db)
+	require.NoError(t, err)
+	rest.collector = collectorStub{data: collector.Data{TotalCommits: 3, TotalMerges: 1, Worklogs: 7200}}
+	return rest, db
+}
+
+func dashboardRequest(rest *REST, path string, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", path, nil)
+	for _, cookie := range cookies {
+		req.AddCookie(cookie)
+	}
+	rec := httptest.NewRecorder()
+	rest.echo.ServeHTTP(rec, req)
+	return rec
+}
+
+func sessionOf(rest *REST, userID string) *http.Cookie {
+	return &http.Cookie{Name: sessionCookie, Value: rest.session(userID, time.Now().Add(time.Hour))}
+}
+
+func TestDashboardIsOff(t *testing.T) {
+	rest, _ := newJSONAPI(t)
+	rec := dashboardRequest(rest, "/dashboard")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestDashboardSignIn(t *testing.T) {
+	rest, db := newDashboard(t)
+	_, err := db.CreateStandupUser(model.StandupUser{SlackUserID: "U1", SlackName: "anna", ChannelID: "C1", Channel: "backend"})
+	require.NoError(t, err)
+	_, err = db.CreateStandupUser(model.StandupUser{SlackUserID: "UADMIN", SlackName: "lead", ChannelID: "C1", Channel: "backend", Role: "admin"})
+	require.NoError(t, err)
+
+	rec := dashboardRequest(rest, "/dashboard")
+	assert.Equal(t, http.StatusFound, rec.Code)
+	assert.Equal(t, "/dashboard/login", rec.Header().Get("Location"))
+
+	rec = dashboardRequest(rest, "/dashboard/login")
+	assert.Equal(t, http.StatusFound, rec.Code)
+	location, err := url.Parse(rec.Header().Get("Location"))
+	require.NoError(t, err)
+	assert.Equal(t, "slack.com", location.Host)
+	assert.Equal(t, "client", location.Query().Get("client_id"))
+	assert.Equal(t, "identity.basic", location.Query().Get("scope"))
+	assert.Equal(t, "https://comedian.example.com/dashboard/oauth", location.Query().Get("redirect_uri"))
+	state := rec.Result().Cookies()[0]
+	assert.Equal(t, stateCookie, state.Name)
+	assert.Equal(t, location.Query().Get("state"), state.Value)
+	assert.True(t, state.Secure)
+
+	rec = dashboardRequest(rest, "/dashboard/oauth?code=code&state=wrong", state)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterResponder("POST", "https://slack.com/api/oauth.access",
+		httpmock.NewStringResponder(200, `{"ok": true, "access_token": "xoxp-user"}`))
+	signIn := func(userID string) *httptest.ResponseRecorder {
+		httpmock.RegisterResponder("POST", "https://slack.com/api/users.identity",
+			httpmock.NewStringResponder(200, `{"ok": true, "user": {"id": "`+userID+`", "name": "someone"}}`))
+		return dashboardRequest(rest, "/dashboard/oauth?code=code&state="+state.Value, state)
+	}
+
+	rec = signIn("UADMIN")
+	assert.Equal(t, http.StatusFound, rec.Code)
+	assert.Equal(t, "/dashboard", rec.Header().Get("Location"))
+	var session *http.Cookie
+	for _, cookie := range rec.Result().Cookies() {
+		if cookie.Name == sessionCookie {
+			session = cookie
+		}
+	}
+	require.NotNil(t, session)
+	assert.True(t, session.HttpOnly)
+	assert.True(t, session.Secure)
+	rec = dashboardRequest(rest, "/dashboard", session)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `<a href="/dashboard/channels/C1">backend</a>`)
+
+	rec = signIn(rest.conf.ManagerSlackUserID)
+	assert.Equal(t, http.StatusFound, rec.Code)
+
+	// standupers who are not admins cannot sign in
+	rec = signIn("U1")
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	rec = dashboardRequest(rest, "/dashboard/logout", session)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, -1, rec.Result().Cookies()[0].MaxAge)
+}
+
+func TestDashboardWithoutKey(t *testing.T) {
+	c, err := config.Get()
+	require.NoError(t, err)
+	c.SlackClientID = "client"
+	c.SlackClientSecret = "client secret"
+	c.DashboardSecret = ""
+	monkey.Patch(rand.Read, func([]byte) (int, error) { return 0, errors.New("no entropy") })
+	rest, err := NewRESTAPI(c, storage.NewMemory())
+	monkey.Unpatch(rand.Read)
+	require.NoError(t, err)
+	assert.Empty(t, rest.sessionKey)
+	rec := dashboardRequest(rest, "/dashboard")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestDashboardSession(t *testing.T) {
+	rest, _ := newDashboard(t)
+	now := time.Now()
+	value := rest.session("U1", now.Add(time.Hour))
+	userID, ok := rest.checkSession(value, now)
+	assert.True(t, ok)
+	assert.Equal(t, "U1", userID)
+	_, ok = rest.checkSession(value, now.Add(2*time.Hour))
+	assert.False(t, ok)
+	_, ok = rest.checkSession(strings.Replace(value, "U1", "U2", 1), now)
+	assert.False(t, ok)
+	_, ok = rest.checkSession("U1", now)
+	assert.False(t, ok)
+
+	rest.sessionKey = []byte("another secret")
+	_, ok = rest.checkSession(value, now)
+	assert.False(t, ok)
+}
+
+func TestDashboardChannel(t *testing.T) {
+	rest, db := newDashboard(t)
+	at := func(date string) {
+		d, err := time.Parse("2006-01-02 15:04", date)
+		require.NoError(t, err)
+		monkey.Patch(time.Now, func() time.Time { return d })
+	}
+	defer monkey.Unpatch(time.Now)
+
+	// users join on Friday, June 1
+	at("2018-06-01 09:00")
+	_, err := db.CreateStandupTime(model.StandupTime{ChannelID: "C1", Channel: "backend", Time: 1527840000, Timezone: "UTC"})
+	require.NoError(t, err)
+	_, err = db.CreateStandupUser(model.StandupUser{SlackUserID: "U1", SlackName: "anna", ChannelID: "C1", Channel: "backend"})
+	require.NoError(t, err)
+	_, err = db.CreateStandupUser(model.StandupUser{SlackUserID: "U2", SlackName: "bob", ChannelID: "C1", Channel: "backend"})
+	require.NoError(t, err)
+	_, err = db.CreateStandupUser(model.StandupUser{SlackUserID: "UADMIN", SlackName: "lead", ChannelID: "C1", Channel: "backend", Role: "admin"})
+	require.NoError(t, err)
+	_, err = db.CreateHoliday(model.Holiday{ChannelID: "C1", Date: "2018-06-12", Name: "Holiday"})
+	require.NoError(t, err)
+	_, err = db.CreateAbsence(model.Absence{SlackUserID: "U2", DateFrom: "2018-06-06", DateTo: "2018-06-08"})
+	require.NoError(t, err)
+	at("2018-06-04 10:00")
+	_, err = db.CreateStandup(model.Standup{ChannelID: "C1", UsernameID: "U1", Comment: "first standup", MessageTS: "1"})
+	require.NoError(t, err)
+	at("2018-06-05 10:00")
+	standup, err := db.CreateStandup(model.Standup{ChannelID: "C1", UsernameID: "U1", Comment: "fixed bug in api", MessageTS: "2"})
+	require.NoError(t, err)
+	at("2018-06-05 10:30")
+	_, err = db.AddToStandupHistory(model.StandupEditHistory{StandupID: standup.ID, StandupText: standup.Comment})
+	require.NoError(t, err)
+	standup.Comment = "fixed <b>bug</b> in reports"
+	_, err = db.UpdateStandup(standup)
+	require.NoError(t, err)
+	at("2018-06-11 10:00")
+	_, err = db.CreateStandup(model.Standup{ChannelID: "C1", UsernameID: "U2", Comment: "standup", MessageTS: "3"})
+	require.NoError(t, err)
+	at("2018-06-13 10:00")
+
+	st, err := db.GetChannelStandupTime("C1")
+	require.NoError(t, err)
+	month := time.Date(2018, 6, 1, 0, 0, 0, 0, time.UTC)
+	rows, err := rest.channelCalendar("C1", st, month, time.Now())
+	require.NoError(t, err)
+	require.Equal(t, 2, len(rows))
+	statuses := func(row calendarRow) string {
+		var s []string
+		for _, d := range row.Days[:14] {
+			s = append(s, d.Status)
+		}
+		return strings.Join(s, " ")
+	}
+	assert.Equal(t, "anna", rows[0].User.SlackName)
+	assert.Equal(t, "missed off off done done missed missed missed off off missed off future future", statuses(rows[0]))
+	assert.Equal(t, 2, rows[0].Done)
+	assert.Equal(t, 5, rows[0].Missed)
+	assert.Equal(t, "missed off off missed missed absent absent absent off off done off future future", statuses(rows[1]))
+	assert.Equal(t, 1, rows[1].Done)
+	assert.Equal(t, 3, rows[1].Missed)
+	assert.Equal(t, 30, len(rows[1].Days))
+	assert.Equal(t, "2018-06-30", rows[1].Days[29].Date)
+	assert.Equal(t, dayOff, rows[1].Days[29].Status)
+
+	// the month before users joined
+	rows, err = rest.channelCalendar("C1", st, month.AddDate(0, -1, 0), time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, dayOutside, rows[0].Days[1].Status)
+
+	manager := sessionOf(rest, rest.conf.ManagerSlackUserID)
+	rec := dashboardRequest(rest, "/dashboard/channels/C1", manager)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	body := rec.Body.String()
+	assert.Contains(t, body, "June 2018")
+	assert.Contains(t, body, `href="?month=2018-05"`)
+	assert.Contains(t, body, `<a href="/dashboard/channels/C1/users/U1?month=2018-06">anna</a>`)
+	assert.Contains(t, body, `<td class="missed" title="2018-06-01"></td>`)
+	assert.Contains(t, body, "Commits: 3")
+	assert.Contains(t, body, "Hours logged: 2")
+	assert.NotContains(t, body, "lead")
+
+	rec = dashboardRequest(rest, "/dashboard/channels/C1?month=2018-05", sessionOf(rest, "UADMIN"))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "May 2018")
+	rec = dashboardRequest(rest, "/dashboard/channels/C1?month=May", manager)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	rec = dashboardRequest(rest, "/dashboard/channels/C1", sessionOf(rest, "U1"))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	rec = dashboardRequest(rest, "/dashboard/channels/C2", manager)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = dashboardRequest(rest, "/dashboard/channels/C1/users/U1", manager)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	body = rec.Body.String()
+	assert.Contains(t, body, "anna in")
+	assert.Contains(t, body, "<pre>fixed bug in api</pre>")
+	assert.Contains(t, body, "edited 2018-06-05 10:30")
+	assert.Contains(t, body, "<pre>fixed <del>bug</del><ins>&lt;b&gt;bug&lt;/b&gt;</ins> in <del>api</del><ins>reports</ins></pre>")
+	assert.True(t, strings.Index(body, "2018-06-05 10:00") < strings.Index(body, "2018-06-04 10:00"), "newest standups go first")
+
+	rest.collector = collectorStub{err: errors.New("collector is down")}
+	rec = dashboardRequest(rest, "/dashboard/channels/C1/users/U2?month=2018-05", manager)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "Collector data is unavailable")
+	assert.Contains(t, rec.Body.String(), "No standups in this month.")
+}
+
+func TestDiffWords(t *testing.T) {
+	assert.Equal(t, []diffPart{{Text: "a "}, {Op: "del", Text: "foo"}, {Op: "ins", Text: "bar"}, {Text: " b"}}, diffWords("a foo b", "a bar b"))
+	assert.Equal(t, []diffPart{{Text: "yesterday: tests"}, {Op: "ins", Text: "\ntoday: release"}}, diffWords("yesterday: tests", "yesterday: tests\ntoday: release"))
+	assert.Equal(t, []diffPart{{Op: "del", Text: "old text"}}, diffWords("old text", ""))
+	assert.Equal(t, []diffPart{}, diffWords("", ""))
+
+	s := model.Standup{Comment: "third"}
+	v := editsOf(s, nil)
+	assert.Equal(t, "third", v.Original)
+	assert.Equal(t, 0, len(v.Edits))
+	v = editsOf(s, []model.StandupEditHistory{{StandupText: "first"}, {StandupText: "second"}})
+	assert.Equal(t, "first", v.Original)
+	require.Equal(t, 2, len(v.Edits))
+	assert.Equal(t, []diffPart{{Op: "del", Text: "first"}, {Op: "ins", Text: "second"}}, v.Edits[0].Parts)
+	assert.Equal(t, []diffPart{{Op: "del", Text: "second"}, {Op: "ins", Text: "third"}}, v.Edits[1].Parts)
+}
@@ -0,0 +1,570 @@
+package api
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/maddevsio/comedian/calendar"
+	"github.com/maddevsio/comedian/model"
+	"github.com/maddevsio/comedian/schedule"
+	"github.com/sirupsen/logrus"
+)
+
+// Pagination of JSON API lists
+const (
+	defaultLimit = 50
+	maxLimit     = 500
+)
+
+type (
+	// listPage is a page of JSON API list, Total is the number of items matching the filters
+	listPage struct {
+		Items  interface{} `json:"items"`
+		Total  int         `json:"total"`
+		Limit  int         `json:"limit"`
+		Offset int         `json:"offset"`
+	}
+
+	// apiChannel is a channel with standupers or standup time
+	apiChannel struct {
+		ID          string             `json:"id"`
+		Name        string             `json:"name"`
+		Platform    string             `json:"platform"`
+		Standupers  int                `json:"standupers"`
+		StandupTime *model.StandupTime `json:"standupTime,omitempty"`
+	}
+
+	// filter is parsed from query of JSON API list requests
+	filter struct {
+		ChannelID string
+		UserID    string
+		From      time.Time
+		To        time.Time
+		Limit     int
+		Offset    int
+	}
+)
+
+// initJSONAPI mounts JSON API for dashboards and scripts, all its requests need an API token
+func (r *REST) initJSONAPI() {
+	g := r.echo.Group("/api/v1", r.authorizeToken)
+	g.GET("/standups", r.apiListStandups)
+	g.POST("/standups", r.apiCreateStandup)
+	g.GET("/standups/:id", r.apiGetStandup)
+	g.PUT("/standups/:id", r.apiUpdateStandup)
+	g.DELETE("/standups/:id", r.apiDeleteStandup)
+	g.GET("/standupers", r.apiListStandupers)
+	g.POST("/standupers", r.apiCreateStanduper)
+	g.GET("/standupers/:id", r.apiGetStanduper)
+	g.PUT("/standupers/:id", r.apiUpdateStanduper)
+	g.DELETE("/standupers/:id", r.apiDeleteStanduper)
+	g.GET("/standup-times", r.apiListStandupTimes)
+	g.POST("/standup-times", r.apiCreateStandupTime)
+	g.GET("/standup-times/:channel_id", r.apiGetStandupTime)
+	g.PUT("/standup-times/:channel_id", r.apiUpdateStandupTime)
+	g.DELETE("/standup-times/:channel_id", r.apiDeleteStandupTime)
+	g.GET("/channels", r.apiListChannels)
+	g.GET("/channels/:channel_id", r.apiGetChannel)
+	g.GET("/reports/:kind", r.apiReport)
+	r.echo.GET("/api/v1/openapi.json", r.serveSpec)
+}
+
+// authorizeToken rejects JSON API requests without a valid token in "Authorization: Bearer <token>" header
+func (r *REST) authorizeToken(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		header := c.Request().Header.Get("Authorization")
+		if !strings.HasPrefix(header, "Bearer ") {
+			rejectedRequests.Add("api_token", 1)
+			return apiError(c, http.StatusUnauthorized, errors.New("API token is missing"))
+		}
+		_, err := r.db.SelectAPIToken(hashToken(strings.TrimPrefix(header, "Bearer ")))
+		if err == sql.ErrNoRows {
+			rejectedRequests.Add("api_token", 1)
+			return apiError(c, http.StatusUnauthorized, errors.New("API token is invalid"))
+		}
+		if err != nil {
+			logrus.Errorf("rest: SelectAPIToken failed: %v\n", err)
+			return apiError(c, http.StatusInternalServerError, err)
+		}
+		return next(c)
+	}
+}
+
+func (r *REST) apiListStandups(c echo.Context) error {
+	f, err := parseFilter(c.QueryParams())
+	if err != nil {
+		return apiError(c, http.StatusBadRequest, err)
+	}
+	var standups []model.Standup
+	if f.ChannelID != "" && !f.From.IsZero() && !f.To.IsZero() {
+		standups, err = r.db.SelectStandupsByChannelIDForPeriod(f.ChannelID, f.From, f.To)
+	} else {
+		standups, err = r.db.ListStandups()
+	}
+	if err != nil {
+		logrus.Errorf("rest: ListStandups failed: %v\n", err)
+		return apiError(c, http.StatusInternalServerError, err)
+	}
+	items := []model.Standup{}
+	for _, s := range standups {
+		if f.match(s.ChannelID, s.UsernameID, s.Created) {
+			items = append(items, s)
+		}
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].ID < items[j].ID })
+	from, to := f.page(len(items))
+	return c.JSON(http.StatusOK, listPage{Items: items[from:to], Total: len(items), Limit: f.Limit, Offset: f.Offset})
+}
+
+func (r *REST) apiGetStandup(c echo.Context) error {
+	standup, ok := r.apiStandup(c)
+	if !ok {
+		return nil
+	}
+	return c.JSON(http.StatusOK, standup)
+}
+
+func (r *REST) apiCreateStandup(c echo.Context) error {
+	var standup model.Standup
+	if err := json.NewDecoder(c.Request().Body).Decode(&standup); err != nil {
+		return apiError(c, http.StatusBadRequest, err)
+	}
+	if err := standup.Validate(); err != nil {
+		return apiError(c, http.StatusBadRequest, err)
+	}
+	standup, err := r.db.CreateStandup(standup)
+	if err != nil {
+		logrus.Errorf("rest: CreateStandup failed: %v\n", err)
+		return apiError(c, http.StatusInternalServerError, err)
+	}
+	return c.JSON(http.StatusCreated, standup)
+}
+
+func (r *REST) apiUpdateStandup(c echo.Context) error {
+	standup, ok := r.apiStandup(c)
+	if !ok {
+		return nil
+	}
+	id := standup.ID
+	if err := json.NewDecoder(c.Request().Body).Decode(&standup); err != nil {
+		return apiError(c, http.StatusBadRequest, err)
+	}
+	standup.ID = id
+	if err := standup.Validate(); err != nil {
+		return apiError(c, http.StatusBadRequest, err)
+	}
+	standup, err := r.db.UpdateStandup(standup)
+	if err != nil {
+		logrus.Errorf("rest: UpdateStandup failed: %v\n", err)
+		return apiError(c, http.StatusInternalServerError, err)
+	}
+	return c.JSON(http.StatusOK, standup)
+}
+
+func (r *REST) apiDeleteStandup(c echo.Context) error {
+	standup, ok := r.apiStandup(c)
+	if !ok {
+		return nil
+	}
+	if err := r.db.DeleteStandup(standup.ID); err != nil {
+		logrus.Errorf("rest: DeleteStandup failed: %v\n", err)
+		return apiError(c, http.StatusInternalServerError, err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// apiStandup returns standup by id from path, ok is false if error response was sent instead
+func (r *REST) apiStandup(c echo.Context) (model.Standup, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		apiError(c, http.StatusBadRequest, fmt.Errorf("wrong id: %v", c.Param("id")))
+		return model.Standup{}, false
+	}
+	standup, err := r.db.SelectStandup(id)
+	if err != nil {
+		r.apiNotFound(c, "SelectStandup", err)
+		return standup, false
+	}
+	return standup, true
+}
+
+// apiListStandupers lists standupers, channel admins are not standupers and are not listed
+func (r *REST) apiListStandupers(c echo.Context) error {
+	f, err := parseFilter(c.QueryParams())
+	if err != nil {
+		return apiError(c, http.StatusBadRequest, err)
+	}
+	var users []model.StandupUser
+	if f.ChannelID != "" {
+		users, err = r.db.ListStandupUsersByChannelID(f.ChannelID)
+	} else {
+		users, err = r.db.ListAllStandupUsers()
+	}
+	if err != nil {
+		logrus.Errorf("rest: ListAllStandupUsers failed: %v\n", err)
+		return apiError(c, http.StatusInternalServerError, err)
+	}
+	items := []model.StandupUser{}
+	for _, u := range users {
+		if f.match(u.ChannelID, u.SlackUserID, u.Created) {
+			items = append(items, u)
+		}
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].ID < items[j].ID })
+	from, to := f.page(len(items))
+	return c.JSON(http.StatusOK, listPage{Items: items[from:to], Total: len(items), Limit: f.Limit, Offset: f.Offset})
+}
+
+func (r *REST) apiGetStanduper(c echo.Context) error {
+	user, ok := r.apiStanduper(c)
+	if !ok {
+		return nil
+	}
+	return c.JSON(http.StatusOK, user)
+}
+
+func (r *REST) apiCreateStanduper(c echo.Context) error {
+	var user model.StandupUser
+	if err := json.NewDecoder(c.Request().Body).Decode(&user); err != nil {
+		return apiError(c, http.StatusBadRequest, err)
+	}
+	if err := user.Validate(); err != nil {
+		return apiError(c, http.StatusBadRequest, err)
+	}
+	if user.ChannelID == "" {
+		return apiError(c, http.StatusBadRequest, errors.New("Channel cannot be empty"))
+	}
+	if _, err := r.db.FindStandupUserInChannelByUserID(user.SlackUserID, user.ChannelID); err == nil {
+		return apiError(c, http.StatusConflict, errors.New("User is already in channel"))
+	}
+	user, err := r.db.CreateStandupUser(user)
+	if err != nil {
+		logrus.Errorf("rest: CreateStandupUser failed: %v\n", err)
+		return apiError(c, http.StatusInternalServerError, err)
+	}
+	return c.JSON(http.StatusCreated, user)
+}
+
+// apiUpdateStanduper updates name, channel name and role of standuper,
+// users and channels are changed by deleting standupers and creating new ones
+func (r *REST) apiUpdateStanduper(c echo.Context) error {
+	user, ok := r.apiStanduper(c)
+	if !ok {
+		return nil
+	}
+	id := user.ID
+	if err := json.NewDecoder(c.Request().Body).Decode(&user); err != nil {
+		return apiError(c, http.StatusBadRequest, err)
+	}
+	user.ID = id
+	if err := user.Validate(); err != nil {
+		return apiError(c, http.StatusBadRequest, err)
+	}
+	user, err := r.db.UpdateStandupUser(user)
+	if err != nil {
+		logrus.Errorf("rest: UpdateStandupUser failed: %v\n", err)
+		return apiError(c, http.StatusInternalServerError, err)
+	}
+	return c.JSON(http.StatusOK, user)
+}
+
+func (r *REST) apiDeleteStanduper(c echo.Context) error {
+	user, ok := r.apiStanduper(c)
+	if !ok {
+		return nil
+	}
+	if err := r.db.DeleteStandupUser(user.SlackName, user.ChannelID); err != nil {
+		logrus.Errorf("rest: DeleteStandupUser failed: %v\n", err)
+		return apiError(c, http.StatusInternalServerError, err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// apiStanduper returns standuper by id from path, ok is false if error response was sent instead
+func (r *REST) apiStanduper(c echo.Context) (model.StandupUser, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		apiError(c, http.StatusBadRequest, fmt.Errorf("wrong id: %v", c.Param("id")))
+		return model.StandupUser{}, false
+	}
+	user, err := r.db.SelectStandupUser(id)
+	if err != nil {
+		r.apiNotFound(c, "SelectStandupUser", err)
+		return user, false
+	}
+	return user, true
+}
+
+func (r *REST) apiListStandupTimes(c echo.Context) error {
+	f, err := parseFilter(c.QueryParams())
+	if err != nil {
+		return apiError(c, http.StatusBadRequest, err)
+	}
+	times, err := r.db.ListAllStandupTime()
+	if err != nil {
+		logrus.Errorf("rest: ListAllStandupTime failed: %v\n", err)
+		return apiError(c, http.StatusInternalServerError, err)
+	}
+	items := []model.StandupTime{}
+	for _, st := range times {
+		if f.ChannelID == "" || st.ChannelID == f.ChannelID {
+			items = append(items, st)
+		}
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].ChannelID < items[j].ChannelID })
+	from, to := f.page(len(items))
+	return c.JSON(http.StatusOK, listPage{Items: items[from:to], Total: len(items), Limit: f.Limit, Offset: f.Offset})
+}
+
+func (r *REST) apiGetStandupTime(c echo.Context) error {
+	st, err := r.db.GetChannelStandupTime(c.Param("channel_id"))
+	if err != nil {
+		return r.apiNotFound(c, "GetChannelStandupTime", err)
+	}
+	return c.JSON(http.StatusOK, st)
+}
+
+func (r *REST) apiCreateStandupTime(c echo.Context) error {
+	var st model.StandupTime
+	if err := json.NewDecoder(c.Request().Body).Decode(&st); err != nil {
+		return apiError(c, http.StatusBadRequest, err)
+	}
+	if st.ChannelID == "" {
+		return apiError(c, http.StatusBadRequest, errors.New("Channel cannot be empty"))
+	}
+	if err := validateStandupTime(&st); err != nil {
+		return apiError(c, http.StatusBadRequest, err)
+	}
+	if _, err := r.db.GetChannelStandupTime(st.ChannelID); err == nil {
+		return apiError(c, http.StatusConflict, errors.New("Channel already has standup time"))
+	}
+	st, err := r.db.CreateStandupTime(st)
+	if err != nil {
+		logrus.Errorf("rest: CreateStandupTime failed: %v\n", err)
+		return apiError(c, http.StatusInternalServerError, err)
+	}
+	return c.JSON(http.StatusCreated, st)
+}
+
+func (r *REST) apiUpdateStandupTime(c echo.Context) error {
+	st, err := r.db.GetChannelStandupTime(c.Param("channel_id"))
+	if err != nil {
+		return r.apiNotFound(c, "GetChannelStandupTime", err)
+	}
+	channelID := st.ChannelID
+	if err := json.NewDecoder(c.Request().Body).Decode(&st); err != nil {
+		return apiError(c, http.StatusBadRequest, err)
+	}
+	st.ChannelID = channelID
+	if err := validateStandupTime(&st); err != nil {
+		return apiError(c, http.StatusBadRequest, err)
+	}
+	st, err = r.db.UpdateStandupTime(st)
+	if err != nil {
+		logrus.Errorf("rest: UpdateStandupTime failed: %v\n", err)
+		return apiError(c, http.StatusInternalServerError, err)
+	}
+	return c.JSON(http.StatusOK, st)
+}
+
+func (r *REST) apiDeleteStandupTime(c echo.Context) error {
+	st, err := r.db.GetChannelStandupTime(c.Param("channel_id"))
+	if err != nil {
+		return r.apiNotFound(c, "GetChannelStandupTime", err)
+	}
+	if err := r.db.DeleteStandupTime(st.ChannelID); err != nil {
+		logrus.Errorf("rest: DeleteStandupTime failed: %v\n", err)
+		return apiError(c, http.StatusInternalServerError, err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// validateStandupTime checks standup time the same way slash commands do and normalizes work days
+func validateStandupTime(st *model.StandupTime) error {
+	if err := st.Validate(); err != nil {
+		return err
+	}
+	if _, err := loadLocation(st.Timezone); err != nil {
+		return fmt.Errorf("wrong timezone: %v", st.Timezone)
+	}
+	if st.WorkDays != "" {
+		days, err := calendar.ParseWorkDays(st.WorkDays)
+		if err != nil {
+			return err
+		}
+		st.WorkDays = calendar.FormatWorkDays(days)
+	}
+	if st.Schedule != "" {
+		s, err := schedule.Parse(st.Schedule)
+		if err != nil {
+			return err
+		}
+		if err := schedule.Upcoming(s, time.Now()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *REST) apiListChannels(c echo.Context) error {
+	f, err := parseFilter(c.QueryParams())
+	if err != nil {
+		return apiError(c, http.StatusBadRequest, err)
+	}
+	channels, err := r.apiChannels()
+	if err != nil {
+		return apiError(c, http.StatusInternalServerError, err)
+	}
+	from, to := f.page(len(channels))
+	return c.JSON(http.StatusOK, listPage{Items: channels[from:to], Total: len(channels), Limit: f.Limit, Offset: f.Offset})
+}
+
+func (r *REST) apiGetChannel(c echo.Context) error {
+	channels, err := r.apiChannels()
+	if err != nil {
+		return apiError(c, http.StatusInternalServerError, err)
+	}
+	for _, ch := range channels {
+		if ch.ID == c.Param("channel_id") {
+			return c.JSON(http.StatusOK, ch)
+		}
+	}
+	return apiError(c, http.StatusNotFound, errors.New("Not found"))
+}
+
+// apiChannels returns channels which have standupers or standup time ordered by ID
+func (r *REST) apiChannels() ([]apiChannel, error) {
+	channels := map[string]*apiChannel{}
+	users, err := r.db.ListAllStandupUsers()
+	if err != nil {
+		logrus.Errorf("rest: ListAllStandupUsers failed: %v\n", err)
+		return nil, err
+	}
+	for _, u := range users {
+		ch, ok := channels[u.ChannelID]
+		if !ok {
+			ch = &apiChannel{ID: u.ChannelID, Name: u.Channel, Platform: u.Platform}
+			channels[u.ChannelID] = ch
+		}
+		ch.Standupers++
+	}
+	times, err := r.db.ListAllStandupTime()
+	if err != nil {
+		logrus.Errorf("rest: ListAllStandupTime failed: %v\n", err)
+		return nil, err
+	}
+	for i, st := range times {
+		ch, ok := channels[st.ChannelID]
+		if !ok {
+			ch = &apiChannel{ID: st.ChannelID, Name: st.Channel, Platform: st.Platform}
+			channels[st.ChannelID] = ch
+		}
+		ch.StandupTime = &times[i]
+	}
+	items := []apiChannel{}
+	for _, ch := range channels {
+		items = append(items, *ch)
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].ID < items[j].ID })
+	return items, nil
+}
+
+// parseFilter parses channel_id, user_id, from, to (2006-01-02, both days included), limit and offset
+func parseFilter(q url.Values) (filter, error) {
+	f := filter{ChannelID: q.Get("channel_id"), UserID: q.Get("user_id"), Limit: defaultLimit}
+	var err error
+	if v := q.Get("from"); v != "" {
+		if f.From, err = time.Parse("2006-01-02", v); err != nil {
+			return f, fmt.Errorf("wrong from: %v", v)
+		}
+	}
+	if v := q.Get("to"); v != "" {
+		if f.To, err = time.Parse("2006-01-02", v); err != nil {
+			return f, fmt.Errorf("wrong to: %v", v)
+		}
+		f.To = f.To.AddDate(0, 0, 1).Add(-time.Second)
+	}
+	if v := q.Get("limit"); v != "" {
+		if f.Limit, err = strconv.Atoi(v); err != nil || f.Limit < 1 || f.Limit > maxLimit {
+			return f, fmt.Errorf("limit must be from 1 to %v", maxLimit)
+		}
+	}
+	if v := q.Get("offset"); v != "" {
+		if f.Offset, err = strconv.Atoi(v); err != nil || f.Offset < 0 {
+			return f, errors.New("offset must not be negative")
+		}
+	}
+	return f, nil
+}
+
+func (f filter) match(channelID, userID string, created time.Time) bool {
+	switch {
+	case f.ChannelID != "" && channelID != f.ChannelID:
+		return false
+	case f.UserID != "" && userID != f.UserID:
+		return false
+	case !f.From.IsZero() && created.Before(f.From):
+		return false
+	case !f.To.IsZero() && created.After(f.To):
+		return false
+	}
+	return true
+}
+
+// page returns bounds of the page in list of n items
+func (f filter) page(n int) (int, int) {
+	from := f.Offset
+	if from > n {
+		from = n
+	}
+	to := from + f.Limit
+	if to > n {
+		to = n
+	}
+	return from, to
+}
+
+func (r *REST) apiNotFound(c echo.Context, method string, err error) error {
+	if err == sql.ErrNoRows {
+		return apiError(c, http.StatusNotFound, errors.New("Not found"))
+	}
+	logrus.Errorf("rest: %v failed: %v\n", method, err)
+	return apiError(c, http.StatusInternalServerError, err)
+}
+
+// apiError responds with err, errors of server are logged and replaced with a generic
+// message as they may tell about database and other internals
+func apiError(c echo.Context, status int, err error) error {
+	if status >= http.StatusInternalServerError {
+		logrus.Errorf("rest: %v %v failed: %v\n", c.Request().Method, c.Request().URL.Path, err)
+		err = errors.New(http.StatusText(status))
+	}
+	return c.JSON(status, map[string]string{"error": err.Error()})
+}
+
+// newToken returns a random API token
+func newToken() (string, error) {
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// hashToken returns hash of token kept in database instead of the token
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,239 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/maddevsio/comedian/config"
+	"github.com/maddevsio/comedian/model"
+	"github.com/maddevsio/comedian/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newJSONAPI(t *testing.T) (*REST, storage.Storage) {
+	c, err := config.Get()
+	require.NoError(t, err)
+	db := storage.NewMemory()
+	rest, err := NewRESTAPI(c, db)
+	require.NoError(t, err)
+	_, err = db.CreateAPIToken(model.APIToken{Name: "tests", TokenHash: hashToken("secret")})
+	require.NoError(t, err)
+	return rest, db
+}
+
+// apiRequest sends request to JSON API and decodes response into v if it is not nil
+func apiRequest(t *testing.T, rest *REST, method, path, body string, v interface{}) int {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	rest.echo.ServeHTTP(rec, req)
+	// every response has one JSON value in the body
+	if rec.Code != http.StatusNoContent {
+		var body interface{}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body), rec.Body.String())
+	}
+	if v != nil {
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), v), rec.Body.String())
+	}
+	return rec.Code
+}
+
+type standupsPage struct {
+	Items  []model.Standup `json:"items"`
+	Total  int             `json:"total"`
+	Limit  int             `json:"limit"`
+	Offset int             `json:"offset"`
+}
+
+func TestJSONAPIAuthorization(t *testing.T) {
+	rest, _ := newJSONAPI(t)
+	for _, header := range []string{"", "Bearer wrong", "Token secret"} {
+		req := httptest.NewRequest("GET", "/api/v1/standups", nil)
+		req.Header.Set("Authorization", header)
+		rec := httptest.NewRecorder()
+		rest.echo.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code, header)
+	}
+	assert.Equal(t, http.StatusOK, apiRequest(t, rest, "GET", "/api/v1/standups", "", nil))
+}
+
+// brokenStorage fails to list standups like a database which is gone
+type brokenStorage struct {
+	storage.Storage
+}
+
+func (s brokenStorage) ListStandups() ([]model.Standup, error) {
+	return nil, errors.New("dial tcp 10.0.0.5:3306: connection refused")
+}
+
+func TestJSONAPIServerErrors(t *testing.T) {
+	rest, db := newJSONAPI(t)
+	rest.db = brokenStorage{db}
+	var e map[string]string
+	assert.Equal(t, http.StatusInternalServerError, apiRequest(t, rest, "GET", "/api/v1/standups", "", &e))
+	assert.Equal(t, "Internal Server Error", e["error"])
+}
+
+func TestJSONAPIStandups(t *testing.T) {
+	rest, db := newJSONAPI(t)
+	for i, s := range []model.Standup{
+		{ChannelID: "C1", UsernameID: "U1", Comment: "first", MessageTS: "1"},
+		{ChannelID: "C1", UsernameID: "U2", Comment: "second", MessageTS: "2"},
+		{ChannelID: "C2", UsernameID: "U1", Comment: "third", MessageTS: "3"},
+	} {
+		_, err := db.CreateStandup(s)
+		require.NoError(t, err, i)
+	}
+
+	var page standupsPage
+	assert.Equal(t, http.StatusOK, apiRequest(t, rest, "GET", "/api/v1/standups", "", &page))
+	assert.Equal(t, 3, page.Total)
+	assert.Equal(t, defaultLimit, page.Limit)
+	assert.Equal(t, http.StatusOK, apiRequest(t, rest, "GET", "/api/v1/standups?channel_id=C1&user_id=U2", "", &page))
+	assert.Equal(t, 1, page.Total)
+	assert.Equal(t, "second", page.Items[0].Comment)
+	assert.Equal(t, http.StatusOK, apiRequest(t, rest, "GET", "/api/v1/standups?user_id=U1&limit=1&offset=1", "", &page))
+	assert.Equal(t, 2, page.Total)
+	assert.Equal(t, 1, len(page.Items))
+	assert.Equal(t, "third", page.Items[0].Comment)
+	today := time.Now().Format("2006-01-02")
+	assert.Equal(t, http.StatusOK, apiRequest(t, rest, "GET", "/api/v1/standups?channel_id=C1&from="+today+"&to="+today, "", &page))
+	assert.Equal(t, 2, page.Total)
+	assert.Equal(t, http.StatusOK, apiRequest(t, rest, "GET", "/api/v1/standups?to=2018-01-01", "", &page))
+	assert.Equal(t, 0, page.Total)
+	assert.Equal(t, http.StatusBadRequest, apiRequest(t, rest, "GET", "/api/v1/standups?from=yesterday", "", nil))
+	assert.Equal(t, http.StatusBadRequest, apiRequest(t, rest, "GET", "/api/v1/standups?limit=1000", "", nil))
+
+	var standup model.Standup
+	assert.Equal(t, http.StatusCreated, apiRequest(t, rest, "POST", "/api/v1/standups", `{"channelId":"C2","userNameId":"U2","comment":"fourth"}`, &standup))
+	assert.Equal(t, "fourth", standup.Comment)
+	var e map[string]string
+	assert.Equal(t, http.StatusBadRequest, apiRequest(t, rest, "POST", "/api/v1/standups", `{"channelId":"C2"}`, &e))
+	assert.Equal(t, "Standup cannot be empty", e["error"])
+
+	path := "/api/v1/standups/" + jsonID(standup.ID)
+	assert.Equal(t, http.StatusOK, apiRequest(t, rest, "PUT", path, `{"comment":"edited"}`, &standup))
+	assert.Equal(t, "edited", standup.Comment)
+	assert.Equal(t, "C2", standup.ChannelID)
+	assert.Equal(t, http.StatusBadRequest, apiRequest(t, rest, "PUT", path, `{"comment":""}`, nil))
+	assert.Equal(t, http.StatusOK, apiRequest(t, rest, "GET", path, "", &standup))
+	assert.Equal(t, "edited", standup.Comment)
+	assert.Equal(t, http.StatusNoContent, apiRequest(t, rest, "DELETE", path, "", nil))
+	assert.Equal(t, http.StatusNotFound, apiRequest(t, rest, "GET", path, "", nil))
+	assert.Equal(t, http.StatusNotFound, apiRequest(t, rest, "DELETE", path, "", nil))
+	assert.Equal(t, http.StatusBadRequest, apiRequest(t, rest, "GET", "/api/v1/standups/first", "", nil))
+}
+
+func TestJSONAPIStandupers(t *testing.T) {
+	rest, _ := newJSONAPI(t)
+
+	var user model.StandupUser
+	assert.Equal(t, http.StatusCreated, apiRequest(t, rest, "POST", "/api/v1/standupers", `{"slack_user_id":"U1","username":"anna","channelId":"C1","channel":"general"}`, &user))
+	assert.Equal(t, "anna", user.SlackName)
+	assert.Equal(t, http.StatusConflict, apiRequest(t, rest, "POST", "/api/v1/standupers", `{"slack_user_id":"U1","username":"anna","channelId":"C1"}`, nil))
+	assert.Equal(t, http.StatusBadRequest, apiRequest(t, rest, "POST", "/api/v1/standupers", `{"channelId":"C1"}`, nil))
+	assert.Equal(t, http.StatusBadRequest, apiRequest(t, rest, "POST", "/api/v1/standupers", `{"slack_user_id":"U2"}`, nil))
+	assert.Equal(t, http.StatusCreated, apiRequest(t, rest, "POST", "/api/v1/standupers", `{"slack_user_id":"U1","username":"anna","channelId":"C2"}`, nil))
+
+	var page struct {
+		Items []model.StandupUser `json:"items"`
+		Total int                 `json:"total"`
+	}
+	assert.Equal(t, http.StatusOK, apiRequest(t, rest, "GET", "/api/v1/standupers?user_id=U1", "", &page))
+	assert.Equal(t, 2, page.Total)
+	assert.Equal(t, http.StatusOK, apiRequest(t, rest, "GET", "/api/v1/standupers?channel_id=C1", "", &page))
+	assert.Equal(t, 1, page.Total)
+
+	path := "/api/v1/standupers/" + jsonID(user.ID)
+	assert.Equal(t, http.StatusOK, apiRequest(t, rest, "PUT", path, `{"username":"anna.s"}`, &user))
+	assert.Equal(t, "anna.s", user.SlackName)
+	assert.Equal(t, "C1", user.ChannelID)
+	assert.Equal(t, http.StatusNoContent, apiRequest(t, rest, "DELETE", path, "", nil))
+	assert.Equal(t, http.StatusNotFound, apiRequest(t, rest, "GET", path, "", nil))
+	assert.Equal(t, http.StatusOK, apiRequest(t, rest, "GET", "/api/v1/standupers", "", &page))
+	assert.Equal(t, 1, page.Total)
+}
+
+func TestJSONAPIStandupTimesAndChannels(t *testing.T) {
+	rest, _ := newJSONAPI(t)
+
+	var st model.StandupTime
+	assert.Equal(t, http.StatusCreated, apiRequest(t, rest, "POST", "/api/v1/standup-times", `{"channelId":"C1","channel":"general","time":1530000000,"workDays":"mon-fri"}`, &st))
+	assert.Equal(t, "mon,tue,wed,thu,fri", st.WorkDays)
+	assert.Equal(t, http.StatusConflict, apiRequest(t, rest, "POST", "/api/v1/standup-times", `{"channelId":"C1","time":1530000000}`, nil))
+	assert.Equal(t, http.StatusBadRequest, apiRequest(t, rest, "POST", "/api/v1/standup-times", `{"channelId":"C2"}`, nil))
+	assert.Equal(t, http.StatusBadRequest, apiRequest(t, rest, "POST", "/api/v1/standup-times", `{"channelId":"C2","time":1530000000,"timezone":"Mars/Olympus"}`, nil))
+	assert.Equal(t, http.StatusBadRequest, apiRequest(t, rest, "POST", "/api/v1/standup-times", `{"channelId":"C2","time":1530000000,"schedule":"sometimes"}`, nil))
+	assert.Equal(t, http.StatusCreated, apiRequest(t, rest, "POST", "/api/v1/standupers", `{"slack_user_id":"U1","username":"anna","channelId":"C3","channel":"dev"}`, nil))
+
+	assert.Equal(t, http.StatusOK, apiRequest(t, rest, "PUT", "/api/v1/standup-times/C1", `{"timezone":"Asia/Bishkek"}`, &st))
+	assert.Equal(t, "Asia/Bishkek", st.Timezone)
+	assert.Equal(t, int64(1530000000), st.Time)
+	assert.Equal(t, http.StatusNotFound, apiRequest(t, rest, "PUT", "/api/v1/standup-times/C2", `{"time":1530000000}`, nil))
+
+	var channels struct {
+		Items []apiChannel `json:"items"`
+		Total int          `json:"total"`
+	}
+	assert.Equal(t, http.StatusOK, apiRequest(t, rest, "GET", "/api/v1/channels", "", &channels))
+	assert.Equal(t, 2, channels.Total)
+	assert.Equal(t, "general", channels.Items[0].Name)
+	assert.Equal(t, "Asia/Bishkek", channels.Items[0].StandupTime.Timezone)
+	assert.Equal(t, 1, channels.Items[1].Standupers)
+	assert.Nil(t, channels.Items[1].StandupTime)
+	var channel apiChannel
+	assert.Equal(t, http.StatusOK, apiRequest(t, rest, "GET", "/api/v1/channels/C3", "", &channel))
+	assert.Equal(t, "dev", channel.Name)
+	assert.Equal(t, http.StatusNotFound, apiRequest(t, rest, "GET", "/api/v1/channels/C2", "", nil))
+
+	assert.Equal(t, http.StatusNoContent, apiRequest(t, rest, "DELETE", "/api/v1/standup-times/C1", "", nil))
+	assert.Equal(t, http.StatusNotFound, apiRequest(t, rest, "GET", "/api/v1/standup-times/C1", "", nil))
+}
+
+func TestHandleAPITokenCommands(t *testing.T) {
+	AddToken := "user_id=UB9AE7CL9&command=/apitokenadd&channel_id=tokenchan&text=dashboard"
+	AdminAddToken := "user_id=UADMIN&command=/apitokenadd&channel_id=tokenchan&text=scripts"
+	ListTokens := "user_id=UB9AE7CL9&command=/apitokens&channel_id=tokenchan"
+	RemoveToken := "user_id=UB9AE7CL9&command=/apitokenremove&channel_id=tokenchan&text=dashboard"
+
+	rest, db := newJSONAPI(t)
+	_, err := db.CreateStandupUser(model.StandupUser{SlackUserID: "UADMIN", SlackName: "admin", ChannelID: "tokenchan", Role: "admin"})
+	require.NoError(t, err)
+	command := func(command string) string {
+		context, rec := getContext(command)
+		assert.NoError(t, rest.handleCommands(context))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		return rec.Body.String()
+	}
+
+	// channel admins cannot create tokens giving access to all channels
+	assert.Equal(t, rest.conf.Translate.AccessDenied, command(AdminAddToken))
+	reply := command(AddToken)
+	assert.True(t, strings.HasPrefix(reply, "API token dashboard created: "))
+	token := strings.Fields(strings.TrimPrefix(reply, "API token dashboard created: "))[0]
+	assert.Equal(t, 40, len(token))
+	assert.Equal(t, "API tokens: dashboard, tests", command(ListTokens))
+
+	req := httptest.NewRequest("GET", "/api/v1/channels", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec := httptest.NewRecorder()
+	rest.echo.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	assert.Equal(t, "API token dashboard removed", command(RemoveToken))
+	rec = httptest.NewRecorder()
+	rest.echo.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func jsonID(id int64) string {
+	b, _ := json.Marshal(id)
+	return string(b)
+}
@@ -0,0 +1,99 @@
+package api
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"regexp"
+
+	"github.com/labstack/echo"
+	"github.com/maddevsio/comedian/chat"
+	"github.com/sirupsen/logrus"
+)
+
+// MattermostResolver looks up IDs of users and channels mentioned in Mattermost slash commands
+type MattermostResolver interface {
+	UserIDByName(username string) (string, error)
+	ChannelIDByName(teamID, name string) (string, error)
+}
+
+var (
+	mattermostUserMention    = regexp.MustCompile(`(^|\s)@([a-z0-9._-]+)`)
+	mattermostChannelMention = regexp.MustCompile(`(^|\s)~([a-z0-9_-]+)`)
+)
+
+// mentionCommands expect users and channels escaped the way Slack does it: <@U123|name>, <#C123|name>
+var mentionCommands = map[string]bool{
+	commandAddUser:                true,
+	commandAddAdmin:               true,
+	commandReportByProject:        true,
+	commandReportByUser:           true,
+	commandReportByProjectAndUser: true,
+	commandReportBlockers:         true,
+	commandAddAbsence:             true,
+	commandRemoveAbsence:          true,
+	commandListAbsences:           true,
+	commandLinkUser:               true,
+	commandUnlinkUser:             true,
+}
+
+// AddMattermostCommands mounts handler for Mattermost slash commands at /mattermost/commands.
+// Mattermost payload has the same fields as Slack one, so commands are handled by handleCommands
+func (r *REST) AddMattermostCommands(resolver MattermostResolver) {
+	if len(r.conf.MattermostTokens) == 0 {
+		logrus.Warning("rest: MATTERMOST_COMMAND_TOKENS is not set, /mattermost/commands is disabled")
+		return
+	}
+	r.echo.POST("/mattermost/commands", r.handleCommands, r.verifyMattermostRequest, r.normalizeMattermostCommand(resolver))
+}
+
+// verifyMattermostRequest rejects requests without a token of one of configured slash commands,
+// Mattermost generates a separate token for every command
+func (r *REST) verifyMattermostRequest(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		token := []byte(c.FormValue("token"))
+		for _, t := range r.conf.MattermostTokens {
+			if subtle.ConstantTimeCompare(token, []byte(t)) == 1 {
+				c.Set("platform", chat.PlatformMattermost)
+				return next(c)
+			}
+		}
+		return r.reject(c, "mattermost_token", "command token is invalid")
+	}
+}
+
+// normalizeMattermostCommand converts @user and ~channel mentions in command text
+// to Slack format, mentions which are not found are left as is
+func (r *REST) normalizeMattermostCommand(resolver MattermostResolver) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			form, err := c.FormParams()
+			if err != nil {
+				logrus.Errorf("rest: c.FormParams failed: %v\n", err)
+				return c.String(http.StatusBadRequest, err.Error())
+			}
+			if !mentionCommands[form.Get("command")] {
+				return next(c)
+			}
+			text := mattermostUserMention.ReplaceAllStringFunc(form.Get("text"), func(s string) string {
+				parts := mattermostUserMention.FindStringSubmatch(s)
+				id, err := resolver.UserIDByName(parts[2])
+				if err != nil {
+					logrus.Errorf("rest: UserIDByName %v failed: %v\n", parts[2], err)
+					return s
+				}
+				return parts[1] + "<@" + id + "|" + parts[2] + ">"
+			})
+			text = mattermostChannelMention.ReplaceAllStringFunc(text, func(s string) string {
+				parts := mattermostChannelMention.FindStringSubmatch(s)
+				id, err := resolver.ChannelIDByName(form.Get("team_id"), parts[2])
+				if err != nil {
+					logrus.Errorf("rest: ChannelIDByName %v failed: %v\n", parts[2], err)
+					return s
+				}
+				return parts[1] + "<#" + id + "|" + parts[2] + ">"
+			})
+			form.Set("text", text)
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo"
+	"github.com/maddevsio/comedian/chat"
+	"github.com/maddevsio/comedian/config"
+	"github.com/maddevsio/comedian/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+type resolverStub struct{}
+
+func (resolverStub) UserIDByName(username string) (string, error) {
+	if username == "ivan" {
+		return "u1", nil
+	}
+	return "", errors.New("user not found")
+}
+
+func (resolverStub) ChannelIDByName(teamID, name string) (string, error) {
+	if teamID == "t1" && name == "backend" {
+		return "c1", nil
+	}
+	return "", errors.New("channel not found")
+}
+
+func newMattermostREST(t *testing.T) (*REST, storage.Storage) {
+	tr, err := config.GetTranslation("en_US")
+	assert.NoError(t, err)
+	c := config.Config{
+		ManagerSlackUserID: "UB9AE7CL9",
+		MattermostTokens:   []string{"add-token", "list-token"},
+		MattermostManager:  "manager",
+		Translate:          tr,
+	}
+	db := storage.NewMemory()
+	rest, err := NewRESTAPI(c, db)
+	assert.NoError(t, err)
+	rest.AddMattermostCommands(resolverStub{})
+	return rest, db
+}
+
+func mattermostRequest(body string) *http.Request {
+	req := httptest.NewRequest(echo.POST, "/mattermost/commands", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+	return req
+}
+
+func TestMattermostCommandToken(t *testing.T) {
+	rest, _ := newMattermostREST(t)
+	testCases := []struct {
+		title      string
+		body       string
+		statusCode int
+	}{
+		{"valid token", "token=list-token&user_id=manager&command=/comedianlist&channel_id=c1", http.StatusOK},
+		{"token of another command", "token=add-token&user_id=manager&command=/comedianlist&channel_id=c1", http.StatusOK},
+		{"wrong token", "token=wrong&user_id=manager&command=/comedianlist&channel_id=c1", http.StatusUnauthorized},
+		{"no token", "user_id=manager&command=/comedianlist&channel_id=c1", http.StatusUnauthorized},
+	}
+	for _, tt := range testCases {
+		rec := httptest.NewRecorder()
+		rest.echo.ServeHTTP(rec, mattermostRequest(tt.body))
+		assert.Equal(t, tt.statusCode, rec.Code, tt.title)
+	}
+
+	rec := httptest.NewRecorder()
+	rest.echo.ServeHTTP(rec, mattermostRequest("token=list-token&user_id=u1&command=/comedianlist&channel_id=c1"))
+	assert.Equal(t, rest.conf.Translate.AccessDenied, rec.Body.String())
+
+	// Mattermost commands are not accepted without configured tokens
+	rest = newVerifiedREST(t, "", "")
+	rest.AddMattermostCommands(resolverStub{})
+	rec = httptest.NewRecorder()
+	rest.echo.ServeHTTP(rec, mattermostRequest("token=&user_id=manager&command=/comedianlist&channel_id=c1"))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestMattermostCommandMentions(t *testing.T) {
+	rest, db := newMattermostREST(t)
+
+	rec := httptest.NewRecorder()
+	rest.echo.ServeHTTP(rec, mattermostRequest("token=add-token&user_id=manager&command=/comedianadd&channel_id=c1&channel_name=backend&text=@ivan"))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	user, err := db.FindStandupUserInChannelByUserID("u1", "c1")
+	assert.NoError(t, err)
+	assert.Equal(t, "ivan", user.SlackName)
+
+	rec = httptest.NewRecorder()
+	rest.echo.ServeHTTP(rec, mattermostRequest("token=add-token&user_id=manager&command=/comedianadd&channel_id=c1&channel_name=backend&text=@nobody"))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, rest.conf.Translate.WrongUsername, rec.Body.String())
+	_, err = db.FindStandupUser("nobody")
+	assert.Error(t, err)
+
+	form := map[string]string{}
+	rest.echo.POST("/echo", func(c echo.Context) error {
+		form["text"] = c.FormValue("text")
+		return nil
+	}, rest.normalizeMattermostCommand(resolverStub{}))
+	req := httptest.NewRequest(echo.POST, "/echo", strings.NewReader("command=/report_by_project_and_user&team_id=t1&text=~backend @ivan 2018-07-01 2018-07-31"))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+	rest.echo.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Equal(t, "<#c1|backend> <@u1|ivan> 2018-07-01 2018-07-31", form["text"])
+}
+
+func TestHandleCommandPlatform(t *testing.T) {
+	tr, err := config.GetTranslation("en_US")
+	assert.NoError(t, err)
+	c := config.Config{ManagerSlackUserID: "UB9AE7CL9", TelegramManager: "7", Translate: tr}
+	db := storage.NewMemory()
+	rest, err := NewRESTAPI(c, db)
+	assert.NoError(t, err)
+
+	form := url.Values{
+		"command":      {"/comedianadd"},
+		"text":         {"<@42|ivan>"},
+		"channel_id":   {"-100"},
+		"channel_name": {"team"},
+		"user_id":      {"7"},
+	}
+	assert.Equal(t, fmt.Sprintf(tr.AddUserNoStandupTime, "ivan"), rest.HandleCommand(chat.PlatformTelegram, form))
+	user, err := db.FindStandupUserInChannelByUserID("42", "-100")
+	assert.NoError(t, err)
+	assert.Equal(t, chat.PlatformTelegram, user.Platform)
+
+	// Telegram manager has no rights in Slack and vice versa
+	assert.Equal(t, tr.AccessDenied, rest.HandleCommand(chat.PlatformSlack, form))
+	form.Set("user_id", "UB9AE7CL9")
+	assert.Equal(t, tr.AccessDenied, rest.HandleCommand(chat.PlatformTelegram, form))
+
+	// Telegram cannot mention channels, they are given by ID
+	form = url.Values{"command": {"/report_by_project"}, "channel_id": {"-100"}, "channel_name": {"team"}, "user_id": {"7"}}
+	form.Set("text", "general 2018-01-01 2018-01-02")
+	assert.Equal(t, "unknown channel general", rest.HandleCommand(chat.PlatformTelegram, form))
+	form.Set("text", "-100 2018-01-01 2018-01-02")
+	assert.Contains(t, rest.HandleCommand(chat.PlatformTelegram, form), "Full Report on project <#-100>")
+	form.Set("command", "/report_by_project_and_user")
+	form.Set("text", "-100 ivan 2018-01-01 2018-01-02")
+	assert.Equal(t, "wrong user ivan, please mention user with @", rest.HandleCommand(chat.PlatformTelegram, form))
+}
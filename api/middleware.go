@@ -0,0 +1,61 @@
+package api
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"expvar"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/maddevsio/comedian/chat"
+	"github.com/sirupsen/logrus"
+)
+
+// rejectedRequests counts requests rejected by verification middleware by reason,
+// it is published at /debug/vars to holders of API tokens
+var rejectedRequests = expvar.NewMap("comedian_rejected_requests")
+
+// verifySlackRequest rejects slash command requests which were not sent by Slack.
+// With signing secret configured X-Slack-Signature must be valid and fresh,
+// otherwise legacy verification token is compared with `token` form value.
+// Requests are rejected if neither is configured
+func (r *REST) verifySlackRequest(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := c.Request()
+		body, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			logrus.Errorf("rest: read request body failed: %v\n", err)
+			return c.String(http.StatusBadRequest, err.Error())
+		}
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+		switch {
+		case r.conf.SlackSigningSecret != "":
+			err := chat.VerifySlackSignature(r.conf.SlackSigningSecret, req.Header, body, time.Now())
+			if err != nil {
+				return r.reject(c, "signature", err.Error())
+			}
+		case r.conf.SlackVerifyToken != "":
+			form, err := url.ParseQuery(string(body))
+			if err != nil {
+				return r.reject(c, "malformed", err.Error())
+			}
+			if subtle.ConstantTimeCompare([]byte(form.Get("token")), []byte(r.conf.SlackVerifyToken)) != 1 {
+				return r.reject(c, "token", "verification token is invalid")
+			}
+		default:
+			// without a secret anyone could send commands on behalf of any user
+			return r.reject(c, "not_configured", "neither signing secret nor verification token is set")
+		}
+		return next(c)
+	}
+}
+
+func (r *REST) reject(c echo.Context, reason, message string) error {
+	rejectedRequests.Add(reason, 1)
+	logrus.Warningf("rest: request %v %v from %v rejected: %v\n", c.Request().Method, c.Path(), c.RealIP(), message)
+	return c.String(http.StatusUnauthorized, "Request verification failed")
+}
@@ -0,0 +1,129 @@
+package api
+
+import (
+	"expvar"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/maddevsio/comedian/chat"
+	"github.com/maddevsio/comedian/config"
+	"github.com/maddevsio/comedian/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func newVerifiedREST(t *testing.T, secret, token string) *REST {
+	tr, err := config.GetTranslation("en_US")
+	assert.NoError(t, err)
+	c := config.Config{
+		ManagerSlackUserID: "UB9AE7CL9",
+		SlackSigningSecret: secret,
+		SlackVerifyToken:   token,
+		Translate:          tr,
+	}
+	rest, err := NewRESTAPI(c, storage.NewMemory())
+	assert.NoError(t, err)
+	return rest
+}
+
+// rejectedCount reads counter of rejected requests, counters are global and keep values of previous runs
+func rejectedCount(reason string) int64 {
+	if v, ok := rejectedRequests.Get(reason).(*expvar.Int); ok {
+		return v.Value()
+	}
+	return 0
+}
+
+func commandRequest(body string) *http.Request {
+	req := httptest.NewRequest(echo.POST, "/commands", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+	return req
+}
+
+func signRequest(req *http.Request, secret, body string, ts time.Time) {
+	timestamp := strconv.FormatInt(ts.Unix(), 10)
+	req.Header.Set("X-Slack-Request-Timestamp", timestamp)
+	req.Header.Set("X-Slack-Signature", chat.SlackSignature(secret, timestamp, []byte(body)))
+}
+
+func TestVerifySlackSignature(t *testing.T) {
+	secret := "8f742231b10e8888abcd99yyyzzz85a5"
+	body := "user_id=UB9AE7CL9&command=/comedianlist&channel_id=chanid"
+	rest := newVerifiedREST(t, secret, "")
+
+	testCases := []struct {
+		title      string
+		secret     string
+		ts         time.Time
+		statusCode int
+	}{
+		{"valid signature", secret, time.Now(), http.StatusOK},
+		{"wrong secret", "wrong", time.Now(), http.StatusUnauthorized},
+		{"replayed request", secret, time.Now().Add(-10 * time.Minute), http.StatusUnauthorized},
+	}
+	rejected := rejectedCount("signature")
+	for _, tt := range testCases {
+		req := commandRequest(body)
+		signRequest(req, tt.secret, body, tt.ts)
+		rec := httptest.NewRecorder()
+		rest.echo.ServeHTTP(rec, req)
+		assert.Equal(t, tt.statusCode, rec.Code, tt.title)
+	}
+	assert.Equal(t, rejected+2, rejectedCount("signature"))
+
+	// body is still readable by command handlers after verification
+	req := commandRequest(body)
+	signRequest(req, secret, body, time.Now())
+	rec := httptest.NewRecorder()
+	rest.echo.ServeHTTP(rec, req)
+	assert.Equal(t, "No standupers in this channel! To add one, please, use /comedianadd slash command", rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	rest.echo.ServeHTTP(rec, commandRequest(body))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestVerifySlackToken(t *testing.T) {
+	rest := newVerifiedREST(t, "", "legacytoken")
+
+	rec := httptest.NewRecorder()
+	rest.echo.ServeHTTP(rec, commandRequest("token=legacytoken&user_id=UB9AE7CL9&command=/comedianlist&channel_id=chanid"))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = httptest.NewRecorder()
+	rest.echo.ServeHTTP(rec, commandRequest("token=forged&user_id=UB9AE7CL9&command=/comedianlist&channel_id=chanid"))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	rec = httptest.NewRecorder()
+	rest.echo.ServeHTTP(rec, commandRequest("user_id=UB9AE7CL9&command=/comedianlist&channel_id=chanid"))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestVerificationNotConfigured(t *testing.T) {
+	rest := newVerifiedREST(t, "", "")
+	rejected := rejectedCount("not_configured")
+	rec := httptest.NewRecorder()
+	rest.echo.ServeHTTP(rec, commandRequest("user_id=UB9AE7CL9&command=/comedianlist&channel_id=chanid"))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, rejected+1, rejectedCount("not_configured"))
+}
+
+func TestDebugVarsNeedToken(t *testing.T) {
+	rest, _ := newJSONAPI(t)
+	rejected := rejectedCount("api_token")
+	rec := httptest.NewRecorder()
+	rest.echo.ServeHTTP(rec, httptest.NewRequest("GET", "/debug/vars", nil))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, rejected+1, rejectedCount("api_token"))
+
+	req := httptest.NewRequest("GET", "/debug/vars", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	rec = httptest.NewRecorder()
+	rest.echo.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "comedian_rejected_requests")
+}
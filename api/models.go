@@ -25,6 +25,13 @@ type (
 		Command   string `schema:"command"`
 		ChannelID string `schema:"channel_id"`
 	}
+	// UserTextForm struct used for parsing user_id and optional text param
+	UserTextForm struct {
+		Command   string `schema:"command"`
+		Text      string `schema:"text"`
+		UserID    string `schema:"user_id"`
+		ChannelID string `schema:"channel_id"`
+	}
 	// ChannelForm struct used for parsing channel_id and channel_name payload
 	ChannelForm struct {
 		Command     string `schema:"command"`
@@ -95,3 +102,14 @@ func (s ChannelForm) Validate() error {
 
 	return nil
 }
+
+// Validate validates struct
+func (s UserTextForm) Validate() error {
+	if s.UserID == "" {
+		err := errors.New("`user_id` cannot be empty")
+		logrus.Errorf("api/models: UserTextForm Validate failed: %s", err.Error())
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,116 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/maddevsio/comedian/collector"
+	"github.com/maddevsio/comedian/model"
+	"github.com/maddevsio/comedian/reporting"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type openAPI struct {
+	Paths      map[string]map[string]json.RawMessage `json:"paths"`
+	Components struct {
+		Schemas map[string]struct {
+			Required   []string                   `json:"required"`
+			Properties map[string]json.RawMessage `json:"properties"`
+		} `json:"schemas"`
+	} `json:"components"`
+}
+
+var pathParam = regexp.MustCompile(`:([a-z_]+)`)
+
+func loadSpec(t *testing.T) openAPI {
+	var spec openAPI
+	require.NoError(t, json.Unmarshal([]byte(openAPISpec), &spec))
+	return spec
+}
+
+func TestOpenAPIMatchesRoutes(t *testing.T) {
+	rest, _ := newJSONAPI(t)
+	spec := loadSpec(t)
+
+	var routes []string
+	for _, route := range rest.echo.Routes() {
+		// group middleware is mounted on catch-all routes of the prefix
+		if !strings.HasPrefix(route.Path, "/api/v1/") || route.Path == "/api/v1/*" {
+			continue
+		}
+		path := pathParam.ReplaceAllString(strings.TrimPrefix(route.Path, "/api/v1"), "{$1}")
+		routes = append(routes, route.Method+" "+path)
+	}
+	var operations []string
+	for path, methods := range spec.Paths {
+		for method := range methods {
+			if method != "parameters" {
+				operations = append(operations, strings.ToUpper(method)+" "+path)
+			}
+		}
+	}
+	sort.Strings(routes)
+	sort.Strings(operations)
+	assert.Equal(t, routes, operations)
+}
+
+func TestOpenAPIMatchesModels(t *testing.T) {
+	spec := loadSpec(t)
+	for schema, v := range map[string]interface{}{
+		"Standup":         model.Standup{},
+		"Answer":          model.Answer{},
+		"Standuper":       model.StandupUser{},
+		"StandupTime":     model.StandupTime{},
+		"Channel":         apiChannel{},
+		"StandupList":     listPage{},
+		"StanduperList":   listPage{},
+		"StandupTimeList": listPage{},
+		"ChannelList":     listPage{},
+		"Report":          reporting.Report{},
+		"ReportDay":       reporting.Day{},
+		"ReportEntry":     reporting.Entry{},
+		"CollectorData":   collector.Data{},
+	} {
+		s, ok := spec.Components.Schemas[schema]
+		require.True(t, ok, schema)
+		var properties []string
+		for p := range s.Properties {
+			properties = append(properties, p)
+		}
+		sort.Strings(properties)
+		assert.Equal(t, jsonFields(v), properties, schema)
+		for _, p := range s.Required {
+			assert.Contains(t, properties, p, schema)
+		}
+	}
+}
+
+func TestServeOpenAPI(t *testing.T) {
+	rest, _ := newJSONAPI(t)
+	req := httptest.NewRequest("GET", "/api/v1/openapi.json", nil)
+	rec := httptest.NewRecorder()
+	rest.echo.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, openAPISpec, rec.Body.String())
+}
+
+// jsonFields returns sorted names of fields of struct v in JSON
+func jsonFields(v interface{}) []string {
+	var fields []string
+	t := reflect.TypeOf(v)
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if name != "" && name != "-" {
+			fields = append(fields, name)
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
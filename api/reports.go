@@ -0,0 +1,144 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/maddevsio/comedian/chat"
+	"github.com/maddevsio/comedian/model"
+	"github.com/maddevsio/comedian/reporting"
+	"github.com/nlopes/slack"
+	"github.com/sirupsen/logrus"
+)
+
+// reportContentTypes are content types of reports served by JSON API
+var reportContentTypes = map[string]string{
+	reporting.FormatJSON:     echo.MIMEApplicationJSONCharsetUTF8,
+	reporting.FormatCSV:      "text/csv; charset=utf-8",
+	reporting.FormatMarkdown: "text/markdown; charset=utf-8",
+	reporting.FormatText:     echo.MIMETextPlainCharsetUTF8,
+}
+
+// fileUploader uploads reports in formats other than text to Slack
+type fileUploader interface {
+	UploadFile(slack.FileUploadParameters) (*slack.File, error)
+}
+
+// reportFormat takes format from the end of report command params, n is the number
+// of params without format. Reports are sent as text if format is not given
+func reportFormat(params []string, n int) ([]string, string) {
+	if len(params) == n+1 {
+		return params[:n], params[n]
+	}
+	return params, reporting.FormatText
+}
+
+// sendReport answers report command with text report. Reports in other formats are
+// uploaded as files to Slack channel of the command, other platforms get them as text
+func (r *REST) sendReport(c echo.Context, f url.Values, report reporting.Report, format string) error {
+	doc, err := r.report.Render(report, format)
+	if err != nil {
+		logrus.Errorf("rest: Render failed: %v\n", err)
+		return c.String(http.StatusOK, err.Error())
+	}
+	if format == reporting.FormatText || r.platform(c) != chat.PlatformSlack {
+		return c.String(http.StatusOK, string(doc))
+	}
+	name := report.FileName(r.report.Renderers[format].Extension())
+	_, err = r.files.UploadFile(slack.FileUploadParameters{
+		Content:  string(doc),
+		Filename: name,
+		Title:    name,
+		Channels: []string{f.Get("channel_id")},
+	})
+	if err != nil {
+		logrus.Errorf("rest: UploadFile failed: %v\n", err)
+		return c.String(http.StatusOK, err.Error())
+	}
+	return c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.ReportUploaded, name))
+}
+
+// apiReport builds report of kind from path for period from and to (both days included)
+// and renders it in format, JSON by default
+func (r *REST) apiReport(c echo.Context) error {
+	kind := c.Param("kind")
+	switch kind {
+	case reporting.KindProject, reporting.KindUser, reporting.KindProjectUser, reporting.KindBlockers:
+	default:
+		return apiError(c, http.StatusNotFound, errors.New("Not found"))
+	}
+	q := c.QueryParams()
+	from, err := time.Parse("2006-01-02", q.Get("from"))
+	if err != nil {
+		return apiError(c, http.StatusBadRequest, fmt.Errorf("wrong from: %v", q.Get("from")))
+	}
+	to, err := time.Parse("2006-01-02", q.Get("to"))
+	if err != nil {
+		return apiError(c, http.StatusBadRequest, fmt.Errorf("wrong to: %v", q.Get("to")))
+	}
+	format := q.Get("format")
+	if format == "" {
+		format = reporting.FormatJSON
+	}
+	channelID, userID := q.Get("channel_id"), q.Get("user_id")
+	if kind != reporting.KindUser && channelID == "" {
+		return apiError(c, http.StatusBadRequest, errors.New("channel_id is required"))
+	}
+	if (kind == reporting.KindUser || kind == reporting.KindProjectUser) && userID == "" {
+		return apiError(c, http.StatusBadRequest, errors.New("user_id is required"))
+	}
+	channelName, err := r.channelName(channelID)
+	if err != nil {
+		return apiError(c, http.StatusInternalServerError, err)
+	}
+
+	var report reporting.Report
+	switch kind {
+	case reporting.KindProject:
+		data := collectorData(r.collector.ProjectData(c.Request().Context(), channelID, channelName, from, to))
+		report, err = r.report.ProjectReport(channelID, from, to, data)
+	case reporting.KindUser:
+		data := collectorData(r.collector.UserData(c.Request().Context(), userID, from, to))
+		report, err = r.report.UserReport(model.StandupUser{SlackUserID: userID}, from, to, data)
+	case reporting.KindProjectUser:
+		var user model.StandupUser
+		if user, err = r.db.FindStandupUserInChannelByUserID(userID, channelID); err != nil {
+			return r.apiNotFound(c, "FindStandupUserInChannelByUserID", err)
+		}
+		data := collectorData(r.collector.ProjectUserData(c.Request().Context(), channelID, channelName, userID, from, to))
+		report, err = r.report.ProjectUserReport(channelID, user, from, to, data)
+	case reporting.KindBlockers:
+		report, err = r.report.BlockersReport(channelID, from, to)
+	}
+	if err != nil {
+		return apiError(c, http.StatusBadRequest, err)
+	}
+	doc, err := r.report.Render(report, format)
+	if err != nil {
+		return apiError(c, http.StatusBadRequest, err)
+	}
+	contentType, ok := reportContentTypes[format]
+	if !ok {
+		contentType = echo.MIMEOctetStream
+	}
+	return c.Blob(http.StatusOK, contentType, doc)
+}
+
+// channelName returns name of channel known from its standupers or standup time, collector
+// finds projects by it
+func (r *REST) channelName(channelID string) (string, error) {
+	channels, err := r.apiChannels()
+	if err != nil {
+		return "", err
+	}
+	for _, ch := range channels {
+		if ch.ID == channelID {
+			return ch.Name, nil
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,134 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/maddevsio/comedian/chat"
+	"github.com/maddevsio/comedian/collector"
+	"github.com/maddevsio/comedian/model"
+	"github.com/maddevsio/comedian/reporting"
+	"github.com/nlopes/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// uploaderStub records uploaded files and fails with err
+type uploaderStub struct {
+	uploads []slack.FileUploadParameters
+	err     error
+}
+
+func (u *uploaderStub) UploadFile(params slack.FileUploadParameters) (*slack.File, error) {
+	u.uploads = append(u.uploads, params)
+	return &slack.File{}, u.err
+}
+
+func TestReportFormat(t *testing.T) {
+	params, format := reportFormat([]string{"<#C1|backend>", "2018-06-25", "2018-06-26", "csv"}, 3)
+	assert.Equal(t, []string{"<#C1|backend>", "2018-06-25", "2018-06-26"}, params)
+	assert.Equal(t, reporting.FormatCSV, format)
+	params, format = reportFormat([]string{"<#C1|backend>", "2018-06-25", "2018-06-26"}, 3)
+	assert.Equal(t, 3, len(params))
+	assert.Equal(t, reporting.FormatText, format)
+	params, format = reportFormat([]string{"<#C1|backend>", "2018-06-25"}, 3)
+	assert.Equal(t, 2, len(params))
+	assert.Equal(t, reporting.FormatText, format)
+}
+
+func TestReportCommandsInFormats(t *testing.T) {
+	rest, db := newJSONAPI(t)
+	rest.conf.MattermostManager = "UB9AE7CL9"
+	rest.collector = collectorStub{err: errors.New("collector is down")}
+	files := &uploaderStub{}
+	rest.files = files
+	_, err := db.CreateStandupUser(model.StandupUser{SlackUserID: "U1", SlackName: "anna", ChannelID: "C1", Channel: "backend"})
+	require.NoError(t, err)
+	tr := rest.conf.Translate
+
+	command := "user_id=UB9AE7CL9&command=/report_by_project&channel_id=C2&channel_name=general&text= <#C1|backend> 2018-06-25 2018-06-26 csv"
+	context, rec := getContext(command)
+	require.NoError(t, rest.handleCommands(context))
+	assert.Equal(t, fmt.Sprintf(tr.ReportUploaded, "report_project_C1_2018-06-25_2018-06-26.csv"), rec.Body.String())
+	require.Equal(t, 1, len(files.uploads))
+	assert.Equal(t, []string{"C2"}, files.uploads[0].Channels)
+	assert.Equal(t, "date,channel_id,user_id,status,text\n2018-06-25,C1,U1,missed,\n2018-06-26,C1,U1,missed,\n", files.uploads[0].Content)
+
+	command = "user_id=UB9AE7CL9&command=/report_by_project_and_user&channel_id=C2&channel_name=general&text= <#C1|backend> <@U1|anna> 2018-06-25 2018-06-25 markdown"
+	context, rec = getContext(command)
+	require.NoError(t, rest.handleCommands(context))
+	assert.Equal(t, fmt.Sprintf(tr.ReportUploaded, "report_project_user_C1_U1_2018-06-25_2018-06-25.md"), rec.Body.String())
+	require.Equal(t, 2, len(files.uploads))
+	assert.Equal(t, "# Report on user U1 in channel C1, 2018-06-25 — 2018-06-25\n\n## 2018-06-25\n\n"+
+		"| Channel | User | Status | Text |\n|---|---|---|---|\n| C1 | U1 | missed |  |\n", files.uploads[1].Content)
+
+	command = "user_id=UB9AE7CL9&command=/report_blockers&channel_id=C2&channel_name=general&text= <#C1|backend> 2018-06-25 2018-06-26 xlsx"
+	context, rec = getContext(command)
+	require.NoError(t, rest.handleCommands(context))
+	assert.Equal(t, fmt.Sprintf(tr.ReportWrongFormat, "xlsx", "csv, json, markdown, text"), rec.Body.String())
+
+	files.err = errors.New("not_in_channel")
+	command = "user_id=UB9AE7CL9&command=/report_by_user&channel_id=C2&channel_name=general&text= <@U1|anna> 2018-06-25 2018-06-26 json"
+	context, rec = getContext(command)
+	require.NoError(t, rest.handleCommands(context))
+	assert.Equal(t, "not_in_channel", rec.Body.String())
+	assert.Equal(t, 3, len(files.uploads))
+
+	// other platforms cannot upload files, reports are sent as messages
+	form := url.Values{
+		"command":      {"/report_by_project"},
+		"text":         {"<#C1|backend> 2018-06-25 2018-06-25 csv"},
+		"channel_id":   {"C2"},
+		"channel_name": {"general"},
+		"user_id":      {"UB9AE7CL9"},
+	}
+	assert.Equal(t, "date,channel_id,user_id,status,text\n2018-06-25,C1,U1,missed,\n", rest.HandleCommand(chat.PlatformMattermost, form))
+	assert.Equal(t, 3, len(files.uploads))
+}
+
+func TestJSONAPIReports(t *testing.T) {
+	rest, db := newJSONAPI(t)
+	rest.collector = collectorStub{data: collector.Data{TotalCommits: 3, TotalMerges: 1}}
+	_, err := db.CreateStandupUser(model.StandupUser{SlackUserID: "U1", SlackName: "anna", ChannelID: "C1", Channel: "backend"})
+	require.NoError(t, err)
+
+	var report reporting.Report
+	code := apiRequest(t, rest, "GET", "/api/v1/reports/project?channel_id=C1&from=2018-06-25&to=2018-06-26", "", &report)
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, reporting.KindProject, report.Kind)
+	assert.Equal(t, 2, len(report.Days))
+	assert.Equal(t, []reporting.Entry{{UserID: "U1", ChannelID: "C1", Status: reporting.StatusMissed}}, report.Days[0].Entries)
+	assert.Equal(t, 3, report.Collector.TotalCommits)
+
+	code = apiRequest(t, rest, "GET", "/api/v1/reports/project_user?channel_id=C1&user_id=U1&from=2018-06-25&to=2018-06-25", "", &report)
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "U1", report.UserID)
+
+	req := httptest.NewRequest("GET", "/api/v1/reports/user?user_id=U1&from=2018-06-25&to=2018-06-25&format=csv", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	rec := httptest.NewRecorder()
+	rest.echo.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", rec.Header().Get("Content-Type"))
+	assert.Equal(t, "date,channel_id,user_id,status,text\n2018-06-25,C1,U1,missed,\n", rec.Body.String())
+
+	testCases := []struct {
+		path string
+		code int
+	}{
+		{"/api/v1/reports/team?channel_id=C1&from=2018-06-25&to=2018-06-26", http.StatusNotFound},
+		{"/api/v1/reports/project?from=2018-06-25&to=2018-06-26", http.StatusBadRequest},
+		{"/api/v1/reports/user?from=2018-06-25&to=2018-06-26", http.StatusBadRequest},
+		{"/api/v1/reports/project?channel_id=C1&from=25.06.2018&to=2018-06-26", http.StatusBadRequest},
+		{"/api/v1/reports/project?channel_id=C1&from=2018-06-26&to=2018-06-25", http.StatusBadRequest},
+		{"/api/v1/reports/project?channel_id=C1&from=2018-06-25&to=2018-06-26&format=xlsx", http.StatusBadRequest},
+		{"/api/v1/reports/project_user?channel_id=C1&user_id=U2&from=2018-06-25&to=2018-06-26", http.StatusNotFound},
+	}
+	for _, tt := range testCases {
+		assert.Equal(t, tt.code, apiRequest(t, rest, "GET", tt.path, "", nil), tt.path)
+	}
+}
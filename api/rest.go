@@ -1,21 +1,34 @@
 package api
 
 import (
+	"context"
+	"database/sql"
+	"expvar"
 	"fmt"
-	"io/ioutil"
+	"io"
+	"net"
 	"net/http"
 	"net/url"
 	"strconv"
 
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/gorilla/schema"
 	"github.com/labstack/echo"
+	"github.com/maddevsio/comedian/calendar"
+	"github.com/maddevsio/comedian/collector"
+	"github.com/maddevsio/comedian/chat"
 	"github.com/maddevsio/comedian/config"
+	"github.com/maddevsio/comedian/metrics"
 	"github.com/maddevsio/comedian/model"
+	"github.com/maddevsio/comedian/parser"
 	"github.com/maddevsio/comedian/reporting"
+	"github.com/maddevsio/comedian/schedule"
 	"github.com/maddevsio/comedian/storage"
+	"github.com/maddevsio/comedian/webhook"
+	"github.com/nlopes/slack"
 	"github.com/sirupsen/logrus"
 )
 
@@ -26,6 +39,16 @@ type REST struct {
 	conf    config.Config
 	decoder *schema.Decoder
 	report  *reporting.Reporter
+	// collector is used by reports, they are built without its data if it fails
+	collector collector.Client
+	// hooks redeliver events webhooks did not accept
+	hooks *webhook.Dispatcher
+	// sessionKey signs dashboard session cookies
+	sessionKey []byte
+	// files uploads reports to Slack
+	files fileUploader
+	// calendars downloads holiday calendars, it only connects to public addresses
+	calendars *http.Client
 }
 
 const (
@@ -36,20 +59,51 @@ const (
 	commandAddTime                = "/standuptimeset"
 	commandRemoveTime             = "/standuptimeremove"
 	commandListTime               = "/standuptime"
+	commandSetTimezone            = "/standuptimezone"
+	commandSetWorkDays            = "/standupworkdays"
+	commandSetSchedule            = "/standupschedule"
+	commandRemoveSchedule         = "/standupscheduleremove"
+	commandAddHoliday             = "/holidayadd"
+	commandRemoveHoliday          = "/holidayremove"
+	commandListHolidays           = "/holidays"
+	commandImportHolidays         = "/holidayimport"
+	commandAddAbsence             = "/vacationadd"
+	commandRemoveAbsence          = "/vacationremove"
+	commandListAbsences           = "/vacations"
+	commandSetTemplate            = "/standuptemplateset"
+	commandShowTemplate           = "/standuptemplate"
+	commandRemoveTemplate         = "/standuptemplateremove"
+	commandSetEscalation          = "/standupescalationset"
+	commandShowEscalation         = "/standupescalation"
+	commandRemoveEscalation       = "/standupescalationremove"
+	commandLinkProject            = "/linkproject"
+	commandUnlinkProject          = "/unlinkproject"
+	commandListLinks              = "/links"
+	commandLinkUser               = "/linkuser"
+	commandUnlinkUser             = "/unlinkuser"
+	commandAddAPIToken            = "/apitokenadd"
+	commandListAPITokens          = "/apitokens"
+	commandRemoveAPIToken         = "/apitokenremove"
+	commandAddWebhook             = "/webhookadd"
+	commandListWebhooks           = "/webhooks"
+	commandRemoveWebhook          = "/webhookremove"
+	commandRetryWebhook           = "/webhookretry"
 	commandReportByProject        = "/report_by_project"
 	commandReportByUser           = "/report_by_user"
 	commandReportByProjectAndUser = "/report_by_project_and_user"
+	commandReportBlockers         = "/report_blockers"
 )
 
+// commandTimeout is how long slash commands wait for Collector, Slack expects an answer within 3 seconds
+const commandTimeout = 2500 * time.Millisecond
+
+// maxCalendarSize is the largest calendar /holidayimport downloads
+const maxCalendarSize = 1 << 20
+
 // NewRESTAPI creates API for Slack commands
-func NewRESTAPI(c config.Config) (*REST, error) {
+func NewRESTAPI(c config.Config, db storage.Storage) (*REST, error) {
 	e := echo.New()
-	conn, err := storage.NewMySQL(c)
-	if err != nil {
-		logrus.Errorf("rest: NewMySQL failed: %v\n", err)
-		return nil, err
-	}
-	rep, err := reporting.NewReporter(c)
+	rep, err := reporting.NewReporter(c, db)
 	if err != nil {
 		logrus.Errorf("rest: NewReporter failed: %v\n", err)
 		return nil, err
@@ -58,11 +112,15 @@ func NewRESTAPI(c config.Config) (*REST, error) {
 	decoder := schema.NewDecoder()
 	decoder.IgnoreUnknownKeys(true)
 	r := &REST{
-		db:      conn,
-		echo:    e,
-		conf:    c,
-		decoder: decoder,
-		report:  rep,
+		db:        db,
+		echo:      e,
+		conf:      c,
+		decoder:   decoder,
+		report:    rep,
+		collector: metrics.New(c, db),
+		hooks:     webhook.New(c, db),
+		files:     slack.New(c.SlackToken),
+		calendars: newCalendarClient(),
 	}
 
 	r.initEndpoints()
@@ -70,7 +128,28 @@ func NewRESTAPI(c config.Config) (*REST, error) {
 }
 
 func (r *REST) initEndpoints() {
-	r.echo.POST("/commands", r.handleCommands)
+	if r.conf.SlackSigningSecret == "" && r.conf.SlackVerifyToken == "" {
+		logrus.Warning("rest: neither signing secret nor verification token is set, /commands requests are rejected")
+	}
+	r.echo.POST("/commands", r.handleCommands, r.verifySlackRequest)
+	r.echo.GET("/debug/vars", echo.WrapHandler(expvar.Handler()), r.authorizeToken)
+	r.initJSONAPI()
+	if r.conf.SlackClientID != "" && r.conf.SlackClientSecret != "" {
+		if err := r.initDashboard(); err != nil {
+			logrus.Errorf("rest: initDashboard failed, dashboard is off: %v\n", err)
+		}
+	}
+}
+
+// AddHandler mounts handler for POST requests on path, it is used by chat
+// transports which receive updates over http
+func (r *REST) AddHandler(path string, h http.Handler) {
+	r.echo.POST(path, echo.WrapHandler(h))
+}
+
+// Handler returns http handler of all endpoints, it is used to serve API in tests
+func (r *REST) Handler() http.Handler {
+	return r.echo
 }
 
 // Start starts http server
@@ -85,8 +164,17 @@ func (r *REST) handleCommands(c echo.Context) error {
 	}
 	slackUserID := form.Get("user_id")
 	channelID := form.Get("channel_id")
-	userIsAdmin := r.db.IsAdmin(slackUserID, channelID)
-	if (slackUserID != r.conf.ManagerSlackUserID) && (userIsAdmin == false) {
+	userIsAdmin := r.isManager(r.platform(c), slackUserID) || r.db.IsAdmin(slackUserID, channelID)
+	// standupers manage their own leaves, admins manage leaves of standupers of their channel
+	switch form.Get("command") {
+	case commandAddAbsence:
+		return r.addAbsence(c, form)
+	case commandRemoveAbsence:
+		return r.removeAbsence(c, form)
+	case commandListAbsences:
+		return r.listAbsences(c, form)
+	}
+	if !userIsAdmin {
 		return c.String(http.StatusOK, r.conf.Translate.AccessDenied)
 	}
 	if command := form.Get("command"); command != "" {
@@ -105,12 +193,66 @@ func (r *REST) handleCommands(c echo.Context) error {
 			return r.removeTime(c, form)
 		case commandListTime:
 			return r.listTime(c, form)
+		case commandSetTimezone:
+			return r.setTimezone(c, form)
+		case commandSetWorkDays:
+			return r.setWorkDays(c, form)
+		case commandSetSchedule:
+			return r.setSchedule(c, form)
+		case commandRemoveSchedule:
+			return r.removeSchedule(c, form)
+		case commandAddHoliday:
+			return r.addHoliday(c, form)
+		case commandRemoveHoliday:
+			return r.removeHoliday(c, form)
+		case commandListHolidays:
+			return r.listHolidays(c, form)
+		case commandImportHolidays:
+			return r.importHolidays(c, form)
+		case commandSetTemplate:
+			return r.setTemplate(c, form)
+		case commandShowTemplate:
+			return r.showTemplate(c, form)
+		case commandRemoveTemplate:
+			return r.removeTemplate(c, form)
+		case commandSetEscalation:
+			return r.setEscalation(c, form)
+		case commandShowEscalation:
+			return r.showEscalation(c, form)
+		case commandRemoveEscalation:
+			return r.removeEscalation(c, form)
+		case commandLinkProject:
+			return r.linkProject(c, form)
+		case commandUnlinkProject:
+			return r.unlinkProject(c, form)
+		case commandListLinks:
+			return r.listLinks(c, form)
+		case commandLinkUser:
+			return r.linkUser(c, form)
+		case commandUnlinkUser:
+			return r.unlinkUser(c, form)
+		case commandAddAPIToken:
+			return r.addAPIToken(c, form)
+		case commandListAPITokens:
+			return r.listAPITokens(c, form)
+		case commandRemoveAPIToken:
+			return r.removeAPIToken(c, form)
+		case commandAddWebhook:
+			return r.addWebhook(c, form)
+		case commandListWebhooks:
+			return r.listWebhooks(c, form)
+		case commandRemoveWebhook:
+			return r.removeWebhook(c, form)
+		case commandRetryWebhook:
+			return r.retryWebhook(c, form)
 		case commandReportByProject:
 			return r.reportByProject(c, form)
 		case commandReportByUser:
 			return r.reportByUser(c, form)
 		case commandReportByProjectAndUser:
 			return r.reportByProjectAndUser(c, form)
+		case commandReportBlockers:
+			return r.reportBlockers(c, form)
 		default:
 			return c.String(http.StatusNotImplemented, "Not implemented")
 		}
@@ -118,6 +260,26 @@ func (r *REST) handleCommands(c echo.Context) error {
 	return c.JSON(http.StatusMethodNotAllowed, "Command not allowed")
 }
 
+// isManager checks if user is a manager on the platform
+func (r *REST) isManager(platform, userID string) bool {
+	switch platform {
+	case chat.PlatformMattermost:
+		return r.conf.MattermostManager != "" && userID == r.conf.MattermostManager
+	case chat.PlatformTelegram:
+		return r.conf.TelegramManager != "" && userID == r.conf.TelegramManager
+	}
+	return userID == r.conf.ManagerSlackUserID
+}
+
+// platform returns platform the command came from, it is set by platform
+// specific endpoints. Commands sent to /commands come from Slack
+func (r *REST) platform(c echo.Context) string {
+	if p, ok := c.Get("platform").(string); ok {
+		return p
+	}
+	return chat.PlatformSlack
+}
+
 func (r *REST) addUserCommand(c echo.Context, f url.Values) error {
 	var ca FullSlackForm
 	if err := r.decoder.Decode(&ca, f); err != nil {
@@ -129,6 +291,9 @@ func (r *REST) addUserCommand(c echo.Context, f url.Values) error {
 		return c.String(http.StatusBadRequest, err.Error())
 	}
 	result := strings.Split(ca.Text, "|")
+	if len(result) != 2 {
+		return c.String(http.StatusOK, r.conf.Translate.WrongUsername)
+	}
 	slackUserID := strings.Replace(result[0], "<@", "", -1)
 	userName := strings.Replace(result[1], ">", "", -1)
 
@@ -140,6 +305,7 @@ func (r *REST) addUserCommand(c echo.Context, f url.Values) error {
 			ChannelID:   ca.ChannelID,
 			Channel:     ca.ChannelName,
 			Role:        "user",
+			Platform:    r.platform(c),
 		})
 		if err != nil {
 			logrus.Errorf("rest: CreateStandupUser failed: %v\n", err)
@@ -170,6 +336,9 @@ func (r *REST) addAdminCommand(c echo.Context, f url.Values) error {
 		return c.String(http.StatusBadRequest, err.Error())
 	}
 	result := strings.Split(ca.Text, "|")
+	if len(result) != 2 {
+		return c.String(http.StatusOK, r.conf.Translate.WrongUsername)
+	}
 	slackUserID := strings.Replace(result[0], "<@", "", -1)
 	userName := strings.Replace(result[1], ">", "", -1)
 
@@ -181,6 +350,7 @@ func (r *REST) addAdminCommand(c echo.Context, f url.Values) error {
 			ChannelID:   ca.ChannelID,
 			Channel:     ca.ChannelName,
 			Role:        "admin",
+			Platform:    r.platform(c),
 		})
 		if err != nil {
 			logrus.Errorf("rest: CreateStandupUser failed: %v\n", err)
@@ -251,7 +421,23 @@ func (r *REST) addTime(c echo.Context, f url.Values) error {
 		return c.String(http.StatusBadRequest, err.Error())
 	}
 
-	result := strings.Split(ca.Text, ":")
+	// time zone is optional: /standuptimeset 09:30 Europe/Berlin
+	params := strings.Fields(ca.Text)
+	if len(params) == 0 || len(params) > 2 {
+		return c.String(http.StatusOK, r.conf.Translate.WrongNArgs)
+	}
+	timezone := ""
+	if len(params) > 1 {
+		timezone = params[1]
+	}
+	loc, err := loadLocation(timezone)
+	if err != nil {
+		return c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.WrongTimezone, timezone))
+	}
+	result := strings.Split(params[0], ":")
+	if len(result) != 2 {
+		return c.String(http.StatusOK, r.conf.Translate.WrongNArgs)
+	}
 	hours, err := strconv.Atoi(result[0])
 	if err != nil {
 		logrus.Errorf("rest: strconv.Atoi failed: %v\n", err)
@@ -262,13 +448,15 @@ func (r *REST) addTime(c echo.Context, f url.Values) error {
 		logrus.Errorf("rest: strconv.Atoi failed: %v\n", err)
 		return err
 	}
-	currentTime := time.Now()
-	timeInt := time.Date(currentTime.Year(), currentTime.Month(), currentTime.Day(), hours, munites, 0, 0, time.Local).Unix()
+	currentTime := time.Now().In(loc)
+	timeInt := time.Date(currentTime.Year(), currentTime.Month(), currentTime.Day(), hours, munites, 0, 0, loc).Unix()
 
 	standupTime, err := r.db.CreateStandupTime(model.StandupTime{
 		ChannelID: ca.ChannelID,
 		Channel:   ca.ChannelName,
 		Time:      timeInt,
+		Platform:  r.platform(c),
+		Timezone:  timezone,
 	})
 	if err != nil {
 		logrus.Errorf("rest: CreateStandupTime failed: %v\n", err)
@@ -285,219 +473,1273 @@ func (r *REST) addTime(c echo.Context, f url.Values) error {
 	return c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.AddStandupTime, standupTime.Time))
 }
 
-func (r *REST) removeTime(c echo.Context, f url.Values) error {
-	var ca ChannelForm
+///standuptimezone Asia/Bishkek
+func (r *REST) setTimezone(c echo.Context, f url.Values) error {
+	var ca ChannelIDTextForm
 	if err := r.decoder.Decode(&ca, f); err != nil {
-		logrus.Errorf("rest: removeTime Decode failed: %v\n", err)
+		logrus.Errorf("rest: setTimezone Decode failed: %v\n", err)
 		return c.String(http.StatusBadRequest, err.Error())
 	}
 	if err := ca.Validate(); err != nil {
-		logrus.Errorf("rest: removeTime Validate failed: %v\n", err)
+		logrus.Errorf("rest: setTimezone Validate failed: %v\n", err)
 		return c.String(http.StatusBadRequest, err.Error())
 	}
+	timezone := strings.TrimSpace(ca.Text)
+	loc, err := loadLocation(timezone)
+	if err != nil {
+		return c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.WrongTimezone, timezone))
+	}
 
-	err := r.db.DeleteStandupTime(ca.ChannelID)
+	standupTime, err := r.db.GetChannelStandupTime(ca.ChannelID)
+	if err == sql.ErrNoRows {
+		return c.String(http.StatusOK, r.conf.Translate.ShowNoStandupTime)
+	}
 	if err != nil {
-		logrus.Errorf("rest: DeleteStandupTime failed: %v\n", err)
-		return c.String(http.StatusBadRequest, fmt.Sprintf("failed to delete standup time :%v\n", err))
+		logrus.Errorf("rest: GetChannelStandupTime failed: %v\n", err)
+		return err
 	}
-	st, err := r.db.ListStandupUsersByChannelID(ca.ChannelID)
-	if len(st) != 0 {
-		return c.String(http.StatusOK, r.conf.Translate.RemoveStandupTimeWithUsers)
+	// standup keeps the same wall clock time in the new time zone
+	old := time.Unix(standupTime.Time, 0).In(standupTime.Location())
+	now := time.Now().In(loc)
+	standupTime.Time = time.Date(now.Year(), now.Month(), now.Day(), old.Hour(), old.Minute(), 0, 0, loc).Unix()
+	standupTime.Timezone = timezone
+	if _, err := r.db.UpdateStandupTime(standupTime); err != nil {
+		logrus.Errorf("rest: UpdateStandupTime failed: %v\n", err)
+		return err
 	}
-	return c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.RemoveStandupTime, ca.ChannelName))
+	return c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.SetStandupTimezone, timezone, old.Format("15:04")))
 }
 
-func (r *REST) listTime(c echo.Context, f url.Values) error {
-	var ca ChannelIDForm
+///standupworkdays sun-thu
+func (r *REST) setWorkDays(c echo.Context, f url.Values) error {
+	var ca ChannelIDTextForm
 	if err := r.decoder.Decode(&ca, f); err != nil {
-		logrus.Errorf("rest: listTime Decode failed: %v\n", err)
+		logrus.Errorf("rest: setWorkDays Decode failed: %v\n", err)
 		return c.String(http.StatusBadRequest, err.Error())
 	}
 	if err := ca.Validate(); err != nil {
-		logrus.Errorf("rest: listTime Validate failed: %v\n", err)
+		logrus.Errorf("rest: setWorkDays Validate failed: %v\n", err)
 		return c.String(http.StatusBadRequest, err.Error())
 	}
+	days, err := calendar.ParseWorkDays(ca.Text)
+	if err != nil {
+		return c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.WrongWorkDays, ca.Text))
+	}
 
 	standupTime, err := r.db.GetChannelStandupTime(ca.ChannelID)
+	if err == sql.ErrNoRows {
+		return c.String(http.StatusOK, r.conf.Translate.ShowNoStandupTime)
+	}
 	if err != nil {
 		logrus.Errorf("rest: GetChannelStandupTime failed: %v\n", err)
-		if err.Error() == "sql: no rows in result set" {
-			return c.String(http.StatusOK, r.conf.Translate.ShowNoStandupTime)
-		} else {
-			return c.String(http.StatusBadRequest, fmt.Sprintf("failed to list time :%v\n", err))
-		}
+		return err
 	}
-	return c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.ShowStandupTime, standupTime.Time))
+	standupTime.WorkDays = calendar.FormatWorkDays(days)
+	if _, err := r.db.UpdateStandupTime(standupTime); err != nil {
+		logrus.Errorf("rest: UpdateStandupTime failed: %v\n", err)
+		return err
+	}
+	return c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.SetWorkDays, standupTime.WorkDays))
 }
 
-///report_by_project #collector-test 2018-07-24 2018-07-26
-func (r *REST) reportByProject(c echo.Context, f url.Values) error {
+///standupschedule 30 9 * * MON,WED,FRI
+func (r *REST) setSchedule(c echo.Context, f url.Values) error {
 	var ca ChannelIDTextForm
 	if err := r.decoder.Decode(&ca, f); err != nil {
-		logrus.Errorf("rest: reportByProject Decode failed: %v\n", err)
-		return c.String(http.StatusOK, err.Error())
+		logrus.Errorf("rest: setSchedule Decode failed: %v\n", err)
+		return c.String(http.StatusBadRequest, err.Error())
 	}
 	if err := ca.Validate(); err != nil {
-		logrus.Errorf("rest: reportByProject Validate failed: %v\n", err)
-		return c.String(http.StatusOK, err.Error())
+		logrus.Errorf("rest: setSchedule Validate failed: %v\n", err)
+		return c.String(http.StatusBadRequest, err.Error())
 	}
-	commandParams := strings.Fields(ca.Text)
-	if len(commandParams) != 3 {
-		return c.String(http.StatusOK, r.conf.Translate.WrongNArgs)
+	expr := strings.TrimSpace(ca.Text)
+	s, err := schedule.Parse(expr)
+	if err == nil {
+		err = schedule.Upcoming(s, time.Now())
 	}
-	channelID, channelName := splitChannel(commandParams[0])
-
-	dateFrom, err := time.Parse("2006-01-02", commandParams[1])
 	if err != nil {
-		logrus.Errorf("rest: time.Parse failed: %v\n", err)
-		return c.String(http.StatusOK, err.Error())
+		return c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.WrongSchedule, expr, err))
 	}
-	dateTo, err := time.Parse("2006-01-02", commandParams[2])
-	if err != nil {
-		logrus.Errorf("rest: time.Parse failed: %v\n", err)
-		return c.String(http.StatusOK, err.Error())
+
+	standupTime, err := r.db.GetChannelStandupTime(ca.ChannelID)
+	if err == sql.ErrNoRows {
+		return c.String(http.StatusOK, r.conf.Translate.ShowNoStandupTime)
 	}
-	data, err := r.getCollectorData("projects", channelName, commandParams[1], commandParams[2])
 	if err != nil {
-		logrus.Errorf("rest: getCollectorData failed: %v\n", err)
-		return c.String(http.StatusOK, err.Error())
+		logrus.Errorf("rest: GetChannelStandupTime failed: %v\n", err)
+		return err
 	}
-	report, err := r.report.StandupReportByProject(channelID, dateFrom, dateTo, data)
-	if err != nil {
-		logrus.Errorf("rest: StandupReportByProject: %v\n", err)
-		return c.String(http.StatusOK, err.Error())
+	standupTime.Schedule = expr
+	if _, err := r.db.UpdateStandupTime(standupTime); err != nil {
+		logrus.Errorf("rest: UpdateStandupTime failed: %v\n", err)
+		return err
 	}
-	return c.String(http.StatusOK, report)
+	return c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.SetStandupSchedule, expr, r.nextStandup(standupTime)))
 }
 
-///report_by_user @Anatoliy 2018-07-24 2018-07-26
-func (r *REST) reportByUser(c echo.Context, f url.Values) error {
-	var ca FullSlackForm
+func (r *REST) removeSchedule(c echo.Context, f url.Values) error {
+	var ca ChannelIDForm
 	if err := r.decoder.Decode(&ca, f); err != nil {
-		logrus.Errorf("rest: reportByUser Decode failed: %v\n", err)
-		return c.String(http.StatusOK, err.Error())
+		logrus.Errorf("rest: removeSchedule Decode failed: %v\n", err)
+		return c.String(http.StatusBadRequest, err.Error())
 	}
 	if err := ca.Validate(); err != nil {
-		logrus.Errorf("rest: reportByUser Validate failed: %v\n", err)
-		return c.String(http.StatusOK, err.Error())
+		logrus.Errorf("rest: removeSchedule Validate failed: %v\n", err)
+		return c.String(http.StatusBadRequest, err.Error())
 	}
-	commandParams := strings.Fields(ca.Text)
-	if len(commandParams) != 3 {
-		return c.String(http.StatusOK, r.conf.Translate.UserExist)
+
+	standupTime, err := r.db.GetChannelStandupTime(ca.ChannelID)
+	if err == sql.ErrNoRows {
+		return c.String(http.StatusOK, r.conf.Translate.ShowNoStandupTime)
 	}
-	userID, userName := splitUser(commandParams[0])
-	user, err := r.db.FindStandupUser(userName)
 	if err != nil {
-		return c.String(http.StatusOK, err.Error())
+		logrus.Errorf("rest: GetChannelStandupTime failed: %v\n", err)
+		return err
 	}
-	dateFrom, err := time.Parse("2006-01-02", commandParams[1])
-	if err != nil {
-		logrus.Errorf("rest: time.Parse failed: %v\n", err)
-		return c.String(http.StatusOK, err.Error())
+	standupTime.Schedule = ""
+	if _, err := r.db.UpdateStandupTime(standupTime); err != nil {
+		logrus.Errorf("rest: UpdateStandupTime failed: %v\n", err)
+		return err
 	}
-	dateTo, err := time.Parse("2006-01-02", commandParams[2])
+	return c.String(http.StatusOK, r.conf.Translate.RemoveStandupSchedule)
+}
+
+// nextStandup returns time of the next standup by channel schedule skipping holidays,
+// in channel time zone
+func (r *REST) nextStandup(st model.StandupTime) string {
+	holidays, err := r.db.ListHolidays(st.ChannelID)
 	if err != nil {
-		logrus.Errorf("rest: time.Parse failed: %v\n", err)
-		return c.String(http.StatusOK, err.Error())
+		logrus.Errorf("rest: ListHolidays failed: %v\n", err)
 	}
-	data, err := r.getCollectorData("users", userID, commandParams[1], commandParams[2])
-	if err != nil {
-		logrus.Errorf("rest: getCollectorData failed: %v\n", err)
-		return c.String(http.StatusOK, err.Error())
+	next, ok := calendar.New(st, holidays).NextStandup(time.Now())
+	if !ok {
+		return "-"
 	}
-	report, err := r.report.StandupReportByUser(user, dateFrom, dateTo, data)
-	if err != nil {
-		logrus.Errorf("rest: StandupReportByUser failed: %v\n", err)
-		return c.String(http.StatusOK, err.Error())
+	return next.Format("Mon, 2006-01-02 15:04 MST")
+}
+
+///holidayadd 2018-12-31 New Year eve
+func (r *REST) addHoliday(c echo.Context, f url.Values) error {
+	var ca ChannelIDTextForm
+	if err := r.decoder.Decode(&ca, f); err != nil {
+		logrus.Errorf("rest: addHoliday Decode failed: %v\n", err)
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+	if err := ca.Validate(); err != nil {
+		logrus.Errorf("rest: addHoliday Validate failed: %v\n", err)
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+	params := strings.SplitN(strings.TrimSpace(ca.Text), " ", 2)
+	if _, err := time.Parse(calendar.DateFormat, params[0]); err != nil {
+		return c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.WrongDate, params[0]))
+	}
+	holiday := model.Holiday{ChannelID: ca.ChannelID, Date: params[0]}
+	if len(params) == 2 {
+		holiday.Name = strings.TrimSpace(params[1])
+	}
+	// the same day is kept once, the new name replaces the old one
+	if err := r.db.DeleteHoliday(ca.ChannelID, holiday.Date); err != nil {
+		logrus.Errorf("rest: DeleteHoliday failed: %v\n", err)
+		return err
+	}
+	if _, err := r.db.CreateHoliday(holiday); err != nil {
+		logrus.Errorf("rest: CreateHoliday failed: %v\n", err)
+		return err
 	}
-	return c.String(http.StatusOK, report)
+	return c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.AddHoliday, holiday.Date))
 }
 
-///report_by_project_and_user #collector-test @Anatoliy 2018-07-24 2018-07-26
-func (r *REST) reportByProjectAndUser(c echo.Context, f url.Values) error {
-	var ca FullSlackForm
+///holidayremove 2018-12-31
+func (r *REST) removeHoliday(c echo.Context, f url.Values) error {
+	var ca ChannelIDTextForm
 	if err := r.decoder.Decode(&ca, f); err != nil {
-		logrus.Errorf("rest: reportByProjectAndUser Decode failed: %v\n", err)
-		return c.String(http.StatusOK, err.Error())
+		logrus.Errorf("rest: removeHoliday Decode failed: %v\n", err)
+		return c.String(http.StatusBadRequest, err.Error())
 	}
 	if err := ca.Validate(); err != nil {
-		logrus.Errorf("rest: reportByProjectAndUser Validate failed: %v\n", err)
-		return c.String(http.StatusOK, err.Error())
+		logrus.Errorf("rest: removeHoliday Validate failed: %v\n", err)
+		return c.String(http.StatusBadRequest, err.Error())
 	}
-	commandParams := strings.Fields(ca.Text)
-	if len(commandParams) != 4 {
-		return c.String(http.StatusOK, r.conf.Translate.WrongNArgs)
+	date := strings.TrimSpace(ca.Text)
+	if _, err := time.Parse(calendar.DateFormat, date); err != nil {
+		return c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.WrongDate, date))
 	}
-	channelID, channelName := splitChannel(commandParams[0])
-	userID, _ := splitUser(commandParams[1])
-	dateFrom, err := time.Parse("2006-01-02", commandParams[2])
-	if err != nil {
-		logrus.Errorf("rest: time.Parse failed: %v\n", err)
-		return c.String(http.StatusOK, err.Error())
+	if err := r.db.DeleteHoliday(ca.ChannelID, date); err != nil {
+		logrus.Errorf("rest: DeleteHoliday failed: %v\n", err)
+		return c.String(http.StatusBadRequest, fmt.Sprintf("failed to delete holiday :%v\n", err))
 	}
-	dateTo, err := time.Parse("2006-01-02", commandParams[3])
-	if err != nil {
-		logrus.Errorf("rest: time.Parse failed: %v\n", err)
-		return c.String(http.StatusOK, err.Error())
+	return c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.RemoveHoliday, date))
+}
+
+func (r *REST) listHolidays(c echo.Context, f url.Values) error {
+	var ca ChannelIDForm
+	if err := r.decoder.Decode(&ca, f); err != nil {
+		logrus.Errorf("rest: listHolidays Decode failed: %v\n", err)
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+	if err := ca.Validate(); err != nil {
+		logrus.Errorf("rest: listHolidays Validate failed: %v\n", err)
+		return c.String(http.StatusBadRequest, err.Error())
 	}
-	pu := channelName + "/" + userID
-	data, err := r.getCollectorData("projects-users", pu, commandParams[2], commandParams[3])
+	holidays, err := r.db.ListHolidays(ca.ChannelID)
 	if err != nil {
-		logrus.Errorf("rest: getCollectorData failed: %v\n", err)
-		return c.String(http.StatusOK, err.Error())
+		logrus.Errorf("rest: ListHolidays failed: %v\n", err)
+		return c.String(http.StatusBadRequest, fmt.Sprintf("failed to list holidays :%v\n", err))
+	}
+	if len(holidays) == 0 {
+		return c.String(http.StatusOK, r.conf.Translate.ListNoHolidays)
 	}
+	days := []string{}
+	for _, h := range holidays {
+		if h.Name == "" {
+			days = append(days, h.Date)
+			continue
+		}
+		days = append(days, fmt.Sprintf("%v (%v)", h.Date, h.Name))
+	}
+	return c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.ListHolidays, strings.Join(days, ", ")))
+}
 
-	user, err := r.db.FindStandupUserInChannelByUserID(userID, channelID)
+///holidayimport https://example.com/holidays.ics
+func (r *REST) importHolidays(c echo.Context, f url.Values) error {
+	var ca ChannelIDTextForm
+	if err := r.decoder.Decode(&ca, f); err != nil {
+		logrus.Errorf("rest: importHolidays Decode failed: %v\n", err)
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+	if err := ca.Validate(); err != nil {
+		logrus.Errorf("rest: importHolidays Validate failed: %v\n", err)
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+	// Slack wraps links in angle brackets: <https://example.com/holidays.ics>
+	link := strings.Trim(strings.TrimSpace(ca.Text), "<>")
+	failed := fmt.Sprintf(r.conf.Translate.ImportHolidaysFailed, link)
+	u, err := url.Parse(link)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		logrus.Errorf("rest: importHolidays wrong link %q: %v\n", link, err)
+		return c.String(http.StatusOK, failed)
+	}
+	ctx, cancel := context.WithTimeout(c.Request().Context(), commandTimeout)
+	defer cancel()
+	req, err := http.NewRequest("GET", u.String(), nil)
 	if err != nil {
-		return c.String(http.StatusOK, r.conf.Translate.ReportByProjectAndUser)
+		logrus.Errorf("rest: NewRequest failed: %v\n", err)
+		return c.String(http.StatusOK, failed)
 	}
-	report, err := r.report.StandupReportByProjectAndUser(channelID, user, dateFrom, dateTo, data)
+	res, err := r.calendars.Do(req.WithContext(ctx))
 	if err != nil {
-		logrus.Errorf("rest: StandupReportByProjectAndUser failed: %v\n", err)
-		return c.String(http.StatusOK, err.Error())
+		logrus.Errorf("rest: importHolidays download failed: %v\n", err)
+		return c.String(http.StatusOK, failed)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		logrus.Errorf("rest: importHolidays download failed: %v\n", res.Status)
+		return c.String(http.StatusOK, failed)
+	}
+	holidays, err := calendar.ParseICS(io.LimitReader(res.Body, maxCalendarSize), ca.ChannelID)
+	if err != nil {
+		logrus.Errorf("rest: ParseICS failed: %v\n", err)
+		return c.String(http.StatusOK, failed)
+	}
+	for _, h := range holidays {
+		if err := r.db.DeleteHoliday(h.ChannelID, h.Date); err != nil {
+			logrus.Errorf("rest: DeleteHoliday failed: %v\n", err)
+			return err
+		}
+		if _, err := r.db.CreateHoliday(h); err != nil {
+			logrus.Errorf("rest: CreateHoliday failed: %v\n", err)
+			return err
+		}
 	}
-	return c.String(http.StatusOK, report)
+	return c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.ImportHolidays, len(holidays)))
 }
 
-func (r *REST) getCollectorData(getDataOn, data, dateFrom, dateTo string) ([]byte, error) {
-	linkURL := fmt.Sprintf("%s/rest/api/v1/logger/%s/%s/%s/%s", r.conf.CollectorURL, getDataOn, data, dateFrom, dateTo)
-	logrus.Infof("rest: getCollectorData request URL: %s", linkURL)
-	req, err := http.NewRequest("GET", linkURL, nil)
-	if err != nil {
-		logrus.Errorf("rest: http.NewRequest failed: %v\n", err)
-		return nil, err
+// newCalendarClient returns client which follows only http and https links to public
+// addresses, so /holidayimport can not be used to reach services of the internal network
+func newCalendarClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: commandTimeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil || !publicIP(ip) {
+				return fmt.Errorf("address %v is not public", host)
+			}
+			return nil
+		},
+	}
+	return &http.Client{
+		Timeout: commandTimeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: commandTimeout,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+				return fmt.Errorf("redirect to %v is not allowed", req.URL.Scheme)
+			}
+			if len(via) >= 5 {
+				return fmt.Errorf("stopped after %v redirects", len(via))
+			}
+			return nil
+		},
 	}
-	token := r.conf.CollectorToken
-	req.Header.Add("Authorization", fmt.Sprintf("Token %s", token))
+}
 
-	res, err := http.DefaultClient.Do(req)
-	if err != nil {
-		logrus.Errorf("rest: http.DefaultClient.Do(req) failed: %v\n", err)
-		return nil, err
+func publicIP(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsUnspecified() || ip.IsPrivate() || ip.IsMulticast() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast())
+}
+
+///vacationadd 2018-07-09 2018-07-13 vacation
+///vacationadd @user 2018-07-09 2018-07-13 sick leave
+func (r *REST) addAbsence(c echo.Context, f url.Values) error {
+	var ca UserTextForm
+	if err := r.decoder.Decode(&ca, f); err != nil {
+		logrus.Errorf("rest: addAbsence Decode failed: %v\n", err)
+		return c.String(http.StatusBadRequest, err.Error())
 	}
-	defer res.Body.Close()
+	if err := ca.Validate(); err != nil {
+		logrus.Errorf("rest: addAbsence Validate failed: %v\n", err)
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+	slackUserID, params, allowed := r.absenceUser(c, ca)
+	if !allowed {
+		return c.String(http.StatusOK, r.conf.Translate.AccessDenied)
+	}
+	if len(params) < 2 {
+		return c.String(http.StatusOK, r.conf.Translate.WrongAbsence)
+	}
+	for _, date := range params[:2] {
+		if _, err := time.Parse(calendar.DateFormat, date); err != nil {
+			return c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.WrongDate, date))
+		}
+	}
+	absence := model.Absence{
+		SlackUserID: slackUserID,
+		DateFrom:    params[0],
+		DateTo:      params[1],
+		Reason:      strings.Join(params[2:], " "),
+	}
+	if err := absence.Validate(); err != nil {
+		return c.String(http.StatusOK, r.conf.Translate.WrongAbsence)
+	}
+	// a leave starting the same day is replaced
+	if err := r.db.DeleteAbsence(slackUserID, absence.DateFrom); err != nil {
+		logrus.Errorf("rest: DeleteAbsence failed: %v\n", err)
+		return err
+	}
+	if _, err := r.db.CreateAbsence(absence); err != nil {
+		logrus.Errorf("rest: CreateAbsence failed: %v\n", err)
+		return err
+	}
+	return c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.AddAbsence, slackUserID, absence.DateFrom, absence.DateTo))
+}
+
+///vacationremove 2018-07-09
+///vacationremove @user 2018-07-09
+func (r *REST) removeAbsence(c echo.Context, f url.Values) error {
+	var ca UserTextForm
+	if err := r.decoder.Decode(&ca, f); err != nil {
+		logrus.Errorf("rest: removeAbsence Decode failed: %v\n", err)
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+	if err := ca.Validate(); err != nil {
+		logrus.Errorf("rest: removeAbsence Validate failed: %v\n", err)
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+	slackUserID, params, allowed := r.absenceUser(c, ca)
+	if !allowed {
+		return c.String(http.StatusOK, r.conf.Translate.AccessDenied)
+	}
+	if len(params) != 1 {
+		return c.String(http.StatusOK, r.conf.Translate.WrongAbsence)
+	}
+	if _, err := time.Parse(calendar.DateFormat, params[0]); err != nil {
+		return c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.WrongDate, params[0]))
+	}
+	if err := r.db.DeleteAbsence(slackUserID, params[0]); err != nil {
+		logrus.Errorf("rest: DeleteAbsence failed: %v\n", err)
+		return c.String(http.StatusBadRequest, fmt.Sprintf("failed to delete leave :%v\n", err))
+	}
+	return c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.RemoveAbsence, slackUserID, params[0]))
+}
 
-	body, err := ioutil.ReadAll(res.Body)
+///vacations
+///vacations @user
+func (r *REST) listAbsences(c echo.Context, f url.Values) error {
+	var ca UserTextForm
+	if err := r.decoder.Decode(&ca, f); err != nil {
+		logrus.Errorf("rest: listAbsences Decode failed: %v\n", err)
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+	if err := ca.Validate(); err != nil {
+		logrus.Errorf("rest: listAbsences Validate failed: %v\n", err)
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+	slackUserID, _, allowed := r.absenceUser(c, ca)
+	if !allowed {
+		return c.String(http.StatusOK, r.conf.Translate.AccessDenied)
+	}
+	absences, err := r.db.ListAbsences(slackUserID)
 	if err != nil {
-		logrus.Errorf("rest: ioutil.ReadAll(res.Body) failed: %v\n", err)
-		return nil, err
+		logrus.Errorf("rest: ListAbsences failed: %v\n", err)
+		return c.String(http.StatusBadRequest, fmt.Sprintf("failed to list leaves :%v\n", err))
+	}
+	if len(absences) == 0 {
+		return c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.ListNoAbsences, slackUserID))
 	}
-	logrus.Infof("rest: getCollectorData responce body: %s", string(body))
-	return body, nil
+	leaves := []string{}
+	for _, a := range absences {
+		leave := fmt.Sprintf("%v - %v", a.DateFrom, a.DateTo)
+		if a.Reason != "" {
+			leave += fmt.Sprintf(" (%v)", a.Reason)
+		}
+		leaves = append(leaves, leave)
+	}
+	return c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.ListAbsences, slackUserID, strings.Join(leaves, ", ")))
+}
 
+// absenceUser returns the user whose leaves are managed by command and the rest of
+// command text. Users are mentioned as <@U123|name>, the manager may mention anyone
+// and channel admins may mention standupers of their channel
+func (r *REST) absenceUser(c echo.Context, ca UserTextForm) (string, []string, bool) {
+	params := strings.Fields(ca.Text)
+	if len(params) == 0 || !strings.HasPrefix(params[0], "<@") {
+		return ca.UserID, params, true
+	}
+	slackUserID := strings.SplitN(strings.Trim(params[0], "<@>"), "|", 2)[0]
+	if slackUserID == ca.UserID || r.isManager(r.platform(c), ca.UserID) {
+		return slackUserID, params[1:], true
+	}
+	if !r.db.IsAdmin(ca.UserID, ca.ChannelID) {
+		return slackUserID, params[1:], false
+	}
+	_, err := r.db.FindStandupUserInChannelByUserID(slackUserID, ca.ChannelID)
+	return slackUserID, params[1:], err == nil
 }
 
-func splitChannel(channel string) (string, string) {
-	channelSeparate := strings.Split(channel, "|")
-	channelID := strings.Replace(channelSeparate[0], "<#", "", -1)
-	channelName := strings.Replace(channelSeparate[1], ">", "", -1)
-	return channelID, channelName
+// loadLocation returns IANA time zone, empty name means server local time
+func loadLocation(name string) (*time.Location, error) {
+	if name == "" {
+		return time.Local, nil
+	}
+	return time.LoadLocation(name)
 }
 
-func splitUser(user string) (string, string) {
-	userFull := strings.Split(user, "|")
+func (r *REST) removeTime(c echo.Context, f url.Values) error {
+	var ca ChannelForm
+	if err := r.decoder.Decode(&ca, f); err != nil {
+		logrus.Errorf("rest: removeTime Decode failed: %v\n", err)
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+	if err := ca.Validate(); err != nil {
+		logrus.Errorf("rest: removeTime Validate failed: %v\n", err)
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+
+	err := r.db.DeleteStandupTime(ca.ChannelID)
+	if err != nil {
+		logrus.Errorf("rest: DeleteStandupTime failed: %v\n", err)
+		return c.String(http.StatusBadRequest, fmt.Sprintf("failed to delete standup time :%v\n", err))
+	}
+	st, err := r.db.ListStandupUsersByChannelID(ca.ChannelID)
+	if len(st) != 0 {
+		return c.String(http.StatusOK, r.conf.Translate.RemoveStandupTimeWithUsers)
+	}
+	return c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.RemoveStandupTime, ca.ChannelName))
+}
+
+func (r *REST) listTime(c echo.Context, f url.Values) error {
+	var ca ChannelIDForm
+	if err := r.decoder.Decode(&ca, f); err != nil {
+		logrus.Errorf("rest: listTime Decode failed: %v\n", err)
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+	if err := ca.Validate(); err != nil {
+		logrus.Errorf("rest: listTime Validate failed: %v\n", err)
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+
+	standupTime, err := r.db.GetChannelStandupTime(ca.ChannelID)
+	if err != nil {
+		logrus.Errorf("rest: GetChannelStandupTime failed: %v\n", err)
+		if err.Error() == "sql: no rows in result set" {
+			return c.String(http.StatusOK, r.conf.Translate.ShowNoStandupTime)
+		} else {
+			return c.String(http.StatusBadRequest, fmt.Sprintf("failed to list time :%v\n", err))
+		}
+	}
+	if standupTime.Schedule != "" {
+		return c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.ShowStandupSchedule, standupTime.Schedule, r.nextStandup(standupTime)))
+	}
+	return c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.ShowStandupTime, standupTime.Time))
+}
+
+///standuptemplateset done=one next:today=/(?i)next|will/ blockers:problems=/(?i)block|stuck/
+func (r *REST) setTemplate(c echo.Context, f url.Values) error {
+	var ca ChannelIDTextForm
+	if err := r.decoder.Decode(&ca, f); err != nil {
+		logrus.Errorf("rest: setTemplate Decode failed: %v\n", err)
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+	if err := ca.Validate(); err != nil {
+		logrus.Errorf("rest: setTemplate Validate failed: %v\n", err)
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+
+	questions := []model.StandupQuestion{}
+	names := []string{}
+	// every name keeps its answer and every role is filled by one question
+	seen, roles := map[string]bool{}, map[string]bool{}
+	for i, field := range strings.Fields(ca.Text) {
+		q := strings.SplitN(field, "=", 2)
+		if len(q) != 2 || q[0] == "" || q[1] == "" {
+			return c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.WrongStandupTemplate, field))
+		}
+		name, role := q[0], ""
+		if n := strings.Index(name, ":"); n >= 0 {
+			name, role = name[:n], name[n+1:]
+			if name == "" || !parser.IsRole(role) {
+				return c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.WrongStandupTemplate, field))
+			}
+		} else if parser.IsRole(name) {
+			role = name
+		}
+		if seen[name] || (role != "" && roles[role]) {
+			return c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.WrongStandupTemplate, field))
+		}
+		seen[name] = true
+		if role != "" {
+			roles[role] = true
+		}
+		if _, err := parser.NewQuestion(name, q[1]); err != nil {
+			return c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.WrongStandupTemplate, err))
+		}
+		questions = append(questions, model.StandupQuestion{ChannelID: ca.ChannelID, Name: name, Pattern: q[1], Position: i, Role: role})
+		names = append(names, name)
+	}
+
+	if err := r.db.DeleteStandupQuestions(ca.ChannelID); err != nil {
+		logrus.Errorf("rest: DeleteStandupQuestions failed: %v\n", err)
+		return err
+	}
+	for _, q := range questions {
+		if _, err := r.db.CreateStandupQuestion(q); err != nil {
+			logrus.Errorf("rest: CreateStandupQuestion failed: %v\n", err)
+			return err
+		}
+	}
+	return c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.AddStandupTemplate, strings.Join(names, ", ")))
+}
+
+func (r *REST) showTemplate(c echo.Context, f url.Values) error {
+	var ca ChannelIDForm
+	if err := r.decoder.Decode(&ca, f); err != nil {
+		logrus.Errorf("rest: showTemplate Decode failed: %v\n", err)
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+	if err := ca.Validate(); err != nil {
+		logrus.Errorf("rest: showTemplate Validate failed: %v\n", err)
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+
+	questions, err := r.db.ListStandupQuestions(ca.ChannelID)
+	if err != nil {
+		logrus.Errorf("rest: ListStandupQuestions failed: %v\n", err)
+		return c.String(http.StatusBadRequest, fmt.Sprintf("failed to list template :%v\n", err))
+	}
+	if len(questions) == 0 {
+		return c.String(http.StatusOK, r.conf.Translate.ShowNoStandupTemplate)
+	}
+	template := []string{}
+	for _, q := range questions {
+		if q.Role != "" && q.Role != q.Name {
+			template = append(template, q.Name+":"+q.Role+"="+q.Pattern)
+			continue
+		}
+		template = append(template, q.Name+"="+q.Pattern)
+	}
+	return c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.ShowStandupTemplate, strings.Join(template, " ")))
+}
+
+func (r *REST) removeTemplate(c echo.Context, f url.Values) error {
+	var ca ChannelIDForm
+	if err := r.decoder.Decode(&ca, f); err != nil {
+		logrus.Errorf("rest: removeTemplate Decode failed: %v\n", err)
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+	if err := ca.Validate(); err != nil {
+		logrus.Errorf("rest: removeTemplate Validate failed: %v\n", err)
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+
+	if err := r.db.DeleteStandupQuestions(ca.ChannelID); err != nil {
+		logrus.Errorf("rest: DeleteStandupQuestions failed: %v\n", err)
+		return c.String(http.StatusBadRequest, fmt.Sprintf("failed to delete template :%v\n", err))
+	}
+	return c.String(http.StatusOK, r.conf.Translate.RemoveStandupTemplate)
+}
+
+///standupescalationset dm=0 channel=30 admins=60 general=120
+func (r *REST) setEscalation(c echo.Context, f url.Values) error {
+	var ca ChannelIDTextForm
+	if err := r.decoder.Decode(&ca, f); err != nil {
+		logrus.Errorf("rest: setEscalation Decode failed: %v\n", err)
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+	if err := ca.Validate(); err != nil {
+		logrus.Errorf("rest: setEscalation Validate failed: %v\n", err)
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+
+	steps := []model.EscalationStep{}
+	for i, field := range strings.Fields(ca.Text) {
+		s := strings.SplitN(field, "=", 2)
+		if len(s) != 2 {
+			return c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.WrongEscalation, field))
+		}
+		delay, err := strconv.Atoi(s[1])
+		if err != nil {
+			return c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.WrongEscalation, field))
+		}
+		step := model.EscalationStep{ChannelID: ca.ChannelID, Action: strings.ToLower(s[0]), Delay: delay, Position: i}
+		if err := step.Validate(); err != nil {
+			return c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.WrongEscalation, field))
+		}
+		// steps are taken in order, so a step cannot come before the previous one
+		if i > 0 && delay < steps[i-1].Delay {
+			return c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.WrongEscalation, field))
+		}
+		steps = append(steps, step)
+	}
+
+	if err := r.db.DeleteEscalationSteps(ca.ChannelID); err != nil {
+		logrus.Errorf("rest: DeleteEscalationSteps failed: %v\n", err)
+		return err
+	}
+	for _, s := range steps {
+		if _, err := r.db.CreateEscalationStep(s); err != nil {
+			logrus.Errorf("rest: CreateEscalationStep failed: %v\n", err)
+			return err
+		}
+	}
+	return c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.AddEscalation, formatEscalation(steps)))
+}
+
+func (r *REST) showEscalation(c echo.Context, f url.Values) error {
+	var ca ChannelIDForm
+	if err := r.decoder.Decode(&ca, f); err != nil {
+		logrus.Errorf("rest: showEscalation Decode failed: %v\n", err)
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+	if err := ca.Validate(); err != nil {
+		logrus.Errorf("rest: showEscalation Validate failed: %v\n", err)
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+
+	steps, err := r.db.ListEscalationSteps(ca.ChannelID)
+	if err != nil {
+		logrus.Errorf("rest: ListEscalationSteps failed: %v\n", err)
+		return c.String(http.StatusBadRequest, fmt.Sprintf("failed to list escalation policy :%v\n", err))
+	}
+	if len(steps) == 0 {
+		return c.String(http.StatusOK, r.conf.Translate.ShowNoEscalation)
+	}
+	return c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.ShowEscalation, formatEscalation(steps)))
+}
+
+func (r *REST) removeEscalation(c echo.Context, f url.Values) error {
+	var ca ChannelIDForm
+	if err := r.decoder.Decode(&ca, f); err != nil {
+		logrus.Errorf("rest: removeEscalation Decode failed: %v\n", err)
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+	if err := ca.Validate(); err != nil {
+		logrus.Errorf("rest: removeEscalation Validate failed: %v\n", err)
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+
+	if err := r.db.DeleteEscalationSteps(ca.ChannelID); err != nil {
+		logrus.Errorf("rest: DeleteEscalationSteps failed: %v\n", err)
+		return c.String(http.StatusBadRequest, fmt.Sprintf("failed to delete escalation policy :%v\n", err))
+	}
+	return c.String(http.StatusOK, r.conf.Translate.RemoveEscalation)
+}
+
+// formatEscalation returns escalation steps the way they are set, e.g. "dm=0 channel=30"
+func formatEscalation(steps []model.EscalationStep) string {
+	policy := []string{}
+	for _, s := range steps {
+		policy = append(policy, fmt.Sprintf("%v=%v", s.Action, s.Delay))
+	}
+	return strings.Join(policy, " ")
+}
+
+///linkproject gitlab maddevs/comedian
+func (r *REST) linkProject(c echo.Context, f url.Values) error {
+	var ca ChannelIDTextForm
+	if err := r.decoder.Decode(&ca, f); err != nil {
+		logrus.Errorf("rest: linkProject Decode failed: %v\n", err)
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+	if err := ca.Validate(); err != nil {
+		logrus.Errorf("rest: linkProject Validate failed: %v\n", err)
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+	params := strings.Fields(ca.Text)
+	if len(params) != 2 {
+		return c.String(http.StatusOK, r.conf.Translate.WrongNArgs)
+	}
+	provider := strings.ToLower(params[0])
+	if !r.providerEnabled(provider) {
+		return c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.WrongProvider, provider, strings.Join(metrics.Names(r.conf), ", ")))
+	}
+
+	link := model.ProjectLink{ChannelID: ca.ChannelID, Provider: provider, Project: params[1]}
+	if _, err := r.db.CreateProjectLink(link); err != nil {
+		logrus.Errorf("rest: CreateProjectLink failed: %v\n", err)
+		return c.String(http.StatusBadRequest, fmt.Sprintf("failed to link project :%v\n", err))
+	}
+	return c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.LinkProject, provider, params[1]))
+}
+
+///unlinkproject gitlab maddevs/comedian
+func (r *REST) unlinkProject(c echo.Context, f url.Values) error {
+	var ca ChannelIDTextForm
+	if err := r.decoder.Decode(&ca, f); err != nil {
+		logrus.Errorf("rest: unlinkProject Decode failed: %v\n", err)
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+	if err := ca.Validate(); err != nil {
+		logrus.Errorf("rest: unlinkProject Validate failed: %v\n", err)
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+	params := strings.Fields(ca.Text)
+	if len(params) != 2 {
+		return c.String(http.StatusOK, r.conf.Translate.WrongNArgs)
+	}
+	provider := strings.ToLower(params[0])
+
+	if err := r.db.DeleteProjectLink(ca.ChannelID, provider, params[1]); err != nil {
+		logrus.Errorf("rest: DeleteProjectLink failed: %v\n", err)
+		return c.String(http.StatusBadRequest, fmt.Sprintf("failed to unlink project :%v\n", err))
+	}
+	return c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.UnlinkProject, provider, params[1]))
+}
+
+func (r *REST) listLinks(c echo.Context, f url.Values) error {
+	var ca ChannelIDForm
+	if err := r.decoder.Decode(&ca, f); err != nil {
+		logrus.Errorf("rest: listLinks Decode failed: %v\n", err)
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+	if err := ca.Validate(); err != nil {
+		logrus.Errorf("rest: listLinks Validate failed: %v\n", err)
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+
+	links, err := r.db.ListProjectLinks(ca.ChannelID)
+	if err != nil {
+		logrus.Errorf("rest: ListProjectLinks failed: %v\n", err)
+		return c.String(http.StatusBadRequest, fmt.Sprintf("failed to list project links :%v\n", err))
+	}
+	if len(links) == 0 {
+		return c.String(http.StatusOK, r.conf.Translate.ListNoProjectLinks)
+	}
+	projects := []string{}
+	for _, link := range links {
+		projects = append(projects, link.Provider+" "+link.Project)
+	}
+	return c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.ListProjectLinks, strings.Join(projects, ", ")))
+}
+
+///linkuser gitlab @anna anna.smith
+func (r *REST) linkUser(c echo.Context, f url.Values) error {
+	var ca ChannelIDTextForm
+	if err := r.decoder.Decode(&ca, f); err != nil {
+		logrus.Errorf("rest: linkUser Decode failed: %v\n", err)
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+	if err := ca.Validate(); err != nil {
+		logrus.Errorf("rest: linkUser Validate failed: %v\n", err)
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+	// links apply to all channels of the user, so channel admins may not change them
+	if !r.isManager(r.platform(c), f.Get("user_id")) {
+		return c.String(http.StatusOK, r.conf.Translate.AccessDenied)
+	}
+	params := strings.Fields(ca.Text)
+	if len(params) != 3 {
+		return c.String(http.StatusOK, r.conf.Translate.WrongNArgs)
+	}
+	provider := strings.ToLower(params[0])
+	if !r.providerEnabled(provider) {
+		return c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.WrongProvider, provider, strings.Join(metrics.Names(r.conf), ", ")))
+	}
+	userID := strings.SplitN(strings.Trim(params[1], "<@>"), "|", 2)[0]
+
+	// user has one username in provider, so the previous link is replaced
+	if err := r.db.DeleteUserLink(userID, provider); err != nil {
+		logrus.Errorf("rest: DeleteUserLink failed: %v\n", err)
+		return c.String(http.StatusBadRequest, fmt.Sprintf("failed to link user :%v\n", err))
+	}
+	link := model.UserLink{SlackUserID: userID, Provider: provider, Username: params[2]}
+	if _, err := r.db.CreateUserLink(link); err != nil {
+		logrus.Errorf("rest: CreateUserLink failed: %v\n", err)
+		return c.String(http.StatusBadRequest, fmt.Sprintf("failed to link user :%v\n", err))
+	}
+	return c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.LinkUser, userID, params[2], provider))
+}
+
+///unlinkuser gitlab @anna
+func (r *REST) unlinkUser(c echo.Context, f url.Values) error {
+	var ca ChannelIDTextForm
+	if err := r.decoder.Decode(&ca, f); err != nil {
+		logrus.Errorf("rest: unlinkUser Decode failed: %v\n", err)
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+	if err := ca.Validate(); err != nil {
+		logrus.Errorf("rest: unlinkUser Validate failed: %v\n", err)
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+	// links apply to all channels of the user, so channel admins may not change them
+	if !r.isManager(r.platform(c), f.Get("user_id")) {
+		return c.String(http.StatusOK, r.conf.Translate.AccessDenied)
+	}
+	params := strings.Fields(ca.Text)
+	if len(params) != 2 {
+		return c.String(http.StatusOK, r.conf.Translate.WrongNArgs)
+	}
+	provider := strings.ToLower(params[0])
+	userID := strings.SplitN(strings.Trim(params[1], "<@>"), "|", 2)[0]
+
+	if err := r.db.DeleteUserLink(userID, provider); err != nil {
+		logrus.Errorf("rest: DeleteUserLink failed: %v\n", err)
+		return c.String(http.StatusBadRequest, fmt.Sprintf("failed to unlink user :%v\n", err))
+	}
+	return c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.UnlinkUser, userID, provider))
+}
+
+// providerEnabled checks if channels and users can be linked to provider
+func (r *REST) providerEnabled(provider string) bool {
+	for _, name := range metrics.Names(r.conf) {
+		if name == provider {
+			return true
+		}
+	}
+	return false
+}
+
+///apitokenadd dashboard
+// API tokens give access to all channels, so only the manager can issue them
+func (r *REST) addAPIToken(c echo.Context, f url.Values) error {
+	var ca ChannelIDTextForm
+	if err := r.decoder.Decode(&ca, f); err != nil {
+		logrus.Errorf("rest: addAPIToken Decode failed: %v\n", err)
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+	if !r.isManager(r.platform(c), f.Get("user_id")) {
+		return c.String(http.StatusOK, r.conf.Translate.AccessDenied)
+	}
+	params := strings.Fields(ca.Text)
+	if len(params) != 1 {
+		return c.String(http.StatusOK, r.conf.Translate.WrongNArgs)
+	}
+
+	token, err := newToken()
+	if err != nil {
+		logrus.Errorf("rest: newToken failed: %v\n", err)
+		return c.String(http.StatusBadRequest, fmt.Sprintf("failed to create API token :%v\n", err))
+	}
+	if _, err := r.db.CreateAPIToken(model.APIToken{Name: params[0], TokenHash: hashToken(token)}); err != nil {
+		logrus.Errorf("rest: CreateAPIToken failed: %v\n", err)
+		return c.String(http.StatusBadRequest, fmt.Sprintf("failed to create API token :%v\n", err))
+	}
+	return c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.AddAPIToken, params[0], token))
+}
+
+func (r *REST) listAPITokens(c echo.Context, f url.Values) error {
+	if !r.isManager(r.platform(c), f.Get("user_id")) {
+		return c.String(http.StatusOK, r.conf.Translate.AccessDenied)
+	}
+	tokens, err := r.db.ListAPITokens()
+	if err != nil {
+		logrus.Errorf("rest: ListAPITokens failed: %v\n", err)
+		return c.String(http.StatusBadRequest, fmt.Sprintf("failed to list API tokens :%v\n", err))
+	}
+	if len(tokens) == 0 {
+		return c.String(http.StatusOK, r.conf.Translate.ListNoAPITokens)
+	}
+	names := []string{}
+	for _, t := range tokens {
+		names = append(names, t.Name)
+	}
+	return c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.ListAPITokens, strings.Join(names, ", ")))
+}
+
+///apitokenremove dashboard
+func (r *REST) removeAPIToken(c echo.Context, f url.Values) error {
+	var ca ChannelIDTextForm
+	if err := r.decoder.Decode(&ca, f); err != nil {
+		logrus.Errorf("rest: removeAPIToken Decode failed: %v\n", err)
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+	if !r.isManager(r.platform(c), f.Get("user_id")) {
+		return c.String(http.StatusOK, r.conf.Translate.AccessDenied)
+	}
+	params := strings.Fields(ca.Text)
+	if len(params) != 1 {
+		return c.String(http.StatusOK, r.conf.Translate.WrongNArgs)
+	}
+
+	if err := r.db.DeleteAPIToken(params[0]); err != nil {
+		logrus.Errorf("rest: DeleteAPIToken failed: %v\n", err)
+		return c.String(http.StatusBadRequest, fmt.Sprintf("failed to delete API token :%v\n", err))
+	}
+	return c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.RemoveAPIToken, params[0]))
+}
+
+///webhookadd https://example.com/comedian standup.created standup.missed
+func (r *REST) addWebhook(c echo.Context, f url.Values) error {
+	var ca ChannelIDTextForm
+	if err := r.decoder.Decode(&ca, f); err != nil {
+		logrus.Errorf("rest: addWebhook Decode failed: %v\n", err)
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+	if !r.isManager(r.platform(c), f.Get("user_id")) {
+		return c.String(http.StatusOK, r.conf.Translate.AccessDenied)
+	}
+	params := strings.Fields(ca.Text)
+	if len(params) < 1 {
+		return c.String(http.StatusOK, r.conf.Translate.WrongNArgs)
+	}
+	// Slack sends links as <https://example.com> or <https://example.com|example.com>
+	link := strings.SplitN(strings.Trim(params[0], "<>"), "|", 2)[0]
+	if u, err := url.Parse(link); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.WrongWebhook, "URL must start with http:// or https://"))
+	}
+	events, err := webhook.ParseEvents(params[1:])
+	if err != nil {
+		return c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.WrongWebhook, err))
+	}
+
+	secret, err := webhook.NewSecret()
+	if err != nil {
+		logrus.Errorf("rest: NewSecret failed: %v\n", err)
+		return c.String(http.StatusBadRequest, fmt.Sprintf("failed to create webhook :%v\n", err))
+	}
+	w, err := r.db.CreateWebhook(model.Webhook{URL: link, Secret: secret, Events: events})
+	if err != nil {
+		logrus.Errorf("rest: CreateWebhook failed: %v\n", err)
+		return c.String(http.StatusBadRequest, fmt.Sprintf("failed to create webhook :%v\n", err))
+	}
+	return c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.AddWebhook, w.ID, webhookEvents(w), secret))
+}
+
+func (r *REST) listWebhooks(c echo.Context, f url.Values) error {
+	if !r.isManager(r.platform(c), f.Get("user_id")) {
+		return c.String(http.StatusOK, r.conf.Translate.AccessDenied)
+	}
+	webhooks, err := r.db.ListWebhooks()
+	if err != nil {
+		logrus.Errorf("rest: ListWebhooks failed: %v\n", err)
+		return c.String(http.StatusBadRequest, fmt.Sprintf("failed to list webhooks :%v\n", err))
+	}
+	if len(webhooks) == 0 {
+		return c.String(http.StatusOK, r.conf.Translate.ListNoWebhooks)
+	}
+	lines := []string{}
+	for _, w := range webhooks {
+		letters, err := r.db.ListDeadLetters(w.ID)
+		if err != nil {
+			logrus.Errorf("rest: ListDeadLetters failed: %v\n", err)
+			return c.String(http.StatusBadRequest, fmt.Sprintf("failed to list webhooks :%v\n", err))
+		}
+		lines = append(lines, fmt.Sprintf(r.conf.Translate.ListWebhook, w.ID, w.URL, webhookEvents(w), len(letters)))
+	}
+	return c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.ListWebhooks, strings.Join(lines, "\n")))
+}
+
+///webhookremove 3
+func (r *REST) removeWebhook(c echo.Context, f url.Values) error {
+	w, ok, err := r.webhookParam(c, f)
+	if !ok {
+		return err
+	}
+	if err := r.db.DeleteWebhook(w.ID); err != nil {
+		logrus.Errorf("rest: DeleteWebhook failed: %v\n", err)
+		return c.String(http.StatusBadRequest, fmt.Sprintf("failed to delete webhook :%v\n", err))
+	}
+	return c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.RemoveWebhook, w.ID))
+}
+
+///webhookretry 3
+func (r *REST) retryWebhook(c echo.Context, f url.Values) error {
+	w, ok, err := r.webhookParam(c, f)
+	if !ok {
+		return err
+	}
+	n, err := r.hooks.Redeliver(w)
+	if err != nil {
+		return c.String(http.StatusBadRequest, fmt.Sprintf("failed to redeliver events :%v\n", err))
+	}
+	return c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.RetryWebhook, n, w.ID))
+}
+
+// webhookParam returns webhook by ID from command text, ok is false if reply was sent instead
+func (r *REST) webhookParam(c echo.Context, f url.Values) (model.Webhook, bool, error) {
+	var ca ChannelIDTextForm
+	if err := r.decoder.Decode(&ca, f); err != nil {
+		logrus.Errorf("rest: webhookParam Decode failed: %v\n", err)
+		return model.Webhook{}, false, c.String(http.StatusBadRequest, err.Error())
+	}
+	if !r.isManager(r.platform(c), f.Get("user_id")) {
+		return model.Webhook{}, false, c.String(http.StatusOK, r.conf.Translate.AccessDenied)
+	}
+	params := strings.Fields(ca.Text)
+	if len(params) != 1 {
+		return model.Webhook{}, false, c.String(http.StatusOK, r.conf.Translate.WrongNArgs)
+	}
+	webhooks, err := r.db.ListWebhooks()
+	if err != nil {
+		logrus.Errorf("rest: ListWebhooks failed: %v\n", err)
+		return model.Webhook{}, false, c.String(http.StatusBadRequest, fmt.Sprintf("failed to list webhooks :%v\n", err))
+	}
+	for _, w := range webhooks {
+		if strconv.FormatInt(w.ID, 10) == params[0] {
+			return w, true, nil
+		}
+	}
+	return model.Webhook{}, false, c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.WrongWebhook, "no webhook "+params[0]))
+}
+
+// webhookEvents lists events webhook receives
+func webhookEvents(w model.Webhook) string {
+	if w.Events == "" {
+		return strings.Join(webhook.Events, ", ")
+	}
+	return strings.Replace(w.Events, ",", ", ", -1)
+}
+
+///report_by_project #collector-test 2018-07-24 2018-07-26 [csv|json|markdown]
+func (r *REST) reportByProject(c echo.Context, f url.Values) error {
+	var ca ChannelIDTextForm
+	if err := r.decoder.Decode(&ca, f); err != nil {
+		logrus.Errorf("rest: reportByProject Decode failed: %v\n", err)
+		return c.String(http.StatusOK, err.Error())
+	}
+	if err := ca.Validate(); err != nil {
+		logrus.Errorf("rest: reportByProject Validate failed: %v\n", err)
+		return c.String(http.StatusOK, err.Error())
+	}
+	commandParams, format := reportFormat(strings.Fields(ca.Text), 3)
+	if len(commandParams) != 3 {
+		return c.String(http.StatusOK, r.conf.Translate.WrongNArgs)
+	}
+	channelID, channelName, err := r.reportChannel(commandParams[0])
+	if err != nil {
+		return c.String(http.StatusOK, err.Error())
+	}
+
+	dateFrom, err := time.Parse("2006-01-02", commandParams[1])
+	if err != nil {
+		logrus.Errorf("rest: time.Parse failed: %v\n", err)
+		return c.String(http.StatusOK, err.Error())
+	}
+	dateTo, err := time.Parse("2006-01-02", commandParams[2])
+	if err != nil {
+		logrus.Errorf("rest: time.Parse failed: %v\n", err)
+		return c.String(http.StatusOK, err.Error())
+	}
+	ctx, cancel := context.WithTimeout(c.Request().Context(), commandTimeout)
+	defer cancel()
+	data := collectorData(r.collector.ProjectData(ctx, channelID, channelName, dateFrom, dateTo))
+	report, err := r.report.ProjectReport(channelID, dateFrom, dateTo, data)
+	if err != nil {
+		logrus.Errorf("rest: ProjectReport: %v\n", err)
+		return c.String(http.StatusOK, err.Error())
+	}
+	return r.sendReport(c, f, report, format)
+}
+
+///report_blockers #collector-test 2018-07-24 2018-07-26 [csv|json|markdown]
+func (r *REST) reportBlockers(c echo.Context, f url.Values) error {
+	var ca ChannelIDTextForm
+	if err := r.decoder.Decode(&ca, f); err != nil {
+		logrus.Errorf("rest: reportBlockers Decode failed: %v\n", err)
+		return c.String(http.StatusOK, err.Error())
+	}
+	if err := ca.Validate(); err != nil {
+		logrus.Errorf("rest: reportBlockers Validate failed: %v\n", err)
+		return c.String(http.StatusOK, err.Error())
+	}
+	commandParams, format := reportFormat(strings.Fields(ca.Text), 3)
+	if len(commandParams) != 3 {
+		return c.String(http.StatusOK, r.conf.Translate.WrongNArgs)
+	}
+	channelID, _, err := r.reportChannel(commandParams[0])
+	if err != nil {
+		return c.String(http.StatusOK, err.Error())
+	}
+
+	dateFrom, err := time.Parse("2006-01-02", commandParams[1])
+	if err != nil {
+		logrus.Errorf("rest: time.Parse failed: %v\n", err)
+		return c.String(http.StatusOK, err.Error())
+	}
+	dateTo, err := time.Parse("2006-01-02", commandParams[2])
+	if err != nil {
+		logrus.Errorf("rest: time.Parse failed: %v\n", err)
+		return c.String(http.StatusOK, err.Error())
+	}
+	report, err := r.report.BlockersReport(channelID, dateFrom, dateTo)
+	if err != nil {
+		logrus.Errorf("rest: BlockersReport: %v\n", err)
+		return c.String(http.StatusOK, err.Error())
+	}
+	return r.sendReport(c, f, report, format)
+}
+
+///report_by_user @Anatoliy 2018-07-24 2018-07-26 [csv|json|markdown]
+func (r *REST) reportByUser(c echo.Context, f url.Values) error {
+	var ca FullSlackForm
+	if err := r.decoder.Decode(&ca, f); err != nil {
+		logrus.Errorf("rest: reportByUser Decode failed: %v\n", err)
+		return c.String(http.StatusOK, err.Error())
+	}
+	if err := ca.Validate(); err != nil {
+		logrus.Errorf("rest: reportByUser Validate failed: %v\n", err)
+		return c.String(http.StatusOK, err.Error())
+	}
+	commandParams, format := reportFormat(strings.Fields(ca.Text), 3)
+	if len(commandParams) != 3 {
+		return c.String(http.StatusOK, r.conf.Translate.UserExist)
+	}
+	userID, userName, err := splitUser(commandParams[0])
+	if err != nil {
+		return c.String(http.StatusOK, err.Error())
+	}
+	user, err := r.db.FindStandupUser(userName)
+	if err != nil {
+		return c.String(http.StatusOK, err.Error())
+	}
+	dateFrom, err := time.Parse("2006-01-02", commandParams[1])
+	if err != nil {
+		logrus.Errorf("rest: time.Parse failed: %v\n", err)
+		return c.String(http.StatusOK, err.Error())
+	}
+	dateTo, err := time.Parse("2006-01-02", commandParams[2])
+	if err != nil {
+		logrus.Errorf("rest: time.Parse failed: %v\n", err)
+		return c.String(http.StatusOK, err.Error())
+	}
+	ctx, cancel := context.WithTimeout(c.Request().Context(), commandTimeout)
+	defer cancel()
+	data := collectorData(r.collector.UserData(ctx, userID, dateFrom, dateTo))
+	report, err := r.report.UserReport(user, dateFrom, dateTo, data)
+	if err != nil {
+		logrus.Errorf("rest: UserReport failed: %v\n", err)
+		return c.String(http.StatusOK, err.Error())
+	}
+	return r.sendReport(c, f, report, format)
+}
+
+///report_by_project_and_user #collector-test @Anatoliy 2018-07-24 2018-07-26 [csv|json|markdown]
+func (r *REST) reportByProjectAndUser(c echo.Context, f url.Values) error {
+	var ca FullSlackForm
+	if err := r.decoder.Decode(&ca, f); err != nil {
+		logrus.Errorf("rest: reportByProjectAndUser Decode failed: %v\n", err)
+		return c.String(http.StatusOK, err.Error())
+	}
+	if err := ca.Validate(); err != nil {
+		logrus.Errorf("rest: reportByProjectAndUser Validate failed: %v\n", err)
+		return c.String(http.StatusOK, err.Error())
+	}
+	commandParams, format := reportFormat(strings.Fields(ca.Text), 4)
+	if len(commandParams) != 4 {
+		return c.String(http.StatusOK, r.conf.Translate.WrongNArgs)
+	}
+	channelID, channelName, err := r.reportChannel(commandParams[0])
+	if err != nil {
+		return c.String(http.StatusOK, err.Error())
+	}
+	userID, _, err := splitUser(commandParams[1])
+	if err != nil {
+		return c.String(http.StatusOK, err.Error())
+	}
+	dateFrom, err := time.Parse("2006-01-02", commandParams[2])
+	if err != nil {
+		logrus.Errorf("rest: time.Parse failed: %v\n", err)
+		return c.String(http.StatusOK, err.Error())
+	}
+	dateTo, err := time.Parse("2006-01-02", commandParams[3])
+	if err != nil {
+		logrus.Errorf("rest: time.Parse failed: %v\n", err)
+		return c.String(http.StatusOK, err.Error())
+	}
+	ctx, cancel := context.WithTimeout(c.Request().Context(), commandTimeout)
+	defer cancel()
+	data := collectorData(r.collector.ProjectUserData(ctx, channelID, channelName, userID, dateFrom, dateTo))
+
+	user, err := r.db.FindStandupUserInChannelByUserID(userID, channelID)
+	if err != nil {
+		return c.String(http.StatusOK, r.conf.Translate.ReportByProjectAndUser)
+	}
+	report, err := r.report.ProjectUserReport(channelID, user, dateFrom, dateTo, data)
+	if err != nil {
+		logrus.Errorf("rest: ProjectUserReport failed: %v\n", err)
+		return c.String(http.StatusOK, err.Error())
+	}
+	return r.sendReport(c, f, report, format)
+}
+
+// collectorData returns data from Collector, nil if it failed
+func collectorData(data collector.Data, err error) *collector.Data {
+	if err != nil {
+		logrus.Errorf("rest: collector request failed: %v\n", err)
+		return nil
+	}
+	return &data
+}
+
+// reportChannel returns ID and name of channel mentioned as <#CHANNELID|name>.
+// Telegram cannot mention channels, so a bare ID of a known channel is accepted too
+func (r *REST) reportChannel(channel string) (string, string, error) {
+	if strings.Contains(channel, "|") {
+		return splitChannel(channel)
+	}
+	channelID := strings.TrimSuffix(strings.TrimPrefix(channel, "<#"), ">")
+	channelName, err := r.channelName(channelID)
+	if err != nil {
+		return "", "", err
+	}
+	if channelName == "" {
+		return "", "", fmt.Errorf("unknown channel %v", channelID)
+	}
+	return channelID, channelName, nil
+}
+
+func splitChannel(channel string) (string, string, error) {
+	channelSeparate := strings.Split(channel, "|")
+	if len(channelSeparate) < 2 {
+		return "", "", fmt.Errorf("wrong channel %v, please mention it with #", channel)
+	}
+	channelID := strings.Replace(channelSeparate[0], "<#", "", -1)
+	channelName := strings.Replace(channelSeparate[1], ">", "", -1)
+	return channelID, channelName, nil
+}
+
+func splitUser(user string) (string, string, error) {
+	userFull := strings.Split(user, "|")
+	if len(userFull) < 2 {
+		return "", "", fmt.Errorf("wrong user %v, please mention user with @", user)
+	}
 	userID := strings.Replace(userFull[0], "<@", "", -1)
 	userName := strings.Replace(userFull[1], ">", "", -1)
-	return userID, userName
+	return userID, userName, nil
 }
@@ -2,6 +2,7 @@ package api
 
 import (
 	"fmt"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -13,6 +14,7 @@ import (
 	"github.com/labstack/echo"
 	"github.com/maddevsio/comedian/config"
 	"github.com/maddevsio/comedian/model"
+	"github.com/maddevsio/comedian/storage"
 	"github.com/stretchr/testify/assert"
 	"gopkg.in/jarcoal/httpmock.v1"
 )
@@ -24,7 +26,9 @@ func TestHandleCommands(t *testing.T) {
 	emptyCommand := "user_id=UB9AE7CL9&command=/"
 
 	c, err := config.Get()
-	rest, err := NewRESTAPI(c)
+	db := storage.NewMemory()
+	assert.NoError(t, err)
+	rest, err := NewRESTAPI(c, db)
 	assert.NoError(t, err)
 
 	testCases := []struct {
@@ -58,7 +62,9 @@ func TestHandleUserCommands(t *testing.T) {
 	ListUsers := "user_id=UB9AE7CL9&command=/comedianlist&channel_id=chanid"
 
 	c, err := config.Get()
-	rest, err := NewRESTAPI(c)
+	db := storage.NewMemory()
+	assert.NoError(t, err)
+	rest, err := NewRESTAPI(c, db)
 	assert.NoError(t, err)
 
 	testCases := []struct {
@@ -122,6 +128,8 @@ func TestHandleTimeCommands(t *testing.T) {
 	AddTimeEmptyChannelName := "user_id=UB9AE7CL9&command=/standuptimeset&text=12:05&channel_id=chanid&channel_name="
 	AddTimeEmptyChannelID := "user_id=UB9AE7CL9&command=/standuptimeset&text=12:05&channel_id=&channel_name=channame"
 	AddEmptyTime := "user_id=UB9AE7CL9&command=/standuptimeset&text=&channel_id=chanid&channel_name=channame"
+	AddBlankTime := "user_id=UB9AE7CL9&command=/standuptimeset&text= &channel_id=chanid&channel_name=channame"
+	AddTimeExtraArgs := "user_id=UB9AE7CL9&command=/standuptimeset&text=12:05 Europe/Berlin extra&channel_id=chanid&channel_name=channame"
 	ListTime := "user_id=UB9AE7CL9&command=/standuptime&channel_id=chanid"
 	ListTimeNoChanID := "user_id=UB9AE7CL9&command=/standuptime&channel_id="
 	DelTime := "user_id=UB9AE7CL9&command=/standuptimeremove&channel_id=chanid&channel_name=channame"
@@ -131,7 +139,9 @@ func TestHandleTimeCommands(t *testing.T) {
 	timeInt := time.Date(currentTime.Year(), currentTime.Month(), currentTime.Day(), 12, 5, 0, 0, time.Local).Unix()
 
 	c, err := config.Get()
-	rest, err := NewRESTAPI(c)
+	db := storage.NewMemory()
+	assert.NoError(t, err)
+	rest, err := NewRESTAPI(c, db)
 	assert.NoError(t, err)
 
 	testCases := []struct {
@@ -143,6 +153,8 @@ func TestHandleTimeCommands(t *testing.T) {
 		{"list time no time added", ListTime, http.StatusOK, "No standup time set for this channel yet! Please, add a standup time using `/standuptimeset` command!"},
 		{"add time (no users)", AddTime, http.StatusOK, fmt.Sprintf("<!date^%v^Standup time at {time} added, but there is no standup users for this channel|Standup time at 12:00 added, but there is no standup users for this channel>", timeInt)},
 		{"add time no text", AddEmptyTime, http.StatusBadRequest, "`text` cannot be empty"},
+		{"add time blank text", AddBlankTime, http.StatusOK, "Wrong number of arguments"},
+		{"add time extra args", AddTimeExtraArgs, http.StatusOK, "Wrong number of arguments"},
 		{"add time no channelName", AddTimeEmptyChannelName, http.StatusBadRequest, "`channel_name` cannot be empty"},
 		{"add time (no channelID)", AddTimeEmptyChannelID, http.StatusBadRequest, "`channel_id` cannot be empty"},
 		{"list time no chan ID", ListTimeNoChanID, http.StatusBadRequest, "`channel_id` cannot be empty"},
@@ -199,13 +211,97 @@ func TestHandleTimeCommands(t *testing.T) {
 
 }
 
+func TestHandleTemplateCommands(t *testing.T) {
+	SetTemplate := "user_id=UB9AE7CL9&command=/standuptemplateset&channel_id=chanid&text=done=one next:today=/(?i)next/ problems=block"
+	SetWrongRole := "user_id=UB9AE7CL9&command=/standuptemplateset&channel_id=chanid&text=done=one next:tomorrow=/(?i)next/"
+	SetTwiceRole := "user_id=UB9AE7CL9&command=/standuptemplateset&channel_id=chanid&text=next:today=next today=/(?i)today/"
+	SetWrongTemplate := "user_id=UB9AE7CL9&command=/standuptemplateset&channel_id=chanid&text=done"
+	SetWrongRegexp := "user_id=UB9AE7CL9&command=/standuptemplateset&channel_id=chanid&text=done=/(/"
+	ShowTemplate := "user_id=UB9AE7CL9&command=/standuptemplate&channel_id=chanid"
+	RemoveTemplate := "user_id=UB9AE7CL9&command=/standuptemplateremove&channel_id=chanid"
+
+	c, err := config.Get()
+	db := storage.NewMemory()
+	assert.NoError(t, err)
+	rest, err := NewRESTAPI(c, db)
+	assert.NoError(t, err)
+
+	testCases := []struct {
+		title        string
+		command      string
+		statusCode   int
+		responseBody string
+	}{
+		{"no template", ShowTemplate, http.StatusOK, "No standup template set for this channel, standups must mention yesterday work, today plans and problems"},
+		{"set template", SetTemplate, http.StatusOK, "Standup template set, standups must answer: done, next, problems"},
+		{"show template", ShowTemplate, http.StatusOK, "Standups in this channel must answer: done=one next:today=/(?i)next/ problems=block"},
+		{"wrong template", SetWrongTemplate, http.StatusOK, "Wrong template: done. Use `/standuptemplateset name=keyword name:role=/regexp/`, roles are yesterday, today and problems"},
+		{"wrong regexp", SetWrongRegexp, http.StatusOK, "Wrong template: error parsing regexp: missing closing ): `(`. Use `/standuptemplateset name=keyword name:role=/regexp/`, roles are yesterday, today and problems"},
+		{"wrong role", SetWrongRole, http.StatusOK, "Wrong template: next:tomorrow=/(?i)next/. Use `/standuptemplateset name=keyword name:role=/regexp/`, roles are yesterday, today and problems"},
+		{"role taken twice", SetTwiceRole, http.StatusOK, "Wrong template: today=/(?i)today/. Use `/standuptemplateset name=keyword name:role=/regexp/`, roles are yesterday, today and problems"},
+		{"template is kept", ShowTemplate, http.StatusOK, "Standups in this channel must answer: done=one next:today=/(?i)next/ problems=block"},
+		{"remove template", RemoveTemplate, http.StatusOK, "Standup template for this channel removed"},
+		{"template removed", ShowTemplate, http.StatusOK, "No standup template set for this channel, standups must mention yesterday work, today plans and problems"},
+	}
+
+	for _, tt := range testCases {
+		context, rec := getContext(tt.command)
+		err := rest.handleCommands(context)
+		if err != nil {
+			logrus.Errorf("Template: %s failed. Error: %v\n", tt.title, err)
+		}
+		assert.Equal(t, tt.statusCode, rec.Code, tt.title)
+		assert.Equal(t, tt.responseBody, rec.Body.String(), tt.title)
+	}
+}
+
+func TestHandleTimezoneCommands(t *testing.T) {
+	AddTime := "user_id=UB9AE7CL9&command=/standuptimeset&channel_id=tzchan&channel_name=chanName&text=09:30 Europe/Berlin"
+	AddTimeWrongZone := "user_id=UB9AE7CL9&command=/standuptimeset&channel_id=tzchan&channel_name=chanName&text=09:30 Mars/Olympus"
+	SetTimezone := "user_id=UB9AE7CL9&command=/standuptimezone&channel_id=tzchan&text=Asia/Bishkek"
+	SetWrongTimezone := "user_id=UB9AE7CL9&command=/standuptimezone&channel_id=tzchan&text=Mars/Olympus"
+
+	c, err := config.Get()
+	db := storage.NewMemory()
+	assert.NoError(t, err)
+	rest, err := NewRESTAPI(c, db)
+	assert.NoError(t, err)
+
+	command := func(command string) string {
+		context, rec := getContext(command)
+		assert.NoError(t, rest.handleCommands(context))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		return rec.Body.String()
+	}
+
+	assert.Equal(t, "No standup time set for this channel yet! Please, add a standup time using `/standuptimeset` command!", command(SetTimezone))
+	assert.Equal(t, "Unknown time zone Mars/Olympus, please use names like Europe/Berlin or Asia/Bishkek", command(AddTimeWrongZone))
+
+	command(AddTime)
+	st, err := db.GetChannelStandupTime("tzchan")
+	assert.NoError(t, err)
+	assert.Equal(t, "Europe/Berlin", st.Timezone)
+	assert.Equal(t, "09:30", time.Unix(st.Time, 0).In(st.Location()).Format("15:04"))
+
+	assert.Equal(t, "Unknown time zone Mars/Olympus, please use names like Europe/Berlin or Asia/Bishkek", command(SetWrongTimezone))
+	// standup stays at 09:30 of the new time zone
+	assert.Equal(t, "Standup time zone set to Asia/Bishkek, standup time is 09:30", command(SetTimezone))
+	st, err = db.GetChannelStandupTime("tzchan")
+	assert.NoError(t, err)
+	assert.Equal(t, "Asia/Bishkek", st.Timezone)
+	assert.Equal(t, "09:30", time.Unix(st.Time, 0).In(st.Location()).Format("15:04"))
+	assert.NoError(t, db.DeleteStandupTime("tzchan"))
+}
+
 func TestHandleReportByProjectCommands(t *testing.T) {
 	ReportByProjectEmptyText := "user_id=UB9AE7CL9&command=/report_by_project&channel_id=<#CBA2M41Q8|chanid>&text="
 	ReportByProjectEmptyChanID := "user_id=UB9AE7CL9&command=/report_by_project&channel_id=&text=2018-06-25 2018-06-26"
 	ReportByProject := "user_id=UB9AE7CL9&command=/report_by_project&channel_id=chanid&text= <#CBA2M41Q8|chanid> 2018-06-25 2018-06-26"
 
 	c, err := config.Get()
-	rest, err := NewRESTAPI(c)
+	db := storage.NewMemory()
+	assert.NoError(t, err)
+	rest, err := NewRESTAPI(c, db)
 	assert.NoError(t, err)
 
 	httpmock.Activate()
@@ -237,6 +333,39 @@ func TestHandleReportByProjectCommands(t *testing.T) {
 
 }
 
+func TestHandleReportBlockersCommands(t *testing.T) {
+	ReportBlockersEmptyText := "user_id=UB9AE7CL9&command=/report_blockers&channel_id=chanid&text="
+	ReportBlockersWrongArgs := "user_id=UB9AE7CL9&command=/report_blockers&channel_id=chanid&text= <#CBA2M41Q8|chanid> 2018-06-25"
+	ReportBlockers := "user_id=UB9AE7CL9&command=/report_blockers&channel_id=chanid&text= <#CBA2M41Q8|chanid> 2018-06-25 2018-06-26"
+
+	c, err := config.Get()
+	db := storage.NewMemory()
+	assert.NoError(t, err)
+	rest, err := NewRESTAPI(c, db)
+	assert.NoError(t, err)
+
+	testCases := []struct {
+		title        string
+		command      string
+		statusCode   int
+		responseBody string
+	}{
+		{"empty text", ReportBlockersEmptyText, http.StatusOK, "`text` cannot be empty"},
+		{"wrong number of arguments", ReportBlockersWrongArgs, http.StatusOK, "Wrong number of arguments"},
+		{"correct", ReportBlockers, http.StatusOK, "Blockers on project <#CBA2M41Q8>:\n\nReport for: 2018-06-25\nNo blockers for this day\n\nReport for: 2018-06-26\nNo blockers for this day\n\n"},
+	}
+
+	for _, tt := range testCases {
+		context, rec := getContext(tt.command)
+		err := rest.handleCommands(context)
+		if err != nil {
+			logrus.Errorf("ReportBlockers: %s failed. Error: %v\n", tt.title, err)
+		}
+		assert.Equal(t, tt.statusCode, rec.Code)
+		assert.Equal(t, tt.responseBody, rec.Body.String())
+	}
+}
+
 func TestHandleReportByUserCommands(t *testing.T) {
 	ReportByUserEmptyText := "user_id=UB9AE7CL9&command=/report_by_user&text="
 	ReportByUser := "user_id=UB9AE7CL9&command=/report_by_user&channel_id=123qwe&channel_name=channel1&text= <@userID1|user1> 2018-06-25 2018-06-26"
@@ -245,7 +374,9 @@ func TestHandleReportByUserCommands(t *testing.T) {
 	ReportByUserMessDateT := "user_id=UB9AE7CL9&command=/report_by_user&channel_id=123qwe&channel_name=channel1&text= <@userID1|user1> 2018-06-25 2018-6-26"
 
 	c, err := config.Get()
-	rest, err := NewRESTAPI(c)
+	db := storage.NewMemory()
+	assert.NoError(t, err)
+	rest, err := NewRESTAPI(c, db)
 	assert.NoError(t, err)
 
 	httpmock.Activate()
@@ -297,7 +428,9 @@ func TestHandleReportByProjectAndUserCommands(t *testing.T) {
 	ReportByProjectAndUserDateFromMessUp := "user_id=UB9AE7CL9&command=/report_by_project_and_user&channel_id=123qwe&channel_name=channel1&text= <#CBA2M41Q8|chanid> <@USERID|user1> 2018-06-25 2018-6-26"
 
 	c, err := config.Get()
-	rest, err := NewRESTAPI(c)
+	db := storage.NewMemory()
+	assert.NoError(t, err)
+	rest, err := NewRESTAPI(c, db)
 	assert.NoError(t, err)
 
 	httpmock.Activate()
@@ -352,14 +485,331 @@ func getContext(command string) (echo.Context, *httptest.ResponseRecorder) {
 
 func TestSplitChannel(t *testing.T) {
 	channel := "<#CHANNELID|channelName"
-	id, name := splitChannel(channel)
+	id, name, err := splitChannel(channel)
+	assert.NoError(t, err)
 	assert.Equal(t, "CHANNELID", id)
 	assert.Equal(t, "channelName", name)
+	_, _, err = splitChannel("general")
+	assert.Error(t, err)
 }
 
 func TestSplitUser(t *testing.T) {
 	user := "<@SLACKUSERID|userName"
-	id, name := splitUser(user)
+	id, name, err := splitUser(user)
+	assert.NoError(t, err)
 	assert.Equal(t, "SLACKUSERID", id)
 	assert.Equal(t, "userName", name)
+	_, _, err = splitUser("@userName")
+	assert.Error(t, err)
+}
+
+func TestHandleHolidayCommands(t *testing.T) {
+	AddHoliday := "user_id=UB9AE7CL9&command=/holidayadd&channel_id=holidaychan&text=2018-12-31 New Year eve"
+	AddWrongHoliday := "user_id=UB9AE7CL9&command=/holidayadd&channel_id=holidaychan&text=31.12.2018"
+	RemoveHoliday := "user_id=UB9AE7CL9&command=/holidayremove&channel_id=holidaychan&text=2018-12-31"
+	ListHolidays := "user_id=UB9AE7CL9&command=/holidays&channel_id=holidaychan"
+	ImportHolidays := "user_id=UB9AE7CL9&command=/holidayimport&channel_id=holidaychan&text=<https://example.com/holidays.ics>"
+	SetWorkDays := "user_id=UB9AE7CL9&command=/standupworkdays&channel_id=holidaychan&text=sun-thu"
+	SetWrongWorkDays := "user_id=UB9AE7CL9&command=/standupworkdays&channel_id=holidaychan&text=someday"
+	AddTime := "user_id=UB9AE7CL9&command=/standuptimeset&channel_id=holidaychan&channel_name=chanName&text=09:30"
+
+	c, err := config.Get()
+	db := storage.NewMemory()
+	assert.NoError(t, err)
+	rest, err := NewRESTAPI(c, db)
+	assert.NoError(t, err)
+
+	httpmock.ActivateNonDefault(rest.calendars)
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterResponder("GET", "https://example.com/holidays.ics",
+		httpmock.NewStringResponder(200, "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nDTSTART;VALUE=DATE:20190101\r\nDTEND;VALUE=DATE:20190103\r\nSUMMARY:New Year\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"))
+
+	command := func(command string) string {
+		context, rec := getContext(command)
+		assert.NoError(t, rest.handleCommands(context))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		return rec.Body.String()
+	}
+
+	assert.Equal(t, "No holidays set for this channel", command(ListHolidays))
+	assert.Equal(t, "Wrong date 31.12.2018, please use YYYY-MM-DD", command(AddWrongHoliday))
+	assert.Equal(t, "Holiday 2018-12-31 added, no standups on this day", command(AddHoliday))
+	assert.Equal(t, "Holiday 2018-12-31 added, no standups on this day", command(AddHoliday))
+	assert.Equal(t, "Holidays in this channel: 2018-12-31 (New Year eve)", command(ListHolidays))
+	assert.Equal(t, "2 holidays imported", command(ImportHolidays))
+	assert.Equal(t, "Failed to import holidays from file:///etc/passwd, please check that the link is a public iCalendar file",
+		command("user_id=UB9AE7CL9&command=/holidayimport&channel_id=holidaychan&text=file:///etc/passwd"))
+	assert.Equal(t, "Holidays in this channel: 2018-12-31 (New Year eve), 2019-01-01 (New Year), 2019-01-02 (New Year)", command(ListHolidays))
+	assert.Equal(t, "Holiday 2018-12-31 removed", command(RemoveHoliday))
+	for _, date := range []string{"2019-01-01", "2019-01-02"} {
+		assert.NoError(t, db.DeleteHoliday("holidaychan", date))
+	}
+	assert.Equal(t, "No holidays set for this channel", command(ListHolidays))
+
+	assert.Equal(t, "No standup time set for this channel yet! Please, add a standup time using `/standuptimeset` command!", command(SetWorkDays))
+	command(AddTime)
+	assert.Equal(t, "Wrong working days someday, please use days like mon,tue,wed or ranges like mon-fri", command(SetWrongWorkDays))
+	assert.Equal(t, "Working days of this channel: mon,tue,wed,thu,sun", command(SetWorkDays))
+	st, err := db.GetChannelStandupTime("holidaychan")
+	assert.NoError(t, err)
+	assert.Equal(t, "mon,tue,wed,thu,sun", st.WorkDays)
+	assert.NoError(t, db.DeleteStandupTime("holidaychan"))
+}
+
+func TestCalendarClient(t *testing.T) {
+	local := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("BEGIN:VCALENDAR\r\nEND:VCALENDAR\r\n"))
+	}))
+	defer local.Close()
+
+	// calendars on loopback and private addresses are not downloaded
+	_, err := newCalendarClient().Get(local.URL)
+	assert.Error(t, err)
+	for _, ip := range []string{"127.0.0.1", "10.0.0.1", "192.168.1.1", "169.254.169.254", "::1", "0.0.0.0"} {
+		assert.False(t, publicIP(net.ParseIP(ip)), ip)
+	}
+	assert.True(t, publicIP(net.ParseIP("93.184.216.34")))
+}
+
+func TestHandleScheduleCommands(t *testing.T) {
+	SetSchedule := "user_id=UB9AE7CL9&command=/standupschedule&channel_id=schedulechan&text=30 9 * * MON,WED,FRI"
+	SetYearlySchedule := "user_id=UB9AE7CL9&command=/standupschedule&channel_id=schedulechan&text=@yearly"
+	SetWrongSchedule := "user_id=UB9AE7CL9&command=/standupschedule&channel_id=schedulechan&text=30 9 * *"
+	SetTwiceADaySchedule := "user_id=UB9AE7CL9&command=/standupschedule&channel_id=schedulechan&text=30 9,14 * * *"
+	SetNeverSchedule := "user_id=UB9AE7CL9&command=/standupschedule&channel_id=schedulechan&text=0 9 31 2 *"
+	RemoveSchedule := "user_id=UB9AE7CL9&command=/standupscheduleremove&channel_id=schedulechan"
+	ListTime := "user_id=UB9AE7CL9&command=/standuptime&channel_id=schedulechan"
+	AddTime := "user_id=UB9AE7CL9&command=/standuptimeset&channel_id=schedulechan&channel_name=chanName&text=09:30"
+
+	c, err := config.Get()
+	db := storage.NewMemory()
+	assert.NoError(t, err)
+	rest, err := NewRESTAPI(c, db)
+	assert.NoError(t, err)
+
+	command := func(command string) string {
+		context, rec := getContext(command)
+		assert.NoError(t, rest.handleCommands(context))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		return rec.Body.String()
+	}
+
+	assert.Equal(t, "No standup time set for this channel yet! Please, add a standup time using `/standuptimeset` command!", command(SetSchedule))
+	command(AddTime)
+	assert.True(t, strings.HasPrefix(command(SetWrongSchedule), "Wrong schedule 30 9 * *: schedule: cron expression must have 5 fields, got 4"))
+	assert.True(t, strings.HasPrefix(command(SetTwiceADaySchedule), "Wrong schedule 30 9,14 * * *: schedule: standup happens once a day"))
+	assert.True(t, strings.HasPrefix(command(SetNeverSchedule), "Wrong schedule 0 9 31 2 *: schedule: there is no standup within a year"))
+	assert.True(t, strings.HasPrefix(command(SetSchedule), "Standup schedule set to 30 9 * * MON,WED,FRI, next standup is "))
+	st, err := db.GetChannelStandupTime("schedulechan")
+	assert.NoError(t, err)
+	assert.Equal(t, "30 9 * * MON,WED,FRI", st.Schedule)
+
+	nextYear := time.Now().UTC().Year() + 1
+	assert.Equal(t, fmt.Sprintf("Standup schedule set to @yearly, next standup is %v", time.Date(nextYear, 1, 1, 0, 0, 0, 0, time.UTC).Format("Mon, 2006-01-02 15:04 MST")), command(SetYearlySchedule))
+	assert.Equal(t, fmt.Sprintf("Standup schedule is @yearly, next standup is %v", time.Date(nextYear, 1, 1, 0, 0, 0, 0, time.UTC).Format("Mon, 2006-01-02 15:04 MST")), command(ListTime))
+
+	assert.Equal(t, "Standup schedule removed, standups are held on working days at standup time", command(RemoveSchedule))
+	assert.True(t, strings.HasPrefix(command(ListTime), "<!date^"))
+	assert.NoError(t, db.DeleteStandupTime("schedulechan"))
+}
+
+func TestHandleEscalationCommands(t *testing.T) {
+	SetEscalation := "user_id=UB9AE7CL9&command=/standupescalationset&channel_id=escalationchan&text=DM=0 channel=30 admins=60 general=120"
+	SetUnknownAction := "user_id=UB9AE7CL9&command=/standupescalationset&channel_id=escalationchan&text=dm=0 sms=30"
+	SetWrongDelay := "user_id=UB9AE7CL9&command=/standupescalationset&channel_id=escalationchan&text=dm=0 channel=soon"
+	SetWrongOrder := "user_id=UB9AE7CL9&command=/standupescalationset&channel_id=escalationchan&text=dm=30 channel=0"
+	ShowEscalation := "user_id=UB9AE7CL9&command=/standupescalation&channel_id=escalationchan"
+	RemoveEscalation := "user_id=UB9AE7CL9&command=/standupescalationremove&channel_id=escalationchan"
+
+	c, err := config.Get()
+	db := storage.NewMemory()
+	assert.NoError(t, err)
+	rest, err := NewRESTAPI(c, db)
+	assert.NoError(t, err)
+
+	command := func(command string) string {
+		context, rec := getContext(command)
+		assert.NoError(t, rest.handleCommands(context))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		return rec.Body.String()
+	}
+
+	assert.True(t, strings.HasPrefix(command(ShowEscalation), "No escalation policy set for this channel"))
+	assert.True(t, strings.HasPrefix(command(SetUnknownAction), "Wrong escalation step: sms=30."))
+	assert.True(t, strings.HasPrefix(command(SetWrongDelay), "Wrong escalation step: channel=soon."))
+	assert.True(t, strings.HasPrefix(command(SetWrongOrder), "Wrong escalation step: channel=0."))
+	assert.True(t, strings.HasPrefix(command(ShowEscalation), "No escalation policy set for this channel"))
+	assert.Equal(t, "Escalation policy set: dm=0 channel=30 admins=60 general=120", command(SetEscalation))
+	assert.Equal(t, "Escalation policy set: dm=0 channel=30 admins=60 general=120", command(SetEscalation))
+	assert.Equal(t, "Escalation policy of this channel: dm=0 channel=30 admins=60 general=120", command(ShowEscalation))
+	assert.Equal(t, "Escalation policy for this channel removed", command(RemoveEscalation))
+	steps, err := db.ListEscalationSteps("escalationchan")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(steps))
+}
+
+func TestHandleLinkCommands(t *testing.T) {
+	LinkProject := "user_id=UB9AE7CL9&command=/linkproject&channel_id=linkchan&text=GitLab maddevs/comedian"
+	LinkUnknownProvider := "user_id=UB9AE7CL9&command=/linkproject&channel_id=linkchan&text=svn maddevs/comedian"
+	LinkProjectWrongArgs := "user_id=UB9AE7CL9&command=/linkproject&channel_id=linkchan&text=gitlab"
+	UnlinkProject := "user_id=UB9AE7CL9&command=/unlinkproject&channel_id=linkchan&text=gitlab maddevs/comedian"
+	ListLinks := "user_id=UB9AE7CL9&command=/links&channel_id=linkchan"
+	LinkUser := "user_id=UB9AE7CL9&command=/linkuser&channel_id=linkchan&text=gitlab <@ULINK1|anna> anna.s"
+	RelinkUser := "user_id=UB9AE7CL9&command=/linkuser&channel_id=linkchan&text=gitlab <@ULINK1|anna> anna"
+	UnlinkUser := "user_id=UB9AE7CL9&command=/unlinkuser&channel_id=linkchan&text=gitlab <@ULINK1|anna>"
+
+	c, err := config.Get()
+	c.GitlabToken = "gltoken"
+	db := storage.NewMemory()
+	assert.NoError(t, err)
+	rest, err := NewRESTAPI(c, db)
+	assert.NoError(t, err)
+
+	command := func(command string) string {
+		context, rec := getContext(command)
+		assert.NoError(t, rest.handleCommands(context))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		return rec.Body.String()
+	}
+
+	assert.True(t, strings.HasPrefix(command(ListLinks), "Channel is not linked to any project"))
+	assert.Equal(t, "Unknown provider svn, enabled providers: gitlab", command(LinkUnknownProvider))
+	assert.Equal(t, c.Translate.WrongNArgs, command(LinkProjectWrongArgs))
+	assert.Equal(t, "Channel is linked to gitlab project maddevs/comedian", command(LinkProject))
+	assert.Equal(t, "Channel is linked to projects: gitlab maddevs/comedian", command(ListLinks))
+	assert.Equal(t, "Channel is unlinked from gitlab project maddevs/comedian", command(UnlinkProject))
+	assert.True(t, strings.HasPrefix(command(ListLinks), "Channel is not linked to any project"))
+
+	assert.Equal(t, "<@ULINK1> is anna.s in gitlab", command(LinkUser))
+	assert.Equal(t, "<@ULINK1> is anna in gitlab", command(RelinkUser))
+	links, err := db.ListUserLinks("ULINK1")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(links))
+	assert.Equal(t, "anna", links[0].Username)
+	assert.Equal(t, "<@ULINK1> is unlinked from gitlab", command(UnlinkUser))
+	links, err = db.ListUserLinks("ULINK1")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(links))
+
+	// channel admins may not change links of users
+	_, err = db.CreateStandupUser(model.StandupUser{SlackUserID: "UADMIN", SlackName: "admin", ChannelID: "linkchan", Channel: "linkName", Role: "admin"})
+	assert.NoError(t, err)
+	assert.Equal(t, c.Translate.AccessDenied, command("user_id=UADMIN&command=/linkuser&channel_id=linkchan&text=gitlab <@ULINK1|anna> anna"))
+	assert.Equal(t, c.Translate.AccessDenied, command("user_id=UADMIN&command=/unlinkuser&channel_id=linkchan&text=gitlab <@ULINK1|anna>"))
+	links, err = db.ListUserLinks("ULINK1")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(links))
+}
+
+func TestHandleWebhookCommands(t *testing.T) {
+	var received []string
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = append(received, r.Header.Get("X-Comedian-Event"))
+	}))
+	defer receiver.Close()
+
+	AddWebhook := "user_id=UB9AE7CL9&command=/webhookadd&channel_id=hookchan&text=<" + receiver.URL + "/hook> standup.created standup.missed"
+	AdminAddWebhook := "user_id=UADMIN&command=/webhookadd&channel_id=hookchan&text=" + receiver.URL
+	AddWrongURL := "user_id=UB9AE7CL9&command=/webhookadd&channel_id=hookchan&text=example.com/hook"
+	AddWrongEvent := "user_id=UB9AE7CL9&command=/webhookadd&channel_id=hookchan&text=" + receiver.URL + " standup.deleted"
+	ListWebhooks := "user_id=UB9AE7CL9&command=/webhooks&channel_id=hookchan"
+
+	c, err := config.Get()
+	assert.NoError(t, err)
+	db := storage.NewMemory()
+	rest, err := NewRESTAPI(c, db)
+	assert.NoError(t, err)
+	_, err = db.CreateStandupUser(model.StandupUser{SlackUserID: "UADMIN", SlackName: "admin", ChannelID: "hookchan", Role: "admin"})
+	assert.NoError(t, err)
+
+	command := func(command string) string {
+		context, rec := getContext(command)
+		assert.NoError(t, rest.handleCommands(context))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		return rec.Body.String()
+	}
+
+	assert.Equal(t, "No webhooks created", command(ListWebhooks))
+	// channel admins cannot subscribe to events of all channels
+	assert.Equal(t, c.Translate.AccessDenied, command(AdminAddWebhook))
+	assert.Equal(t, "Wrong webhook: URL must start with http:// or https://", command(AddWrongURL))
+	assert.True(t, strings.HasPrefix(command(AddWrongEvent), "Wrong webhook: unknown event standup.deleted"))
+	reply := command(AddWebhook)
+	webhooks, err := db.ListWebhooks()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(webhooks))
+	assert.Equal(t, receiver.URL+"/hook", webhooks[0].URL)
+	id := fmt.Sprint(webhooks[0].ID)
+	assert.Equal(t, "Webhook "+id+" created for standup.created, standup.missed\nRequests are signed with secret "+webhooks[0].Secret+", keep it safe, it is shown only once", reply)
+
+	_, err = db.CreateDeadLetter(model.DeadLetter{WebhookID: webhooks[0].ID, Event: "standup.missed", Payload: "{}", Attempts: 5, Error: "status 500"})
+	assert.NoError(t, err)
+	assert.Equal(t, "Webhooks:\n"+id+". "+receiver.URL+"/hook: standup.created, standup.missed, undelivered events: 1", command(ListWebhooks))
+	assert.Equal(t, "1 undelivered events are sent to webhook "+id+" again", command("user_id=UB9AE7CL9&command=/webhookretry&channel_id=hookchan&text="+id))
+	rest.hooks.Wait()
+	assert.Equal(t, []string{"standup.missed"}, received)
+	assert.True(t, strings.HasSuffix(command(ListWebhooks), "undelivered events: 0"))
+
+	assert.Equal(t, "Webhook "+id+" removed", command("user_id=UB9AE7CL9&command=/webhookremove&channel_id=hookchan&text="+id))
+	assert.Equal(t, "Wrong webhook: no webhook "+id, command("user_id=UB9AE7CL9&command=/webhookremove&channel_id=hookchan&text="+id))
+	assert.Equal(t, "No webhooks created", command(ListWebhooks))
+}
+
+func TestHandleAbsenceCommands(t *testing.T) {
+	AddOwnAbsence := "user_id=UUSER1&command=/vacationadd&channel_id=vacationchan&text=2018-07-09 2018-07-13 summer vacation"
+	AddWrongAbsence := "user_id=UUSER1&command=/vacationadd&channel_id=vacationchan&text=2018-07-13 2018-07-09"
+	AddWrongDate := "user_id=UUSER1&command=/vacationadd&channel_id=vacationchan&text=2018-07-09 13.07.2018"
+	AddOtherAbsence := "user_id=UUSER1&command=/vacationadd&channel_id=vacationchan&text=<@UUSER2|user2> 2018-07-09 2018-07-13"
+	AdminAddOtherAbsence := "user_id=UB9AE7CL9&command=/vacationadd&channel_id=vacationchan&text=<@UUSER2|user2> 2018-08-01 2018-08-01 sick leave"
+	ListOwnAbsences := "user_id=UUSER1&command=/vacations&channel_id=vacationchan"
+	ListOtherAbsences := "user_id=UB9AE7CL9&command=/vacations&channel_id=vacationchan&text=<@UUSER2|user2>"
+	RemoveOwnAbsence := "user_id=UUSER1&command=/vacationremove&channel_id=vacationchan&text=2018-07-09"
+	AddTime := "user_id=UUSER1&command=/standuptimeset&channel_id=vacationchan&channel_name=chanName&text=09:30"
+
+	c, err := config.Get()
+	db := storage.NewMemory()
+	assert.NoError(t, err)
+	rest, err := NewRESTAPI(c, db)
+	assert.NoError(t, err)
+
+	command := func(command string) string {
+		context, rec := getContext(command)
+		assert.NoError(t, rest.handleCommands(context))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		return rec.Body.String()
+	}
+
+	// other commands are still for admins only
+	assert.Equal(t, "This command is not allowed for you! You are not admin", command(AddTime))
+
+	assert.Equal(t, "<@UUSER1> has no leaves", command(ListOwnAbsences))
+	assert.Equal(t, "<@UUSER1> is on leave from 2018-07-09 to 2018-07-13, no reminders on these days", command(AddOwnAbsence))
+	assert.Equal(t, "Wrong leave, please use `/vacationadd YYYY-MM-DD YYYY-MM-DD reason`, admins may mention @user before the dates", command(AddWrongAbsence))
+	assert.Equal(t, "Wrong date 13.07.2018, please use YYYY-MM-DD", command(AddWrongDate))
+	assert.Equal(t, "Leaves of <@UUSER1>: 2018-07-09 - 2018-07-13 (summer vacation)", command(ListOwnAbsences))
+
+	assert.Equal(t, "This command is not allowed for you! You are not admin", command(AddOtherAbsence))
+	assert.Equal(t, "<@UUSER2> is on leave from 2018-08-01 to 2018-08-01, no reminders on these days", command(AdminAddOtherAbsence))
+	assert.Equal(t, "Leaves of <@UUSER2>: 2018-08-01 - 2018-08-01 (sick leave)", command(ListOtherAbsences))
+
+	assert.Equal(t, "Leave of <@UUSER1> starting 2018-07-09 removed", command(RemoveOwnAbsence))
+	assert.Equal(t, "<@UUSER1> has no leaves", command(ListOwnAbsences))
+	assert.NoError(t, db.DeleteAbsence("UUSER2", "2018-08-01"))
+
+	// channel admins manage leaves of standupers of their channel only
+	for _, su := range []model.StandupUser{
+		{SlackUserID: "UADMIN", SlackName: "admin", ChannelID: "vacationchan", Channel: "chanName", Role: "admin"},
+		{SlackUserID: "UUSER2", SlackName: "user2", ChannelID: "vacationchan", Channel: "chanName"},
+		{SlackUserID: "UUSER3", SlackName: "user3", ChannelID: "otherchan", Channel: "otherName"},
+	} {
+		_, err := db.CreateStandupUser(su)
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, "<@UUSER2> is on leave from 2018-08-01 to 2018-08-01, no reminders on these days",
+		command("user_id=UADMIN&command=/vacationadd&channel_id=vacationchan&text=<@UUSER2|user2> 2018-08-01 2018-08-01"))
+	assert.Equal(t, "This command is not allowed for you! You are not admin",
+		command("user_id=UADMIN&command=/vacationadd&channel_id=vacationchan&text=<@UUSER3|user3> 2018-08-01 2018-08-01"))
+	assert.Equal(t, "This command is not allowed for you! You are not admin",
+		command("user_id=UADMIN&command=/vacationremove&channel_id=otherchan&text=<@UUSER3|user3> 2018-08-01"))
+	assert.NoError(t, db.DeleteAbsence("UUSER2", "2018-08-01"))
 }
@@ -0,0 +1,147 @@
+// Package calendar tells working days of channels from their work week or schedule and holidays
+package calendar
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/maddevsio/comedian/model"
+	"github.com/maddevsio/comedian/schedule"
+)
+
+// DateFormat is the format holidays are stored and entered in
+const DateFormat = "2006-01-02"
+
+// maxLookBack limits search for a previous working day or the next standup, e.g. when a work week is empty
+const maxLookBack = 366
+
+var weekdays = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// DefaultWorkDays are used by channels without own work week
+const DefaultWorkDays = "mon-fri"
+
+// Calendar describes working days of a channel
+type Calendar struct {
+	WorkDays [7]bool
+	Holidays map[string]string
+	Location *time.Location
+	// Time is the daily standup time as unix timestamp, used on work days without schedule
+	Time int64
+	// Schedule replaces work week and daily standup time if set
+	Schedule schedule.Schedule
+}
+
+// New creates calendar from channel standup time and holidays, a standup time
+// without work days means Monday to Friday work week. A wrong schedule is ignored
+func New(st model.StandupTime, holidays []model.Holiday) Calendar {
+	workDays, err := ParseWorkDays(st.WorkDays)
+	if err != nil {
+		workDays, _ = ParseWorkDays(DefaultWorkDays)
+	}
+	c := Calendar{WorkDays: workDays, Holidays: map[string]string{}, Location: st.Location(), Time: st.Time}
+	if st.Schedule != "" {
+		c.Schedule, _ = schedule.Parse(st.Schedule)
+	}
+	for _, h := range holidays {
+		c.Holidays[h.Date] = h.Name
+	}
+	return c
+}
+
+// ParseWorkDays parses comma separated days and ranges of days, e.g. "mon-thu,sat".
+// Empty string means Monday to Friday
+func ParseWorkDays(s string) ([7]bool, error) {
+	var days [7]bool
+	s = strings.ToLower(strings.Replace(s, " ", "", -1))
+	if s == "" {
+		s = DefaultWorkDays
+	}
+	for _, part := range strings.Split(s, ",") {
+		bounds := strings.SplitN(part, "-", 2)
+		from := weekday(bounds[0])
+		to := from
+		if len(bounds) == 2 {
+			to = weekday(bounds[1])
+		}
+		if from < 0 || to < 0 {
+			return days, errors.New("calendar: unknown day " + part)
+		}
+		for d := from; ; d = (d + 1) % 7 {
+			days[d] = true
+			if d == to {
+				break
+			}
+		}
+	}
+	return days, nil
+}
+
+// FormatWorkDays returns work days as comma separated short day names
+func FormatWorkDays(days [7]bool) string {
+	names := []string{}
+	// weeks start on Monday
+	for i := 1; i <= 7; i++ {
+		if days[i%7] {
+			names = append(names, weekdays[i%7])
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+func weekday(name string) int {
+	for i, d := range weekdays {
+		if len(name) >= 3 && strings.HasPrefix(d, name[:3]) {
+			return i
+		}
+	}
+	return -1
+}
+
+// IsWorkday checks if the day of t in calendar time zone is a working day,
+// with a schedule working days are days with standups
+func (c Calendar) IsWorkday(t time.Time) bool {
+	_, ok := c.Standup(t)
+	return ok
+}
+
+// Standup returns time of standup on the day of t in calendar time zone,
+// false if the day is a day off or a holiday
+func (c Calendar) Standup(t time.Time) (time.Time, bool) {
+	t = t.In(c.Location)
+	if _, holiday := c.Holidays[t.Format(DateFormat)]; holiday {
+		return time.Time{}, false
+	}
+	if c.Schedule != nil {
+		return c.Schedule.On(t)
+	}
+	if !c.WorkDays[t.Weekday()] {
+		return time.Time{}, false
+	}
+	standup := time.Unix(c.Time, 0).In(c.Location)
+	return time.Date(t.Year(), t.Month(), t.Day(), standup.Hour(), standup.Minute(), 0, 0, c.Location), true
+}
+
+// NextStandup returns the first standup after t, false if there is no standup within a year
+func (c Calendar) NextStandup(t time.Time) (time.Time, bool) {
+	t = t.In(c.Location)
+	for i := 0; i <= maxLookBack; i++ {
+		if at, ok := c.Standup(t.AddDate(0, 0, i)); ok && at.After(t) {
+			return at, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// PreviousWorkday returns the same time of the last working day before t,
+// e.g. Friday for Monday, or the day before if no working day is found within a year
+func (c Calendar) PreviousWorkday(t time.Time) time.Time {
+	t = t.In(c.Location)
+	for i := 1; i <= maxLookBack; i++ {
+		day := t.AddDate(0, 0, -i)
+		if c.IsWorkday(day) {
+			return day
+		}
+	}
+	return t.AddDate(0, 0, -1)
+}
@@ -0,0 +1,120 @@
+package calendar
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/maddevsio/comedian/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseWorkDays(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+		err      bool
+	}{
+		{"", "mon,tue,wed,thu,fri", false},
+		{"mon-fri", "mon,tue,wed,thu,fri", false},
+		{"Sun-Thu", "mon,tue,wed,thu,sun", false},
+		{"mon, wed,friday", "mon,wed,fri", false},
+		{"fri-mon", "mon,fri,sat,sun", false},
+		{"mon-funday", "", true},
+		{"mon,,fri", "", true},
+	}
+	for _, tt := range testCases {
+		days, err := ParseWorkDays(tt.input)
+		if tt.err {
+			assert.Error(t, err, tt.input)
+			continue
+		}
+		assert.NoError(t, err, tt.input)
+		assert.Equal(t, tt.expected, FormatWorkDays(days), tt.input)
+	}
+}
+
+func TestCalendar(t *testing.T) {
+	c := New(model.StandupTime{}, []model.Holiday{{Date: "2018-01-01", Name: "New Year"}, {Date: "2018-01-02"}})
+	monday := time.Date(2018, 1, 8, 10, 0, 0, 0, time.UTC)
+	assert.True(t, c.IsWorkday(monday))
+	assert.False(t, c.IsWorkday(monday.AddDate(0, 0, -1)))
+	assert.False(t, c.IsWorkday(time.Date(2018, 1, 1, 10, 0, 0, 0, time.UTC)))
+	assert.Equal(t, time.Date(2018, 1, 5, 10, 0, 0, 0, time.UTC), c.PreviousWorkday(monday).UTC())
+	// long weekend: Friday is the last working day before Wednesday after New Year holidays
+	assert.Equal(t, time.Date(2017, 12, 29, 10, 0, 0, 0, time.UTC), c.PreviousWorkday(time.Date(2018, 1, 3, 10, 0, 0, 0, time.UTC)).UTC())
+
+	// days are counted in channel time zone: Sunday 22:00 UTC is Monday in Bishkek
+	c = New(model.StandupTime{Timezone: "Asia/Bishkek", WorkDays: "mon-fri"}, nil)
+	assert.True(t, c.IsWorkday(time.Date(2018, 1, 7, 22, 0, 0, 0, time.UTC)))
+
+	c = New(model.StandupTime{WorkDays: "sun-thu"}, nil)
+	assert.True(t, c.IsWorkday(time.Date(2018, 1, 7, 10, 0, 0, 0, time.UTC)))
+	assert.False(t, c.IsWorkday(time.Date(2018, 1, 5, 10, 0, 0, 0, time.UTC)))
+}
+
+func TestCalendarSchedule(t *testing.T) {
+	// 2018-01-08 is Monday, 2018-01-10 is a holiday
+	c := New(model.StandupTime{Schedule: "30 9 * * MON,WED,FRI"}, []model.Holiday{{Date: "2018-01-10"}})
+	at, ok := c.Standup(time.Date(2018, 1, 8, 7, 0, 0, 0, time.UTC))
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2018, 1, 8, 9, 30, 0, 0, time.UTC), at.UTC())
+	assert.False(t, c.IsWorkday(time.Date(2018, 1, 9, 10, 0, 0, 0, time.UTC)))
+	assert.False(t, c.IsWorkday(time.Date(2018, 1, 10, 10, 0, 0, 0, time.UTC)))
+	assert.Equal(t, time.Date(2018, 1, 8, 10, 0, 0, 0, time.UTC), c.PreviousWorkday(time.Date(2018, 1, 12, 10, 0, 0, 0, time.UTC)).UTC())
+
+	// without schedule standups are at daily standup time on work days
+	c = New(model.StandupTime{Time: time.Date(2018, 1, 1, 12, 15, 0, 0, time.UTC).Unix()}, nil)
+	at, ok = c.Standup(time.Date(2018, 1, 9, 7, 0, 0, 0, time.UTC))
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2018, 1, 9, 12, 15, 0, 0, time.UTC), at.UTC())
+}
+
+func TestParseICS(t *testing.T) {
+	ics := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"BEGIN:VEVENT",
+		"DTSTART;VALUE=DATE:20180101",
+		"DTEND;VALUE=DATE:20180103",
+		"SUMMARY:New Year\\, holidays",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"DTSTART;VALUE=DATE:20180308",
+		"SUMMARY:International Women's",
+		"  Day",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"SUMMARY:no date",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	}, "\r\n")
+	holidays, err := ParseICS(strings.NewReader(ics), "CHAN1")
+	assert.NoError(t, err)
+	assert.Equal(t, []model.Holiday{
+		{ChannelID: "CHAN1", Date: "2018-01-01", Name: "New Year, holidays"},
+		{ChannelID: "CHAN1", Date: "2018-01-02", Name: "New Year, holidays"},
+		{ChannelID: "CHAN1", Date: "2018-03-08", Name: "International Women's Day"},
+	}, holidays)
+}
+
+func TestParseICSLimits(t *testing.T) {
+	event := func(start, end string) string {
+		return "BEGIN:VEVENT\r\nDTSTART;VALUE=DATE:" + start + "\r\nDTEND;VALUE=DATE:" + end + "\r\nEND:VEVENT\r\n"
+	}
+	holidays, err := ParseICS(strings.NewReader(event("20180101", "20180201")), "CHAN1")
+	assert.NoError(t, err)
+	assert.Len(t, holidays, 31)
+	_, err = ParseICS(strings.NewReader(event("20180101", "20180202")), "CHAN1")
+	assert.Error(t, err)
+	_, err = ParseICS(strings.NewReader(event("20180101", "99991231")), "CHAN1")
+	assert.Error(t, err)
+
+	// each event is short, but together they are too many
+	ics := ""
+	for d := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC); d.Year() < 2021; d = d.AddDate(0, 0, 1) {
+		ics += event(d.Format("20060102"), d.AddDate(0, 0, 1).Format("20060102"))
+	}
+	_, err = ParseICS(strings.NewReader(ics), "CHAN1")
+	assert.Error(t, err)
+}
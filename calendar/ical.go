@@ -0,0 +1,108 @@
+package calendar
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/maddevsio/comedian/model"
+)
+
+const (
+	// maxEventDays is the longest event ParseICS accepts, longer ones are likely mistakes
+	maxEventDays = 31
+	// maxHolidays is the most holidays ParseICS reads from one file
+	maxHolidays = 1000
+)
+
+// ParseICS reads all-day events of iCalendar file as holidays of channel.
+// Events lasting several days produce a holiday for each day, it fails on events
+// longer than maxEventDays and on files with more than maxHolidays holidays
+func ParseICS(r io.Reader, channelID string) ([]model.Holiday, error) {
+	holidays := []model.Holiday{}
+	var start, end time.Time
+	var summary string
+	inEvent := false
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+	for _, line := range lines {
+		name, value := splitProperty(line)
+		switch {
+		case name == "BEGIN" && value == "VEVENT":
+			inEvent = true
+			start, end, summary = time.Time{}, time.Time{}, ""
+		case name == "END" && value == "VEVENT":
+			inEvent = false
+			if start.IsZero() {
+				continue
+			}
+			// DTEND of all-day events is exclusive
+			if end.IsZero() || !end.After(start) {
+				end = start.AddDate(0, 0, 1)
+			}
+			if end.After(start.AddDate(0, 0, maxEventDays)) {
+				return nil, fmt.Errorf("calendar: event %q lasts more than %v days", summary, maxEventDays)
+			}
+			for d := start; d.Before(end); d = d.AddDate(0, 0, 1) {
+				if len(holidays) == maxHolidays {
+					return nil, fmt.Errorf("calendar: more than %v holidays", maxHolidays)
+				}
+				holidays = append(holidays, model.Holiday{ChannelID: channelID, Date: d.Format(DateFormat), Name: summary})
+			}
+		case !inEvent:
+		case name == "DTSTART":
+			start = parseICSDate(value)
+		case name == "DTEND":
+			end = parseICSDate(value)
+		case name == "SUMMARY":
+			summary = unescapeICS(value)
+		}
+	}
+	return holidays, nil
+}
+
+// unfold joins continuation lines, which start with a space or a tab
+func unfold(r io.Reader) ([]string, error) {
+	lines := []string{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
+
+// splitProperty returns property name without parameters and its value,
+// e.g. "DTSTART;VALUE=DATE:20180101" is "DTSTART", "20180101"
+func splitProperty(line string) (string, string) {
+	i := strings.Index(line, ":")
+	if i < 0 {
+		return strings.ToUpper(line), ""
+	}
+	name := strings.SplitN(line[:i], ";", 2)[0]
+	return strings.ToUpper(name), line[i+1:]
+}
+
+// parseICSDate parses date part of DATE and DATE-TIME values
+func parseICSDate(value string) time.Time {
+	if len(value) < 8 {
+		return time.Time{}
+	}
+	t, err := time.Parse("20060102", value[:8])
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+func unescapeICS(s string) string {
+	return strings.NewReplacer(`\,`, ",", `\;`, ";", `\n`, " ", `\N`, " ", `\\`, `\`).Replace(s)
+}
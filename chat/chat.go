@@ -1,8 +1,14 @@
 package chat
 
+import "net/url"
+
 // Chat inteface should be implemented for all messengers(facebook, slack, telegram, whatever)
 type Chat interface {
 	Run() error
 	SendMessage(string, string) error
 	SendUserMessage(string, string) error
 }
+
+// CommandHandler handles slash command received as a chat message. Form has the
+// same fields as Slack slash command payload, returned text is sent as a reply
+type CommandHandler func(platform string, form url.Values) string
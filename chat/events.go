@@ -0,0 +1,147 @@
+package chat
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/nlopes/slack"
+	"github.com/sirupsen/logrus"
+)
+
+// Slack transports, choose with SLACK_TRANSPORT env variable
+const (
+	TransportRTM    = "rtm"
+	TransportEvents = "events"
+)
+
+// eventsQueueSize limits number of events accepted over http but not handled yet
+const eventsQueueSize = 100
+
+// eventsTTL is how long IDs of accepted events are remembered, Slack retries an event
+// it got no answer to three times within an hour
+const eventsTTL = time.Hour
+
+// eventsRequest is an outer envelope of requests made by Slack Events API
+type eventsRequest struct {
+	Type      string          `json:"type"`
+	Challenge string          `json:"challenge"`
+	EventID   string          `json:"event_id"`
+	Event     json.RawMessage `json:"event"`
+}
+
+// eventType is used to peek into event type before parsing the event itself
+type eventType struct {
+	Type string `json:"type"`
+}
+
+// eventIDs remembers IDs of events accepted recently, so retries of them are not handled twice
+type eventIDs struct {
+	mu        sync.Mutex
+	seen      map[string]time.Time
+	lastSweep time.Time
+}
+
+// add remembers id at now, it returns false if id was already added within eventsTTL
+func (e *eventIDs) add(id string, now time.Time) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.seen == nil {
+		e.seen = map[string]time.Time{}
+	}
+	if now.Sub(e.lastSweep) > time.Minute {
+		for seenID, at := range e.seen {
+			if now.Sub(at) > eventsTTL {
+				delete(e.seen, seenID)
+			}
+		}
+		e.lastSweep = now
+	}
+	if at, ok := e.seen[id]; ok && now.Sub(at) <= eventsTTL {
+		return false
+	}
+	e.seen[id] = now
+	return true
+}
+
+// remove forgets id, so the event is handled when Slack sends it again
+func (e *eventIDs) remove(id string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	delete(e.seen, id)
+}
+
+// EventsHandler returns http handler for Slack Events API requests. Slack must
+// be configured to send events to it, see https://api.slack.com/events-api
+func (s *Slack) EventsHandler() http.Handler {
+	return http.HandlerFunc(s.handleEventsRequest)
+}
+
+func (s *Slack) handleEventsRequest(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		logrus.Errorf("slack: read events request failed: %v\n", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if err := VerifySlackSignature(s.Conf.SlackSigningSecret, r.Header, body, time.Now()); err != nil {
+		logrus.Errorf("slack: events request rejected: %v\n", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	var req eventsRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		logrus.Errorf("slack: events request Unmarshal failed: %v\n", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	switch req.Type {
+	case "url_verification":
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte(req.Challenge))
+		return
+	case "event_callback":
+		var et eventType
+		if err := json.Unmarshal(req.Event, &et); err != nil {
+			logrus.Errorf("slack: event Unmarshal failed: %v\n", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if et.Type != "message" {
+			logrus.Infof("slack: skip event %v of type %v\n", req.EventID, et.Type)
+			break
+		}
+		msg := &slack.MessageEvent{}
+		if err := json.Unmarshal(req.Event, msg); err != nil {
+			logrus.Errorf("slack: message event Unmarshal failed: %v\n", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		// Slack sends an event again if it got no answer in time, retries of accepted events are only acknowledged
+		if req.EventID != "" && !s.eventIDs.add(req.EventID, time.Now()) {
+			logrus.Infof("slack: skip event %v, retry %v\n", req.EventID, r.Header.Get("X-Slack-Retry-Num"))
+			break
+		}
+		// Slack expects response within 3 seconds, so message is handled later
+		select {
+		case s.events <- msg:
+		default:
+			logrus.Errorf("slack: events queue is full, event %v dropped\n", req.EventID)
+			s.eventIDs.remove(req.EventID)
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// runEvents handles messages received by EventsHandler
+func (s *Slack) runEvents() error {
+	s.handleConnection()
+	for msg := range s.events {
+		s.handleMessage(msg)
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+package chat
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/maddevsio/comedian/config"
+	"github.com/maddevsio/comedian/storage"
+	"github.com/stretchr/testify/assert"
+	httpmock "gopkg.in/jarcoal/httpmock.v1"
+)
+
+const testSigningSecret = "8f742231b10e8888abcd99yyyzzz85a5"
+
+func newEventsSlack(t *testing.T) (*Slack, *storage.Memory) {
+	tr, err := config.GetTranslation("en_US")
+	assert.NoError(t, err)
+	c := config.Config{
+		SlackToken:         "xoxb-test",
+		SlackTransport:     TransportEvents,
+		SlackSigningSecret: testSigningSecret,
+		Translate:          tr,
+	}
+	db := storage.NewMemory()
+	s, err := NewSlack(c, db)
+	assert.NoError(t, err)
+	return s, db
+}
+
+func signedEventsRequest(body string, ts time.Time) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/events", strings.NewReader(body))
+	timestamp := strconv.FormatInt(ts.Unix(), 10)
+	req.Header.Set("X-Slack-Request-Timestamp", timestamp)
+	req.Header.Set("X-Slack-Signature", SlackSignature(testSigningSecret, timestamp, []byte(body)))
+	return req
+}
+
+func TestVerifySlackSignature(t *testing.T) {
+	body := []byte("token=xyzz0WbapA4vBCDEFasx0q6G&team_id=T1DC2JH3J&command=%2Fstanduptime")
+	now := time.Unix(1531420618, 0)
+	header := http.Header{}
+	header.Set("X-Slack-Request-Timestamp", "1531420618")
+	header.Set("X-Slack-Signature", SlackSignature(testSigningSecret, "1531420618", body))
+
+	assert.NoError(t, VerifySlackSignature(testSigningSecret, header, body, now))
+	assert.NoError(t, VerifySlackSignature(testSigningSecret, header, body, now.Add(4*time.Minute)))
+	assert.Equal(t, ErrExpiredSignature, VerifySlackSignature(testSigningSecret, header, body, now.Add(6*time.Minute)))
+	assert.Equal(t, ErrInvalidSignature, VerifySlackSignature("other secret", header, body, now))
+	assert.Equal(t, ErrInvalidSignature, VerifySlackSignature(testSigningSecret, header, append(body, '1'), now))
+	assert.Equal(t, ErrMissingSignature, VerifySlackSignature(testSigningSecret, http.Header{}, body, now))
+}
+
+func TestNewSlackTransport(t *testing.T) {
+	_, err := NewSlack(config.Config{SlackTransport: TransportEvents}, storage.NewMemory())
+	assert.Error(t, err)
+	_, err = NewSlack(config.Config{SlackTransport: "smoke signals"}, storage.NewMemory())
+	assert.Error(t, err)
+	s, err := NewSlack(config.Config{}, storage.NewMemory())
+	assert.NoError(t, err)
+	assert.NotNil(t, s.rtm)
+}
+
+func TestEventsURLVerification(t *testing.T) {
+	s, _ := newEventsSlack(t)
+	body := `{"token":"Jhj5dZrVaK7ZwHHjRyZWjbDl","challenge":"3eZbrw1aBm2rZgRNFdxV2595E9CY3gmdALWMmHkvFXO7tYXAYM8P","type":"url_verification"}`
+
+	rec := httptest.NewRecorder()
+	s.EventsHandler().ServeHTTP(rec, signedEventsRequest(body, time.Now()))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "3eZbrw1aBm2rZgRNFdxV2595E9CY3gmdALWMmHkvFXO7tYXAYM8P", rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	s.EventsHandler().ServeHTTP(rec, signedEventsRequest(body, time.Now().Add(-time.Hour)))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	rec = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/events", strings.NewReader(body))
+	s.EventsHandler().ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestEventsMessages(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterResponder("POST", "https://slack.com/api/chat.postMessage", httpmock.NewStringResponder(200, `{"ok": true}`))
+
+	s, db := newEventsSlack(t)
+	events := []string{
+		`{"type":"event_callback","event_id":"Ev1","event":{"type":"message","channel":"C1","user":"U1","text":"Yesterday: tests, today: more tests, problems: none","ts":"1355517523.000005"}}`,
+		`{"type":"event_callback","event_id":"Ev2","event":{"type":"reaction_added","user":"U1","reaction":"thumbsup"}}`,
+		`{"type":"event_callback","event_id":"Ev3","event":{"type":"message","subtype":"message_changed","channel":"C1","message":{"user":"U1","text":"Yesterday: tests, today: even more tests, problems: none","ts":"1355517523.000005"}}}`,
+	}
+	for _, body := range events {
+		rec := httptest.NewRecorder()
+		s.EventsHandler().ServeHTTP(rec, signedEventsRequest(body, time.Now()))
+		assert.Equal(t, http.StatusOK, rec.Code)
+	}
+	assert.Equal(t, 2, len(s.events))
+	assert.NoError(t, s.handleMessage(<-s.events))
+
+	standup, err := db.SelectStandupByMessageTS("1355517523.000005")
+	assert.NoError(t, err)
+	assert.Equal(t, "C1", standup.ChannelID)
+	assert.Equal(t, "U1", standup.UsernameID)
+
+	assert.NoError(t, s.handleMessage(<-s.events))
+	standup, err = db.SelectStandupByMessageTS("1355517523.000005")
+	assert.NoError(t, err)
+	assert.Equal(t, "Yesterday: tests, today: even more tests, problems: none", standup.Comment)
+	assert.Equal(t, "tests", standup.Yesterday)
+	assert.Equal(t, "even more tests", standup.Today)
+	assert.Equal(t, "none", standup.Problems)
+
+	body := `{"type":"event_callback","event_id":"Ev4","event":{"type":"message","subtype":"message_deleted","channel":"C1","deleted_ts":"1355517523.000005"}}`
+	rec := httptest.NewRecorder()
+	s.EventsHandler().ServeHTTP(rec, signedEventsRequest(body, time.Now()))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NoError(t, s.handleMessage(<-s.events))
+	standups, err := db.ListStandups()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(standups))
+}
+
+func TestEventsRetries(t *testing.T) {
+	s, _ := newEventsSlack(t)
+	body := `{"type":"event_callback","event_id":"Ev1","event":{"type":"message","channel":"C1","user":"U1","text":"Yesterday: tests, today: more tests, problems: none","ts":"1355517523.000005"}}`
+	for retry := 0; retry < 3; retry++ {
+		req := signedEventsRequest(body, time.Now())
+		if retry > 0 {
+			req.Header.Set("X-Slack-Retry-Num", strconv.Itoa(retry))
+			req.Header.Set("X-Slack-Retry-Reason", "http_timeout")
+		}
+		rec := httptest.NewRecorder()
+		s.EventsHandler().ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusOK, rec.Code)
+	}
+	assert.Equal(t, 1, len(s.events))
+
+	// events dropped because queue is full are handled when Slack retries them
+	for i := 1; i < eventsQueueSize; i++ {
+		s.events <- nil
+	}
+	body = strings.Replace(body, "Ev1", "Ev2", 1)
+	rec := httptest.NewRecorder()
+	s.EventsHandler().ServeHTTP(rec, signedEventsRequest(body, time.Now()))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	<-s.events
+	rec = httptest.NewRecorder()
+	s.EventsHandler().ServeHTTP(rec, signedEventsRequest(body, time.Now()))
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestEventIDs(t *testing.T) {
+	var ids eventIDs
+	now := time.Now()
+	assert.True(t, ids.add("Ev1", now))
+	assert.False(t, ids.add("Ev1", now.Add(time.Minute)))
+	assert.True(t, ids.add("Ev2", now.Add(time.Minute)))
+	// expired IDs are forgotten
+	assert.True(t, ids.add("Ev1", now.Add(eventsTTL+2*time.Minute)))
+	assert.Equal(t, 1, len(ids.seen))
+}
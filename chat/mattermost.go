@@ -0,0 +1,312 @@
+package chat
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/maddevsio/comedian/config"
+	"github.com/maddevsio/comedian/model"
+	"github.com/maddevsio/comedian/storage"
+	"github.com/maddevsio/comedian/webhook"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/websocket"
+)
+
+// mattermostRetryDelay is a pause before reconnecting to websocket
+const mattermostRetryDelay = 5 * time.Second
+
+// Mattermost struct used for communicating with Mattermost API v4
+type Mattermost struct {
+	Chat
+	client *http.Client
+	botID  string
+	mu     sync.Mutex
+	// users caches usernames by user ID for mentions in sent messages
+	users map[string]string
+	db    storage.Storage
+	hooks *webhook.Dispatcher
+	Conf  config.Config
+}
+
+type mattermostEvent struct {
+	Event string `json:"event"`
+	Data  struct {
+		// Post is JSON encoded mattermostPost
+		Post        string `json:"post"`
+		ChannelType string `json:"channel_type"`
+	} `json:"data"`
+}
+
+type mattermostPost struct {
+	ID        string `json:"id"`
+	UserID    string `json:"user_id"`
+	ChannelID string `json:"channel_id"`
+	Message   string `json:"message"`
+	Type      string `json:"type"`
+}
+
+type mattermostUser struct {
+	ID       string `json:"id"`
+	Username string `json:"username"`
+}
+
+type mattermostChannel struct {
+	ID string `json:"id"`
+}
+
+// NewMattermost creates a new copy of Mattermost handler
+func NewMattermost(conf config.Config, db storage.Storage) (*Mattermost, error) {
+	if conf.MattermostURL == "" || conf.MattermostToken == "" {
+		return nil, errors.New("mattermost: MATTERMOST_URL and MATTERMOST_TOKEN are required")
+	}
+	m := &Mattermost{}
+	m.Conf = conf
+	m.db = db
+	m.hooks = webhook.New(conf, db)
+	m.client = &http.Client{Timeout: 30 * time.Second}
+	m.users = map[string]string{}
+	return m, nil
+}
+
+// Run listens to Mattermost websocket events and reconnects when connection is lost
+func (m *Mattermost) Run() error {
+	if _, err := m.me(); err != nil {
+		logrus.Errorf("mattermost: get bot user failed: %v\n", err)
+		return err
+	}
+	for {
+		ws, err := m.connect()
+		if err != nil {
+			logrus.Errorf("mattermost: websocket connect failed: %v\n", err)
+			time.Sleep(mattermostRetryDelay)
+			continue
+		}
+		logrus.Info("mattermost: websocket connected")
+		err = m.listen(ws)
+		logrus.Errorf("mattermost: websocket closed: %v\n", err)
+		ws.Close()
+	}
+}
+
+func (m *Mattermost) connect() (*websocket.Conn, error) {
+	u, err := url.Parse(m.apiURL("/websocket"))
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme == "https" {
+		u.Scheme = "wss"
+	} else {
+		u.Scheme = "ws"
+	}
+	wsConfig, err := websocket.NewConfig(u.String(), m.Conf.MattermostURL)
+	if err != nil {
+		return nil, err
+	}
+	wsConfig.Header.Set("Authorization", "Bearer "+m.Conf.MattermostToken)
+	return websocket.DialConfig(wsConfig)
+}
+
+// listen handles events until websocket is closed
+func (m *Mattermost) listen(ws *websocket.Conn) error {
+	for {
+		var ev mattermostEvent
+		if err := websocket.JSON.Receive(ws, &ev); err != nil {
+			return err
+		}
+		m.handleEvent(ev)
+	}
+}
+
+func (m *Mattermost) handleEvent(ev mattermostEvent) error {
+	if ev.Event != "posted" && ev.Event != "post_edited" && ev.Event != "post_deleted" {
+		return nil
+	}
+	var post mattermostPost
+	if err := json.Unmarshal([]byte(ev.Data.Post), &post); err != nil {
+		logrus.Errorf("mattermost: post Unmarshal failed: %v\n", err)
+		return err
+	}
+	m.mu.Lock()
+	botID := m.botID
+	m.mu.Unlock()
+	// system messages (joins, header changes) have a type, user posts do not
+	if post.Type != "" || post.UserID == botID {
+		return nil
+	}
+	switch ev.Event {
+	case "posted":
+		if ev.Data.ChannelType == "D" {
+			return nil
+		}
+		created, err := saveStandup(m.db, m.hooks, m.Conf.Translate, model.Standup{
+			ChannelID:  post.ChannelID,
+			UsernameID: post.UserID,
+			Comment:    post.Message,
+			MessageTS:  post.ID,
+			Platform:   PlatformMattermost,
+		})
+		if err != nil {
+			logrus.Errorf("mattermost: saveStandup failed: %v\n", err)
+			return err
+		}
+		if created {
+			return m.SendMessage(post.ChannelID, m.Conf.Translate.StandupAccepted)
+		}
+	case "post_edited":
+		return editStandup(m.db, m.hooks, m.Conf.Translate, post.Message, post.ID)
+	case "post_deleted":
+		return deleteStandup(m.db, post.ID)
+	}
+	return nil
+}
+
+// SendMessage posts a message in a specified channel
+func (m *Mattermost) SendMessage(channelID, message string) error {
+	err := m.do(http.MethodPost, "/posts", map[string]string{
+		"channel_id": channelID,
+		"message":    m.text(message),
+	}, nil)
+	if err != nil {
+		logrus.Errorf("mattermost: create post failed: %v\n", err)
+		return err
+	}
+	logrus.Infof("mattermost: message sent: chan:%v, message:%v\n", channelID, message)
+	return nil
+}
+
+// SendUserMessage posts a message to a direct channel with a specific user
+func (m *Mattermost) SendUserMessage(userID, message string) error {
+	botID, err := m.me()
+	if err != nil {
+		logrus.Errorf("mattermost: get bot user failed: %v\n", err)
+		return err
+	}
+	var channel mattermostChannel
+	if err := m.do(http.MethodPost, "/channels/direct", []string{botID, userID}, &channel); err != nil {
+		logrus.Errorf("mattermost: create direct channel failed: %v\n", err)
+		return err
+	}
+	return m.SendMessage(channel.ID, message)
+}
+
+// UserIDByName returns ID of user with username, it is used to convert
+// mentions in slash commands to the format Slack uses
+func (m *Mattermost) UserIDByName(username string) (string, error) {
+	var user mattermostUser
+	if err := m.do(http.MethodGet, "/users/username/"+url.PathEscape(username), nil, &user); err != nil {
+		return "", err
+	}
+	return user.ID, nil
+}
+
+// me returns ID of the bot user
+func (m *Mattermost) me() (string, error) {
+	m.mu.Lock()
+	botID := m.botID
+	m.mu.Unlock()
+	if botID != "" {
+		return botID, nil
+	}
+	var user mattermostUser
+	if err := m.do(http.MethodGet, "/users/me", nil, &user); err != nil {
+		return "", err
+	}
+	m.mu.Lock()
+	m.botID = user.ID
+	m.mu.Unlock()
+	return user.ID, nil
+}
+
+// username returns username for user ID, user ID is returned if lookup fails
+func (m *Mattermost) username(userID string) string {
+	m.mu.Lock()
+	name, ok := m.users[userID]
+	m.mu.Unlock()
+	if ok {
+		return name
+	}
+	var user mattermostUser
+	if err := m.do(http.MethodGet, "/users/"+url.PathEscape(userID), nil, &user); err != nil {
+		logrus.Errorf("mattermost: get user %v failed: %v\n", userID, err)
+		return userID
+	}
+	m.mu.Lock()
+	m.users[userID] = user.Username
+	m.mu.Unlock()
+	return user.Username
+}
+
+// text converts Slack mentions to Mattermost markdown
+func (m *Mattermost) text(message string) string {
+	return slackMarkup.ReplaceAllStringFunc(message, func(s string) string {
+		parts := slackMarkup.FindStringSubmatch(s)
+		kind, id, label := parts[1], parts[2], parts[3]
+		switch {
+		case kind == "@" && label != "":
+			return "@" + label
+		case kind == "@":
+			return "@" + m.username(id)
+		case kind == "#" && label != "":
+			return "~" + label
+		case kind == "#":
+			return "~" + id
+		case label != "":
+			return label
+		}
+		return "@" + id
+	})
+}
+
+func (m *Mattermost) apiURL(path string) string {
+	return strings.TrimRight(m.Conf.MattermostURL, "/") + "/api/v4" + path
+}
+
+// do makes API request and unmarshals response to result if it is not nil
+func (m *Mattermost) do(method, path string, body, result interface{}) error {
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			return err
+		}
+	}
+	req, err := http.NewRequest(method, m.apiURL(path), &buf)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+m.Conf.MattermostToken)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		json.NewDecoder(resp.Body).Decode(&apiErr)
+		return fmt.Errorf("%v %v: %v %v", method, path, resp.StatusCode, apiErr.Message)
+	}
+	if result == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
+
+// ChannelIDByName returns ID of team channel with name, it is used to convert
+// channel links in slash commands to the format Slack uses
+func (m *Mattermost) ChannelIDByName(teamID, name string) (string, error) {
+	var channel mattermostChannel
+	path := fmt.Sprintf("/teams/%v/channels/name/%v", url.PathEscape(teamID), url.PathEscape(name))
+	if err := m.do(http.MethodGet, path, nil, &channel); err != nil {
+		return "", err
+	}
+	return channel.ID, nil
+}
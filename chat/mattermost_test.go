@@ -0,0 +1,162 @@
+package chat
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/maddevsio/comedian/config"
+	"github.com/maddevsio/comedian/storage"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/websocket"
+)
+
+// fakeMattermost is a local Mattermost API server, it streams queued events
+// over websocket and records created posts
+type fakeMattermost struct {
+	mu     sync.Mutex
+	events []mattermostEvent
+	posts  []map[string]string
+	direct [][]string
+}
+
+func (f *fakeMattermost) handler() http.Handler {
+	mux := http.NewServeMux()
+	auth := func(h http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "Bearer test-token" {
+				w.WriteHeader(http.StatusUnauthorized)
+				json.NewEncoder(w).Encode(map[string]string{"message": "Invalid or expired session"})
+				return
+			}
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			h(w, r)
+		}
+	}
+	mux.HandleFunc("/api/v4/users/me", auth(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(mattermostUser{ID: "bot", Username: "comedian"})
+	}))
+	mux.HandleFunc("/api/v4/users/u1", auth(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(mattermostUser{ID: "u1", Username: "ivan"})
+	}))
+	mux.HandleFunc("/api/v4/users/username/ivan", auth(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(mattermostUser{ID: "u1", Username: "ivan"})
+	}))
+	mux.HandleFunc("/api/v4/teams/t1/channels/name/backend", auth(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(mattermostChannel{ID: "c1"})
+	}))
+	mux.HandleFunc("/api/v4/posts", auth(func(w http.ResponseWriter, r *http.Request) {
+		var post map[string]string
+		json.NewDecoder(r.Body).Decode(&post)
+		f.posts = append(f.posts, post)
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(post)
+	}))
+	mux.HandleFunc("/api/v4/channels/direct", auth(func(w http.ResponseWriter, r *http.Request) {
+		var ids []string
+		json.NewDecoder(r.Body).Decode(&ids)
+		f.direct = append(f.direct, ids)
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(mattermostChannel{ID: "dm-" + ids[1]})
+	}))
+	ws := websocket.Handler(func(ws *websocket.Conn) {
+		f.mu.Lock()
+		events := f.events
+		f.mu.Unlock()
+		for _, ev := range events {
+			websocket.JSON.Send(ws, ev)
+		}
+	})
+	mux.HandleFunc("/api/v4/websocket", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer test-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		ws.ServeHTTP(w, r)
+	})
+	return mux
+}
+
+func mattermostPostEvent(event, channelType string, post mattermostPost) mattermostEvent {
+	raw, _ := json.Marshal(post)
+	ev := mattermostEvent{Event: event}
+	ev.Data.Post = string(raw)
+	ev.Data.ChannelType = channelType
+	return ev
+}
+
+func newTestMattermost(t *testing.T) (*Mattermost, *fakeMattermost, storage.Storage) {
+	c, err := config.Get()
+	assert.NoError(t, err)
+	api := &fakeMattermost{}
+	server := httptest.NewServer(api.handler())
+	t.Cleanup(server.Close)
+	c.MattermostURL = server.URL
+	c.MattermostToken = "test-token"
+	db := storage.NewMemory()
+	m, err := NewMattermost(c, db)
+	assert.NoError(t, err)
+	return m, api, db
+}
+
+func TestNewMattermost(t *testing.T) {
+	_, err := NewMattermost(config.Config{MattermostURL: "https://mattermost.example.com"}, storage.NewMemory())
+	assert.Error(t, err)
+}
+
+func TestMattermostEvents(t *testing.T) {
+	m, api, db := newTestMattermost(t)
+	api.events = []mattermostEvent{
+		{Event: "hello"},
+		mattermostPostEvent("posted", "O", mattermostPost{ID: "p1", UserID: "u1", ChannelID: "c1", Message: "Yesterday: tests, today: more tests, problems: none"}),
+		mattermostPostEvent("posted", "O", mattermostPost{ID: "p2", UserID: "u1", ChannelID: "c1", Message: "u1 joined the channel", Type: "system_join_channel"}),
+		mattermostPostEvent("posted", "D", mattermostPost{ID: "p3", UserID: "u1", ChannelID: "d1", Message: "Yesterday: tests, today: more tests, problems: none"}),
+		mattermostPostEvent("posted", "O", mattermostPost{ID: "p4", UserID: "bot", ChannelID: "c1", Message: "Yesterday: tests, today: more tests, problems: none"}),
+		mattermostPostEvent("post_edited", "", mattermostPost{ID: "p1", UserID: "u1", ChannelID: "c1", Message: "Yesterday: tests, today: even more tests, problems: none"}),
+	}
+	_, err := m.me()
+	assert.NoError(t, err)
+	ws, err := m.connect()
+	assert.NoError(t, err)
+	// fake server closes websocket after all events are sent
+	assert.Error(t, m.listen(ws))
+
+	standups, err := db.ListStandups()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(standups))
+	assert.Equal(t, "c1", standups[0].ChannelID)
+	assert.Equal(t, "u1", standups[0].UsernameID)
+	assert.Equal(t, "p1", standups[0].MessageTS)
+	assert.Equal(t, "Yesterday: tests, today: even more tests, problems: none", standups[0].Comment)
+	assert.Equal(t, 1, len(api.posts))
+	assert.Equal(t, m.Conf.Translate.StandupAccepted, api.posts[0]["message"])
+
+	assert.NoError(t, m.handleEvent(mattermostPostEvent("post_deleted", "", mattermostPost{ID: "p1", UserID: "u1", ChannelID: "c1"})))
+	standups, err = db.ListStandups()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(standups))
+}
+
+func TestMattermostSendUserMessage(t *testing.T) {
+	m, api, _ := newTestMattermost(t)
+	assert.NoError(t, m.SendUserMessage("u1", "<@u1>, <@u2|petr>, write standup in <#c1|backend>, <!here>"))
+	assert.Equal(t, [][]string{{"bot", "u1"}}, api.direct)
+	assert.Equal(t, 1, len(api.posts))
+	assert.Equal(t, "dm-u1", api.posts[0]["channel_id"])
+	assert.Equal(t, "@ivan, @petr, write standup in ~backend, @here", api.posts[0]["message"])
+
+	id, err := m.UserIDByName("ivan")
+	assert.NoError(t, err)
+	assert.Equal(t, "u1", id)
+	id, err = m.ChannelIDByName("t1", "backend")
+	assert.NoError(t, err)
+	assert.Equal(t, "c1", id)
+	_, err = m.UserIDByName("nobody")
+	assert.Error(t, err)
+
+	m.Conf.MattermostToken = "wrong"
+	assert.Error(t, m.SendMessage("c1", "hello"))
+}
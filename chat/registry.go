@@ -0,0 +1,86 @@
+package chat
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Platforms stored with standups, standupers and standup times
+const (
+	PlatformSlack      = "slack"
+	PlatformTelegram   = "telegram"
+	PlatformMattermost = "mattermost"
+)
+
+// Registry keeps chat backends by platform, so that one Comedian process
+// serves several messengers and reminders go through the right one
+type Registry struct {
+	mu    sync.RWMutex
+	chats map[string]Chat
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{chats: map[string]Chat{}}
+}
+
+// Register adds chat backend for platform, previous backend of the platform is replaced
+func (r *Registry) Register(platform string, c Chat) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.chats[platform] = c
+}
+
+// Get returns chat backend for platform. Entries created before platforms
+// were introduced have no platform and belong to Slack
+func (r *Registry) Get(platform string) (Chat, error) {
+	if platform == "" {
+		platform = PlatformSlack
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	c, ok := r.chats[platform]
+	if !ok {
+		return nil, fmt.Errorf("chat: platform %v is not registered", platform)
+	}
+	return c, nil
+}
+
+// Platforms returns sorted names of registered platforms
+func (r *Registry) Platforms() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	platforms := []string{}
+	for p := range r.chats {
+		platforms = append(platforms, p)
+	}
+	sort.Strings(platforms)
+	return platforms
+}
+
+// Run runs all registered chats and returns the first error, chats
+// which stop without an error do not stop the others
+func (r *Registry) Run() error {
+	r.mu.RLock()
+	errs := make(chan error, len(r.chats))
+	for p, c := range r.chats {
+		go func(p string, c Chat) {
+			err := c.Run()
+			if err != nil {
+				logrus.Errorf("chat: %v stopped: %v\n", p, err)
+			}
+			errs <- err
+		}(p, c)
+	}
+	n := len(r.chats)
+	r.mu.RUnlock()
+	for i := 0; i < n; i++ {
+		if err := <-errs; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package chat
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type chatStub struct {
+	err      error
+	messages []string
+}
+
+func (c *chatStub) Run() error {
+	return c.err
+}
+
+func (c *chatStub) SendMessage(channelID, message string) error {
+	c.messages = append(c.messages, channelID+": "+message)
+	return nil
+}
+
+func (c *chatStub) SendUserMessage(userID, message string) error {
+	c.messages = append(c.messages, userID+": "+message)
+	return nil
+}
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry()
+	slack, telegram := &chatStub{}, &chatStub{}
+	r.Register(PlatformSlack, slack)
+	r.Register(PlatformTelegram, telegram)
+	assert.Equal(t, []string{PlatformSlack, PlatformTelegram}, r.Platforms())
+
+	c, err := r.Get(PlatformTelegram)
+	assert.NoError(t, err)
+	assert.Equal(t, telegram, c)
+
+	// entries created before platforms appeared belong to Slack
+	c, err = r.Get("")
+	assert.NoError(t, err)
+	assert.Equal(t, slack, c)
+
+	_, err = r.Get(PlatformMattermost)
+	assert.Error(t, err)
+
+	assert.NoError(t, r.Run())
+	r.Register(PlatformMattermost, &chatStub{err: errors.New("connection refused")})
+	assert.EqualError(t, r.Run(), "connection refused")
+}
@@ -0,0 +1,54 @@
+package chat
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// signatureMaxAge is how old a signed Slack request may be, older requests are
+// treated as replayed
+const signatureMaxAge = 5 * time.Minute
+
+var (
+	// ErrMissingSignature is returned when request has no Slack signature headers
+	ErrMissingSignature = errors.New("slack signature headers are missing")
+	// ErrExpiredSignature is returned when request timestamp is not fresh
+	ErrExpiredSignature = errors.New("slack request timestamp is too old")
+	// ErrInvalidSignature is returned when request signature does not match its body
+	ErrInvalidSignature = errors.New("slack signature is invalid")
+)
+
+// VerifySlackSignature checks X-Slack-Signature of request body with signing secret
+// of Slack app, see https://api.slack.com/docs/verifying-requests-from-slack
+func VerifySlackSignature(secret string, header http.Header, body []byte, now time.Time) error {
+	signature := header.Get("X-Slack-Signature")
+	timestamp := header.Get("X-Slack-Request-Timestamp")
+	if signature == "" || timestamp == "" {
+		return ErrMissingSignature
+	}
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrMissingSignature
+	}
+	if math.Abs(now.Sub(time.Unix(ts, 0)).Seconds()) > signatureMaxAge.Seconds() {
+		return ErrExpiredSignature
+	}
+	if !hmac.Equal([]byte(signature), []byte(SlackSignature(secret, timestamp, body))) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// SlackSignature calculates signature Slack sends with requests signed by secret
+func SlackSignature(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "v0:%s:%s", timestamp, body)
+	return "v0=" + hex.EncodeToString(mac.Sum(nil))
+}
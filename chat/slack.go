@@ -1,51 +1,64 @@
 package chat
 
 import (
+	"errors"
 	"sync"
 
 	"github.com/maddevsio/comedian/config"
 	"github.com/maddevsio/comedian/model"
+	"github.com/maddevsio/comedian/parser"
 	"github.com/maddevsio/comedian/storage"
+	"github.com/maddevsio/comedian/webhook"
 	"github.com/nlopes/slack"
 	"github.com/sirupsen/logrus"
-
-	"strings"
 )
 
 var (
-	typeMessage     = ""
-	typeEditMessage = "message_changed"
+	typeMessage       = ""
+	typeEditMessage   = "message_changed"
+	typeDeleteMessage = "message_deleted"
 )
 
 // Slack struct used for storing and communicating with slack api
 type Slack struct {
 	Chat
-	api  *slack.Client
-	rtm  *slack.RTM
-	wg   sync.WaitGroup
-	db   *storage.MySQL
-	Conf config.Config
+	api    *slack.Client
+	rtm    *slack.RTM
+	events chan *slack.MessageEvent
+	// eventIDs are IDs of events received over http, Slack may send them again
+	eventIDs eventIDs
+	wg       sync.WaitGroup
+	db       storage.Storage
+	hooks    *webhook.Dispatcher
+	Conf     config.Config
 }
 
 // NewSlack creates a new copy of slack handler
-func NewSlack(conf config.Config) (*Slack, error) {
-	m, err := storage.NewMySQL(conf)
-
-	if err != nil {
-		logrus.Errorf("slack: NewMySQL failed: %v\n", err)
-		return nil, err
-	}
-
+func NewSlack(conf config.Config, db storage.Storage) (*Slack, error) {
 	s := &Slack{}
 	s.Conf = conf
 	s.api = slack.New(conf.SlackToken)
-	s.rtm = s.api.NewRTM()
-	s.db = m
+	s.db = db
+	s.hooks = webhook.New(conf, db)
+	switch conf.SlackTransport {
+	case TransportRTM, "":
+		s.rtm = s.api.NewRTM()
+	case TransportEvents:
+		if conf.SlackSigningSecret == "" {
+			return nil, errors.New("slack: SLACK_SIGNING_SECRET is required for events transport")
+		}
+		s.events = make(chan *slack.MessageEvent, eventsQueueSize)
+	default:
+		return nil, errors.New("slack: unknown transport " + conf.SlackTransport)
+	}
 	return s, nil
 }
 
 // Run runs a listener loop for slack
 func (s *Slack) Run() error {
+	if s.events != nil {
+		return s.runEvents()
+	}
 
 	s.wg.Add(1)
 	go s.rtm.ManageConnection()
@@ -82,81 +95,31 @@ func (s *Slack) handleConnection() error {
 func (s *Slack) handleMessage(msg *slack.MessageEvent) error {
 	switch msg.SubType {
 	case typeMessage:
-		if standupText, ok := s.isStandup(msg.Msg.Text); ok {
-			standup, err := s.db.CreateStandup(model.Standup{
-				ChannelID:  msg.Channel,
-				UsernameID: msg.User,
-				Comment:    standupText,
-				MessageTS:  msg.Msg.Timestamp,
-			})
-			logrus.Infof("slack: Standup created: %v\n", standup)
-			if err != nil {
-				logrus.Errorf("slack: CreateStandup failed: %v\n", err)
-				return err
-			}
-			return s.SendMessage(msg.Msg.Channel, s.Conf.Translate.StandupAccepted)
-		}
-	case typeEditMessage:
-		standup, err := s.db.SelectStandupByMessageTS(msg.SubMessage.Timestamp)
+		created, err := saveStandup(s.db, s.hooks, s.Conf.Translate, model.Standup{
+			ChannelID:  msg.Channel,
+			UsernameID: msg.User,
+			Comment:    msg.Msg.Text,
+			MessageTS:  msg.Msg.Timestamp,
+			Platform:   PlatformSlack,
+		})
 		if err != nil {
-			logrus.Errorf("slack: SelectStandupByMessageTS failed: %v\n", err)
+			logrus.Errorf("slack: saveStandup failed: %v\n", err)
 			return err
 		}
-		standupHistory, err := s.db.AddToStandupHistory(model.StandupEditHistory{
-			StandupID:   standup.ID,
-			StandupText: standup.Comment})
-		if err != nil {
-			logrus.Errorf("slack: AddToStandupHistory failed: %v\n", err)
-			return err
-		}
-		logrus.Infof("slack: Slack standup history: %v\n", standupHistory)
-		if standupText, ok := s.isStandup(msg.SubMessage.Text); ok {
-			standup.Comment = standupText
-
-			standup, err = s.db.UpdateStandup(standup)
-			if err != nil {
-				logrus.Errorf("slack: UpdateStandup failed: %v\n", err)
-				return err
-			}
-			logrus.Infof("slack: standup updated: %v\n", standup)
+		if created {
+			return s.SendMessage(msg.Msg.Channel, s.Conf.Translate.StandupAccepted)
 		}
+	case typeEditMessage:
+		return editStandup(s.db, s.hooks, s.Conf.Translate, msg.SubMessage.Text, msg.SubMessage.Timestamp)
+	case typeDeleteMessage:
+		return deleteStandup(s.db, msg.DeletedTimestamp)
 	}
 	return nil
 }
 
 func (s *Slack) isStandup(message string) (string, bool) {
-
-	mentionsProblem := false
-	problemKeys := []string{s.Conf.Translate.P1, s.Conf.Translate.P2, s.Conf.Translate.P3}
-	for _, problem := range problemKeys {
-		if strings.Contains(message, problem) {
-			mentionsProblem = true
-		}
-	}
-
-	mentionsYesterdayWork := false
-	yesterdayWorkKeys := []string{s.Conf.Translate.Y1, s.Conf.Translate.Y2, s.Conf.Translate.Y3, s.Conf.Translate.Y4}
-	for _, work := range yesterdayWorkKeys {
-		if strings.Contains(message, work) {
-			mentionsYesterdayWork = true
-		}
-	}
-
-	mentionsTodayPlans := false
-	todayPlansKeys := []string{s.Conf.Translate.T1, s.Conf.Translate.T2, s.Conf.Translate.T3}
-	for _, plan := range todayPlansKeys {
-		if strings.Contains(message, plan) {
-			mentionsTodayPlans = true
-		}
-	}
-
-	if mentionsProblem && mentionsYesterdayWork && mentionsTodayPlans {
-		logrus.Infof("slack: this is a standup: %v\n", message)
-		return strings.TrimSpace(message), true
-	}
-
-	logrus.Errorf("slack: This is not a standup: %v\n", message)
-	return message, false
+	standup, ok := isStandup(message, parser.DefaultQuestions(s.Conf.Translate))
+	return standup.Comment, ok
 }
 
 // SendMessage posts a message in a specified channel
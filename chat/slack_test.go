@@ -5,6 +5,7 @@ import (
 
 	"github.com/maddevsio/comedian/config"
 	"github.com/maddevsio/comedian/model"
+	"github.com/maddevsio/comedian/storage"
 	"github.com/nlopes/slack"
 	"github.com/stretchr/testify/assert"
 	httpmock "gopkg.in/jarcoal/httpmock.v1"
@@ -37,7 +38,9 @@ func TestIsStandup(t *testing.T) {
 	}
 	c, err := config.Get()
 	assert.NoError(t, err)
-	s, err := NewSlack(c)
+	db := storage.NewMemory()
+	assert.NoError(t, err)
+	s, err := NewSlack(c, db)
 	assert.NoError(t, err)
 	for _, tt := range testCases {
 		_, ok := s.isStandup(tt.input)
@@ -55,7 +58,9 @@ func TestSendMessage(t *testing.T) {
 
 	c, err := config.Get()
 	assert.NoError(t, err)
-	s, err := NewSlack(c)
+	db := storage.NewMemory()
+	assert.NoError(t, err)
+	s, err := NewSlack(c, db)
 	assert.NoError(t, err)
 	err = s.SendMessage("YYYZZZVVV", "Hey!")
 	assert.NoError(t, err)
@@ -68,7 +73,9 @@ func TestSendUserMessage(t *testing.T) {
 
 	c, err := config.Get()
 	assert.NoError(t, err)
-	s, err := NewSlack(c)
+	db := storage.NewMemory()
+	assert.NoError(t, err)
+	s, err := NewSlack(c, db)
 	assert.NoError(t, err)
 
 	su1, err := s.db.CreateStandupUser(model.StandupUser{
@@ -97,7 +104,9 @@ func TestHandleMessage(t *testing.T) {
 
 	c, err := config.Get()
 	assert.NoError(t, err)
-	s, err := NewSlack(c)
+	db := storage.NewMemory()
+	assert.NoError(t, err)
+	s, err := NewSlack(c, db)
 	assert.NoError(t, err)
 
 	su1, err := s.db.CreateStandupUser(model.StandupUser{
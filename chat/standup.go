@@ -0,0 +1,148 @@
+package chat
+
+import (
+	"database/sql"
+	"strings"
+
+	"github.com/maddevsio/comedian/config"
+	"github.com/maddevsio/comedian/model"
+	"github.com/maddevsio/comedian/parser"
+	"github.com/maddevsio/comedian/storage"
+	"github.com/maddevsio/comedian/webhook"
+	"github.com/sirupsen/logrus"
+)
+
+// isStandup checks if message answers all questions of standup template.
+// Returned standup has trimmed message in Comment, answers to every question
+// and sections filled with answers to questions of their roles
+func isStandup(message string, questions []parser.Question) (model.Standup, bool) {
+	sections, ok := parser.Parse(message, questions)
+	if !ok {
+		logrus.Errorf("chat: This is not a standup: %v\n", message)
+		return model.Standup{Comment: message}, false
+	}
+	logrus.Infof("chat: this is a standup: %v\n", message)
+	standup := model.Standup{Comment: strings.TrimSpace(message)}
+	for _, q := range questions {
+		answer := sections[q.Name]
+		standup.Answers = append(standup.Answers, model.Answer{Question: q.Name, Text: answer})
+		switch q.Role {
+		case parser.Yesterday:
+			standup.Yesterday = answer
+		case parser.Today:
+			standup.Today = answer
+		case parser.Problems:
+			standup.Problems = answer
+		}
+	}
+	return standup, true
+}
+
+// ChannelQuestions returns standup template of channel. Channels without a template
+// use yesterday work, today plans and problems questions with keywords from translation,
+// so all messengers share the same rules. Template questions named like default
+// sections stand for them unless their role is set
+func ChannelQuestions(db storage.Storage, t config.Translate, channelID string) []parser.Question {
+	template, err := db.ListStandupQuestions(channelID)
+	if err != nil {
+		logrus.Errorf("chat: ListStandupQuestions failed: %v\n", err)
+	}
+	questions := []parser.Question{}
+	for _, q := range template {
+		question, err := parser.NewQuestion(q.Name, q.Pattern)
+		if err != nil {
+			logrus.Errorf("chat: NewQuestion failed: %v\n", err)
+			continue
+		}
+		question.Role = q.Role
+		if question.Role == "" && parser.IsRole(q.Name) {
+			question.Role = q.Name
+		}
+		questions = append(questions, question)
+	}
+	// a template without valid questions would accept every message as a standup
+	if len(questions) == 0 {
+		return parser.DefaultQuestions(t)
+	}
+	return questions
+}
+
+// saveStandup creates standup from a new message, message text is passed in
+// Comment. It returns false if message is not a standup or was already saved.
+// Created standup is published to webhooks
+func saveStandup(db storage.Storage, hooks *webhook.Dispatcher, t config.Translate, msg model.Standup) (bool, error) {
+	parsed, ok := isStandup(msg.Comment, ChannelQuestions(db, t, msg.ChannelID))
+	if !ok {
+		return false, nil
+	}
+	// the same message may be delivered twice, e.g. on reconnect or retry
+	if st, err := db.SelectStandupByMessageTS(msg.MessageTS); err == nil && st.ChannelID == msg.ChannelID {
+		logrus.Infof("chat: standup %v already exists\n", msg.MessageTS)
+		return false, nil
+	}
+	msg.Comment = parsed.Comment
+	msg.Yesterday = parsed.Yesterday
+	msg.Today = parsed.Today
+	msg.Problems = parsed.Problems
+	msg.Answers = parsed.Answers
+	standup, err := db.CreateStandup(msg)
+	if err != nil {
+		logrus.Errorf("chat: CreateStandup failed: %v\n", err)
+		return false, err
+	}
+	logrus.Infof("chat: Standup created: %v\n", standup)
+	hooks.Publish(webhook.StandupCreated, standup)
+	return true, nil
+}
+
+// editStandup keeps previous standup text in edit history and updates standup
+// with edited message if it is still a standup, updated standup is published to webhooks
+func editStandup(db storage.Storage, hooks *webhook.Dispatcher, t config.Translate, text, messageTS string) error {
+	standup, err := db.SelectStandupByMessageTS(messageTS)
+	if err != nil {
+		logrus.Errorf("chat: SelectStandupByMessageTS failed: %v\n", err)
+		return err
+	}
+	standupHistory, err := db.AddToStandupHistory(model.StandupEditHistory{
+		StandupID:   standup.ID,
+		StandupText: standup.Comment})
+	if err != nil {
+		logrus.Errorf("chat: AddToStandupHistory failed: %v\n", err)
+		return err
+	}
+	logrus.Infof("chat: standup history: %v\n", standupHistory)
+	if parsed, ok := isStandup(text, ChannelQuestions(db, t, standup.ChannelID)); ok {
+		standup.Comment = parsed.Comment
+		standup.Yesterday = parsed.Yesterday
+		standup.Today = parsed.Today
+		standup.Problems = parsed.Problems
+		standup.Answers = parsed.Answers
+
+		standup, err = db.UpdateStandup(standup)
+		if err != nil {
+			logrus.Errorf("chat: UpdateStandup failed: %v\n", err)
+			return err
+		}
+		logrus.Infof("chat: standup updated: %v\n", standup)
+		hooks.Publish(webhook.StandupUpdated, standup)
+	}
+	return nil
+}
+
+// deleteStandup deletes standup created from a deleted message, if any
+func deleteStandup(db storage.Storage, messageTS string) error {
+	standup, err := db.SelectStandupByMessageTS(messageTS)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		logrus.Errorf("chat: SelectStandupByMessageTS failed: %v\n", err)
+		return err
+	}
+	if err := db.DeleteStandup(standup.ID); err != nil {
+		logrus.Errorf("chat: DeleteStandup failed: %v\n", err)
+		return err
+	}
+	logrus.Infof("chat: standup deleted: %v\n", standup)
+	return nil
+}
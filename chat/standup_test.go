@@ -0,0 +1,46 @@
+package chat
+
+import (
+	"testing"
+
+	"github.com/maddevsio/comedian/config"
+	"github.com/maddevsio/comedian/model"
+	"github.com/maddevsio/comedian/parser"
+	"github.com/maddevsio/comedian/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChannelQuestions(t *testing.T) {
+	tr, err := config.GetTranslation("en_US")
+	assert.NoError(t, err)
+	db := storage.NewMemory()
+	for i, q := range []model.StandupQuestion{
+		{Name: "done", Pattern: "/(?i)done/"},
+		{Name: "next", Pattern: "/(?i)next/", Role: parser.Today},
+		{Name: "blockers", Pattern: "/(?i)block/", Role: parser.Problems},
+	} {
+		q.ChannelID = "TEMPLATE"
+		q.Position = i
+		_, err := db.CreateStandupQuestion(q)
+		assert.NoError(t, err)
+	}
+	_, err = db.CreateStandupQuestion(model.StandupQuestion{ChannelID: "BROKEN", Name: "done", Pattern: "/(/"})
+	assert.NoError(t, err)
+
+	standup, ok := isStandup("Done: release\nNext: deploy\nBlockers: CI is down", ChannelQuestions(db, tr, "TEMPLATE"))
+	assert.True(t, ok)
+	assert.Equal(t, model.Answers{{Question: "done", Text: "release"}, {Question: "next", Text: "deploy"}, {Question: "blockers", Text: "CI is down"}}, standup.Answers)
+	assert.Equal(t, "", standup.Yesterday)
+	assert.Equal(t, "deploy", standup.Today)
+	assert.Equal(t, "CI is down", standup.Problems)
+
+	// channels without valid questions use default ones instead of accepting every message
+	questions := ChannelQuestions(db, tr, "BROKEN")
+	assert.Equal(t, parser.DefaultQuestions(tr), questions)
+	_, ok = isStandup("hello, everyone", questions)
+	assert.False(t, ok)
+	standup, ok = isStandup("Yesterday: tests\nToday: deploy\nProblems: none", questions)
+	assert.True(t, ok)
+	assert.Equal(t, "deploy", standup.Today)
+	assert.Equal(t, 3, len(standup.Answers))
+}
@@ -0,0 +1,344 @@
+package chat
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/maddevsio/comedian/config"
+	"github.com/maddevsio/comedian/model"
+	"github.com/maddevsio/comedian/storage"
+	"github.com/maddevsio/comedian/webhook"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// telegramPollTimeout is how long Telegram holds getUpdates request open
+	telegramPollTimeout = 30
+	// telegramRetryDelay is a pause after failed getUpdates request
+	telegramRetryDelay = 5 * time.Second
+	// telegramSecretHeader carries secret token set with setWebhook
+	telegramSecretHeader = "X-Telegram-Bot-Api-Secret-Token"
+)
+
+// telegramMention matches @username mentions in command arguments
+var telegramMention = regexp.MustCompile(`(^|\s)@([A-Za-z0-9_]{5,32})`)
+
+// slackMarkup matches user, channel and special mentions in Slack format,
+// which are used by translations and reports
+var slackMarkup = regexp.MustCompile(`<([@#!])([^>|]+)(?:\|([^>]*))?>`)
+
+// Telegram struct used for communicating with Telegram Bot API
+type Telegram struct {
+	Chat
+	client  *http.Client
+	updates chan telegramUpdate
+	offset  int64
+	// users keeps IDs of group members by lowercase username, Bot API can not
+	// look users up, so only members who wrote something can be mentioned in commands
+	users    map[string]int64
+	commands CommandHandler
+	db       storage.Storage
+	hooks    *webhook.Dispatcher
+	Conf     config.Config
+}
+
+type telegramResponse struct {
+	OK          bool            `json:"ok"`
+	Result      json.RawMessage `json:"result"`
+	ErrorCode   int             `json:"error_code"`
+	Description string          `json:"description"`
+}
+
+type telegramUpdate struct {
+	UpdateID      int64            `json:"update_id"`
+	Message       *telegramMessage `json:"message"`
+	EditedMessage *telegramMessage `json:"edited_message"`
+}
+
+type telegramMessage struct {
+	MessageID int64         `json:"message_id"`
+	From      *telegramUser `json:"from"`
+	Chat      telegramChat  `json:"chat"`
+	Date      int64         `json:"date"`
+	Text      string        `json:"text"`
+}
+
+type telegramUser struct {
+	ID        int64  `json:"id"`
+	IsBot     bool   `json:"is_bot"`
+	FirstName string `json:"first_name"`
+	Username  string `json:"username"`
+}
+
+type telegramChat struct {
+	ID    int64  `json:"id"`
+	Type  string `json:"type"`
+	Title string `json:"title"`
+}
+
+// NewTelegram creates a new copy of Telegram handler
+func NewTelegram(conf config.Config, db storage.Storage) (*Telegram, error) {
+	if conf.TelegramToken == "" {
+		return nil, errors.New("telegram: TELEGRAM_TOKEN is required")
+	}
+	// without a secret anyone could post updates to the webhook as any user
+	if conf.TelegramWebhookURL != "" && conf.TelegramSecret == "" {
+		return nil, errors.New("telegram: TELEGRAM_WEBHOOK_SECRET is required with TELEGRAM_WEBHOOK_URL")
+	}
+	t := &Telegram{}
+	t.Conf = conf
+	t.db = db
+	t.hooks = webhook.New(conf, db)
+	t.client = &http.Client{Timeout: (telegramPollTimeout + 10) * time.Second}
+	t.updates = make(chan telegramUpdate, eventsQueueSize)
+	t.users = map[string]int64{}
+	return t, nil
+}
+
+// HandleCommands makes group messages starting with / to be handled as slash commands
+func (t *Telegram) HandleCommands(h CommandHandler) {
+	t.commands = h
+}
+
+// Run receives updates with a webhook if TELEGRAM_WEBHOOK_URL is set
+// and with long polling otherwise
+func (t *Telegram) Run() error {
+	if t.Conf.TelegramWebhookURL != "" {
+		if t.Conf.TelegramSecret == "" {
+			return errors.New("telegram: TELEGRAM_WEBHOOK_SECRET is required with TELEGRAM_WEBHOOK_URL")
+		}
+		params := map[string]interface{}{
+			"url":             t.Conf.TelegramWebhookURL,
+			"allowed_updates": []string{"message", "edited_message"},
+			"secret_token":    t.Conf.TelegramSecret,
+		}
+		if err := t.call("setWebhook", params, nil); err != nil {
+			logrus.Errorf("telegram: setWebhook failed: %v\n", err)
+			return err
+		}
+		for u := range t.updates {
+			t.handleUpdate(u)
+		}
+		return nil
+	}
+
+	// getUpdates is refused by Telegram while a webhook is set
+	if err := t.call("deleteWebhook", map[string]interface{}{}, nil); err != nil {
+		logrus.Errorf("telegram: deleteWebhook failed: %v\n", err)
+		return err
+	}
+	for {
+		if err := t.poll(); err != nil {
+			time.Sleep(telegramRetryDelay)
+		}
+	}
+}
+
+// poll requests new updates once and handles them
+func (t *Telegram) poll() error {
+	var updates []telegramUpdate
+	err := t.call("getUpdates", map[string]interface{}{
+		"offset":          t.offset,
+		"timeout":         telegramPollTimeout,
+		"allowed_updates": []string{"message", "edited_message"},
+	}, &updates)
+	if err != nil {
+		logrus.Errorf("telegram: getUpdates failed: %v\n", err)
+		return err
+	}
+	for _, u := range updates {
+		// confirms the update, so it is not returned by the next request
+		t.offset = u.UpdateID + 1
+		t.handleUpdate(u)
+	}
+	return nil
+}
+
+// WebhookHandler returns http handler for updates sent by Telegram to TELEGRAM_WEBHOOK_URL,
+// requests without TELEGRAM_WEBHOOK_SECRET are rejected
+func (t *Telegram) WebhookHandler() http.Handler {
+	return http.HandlerFunc(t.handleWebhookRequest)
+}
+
+func (t *Telegram) handleWebhookRequest(w http.ResponseWriter, r *http.Request) {
+	secret := r.Header.Get(telegramSecretHeader)
+	if t.Conf.TelegramSecret == "" || subtle.ConstantTimeCompare([]byte(secret), []byte(t.Conf.TelegramSecret)) != 1 {
+		logrus.Errorf("telegram: webhook request rejected: invalid secret token\n")
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	var u telegramUpdate
+	if err := json.NewDecoder(r.Body).Decode(&u); err != nil {
+		logrus.Errorf("telegram: webhook request Decode failed: %v\n", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	// Telegram retries the update until it gets successful response
+	select {
+	case t.updates <- u:
+	default:
+		logrus.Errorf("telegram: updates queue is full, update %v dropped\n", u.UpdateID)
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (t *Telegram) handleUpdate(u telegramUpdate) error {
+	switch {
+	case u.Message != nil:
+		msg := u.Message
+		// bots can not write standups or run commands, as in Slack
+		if !isTelegramGroup(msg.Chat) || msg.From == nil || msg.From.IsBot {
+			return nil
+		}
+		if msg.From.Username != "" {
+			t.users[strings.ToLower(msg.From.Username)] = msg.From.ID
+		}
+		if strings.HasPrefix(msg.Text, "/") {
+			return t.handleCommand(msg)
+		}
+		chatID := strconv.FormatInt(msg.Chat.ID, 10)
+		created, err := saveStandup(t.db, t.hooks, t.Conf.Translate, model.Standup{
+			ChannelID:  chatID,
+			UsernameID: strconv.FormatInt(msg.From.ID, 10),
+			Comment:    msg.Text,
+			MessageTS:  telegramMessageTS(msg),
+			Platform:   PlatformTelegram,
+		})
+		if err != nil {
+			logrus.Errorf("telegram: saveStandup failed: %v\n", err)
+			return err
+		}
+		if created {
+			return t.SendMessage(chatID, t.Conf.Translate.StandupAccepted)
+		}
+	case u.EditedMessage != nil:
+		msg := u.EditedMessage
+		if !isTelegramGroup(msg.Chat) || msg.From == nil || msg.From.IsBot {
+			return nil
+		}
+		return editStandup(t.db, t.hooks, t.Conf.Translate, msg.Text, telegramMessageTS(msg))
+	}
+	return nil
+}
+
+func (t *Telegram) handleCommand(msg *telegramMessage) error {
+	if t.commands == nil {
+		return nil
+	}
+	fields := strings.SplitN(strings.TrimSpace(msg.Text), " ", 2)
+	// in groups commands may be addressed to a bot as /command@bot_name
+	command := strings.SplitN(fields[0], "@", 2)[0]
+	text := ""
+	if len(fields) == 2 {
+		text = telegramMention.ReplaceAllStringFunc(strings.TrimSpace(fields[1]), func(s string) string {
+			parts := telegramMention.FindStringSubmatch(s)
+			id, ok := t.users[strings.ToLower(parts[2])]
+			if !ok {
+				return s
+			}
+			return fmt.Sprintf("%v<@%d|%v>", parts[1], id, parts[2])
+		})
+	}
+	chatID := strconv.FormatInt(msg.Chat.ID, 10)
+	reply := t.commands(PlatformTelegram, url.Values{
+		"command":      {command},
+		"text":         {text},
+		"channel_id":   {chatID},
+		"channel_name": {msg.Chat.Title},
+		"user_id":      {strconv.FormatInt(msg.From.ID, 10)},
+		"user_name":    {msg.From.Username},
+	})
+	if reply == "" {
+		return nil
+	}
+	return t.SendMessage(chatID, reply)
+}
+
+// SendMessage posts a message in a specified chat
+func (t *Telegram) SendMessage(chatID, message string) error {
+	err := t.call("sendMessage", map[string]interface{}{
+		"chat_id":    chatID,
+		"text":       telegramText(message),
+		"parse_mode": "HTML",
+	}, nil)
+	if err != nil {
+		logrus.Errorf("telegram: sendMessage failed: %v\n", err)
+		return err
+	}
+	logrus.Infof("telegram: message sent: chat:%v, message:%v\n", chatID, message)
+	return nil
+}
+
+// SendUserMessage posts a message to a specific user. Private chat ID
+// is the same as user ID, user must have started a chat with the bot before
+func (t *Telegram) SendUserMessage(userID, message string) error {
+	return t.SendMessage(userID, message)
+}
+
+// call makes Bot API request and unmarshals its result to result if it is not nil
+func (t *Telegram) call(method string, params, result interface{}) error {
+	body, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	endpoint := fmt.Sprintf("%s/bot%s/%s", strings.TrimRight(t.Conf.TelegramAPIURL, "/"), t.Conf.TelegramToken, method)
+	resp, err := t.client.Post(endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	var r telegramResponse
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return fmt.Errorf("%v: %v", method, err)
+	}
+	if !r.OK {
+		return fmt.Errorf("%v: %v %v", method, r.ErrorCode, r.Description)
+	}
+	if result == nil {
+		return nil
+	}
+	return json.Unmarshal(r.Result, result)
+}
+
+func isTelegramGroup(c telegramChat) bool {
+	return c.Type == "group" || c.Type == "supergroup"
+}
+
+// telegramMessageTS identifies a message, message IDs are only unique within a chat
+func telegramMessageTS(msg *telegramMessage) string {
+	return fmt.Sprintf("%d:%d", msg.Chat.ID, msg.MessageID)
+}
+
+// telegramText converts Slack mentions to Telegram HTML and escapes the rest of the text
+func telegramText(message string) string {
+	var b strings.Builder
+	last := 0
+	for _, m := range slackMarkup.FindAllStringSubmatchIndex(message, -1) {
+		b.WriteString(html.EscapeString(message[last:m[0]]))
+		last = m[1]
+		kind, id := message[m[2]:m[3]], message[m[4]:m[5]]
+		label := id
+		if m[6] >= 0 && m[7] > m[6] {
+			label = message[m[6]:m[7]]
+		}
+		if _, err := strconv.ParseInt(id, 10, 64); kind == "@" && err == nil {
+			fmt.Fprintf(&b, `<a href="tg://user?id=%s">%s</a>`, id, html.EscapeString(label))
+			continue
+		}
+		b.WriteString(html.EscapeString(label))
+	}
+	b.WriteString(html.EscapeString(message[last:]))
+	return b.String()
+}
@@ -0,0 +1,256 @@
+package chat
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/maddevsio/comedian/config"
+	"github.com/maddevsio/comedian/model"
+	"github.com/maddevsio/comedian/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeBotAPI is a local Telegram Bot API server which returns queued updates
+// and records sent messages
+type fakeBotAPI struct {
+	mu      sync.Mutex
+	updates []telegramUpdate
+	offsets []int64
+	sent    []map[string]interface{}
+	webhook map[string]interface{}
+}
+
+func (f *fakeBotAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !strings.HasPrefix(r.URL.Path, "/bottest-token/") {
+		json.NewEncoder(w).Encode(telegramResponse{ErrorCode: 401, Description: "Unauthorized"})
+		return
+	}
+	var params map[string]interface{}
+	json.NewDecoder(r.Body).Decode(&params)
+	var result interface{} = true
+	switch strings.TrimPrefix(r.URL.Path, "/bottest-token/") {
+	case "getUpdates":
+		offset := int64(params["offset"].(float64))
+		f.offsets = append(f.offsets, offset)
+		updates := []telegramUpdate{}
+		for _, u := range f.updates {
+			if u.UpdateID >= offset {
+				updates = append(updates, u)
+			}
+		}
+		result = updates
+	case "sendMessage":
+		if params["chat_id"] == "404" {
+			json.NewEncoder(w).Encode(telegramResponse{ErrorCode: 400, Description: "Bad Request: chat not found"})
+			return
+		}
+		f.sent = append(f.sent, params)
+	case "setWebhook":
+		f.webhook = params
+	}
+	raw, _ := json.Marshal(result)
+	json.NewEncoder(w).Encode(telegramResponse{OK: true, Result: raw})
+}
+
+func newTestTelegram(t *testing.T) (*Telegram, *fakeBotAPI, storage.Storage) {
+	c, err := config.Get()
+	assert.NoError(t, err)
+	api := &fakeBotAPI{}
+	server := httptest.NewServer(api)
+	t.Cleanup(server.Close)
+	c.TelegramToken = "test-token"
+	c.TelegramAPIURL = server.URL
+	db := storage.NewMemory()
+	tg, err := NewTelegram(c, db)
+	assert.NoError(t, err)
+	return tg, api, db
+}
+
+func TestNewTelegram(t *testing.T) {
+	_, err := NewTelegram(config.Config{}, storage.NewMemory())
+	assert.Error(t, err)
+	_, err = NewTelegram(config.Config{TelegramToken: "test-token", TelegramWebhookURL: "https://comedian.example.com/telegram"}, storage.NewMemory())
+	assert.Error(t, err)
+}
+
+func TestTelegramPolling(t *testing.T) {
+	tg, api, db := newTestTelegram(t)
+	group := telegramChat{ID: -100500, Type: "supergroup", Title: "team"}
+	user := &telegramUser{ID: 42, FirstName: "Ivan"}
+	api.updates = []telegramUpdate{
+		{UpdateID: 10, Message: &telegramMessage{MessageID: 1, From: user, Chat: group, Text: "Yesterday: tests, today: more tests, problems: none"}},
+		{UpdateID: 11, Message: &telegramMessage{MessageID: 2, From: user, Chat: group, Text: "hello everyone"}},
+		{UpdateID: 12, Message: &telegramMessage{MessageID: 3, From: user, Chat: telegramChat{ID: 42, Type: "private"}, Text: "Yesterday: tests, today: more tests, problems: none"}},
+		{UpdateID: 13, EditedMessage: &telegramMessage{MessageID: 1, From: user, Chat: group, Text: "Yesterday: tests, today: even more tests, problems: none"}},
+	}
+
+	assert.NoError(t, tg.poll())
+	assert.Equal(t, int64(14), tg.offset)
+
+	standups, err := db.ListStandups()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(standups))
+	assert.Equal(t, "-100500", standups[0].ChannelID)
+	assert.Equal(t, "42", standups[0].UsernameID)
+	assert.Equal(t, "-100500:1", standups[0].MessageTS)
+	assert.Equal(t, "Yesterday: tests, today: even more tests, problems: none", standups[0].Comment)
+
+	assert.Equal(t, 1, len(api.sent))
+	assert.Equal(t, "-100500", api.sent[0]["chat_id"])
+	assert.Equal(t, tg.Conf.Translate.StandupAccepted, api.sent[0]["text"])
+
+	// confirmed updates are not handled twice
+	assert.NoError(t, tg.poll())
+	assert.Equal(t, []int64{0, 14}, api.offsets)
+	standups, err = db.ListStandups()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(standups))
+
+	tg.Conf.TelegramToken = "wrong-token"
+	assert.Error(t, tg.poll())
+}
+
+func TestTelegramStandupEvents(t *testing.T) {
+	tg, api, db := newTestTelegram(t)
+	var events []string
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		events = append(events, r.Header.Get("X-Comedian-Event"))
+	}))
+	defer receiver.Close()
+	_, err := db.CreateWebhook(model.Webhook{URL: receiver.URL, Secret: "secret"})
+	assert.NoError(t, err)
+
+	group := telegramChat{ID: -100500, Type: "supergroup", Title: "team"}
+	user := &telegramUser{ID: 42, FirstName: "Ivan"}
+	api.updates = []telegramUpdate{
+		{UpdateID: 1, Message: &telegramMessage{MessageID: 1, From: user, Chat: group, Text: "Yesterday: tests, today: more tests, problems: none"}},
+		{UpdateID: 2, Message: &telegramMessage{MessageID: 2, From: user, Chat: group, Text: "hello everyone"}},
+	}
+	assert.NoError(t, tg.poll())
+	tg.hooks.Wait()
+	api.updates = []telegramUpdate{
+		{UpdateID: 3, EditedMessage: &telegramMessage{MessageID: 1, From: user, Chat: group, Text: "Yesterday: tests, today: even more tests, problems: none"}},
+	}
+	assert.NoError(t, tg.poll())
+	tg.hooks.Wait()
+	assert.Equal(t, []string{"standup.created", "standup.updated"}, events)
+}
+
+func TestTelegramWebhook(t *testing.T) {
+	tg, _, db := newTestTelegram(t)
+	tg.Conf.TelegramSecret = "secret"
+	body := `{"update_id":1,"message":{"message_id":7,"from":{"id":42,"first_name":"Ivan"},"chat":{"id":-1,"type":"group"},"date":1535000000,"text":"Yesterday: tests, today: more tests, problems: none"}}`
+
+	rec := httptest.NewRecorder()
+	tg.WebhookHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/telegram", strings.NewReader(body)))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	req := httptest.NewRequest(http.MethodPost, "/telegram", strings.NewReader(body))
+	req.Header.Set(telegramSecretHeader, "secret")
+	rec = httptest.NewRecorder()
+	tg.WebhookHandler().ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, 1, len(tg.updates))
+
+	assert.NoError(t, tg.handleUpdate(<-tg.updates))
+	standup, err := db.SelectStandupByMessageTS("-1:7")
+	assert.NoError(t, err)
+	assert.Equal(t, "42", standup.UsernameID)
+
+	// webhook without a secret accepts nothing
+	tg.Conf.TelegramSecret = ""
+	req = httptest.NewRequest(http.MethodPost, "/telegram", strings.NewReader(body))
+	req.Header.Set(telegramSecretHeader, "")
+	rec = httptest.NewRecorder()
+	tg.WebhookHandler().ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, 0, len(tg.updates))
+}
+
+func TestTelegramIgnoresBots(t *testing.T) {
+	tg, api, db := newTestTelegram(t)
+	group := telegramChat{ID: -1, Type: "group"}
+	bot := &telegramUser{ID: 99, IsBot: true, FirstName: "Bot"}
+	assert.NoError(t, tg.handleUpdate(telegramUpdate{UpdateID: 1, Message: &telegramMessage{MessageID: 1, From: bot, Chat: group, Text: "Yesterday: tests, today: more tests, problems: none"}}))
+	assert.NoError(t, tg.handleUpdate(telegramUpdate{UpdateID: 2, EditedMessage: &telegramMessage{MessageID: 1, From: bot, Chat: group, Text: "Yesterday: tests, today: deploy, problems: none"}}))
+	standups, err := db.ListStandups()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(standups))
+	assert.Equal(t, 0, len(api.sent))
+}
+
+func TestTelegramSendUserMessage(t *testing.T) {
+	tg, api, _ := newTestTelegram(t)
+	assert.NoError(t, tg.SendUserMessage("42", "<@42> & <@UB9AE7CL9|manager>, write standup in <#-100|team>"))
+	assert.Equal(t, 1, len(api.sent))
+	assert.Equal(t, "42", api.sent[0]["chat_id"])
+	assert.Equal(t, "HTML", api.sent[0]["parse_mode"])
+	assert.Equal(t, `<a href="tg://user?id=42">42</a> &amp; manager, write standup in team`, api.sent[0]["text"])
+
+	assert.Error(t, tg.SendUserMessage("404", "hello"))
+}
+
+func TestTelegramRunWebhook(t *testing.T) {
+	tg, api, _ := newTestTelegram(t)
+	tg.Conf.TelegramWebhookURL = "https://comedian.example.com/telegram"
+	assert.Error(t, tg.Run())
+	assert.Nil(t, api.webhook)
+
+	tg.Conf.TelegramSecret = "secret"
+	close(tg.updates)
+	assert.NoError(t, tg.Run())
+	assert.Equal(t, "https://comedian.example.com/telegram", api.webhook["url"])
+	assert.Equal(t, "secret", api.webhook["secret_token"])
+}
+
+func TestTelegramCommands(t *testing.T) {
+	tg, api, _ := newTestTelegram(t)
+	var platform string
+	var form url.Values
+	tg.HandleCommands(func(p string, f url.Values) string {
+		platform, form = p, f
+		return "<@42|ivan> added"
+	})
+	group := telegramChat{ID: -100, Type: "group", Title: "team"}
+	assert.NoError(t, tg.handleUpdate(telegramUpdate{UpdateID: 1, Message: &telegramMessage{MessageID: 1, From: &telegramUser{ID: 42, Username: "Ivan_Petrov"}, Chat: group, Text: "hello"}}))
+	assert.NoError(t, tg.handleUpdate(telegramUpdate{UpdateID: 2, Message: &telegramMessage{MessageID: 2, From: &telegramUser{ID: 7, Username: "manager"}, Chat: group, Text: "/comedianadd@comedian_bot @ivan_petrov @stranger"}}))
+
+	assert.Equal(t, PlatformTelegram, platform)
+	assert.Equal(t, "/comedianadd", form.Get("command"))
+	assert.Equal(t, "<@42|ivan_petrov> @stranger", form.Get("text"))
+	assert.Equal(t, "-100", form.Get("channel_id"))
+	assert.Equal(t, "team", form.Get("channel_name"))
+	assert.Equal(t, "7", form.Get("user_id"))
+	assert.Equal(t, 1, len(api.sent))
+	assert.Equal(t, `<a href="tg://user?id=42">ivan</a> added`, api.sent[0]["text"])
+}
+
+func TestTelegramStandupTemplate(t *testing.T) {
+	tg, _, db := newTestTelegram(t)
+	group := telegramChat{ID: -100500, Type: "supergroup", Title: "team"}
+	user := &telegramUser{ID: 42, FirstName: "Ivan"}
+	for i, q := range []model.StandupQuestion{{Name: "done", Pattern: "/(?i)done/"}, {Name: "problems", Pattern: "/(?i)block/"}} {
+		q.ChannelID = "-100500"
+		q.Position = i
+		_, err := db.CreateStandupQuestion(q)
+		assert.NoError(t, err)
+	}
+
+	// messages are checked against channel template instead of translation keywords
+	assert.NoError(t, tg.handleUpdate(telegramUpdate{UpdateID: 1, Message: &telegramMessage{MessageID: 1, From: user, Chat: group, Text: "Yesterday: tests, today: more tests, problems: none"}}))
+	assert.NoError(t, tg.handleUpdate(telegramUpdate{UpdateID: 2, Message: &telegramMessage{MessageID: 2, From: user, Chat: group, Text: "Done: release\nBlockers: none"}}))
+
+	standups, err := db.ListStandups()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(standups))
+	assert.Equal(t, "-100500:2", standups[0].MessageTS)
+	assert.Equal(t, "none", standups[0].Problems)
+	assert.Equal(t, "", standups[0].Yesterday)
+}
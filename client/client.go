@@ -0,0 +1,283 @@
+// Package client talks to JSON API of Comedian described by OpenAPI document
+// served at /api/v1/openapi.json, every operation of the document has a method here
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/maddevsio/comedian/model"
+)
+
+const dateFormat = "2006-01-02"
+
+type (
+	// Client requests JSON API at URL like "https://comedian.example.com/api/v1"
+	Client struct {
+		URL   string
+		Token string
+		HTTP  *http.Client
+	}
+
+	// Error is returned when API responds with an error status
+	Error struct {
+		Status  int
+		Message string
+	}
+
+	// Filter selects items of lists, zero fields are not sent. From and To are days, both included
+	Filter struct {
+		ChannelID string
+		UserID    string
+		From      time.Time
+		To        time.Time
+		Limit     int
+		Offset    int
+	}
+
+	// Channel is a channel with standupers or standup time
+	Channel struct {
+		ID          string             `json:"id"`
+		Name        string             `json:"name"`
+		Platform    string             `json:"platform"`
+		Standupers  int                `json:"standupers"`
+		StandupTime *model.StandupTime `json:"standupTime,omitempty"`
+	}
+
+	// StandupList is a page of standups, Total is the number of standups matching the filter
+	StandupList struct {
+		Items  []model.Standup `json:"items"`
+		Total  int             `json:"total"`
+		Limit  int             `json:"limit"`
+		Offset int             `json:"offset"`
+	}
+
+	// StanduperList is a page of standupers
+	StanduperList struct {
+		Items  []model.StandupUser `json:"items"`
+		Total  int                 `json:"total"`
+		Limit  int                 `json:"limit"`
+		Offset int                 `json:"offset"`
+	}
+
+	// StandupTimeList is a page of standup times
+	StandupTimeList struct {
+		Items  []model.StandupTime `json:"items"`
+		Total  int                 `json:"total"`
+		Limit  int                 `json:"limit"`
+		Offset int                 `json:"offset"`
+	}
+
+	// ChannelList is a page of channels
+	ChannelList struct {
+		Items  []Channel `json:"items"`
+		Total  int       `json:"total"`
+		Limit  int       `json:"limit"`
+		Offset int       `json:"offset"`
+	}
+)
+
+func (e Error) Error() string {
+	return fmt.Sprintf("comedian: %v %v", e.Status, e.Message)
+}
+
+// New creates client of API at url with token created by /apitokenadd command
+func New(url, token string) *Client {
+	return &Client{
+		URL:   strings.TrimRight(url, "/"),
+		Token: token,
+		HTTP:  &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// ListStandups returns page of standups ordered by ID
+func (c *Client) ListStandups(f Filter) (StandupList, error) {
+	var list StandupList
+	return list, c.do("GET", "/standups?"+f.query(), nil, &list)
+}
+
+// GetStandup returns standup by ID
+func (c *Client) GetStandup(id int64) (model.Standup, error) {
+	var standup model.Standup
+	return standup, c.do("GET", "/standups/"+strconv.FormatInt(id, 10), nil, &standup)
+}
+
+// CreateStandup creates standup and returns it with ID
+func (c *Client) CreateStandup(s model.Standup) (model.Standup, error) {
+	var standup model.Standup
+	return standup, c.do("POST", "/standups", s, &standup)
+}
+
+// UpdateStandup updates standup with ID of s
+func (c *Client) UpdateStandup(s model.Standup) (model.Standup, error) {
+	var standup model.Standup
+	return standup, c.do("PUT", "/standups/"+strconv.FormatInt(s.ID, 10), s, &standup)
+}
+
+// DeleteStandup deletes standup by ID
+func (c *Client) DeleteStandup(id int64) error {
+	return c.do("DELETE", "/standups/"+strconv.FormatInt(id, 10), nil, nil)
+}
+
+// ListStandupers returns page of standupers ordered by ID, admins are not listed
+func (c *Client) ListStandupers(f Filter) (StanduperList, error) {
+	var list StanduperList
+	return list, c.do("GET", "/standupers?"+f.query(), nil, &list)
+}
+
+// GetStanduper returns standuper by ID
+func (c *Client) GetStanduper(id int64) (model.StandupUser, error) {
+	var user model.StandupUser
+	return user, c.do("GET", "/standupers/"+strconv.FormatInt(id, 10), nil, &user)
+}
+
+// CreateStanduper adds user to standupers of the channel
+func (c *Client) CreateStanduper(u model.StandupUser) (model.StandupUser, error) {
+	var user model.StandupUser
+	return user, c.do("POST", "/standupers", u, &user)
+}
+
+// UpdateStanduper updates standuper with ID of u
+func (c *Client) UpdateStanduper(u model.StandupUser) (model.StandupUser, error) {
+	var user model.StandupUser
+	return user, c.do("PUT", "/standupers/"+strconv.FormatInt(u.ID, 10), u, &user)
+}
+
+// DeleteStanduper deletes standuper by ID
+func (c *Client) DeleteStanduper(id int64) error {
+	return c.do("DELETE", "/standupers/"+strconv.FormatInt(id, 10), nil, nil)
+}
+
+// ListStandupTimes returns page of standup times ordered by channel ID, only ChannelID, Limit and Offset of filter are used
+func (c *Client) ListStandupTimes(f Filter) (StandupTimeList, error) {
+	var list StandupTimeList
+	return list, c.do("GET", "/standup-times?"+f.query(), nil, &list)
+}
+
+// GetStandupTime returns standup time of the channel
+func (c *Client) GetStandupTime(channelID string) (model.StandupTime, error) {
+	var st model.StandupTime
+	return st, c.do("GET", "/standup-times/"+url.PathEscape(channelID), nil, &st)
+}
+
+// CreateStandupTime sets standup time of the channel
+func (c *Client) CreateStandupTime(st model.StandupTime) (model.StandupTime, error) {
+	var created model.StandupTime
+	return created, c.do("POST", "/standup-times", st, &created)
+}
+
+// UpdateStandupTime updates standup time of channel with ChannelID of st
+func (c *Client) UpdateStandupTime(st model.StandupTime) (model.StandupTime, error) {
+	var updated model.StandupTime
+	return updated, c.do("PUT", "/standup-times/"+url.PathEscape(st.ChannelID), st, &updated)
+}
+
+// DeleteStandupTime removes standup time of the channel
+func (c *Client) DeleteStandupTime(channelID string) error {
+	return c.do("DELETE", "/standup-times/"+url.PathEscape(channelID), nil, nil)
+}
+
+// ListChannels returns page of channels ordered by ID, only Limit and Offset of filter are used
+func (c *Client) ListChannels(f Filter) (ChannelList, error) {
+	var list ChannelList
+	return list, c.do("GET", "/channels?"+f.query(), nil, &list)
+}
+
+// GetChannel returns channel by ID
+func (c *Client) GetChannel(channelID string) (Channel, error) {
+	var channel Channel
+	return channel, c.do("GET", "/channels/"+url.PathEscape(channelID), nil, &channel)
+}
+
+// Report returns report of kind "project", "user", "project_user" or "blockers" rendered in format
+// "json", "csv", "markdown" or "text". ChannelID, UserID, From and To of filter are used
+func (c *Client) Report(kind string, f Filter, format string) ([]byte, error) {
+	q := f.query()
+	if format != "" {
+		q += "&format=" + url.QueryEscape(format)
+	}
+	var doc []byte
+	return doc, c.do("GET", "/reports/"+url.PathEscape(kind)+"?"+q, nil, &doc)
+}
+
+// Spec returns OpenAPI document of the API
+func (c *Client) Spec() ([]byte, error) {
+	var spec json.RawMessage
+	return spec, c.do("GET", "/openapi.json", nil, &spec)
+}
+
+// do sends body encoded to JSON and decodes response into v if it is not nil,
+// response is not decoded if v is *[]byte
+func (c *Client) do(method, path string, body, v interface{}) error {
+	var r io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		r = bytes.NewReader(b)
+	}
+	req, err := http.NewRequest(method, c.URL+path, r)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.Token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	res, err := c.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode >= http.StatusBadRequest {
+		var e struct {
+			Error string `json:"error"`
+		}
+		b, _ := ioutil.ReadAll(res.Body)
+		if json.Unmarshal(b, &e) != nil || e.Error == "" {
+			e.Error = strings.TrimSpace(string(b))
+		}
+		return Error{Status: res.StatusCode, Message: e.Error}
+	}
+	if v == nil {
+		return nil
+	}
+	if raw, ok := v.(*[]byte); ok {
+		*raw, err = ioutil.ReadAll(res.Body)
+		return err
+	}
+	return json.NewDecoder(res.Body).Decode(v)
+}
+
+// query encodes non-zero fields of filter
+func (f Filter) query() string {
+	q := url.Values{}
+	if f.ChannelID != "" {
+		q.Set("channel_id", f.ChannelID)
+	}
+	if f.UserID != "" {
+		q.Set("user_id", f.UserID)
+	}
+	if !f.From.IsZero() {
+		q.Set("from", f.From.Format(dateFormat))
+	}
+	if !f.To.IsZero() {
+		q.Set("to", f.To.Format(dateFormat))
+	}
+	if f.Limit != 0 {
+		q.Set("limit", strconv.Itoa(f.Limit))
+	}
+	if f.Offset != 0 {
+		q.Set("offset", strconv.Itoa(f.Offset))
+	}
+	return q.Encode()
+}
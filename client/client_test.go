@@ -0,0 +1,179 @@
+package client
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/maddevsio/comedian/api"
+	"github.com/maddevsio/comedian/config"
+	"github.com/maddevsio/comedian/model"
+	"github.com/maddevsio/comedian/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var pathParam = regexp.MustCompile(`\{[a-z_]+\}`)
+
+// server serves JSON API over memory storage and records requested methods and paths
+type server struct {
+	sync.Mutex
+	handler  http.Handler
+	requests []string
+}
+
+func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.Lock()
+	s.requests = append(s.requests, r.Method+" "+strings.TrimPrefix(r.URL.Path, "/api/v1"))
+	s.Unlock()
+	s.handler.ServeHTTP(w, r)
+}
+
+func newClient(t *testing.T) (*Client, *server) {
+	c, err := config.Get()
+	require.NoError(t, err)
+	db := storage.NewMemory()
+	rest, err := api.NewRESTAPI(c, db)
+	require.NoError(t, err)
+	// token is stored as sha256 hash as /apitokenadd does
+	_, err = db.CreateAPIToken(model.APIToken{Name: "client", TokenHash: "2bb80d537b1da3e38bd30361aa855686bde0eacd7162fef6a25fe97bf527a25b"})
+	require.NoError(t, err)
+	s := &server{handler: rest.Handler()}
+	ts := httptest.NewServer(s)
+	t.Cleanup(ts.Close)
+	return New(ts.URL+"/api/v1/", "secret"), s
+}
+
+func TestClient(t *testing.T) {
+	c, _ := newClient(t)
+
+	standup, err := c.CreateStandup(model.Standup{ChannelID: "C1", UsernameID: "U1", Comment: "first"})
+	require.NoError(t, err)
+	assert.NotZero(t, standup.ID)
+	standup.Comment = "edited"
+	standup, err = c.UpdateStandup(standup)
+	require.NoError(t, err)
+	assert.Equal(t, "edited", standup.Comment)
+	standup, err = c.GetStandup(standup.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "edited", standup.Comment)
+	standups, err := c.ListStandups(Filter{ChannelID: "C1", From: time.Now(), To: time.Now(), Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, 1, standups.Total)
+	assert.Equal(t, 10, standups.Limit)
+	require.NoError(t, c.DeleteStandup(standup.ID))
+	_, err = c.GetStandup(standup.ID)
+	assert.Equal(t, Error{Status: http.StatusNotFound, Message: "Not found"}, err)
+	_, err = c.CreateStandup(model.Standup{ChannelID: "C1"})
+	assert.Equal(t, Error{Status: http.StatusBadRequest, Message: "Standup cannot be empty"}, err)
+
+	user, err := c.CreateStanduper(model.StandupUser{SlackUserID: "U1", SlackName: "anna", ChannelID: "C1", Channel: "general"})
+	require.NoError(t, err)
+	user.SlackName = "anna.s"
+	user, err = c.UpdateStanduper(user)
+	require.NoError(t, err)
+	assert.Equal(t, "anna.s", user.SlackName)
+	user, err = c.GetStanduper(user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "C1", user.ChannelID)
+	users, err := c.ListStandupers(Filter{UserID: "U1"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, users.Total)
+
+	st, err := c.CreateStandupTime(model.StandupTime{ChannelID: "C1", Channel: "general", Time: 1530000000})
+	require.NoError(t, err)
+	st.Timezone = "Asia/Bishkek"
+	st, err = c.UpdateStandupTime(st)
+	require.NoError(t, err)
+	st, err = c.GetStandupTime("C1")
+	require.NoError(t, err)
+	assert.Equal(t, "Asia/Bishkek", st.Timezone)
+	times, err := c.ListStandupTimes(Filter{ChannelID: "C1"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, times.Total)
+
+	channels, err := c.ListChannels(Filter{})
+	require.NoError(t, err)
+	require.Equal(t, 1, channels.Total)
+	assert.Equal(t, 1, channels.Items[0].Standupers)
+	channel, err := c.GetChannel("C1")
+	require.NoError(t, err)
+	assert.Equal(t, "Asia/Bishkek", channel.StandupTime.Timezone)
+
+	report, err := c.Report("project_user", Filter{ChannelID: "C1", UserID: "U1", From: time.Now().AddDate(0, 0, -1), To: time.Now()}, "csv")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(report), "date,channel_id,user_id,status,text\n"))
+	_, err = c.Report("team", Filter{ChannelID: "C1"}, "")
+	assert.Equal(t, http.StatusNotFound, err.(Error).Status)
+
+	require.NoError(t, c.DeleteStandupTime("C1"))
+	require.NoError(t, c.DeleteStanduper(user.ID))
+	_, err = c.GetChannel("C1")
+	assert.Equal(t, http.StatusNotFound, err.(Error).Status)
+
+	c.Token = "wrong"
+	_, err = c.ListChannels(Filter{})
+	assert.Equal(t, Error{Status: http.StatusUnauthorized, Message: "API token is invalid"}, err)
+}
+
+// TestClientCoversSpec checks that every operation of OpenAPI document has a client method
+// and that the client sends only requests described in the document
+func TestClientCoversSpec(t *testing.T) {
+	c, s := newClient(t)
+	raw, err := c.Spec()
+	require.NoError(t, err)
+	var spec struct {
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	require.NoError(t, json.Unmarshal(raw, &spec))
+
+	_, _ = c.ListStandups(Filter{})
+	_, _ = c.GetStandup(1)
+	_, _ = c.CreateStandup(model.Standup{})
+	_, _ = c.UpdateStandup(model.Standup{ID: 1})
+	_ = c.DeleteStandup(1)
+	_, _ = c.ListStandupers(Filter{})
+	_, _ = c.GetStanduper(1)
+	_, _ = c.CreateStanduper(model.StandupUser{})
+	_, _ = c.UpdateStanduper(model.StandupUser{ID: 1})
+	_ = c.DeleteStanduper(1)
+	_, _ = c.ListStandupTimes(Filter{})
+	_, _ = c.GetStandupTime("C1")
+	_, _ = c.CreateStandupTime(model.StandupTime{})
+	_, _ = c.UpdateStandupTime(model.StandupTime{ChannelID: "C1"})
+	_ = c.DeleteStandupTime("C1")
+	_, _ = c.ListChannels(Filter{})
+	_, _ = c.GetChannel("C1")
+	_, _ = c.Report("project", Filter{ChannelID: "C1"}, "csv")
+
+	// operations of the document as patterns of requests, path parameters match any segment
+	operations := map[string]*regexp.Regexp{}
+	for path, methods := range spec.Paths {
+		for method := range methods {
+			if method == "parameters" {
+				continue
+			}
+			operation := strings.ToUpper(method) + " " + path
+			operations[operation] = regexp.MustCompile("^" + pathParam.ReplaceAllString(operation, "[^/]+") + "$")
+		}
+	}
+	covered := map[string]bool{}
+	for _, request := range s.requests {
+		found := false
+		for operation, pattern := range operations {
+			if pattern.MatchString(request) {
+				covered[operation] = true
+				found = true
+			}
+		}
+		assert.True(t, found, "%v is not in OpenAPI document", request)
+	}
+	for operation := range operations {
+		assert.True(t, covered[operation], "%v has no client method", operation)
+	}
+}
@@ -0,0 +1,226 @@
+// Package collector is a client of Collector, the service counting commits, merges
+// and logged time of users and projects
+package collector
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/maddevsio/comedian/config"
+	"github.com/sirupsen/logrus"
+)
+
+// Kinds of data Collector has
+const (
+	Users         = "users"
+	Projects      = "projects"
+	ProjectsUsers = "projects-users"
+)
+
+// defaultBackoff is the delay before the first retry, every next retry waits twice as long
+const defaultBackoff = 500 * time.Millisecond
+
+// maxCacheEntries bounds the number of cached responses, expired ones are dropped when it is reached
+const maxCacheEntries = 1000
+
+// Data is what Collector knows about a user or a project for a period, Worklogs are in seconds.
+// Collector does not count reviews, they come from providers
+type Data struct {
+	TotalCommits int `json:"total_commits"`
+	TotalMerges  int `json:"total_merges"`
+	TotalReviews int `json:"total_reviews"`
+	Worklogs     int `json:"worklogs"`
+}
+
+// Client gets data on users and channels, Collector knows channels by their names.
+// Requests give up when ctx is done, handlers pass a context with the deadline of their answer
+type Client interface {
+	// UserData returns data on user by Slack member ID
+	UserData(ctx context.Context, userID string, dateFrom, dateTo time.Time) (Data, error)
+	// ProjectData returns data on project of channel
+	ProjectData(ctx context.Context, channelID, channelName string, dateFrom, dateTo time.Time) (Data, error)
+	// ProjectUserData returns data on user in project of channel
+	ProjectUserData(ctx context.Context, channelID, channelName, userID string, dateFrom, dateTo time.Time) (Data, error)
+}
+
+// Provider counts data in projects of a service like GitLab instead of Collector.
+// Projects and usernames are those of the service, channels and users are linked to them
+type Provider interface {
+	// Data returns data on projects, only work of username is counted if it is not empty
+	Data(ctx context.Context, projects []string, username string, dateFrom, dateTo time.Time) (Data, error)
+}
+
+// StatusError is returned when Collector answers with a status other than 200 OK
+type StatusError struct {
+	Code int
+}
+
+func (e StatusError) Error() string {
+	return fmt.Sprintf("collector: unexpected status %v", e.Code)
+}
+
+// temporary checks if request may succeed if it is sent again: network failures,
+// timeouts and server errors are temporary, wrong requests and responses are not
+func temporary(err error) bool {
+	switch e := err.(type) {
+	case StatusError:
+		return e.Code >= http.StatusInternalServerError || e.Code == http.StatusTooManyRequests
+	case *url.Error:
+		_, ok := e.Err.(net.Error)
+		return ok
+	}
+	return false
+}
+
+// HTTPClient requests Collector REST API. Failed requests are retried with exponential
+// backoff while the deadline of the context allows, successful responses are cached for CacheTTL
+type HTTPClient struct {
+	URL      string
+	Token    string
+	HTTP     *http.Client
+	Retries  int
+	Backoff  time.Duration
+	CacheTTL time.Duration
+
+	mu    sync.Mutex
+	cache map[string]cached
+}
+
+type cached struct {
+	data    Data
+	expires time.Time
+}
+
+// New creates Collector client from config
+func New(c config.Config) *HTTPClient {
+	return &HTTPClient{
+		URL:      c.CollectorURL,
+		Token:    c.CollectorToken,
+		HTTP:     &http.Client{Timeout: time.Duration(c.CollectorTimeout) * time.Second},
+		Retries:  c.CollectorRetries,
+		Backoff:  defaultBackoff,
+		CacheTTL: time.Duration(c.CollectorCacheTTL) * time.Second,
+		cache:    map[string]cached{},
+	}
+}
+
+// Path returns path of Collector data on user, project or user in project (id is "project/userID")
+func Path(kind, id string, dateFrom, dateTo time.Time) string {
+	return fmt.Sprintf("/rest/api/v1/logger/%s/%s/%s/%s", kind, id, dateFrom.Format("2006-01-02"), dateTo.Format("2006-01-02"))
+}
+
+// UserData returns data on user by Slack member ID
+func (c *HTTPClient) UserData(ctx context.Context, userID string, dateFrom, dateTo time.Time) (Data, error) {
+	return c.get(ctx, Path(Users, userID, dateFrom, dateTo))
+}
+
+// ProjectData returns data on project by channel name
+func (c *HTTPClient) ProjectData(ctx context.Context, channelID, channelName string, dateFrom, dateTo time.Time) (Data, error) {
+	return c.get(ctx, Path(Projects, channelName, dateFrom, dateTo))
+}
+
+// ProjectUserData returns data on user in project by channel name
+func (c *HTTPClient) ProjectUserData(ctx context.Context, channelID, channelName, userID string, dateFrom, dateTo time.Time) (Data, error) {
+	return c.get(ctx, Path(ProjectsUsers, channelName+"/"+userID, dateFrom, dateTo))
+}
+
+func (c *HTTPClient) get(ctx context.Context, path string) (Data, error) {
+	if data, ok := c.cached(path); ok {
+		return data, nil
+	}
+	backoff := c.Backoff
+	var data Data
+	var err error
+	for attempt := 0; ; attempt++ {
+		data, err = c.request(ctx, path)
+		if err == nil {
+			break
+		}
+		if !temporary(err) || attempt >= c.Retries {
+			return data, err
+		}
+		// there is no point in waiting for a retry the caller will not wait for
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < backoff {
+			return data, err
+		}
+		logrus.Warningf("collector: request %v failed, retrying in %v: %v\n", path, backoff, err)
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return data, ctx.Err()
+		case <-timer.C:
+		}
+		backoff *= 2
+	}
+	c.store(path, data)
+	return data, nil
+}
+
+func (c *HTTPClient) request(ctx context.Context, path string) (Data, error) {
+	var data Data
+	req, err := http.NewRequest("GET", c.URL+path, nil)
+	if err != nil {
+		return data, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Add("Authorization", fmt.Sprintf("Token %s", c.Token))
+	res, err := c.HTTP.Do(req)
+	if err != nil {
+		return data, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return data, StatusError{Code: res.StatusCode}
+	}
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return data, err
+	}
+	err = json.Unmarshal(body, &data)
+	return data, err
+}
+
+func (c *HTTPClient) cached(path string) (Data, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.cache[path]
+	if !ok {
+		return Data{}, false
+	}
+	if time.Now().After(entry.expires) {
+		delete(c.cache, path)
+		return Data{}, false
+	}
+	return entry.data, true
+}
+
+func (c *HTTPClient) store(path string, data Data) {
+	if c.CacheTTL <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.cache == nil {
+		c.cache = map[string]cached{}
+	}
+	if len(c.cache) >= maxCacheEntries {
+		now := time.Now()
+		for key, entry := range c.cache {
+			if now.After(entry.expires) {
+				delete(c.cache, key)
+			}
+		}
+	}
+	if len(c.cache) >= maxCacheEntries {
+		return
+	}
+	c.cache[path] = cached{data: data, expires: time.Now().Add(c.CacheTTL)}
+}
@@ -0,0 +1,91 @@
+package collector_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/maddevsio/comedian/collector"
+	"github.com/maddevsio/comedian/collector/collectortest"
+	"github.com/stretchr/testify/assert"
+)
+
+func newClient(s *collectortest.Server, token string) *collector.HTTPClient {
+	return &collector.HTTPClient{URL: s.URL, Token: token, HTTP: &http.Client{Timeout: time.Second}, Retries: 2, Backoff: time.Millisecond, CacheTTL: time.Minute}
+}
+
+func TestClient(t *testing.T) {
+	s := collectortest.NewServer("cotoken")
+	defer s.Close()
+	from, to := time.Date(2018, 6, 25, 0, 0, 0, 0, time.UTC), time.Date(2018, 6, 26, 0, 0, 0, 0, time.UTC)
+	s.Set(collector.Path(collector.Users, "U1", from, to), collector.Data{TotalCommits: 2, TotalMerges: 1, Worklogs: 7200})
+	s.Set(collector.Path(collector.Projects, "chanName", from, to), collector.Data{TotalCommits: 5})
+	s.Set(collector.Path(collector.ProjectsUsers, "chanName/U1", from, to), collector.Data{TotalMerges: 3})
+
+	c := newClient(s, "cotoken")
+	data, err := c.UserData(context.Background(), "U1", from, to)
+	assert.NoError(t, err)
+	assert.Equal(t, collector.Data{TotalCommits: 2, TotalMerges: 1, Worklogs: 7200}, data)
+	data, err = c.ProjectData(context.Background(), "CHANID", "chanName", from, to)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, data.TotalCommits)
+	data, err = c.ProjectUserData(context.Background(), "CHANID", "chanName", "U1", from, to)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, data.TotalMerges)
+	assert.Equal(t, 3, s.Requests())
+
+	// responses are cached
+	_, err = c.UserData(context.Background(), "U1", from, to)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, s.Requests())
+
+	// client errors are not retried
+	_, err = c.UserData(context.Background(), "U2", from, to)
+	assert.Equal(t, collector.StatusError{Code: http.StatusNotFound}, err)
+	assert.Equal(t, 4, s.Requests())
+	_, err = newClient(s, "wrong").ProjectData(context.Background(), "CHANID", "chanName", from, to)
+	assert.Equal(t, collector.StatusError{Code: http.StatusUnauthorized}, err)
+	assert.Equal(t, 5, s.Requests())
+}
+
+func TestClientRetries(t *testing.T) {
+	s := collectortest.NewServer("cotoken")
+	defer s.Close()
+	from := time.Date(2018, 6, 25, 0, 0, 0, 0, time.UTC)
+	s.Set(collector.Path(collector.Users, "U1", from, from), collector.Data{TotalCommits: 2})
+
+	s.Fail(2)
+	data, err := newClient(s, "cotoken").UserData(context.Background(), "U1", from, from)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, data.TotalCommits)
+	assert.Equal(t, 3, s.Requests())
+
+	// the third failure is returned
+	s.Fail(3)
+	_, err = newClient(s, "cotoken").UserData(context.Background(), "U1", from, from)
+	assert.Equal(t, collector.StatusError{Code: http.StatusInternalServerError}, err)
+	assert.Equal(t, 6, s.Requests())
+
+	// requests to a slow server time out
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer slow.Close()
+	c := &collector.HTTPClient{URL: slow.URL, HTTP: &http.Client{Timeout: 50 * time.Millisecond}, Retries: 1, Backoff: time.Millisecond}
+	start := time.Now()
+	_, err = c.UserData(context.Background(), "U1", from, from)
+	assert.Error(t, err)
+	assert.True(t, time.Since(start) < 200*time.Millisecond)
+
+	// retries the deadline leaves no time for are not made
+	s.Fail(1)
+	c = newClient(s, "cotoken")
+	c.Backoff = time.Second
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, err = c.UserData(ctx, "U1", from, from)
+	assert.Equal(t, collector.StatusError{Code: http.StatusInternalServerError}, err)
+	assert.Equal(t, 7, s.Requests())
+}
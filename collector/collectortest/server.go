@@ -0,0 +1,74 @@
+// Package collectortest provides a fake Collector server for tests
+package collectortest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+
+	"github.com/maddevsio/comedian/collector"
+)
+
+// Server answers Collector API requests with data set by tests. Requests without
+// the token are unauthorized, unknown paths are not found
+type Server struct {
+	*httptest.Server
+	Token string
+
+	mu       sync.Mutex
+	data     map[string]collector.Data
+	failures int
+	requests int
+}
+
+// NewServer starts a fake Collector accepting token, it must be closed by the caller
+func NewServer(token string) *Server {
+	s := &Server{Token: token, data: map[string]collector.Data{}}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+// Set sets data returned for path, see collector.Path
+func (s *Server) Set(path string, data collector.Data) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data[path] = data
+}
+
+// Fail makes the next n requests fail with internal server error
+func (s *Server) Fail(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures = n
+}
+
+// Requests returns number of requests received
+func (s *Server) Requests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests++
+	data, ok := s.data[r.URL.Path]
+	fail := s.failures > 0
+	if fail {
+		s.failures--
+	}
+	s.mu.Unlock()
+
+	switch {
+	case r.Header.Get("Authorization") != "Token "+s.Token:
+		w.WriteHeader(http.StatusUnauthorized)
+	case fail:
+		w.WriteHeader(http.StatusInternalServerError)
+	case !ok:
+		w.WriteHeader(http.StatusNotFound)
+	default:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(data)
+	}
+}
@@ -8,18 +8,49 @@ import (
 
 // Config struct used for configuration of app with env variables
 type Config struct {
-	SlackToken         string `envconfig:"SLACK_TOKEN" required:"true"`
-	DatabaseURL        string `envconfig:"DATABASE" required:"true" default:"comedian:comedian@/comedian?parseTime=true"`
-	HTTPBindAddr       string `envconfig:"HTTP_BIND_ADDR" required:"true" default:"0.0.0.0:8080"`
-	NotifierInterval   int    `envconfig:"NOTIFIER_INTERVAL" required:"true" default:2`
-	ManagerSlackUserID string `envconfig:"MANAGER_SLACK_USER_ID" required:"true"`
-	ReportTime         string `envconfig:"REPORT_TIME" required:"true" default:"13:05"`
-	Language           string `envconfig:"LANGUAGE" required:"true" default:"en_US"`
-	CollectorURL       string `envconfig:"COLLECTOR_URL" required:"true"`
-	CollectorToken     string `envconfig:"COLLECTOR_TOKEN" required:"true"`
-	ChanGeneral        string `envconfig:"MANAGER_SLACK_CHAN_GENERAL" required:"true"`
-	ReminderRepeatsMax int    `envconfig:"REMINDER_REPEATS_MAX" required:"true" default:5`
-	ReminderTime       int64  `envconfig:"REMINDER_TIME" required:"true" default:5`
+	SlackToken         string   `envconfig:"SLACK_TOKEN" required:"true"`
+	DatabaseURL        string   `envconfig:"DATABASE" required:"true" default:"comedian:comedian@/comedian?parseTime=true"`
+	HTTPBindAddr       string   `envconfig:"HTTP_BIND_ADDR" required:"true" default:"0.0.0.0:8080"`
+	NotifierInterval   int      `envconfig:"NOTIFIER_INTERVAL" required:"true" default:"2"`
+	ManagerSlackUserID string   `envconfig:"MANAGER_SLACK_USER_ID" required:"true"`
+	ReportTime         string   `envconfig:"REPORT_TIME" required:"true" default:"13:05"`
+	Language           string   `envconfig:"LANGUAGE" required:"true" default:"en_US"`
+	CollectorURL       string   `envconfig:"COLLECTOR_URL" required:"true"`
+	CollectorToken     string   `envconfig:"COLLECTOR_TOKEN" required:"true"`
+	CollectorTimeout   int      `envconfig:"COLLECTOR_TIMEOUT" default:"10"`
+	CollectorRetries   int      `envconfig:"COLLECTOR_RETRIES" default:"3"`
+	CollectorCacheTTL  int      `envconfig:"COLLECTOR_CACHE_TTL" default:"300"`
+	GitlabURL          string   `envconfig:"GITLAB_URL" default:"https://gitlab.com"`
+	GitlabToken        string   `envconfig:"GITLAB_TOKEN"`
+	GithubURL          string   `envconfig:"GITHUB_URL" default:"https://api.github.com"`
+	GithubToken        string   `envconfig:"GITHUB_TOKEN"`
+	JiraURL            string   `envconfig:"JIRA_URL"`
+	JiraUser           string   `envconfig:"JIRA_USER"`
+	JiraToken          string   `envconfig:"JIRA_TOKEN"`
+	ChanGeneral        string   `envconfig:"MANAGER_SLACK_CHAN_GENERAL" required:"true"`
+	ReminderRepeatsMax int      `envconfig:"REMINDER_REPEATS_MAX" required:"true" default:"5"`
+	ReminderTime       int64    `envconfig:"REMINDER_TIME" required:"true" default:"5"`
+	SlackTransport     string   `envconfig:"SLACK_TRANSPORT" default:"rtm"`
+	SlackSigningSecret string   `envconfig:"SLACK_SIGNING_SECRET"`
+	SlackVerifyToken   string   `envconfig:"SLACK_VERIFICATION_TOKEN"`
+	SlackClientID      string   `envconfig:"SLACK_CLIENT_ID"`
+	SlackClientSecret  string   `envconfig:"SLACK_CLIENT_SECRET"`
+	DashboardURL       string   `envconfig:"DASHBOARD_URL"`
+	DashboardSecret    string   `envconfig:"DASHBOARD_SECRET"`
+	TelegramToken      string   `envconfig:"TELEGRAM_TOKEN"`
+	TelegramAPIURL     string   `envconfig:"TELEGRAM_API_URL" default:"https://api.telegram.org"`
+	TelegramWebhookURL string   `envconfig:"TELEGRAM_WEBHOOK_URL"`
+	TelegramSecret     string   `envconfig:"TELEGRAM_WEBHOOK_SECRET"`
+	TelegramManager    string   `envconfig:"TELEGRAM_MANAGER_USER_ID"`
+	MattermostURL      string   `envconfig:"MATTERMOST_URL"`
+	MattermostToken    string   `envconfig:"MATTERMOST_TOKEN"`
+	MattermostTokens   []string `envconfig:"MATTERMOST_COMMAND_TOKENS"`
+	MattermostManager  string   `envconfig:"MATTERMOST_MANAGER_USER_ID"`
+	ReplicaID          string   `envconfig:"REPLICA_ID"`
+	LeaderTTL          int      `envconfig:"LEADER_TTL" default:"30"`
+	WebhookTimeout     int      `envconfig:"WEBHOOK_TIMEOUT" default:"10"`
+	WebhookAttempts    int      `envconfig:"WEBHOOK_ATTEMPTS" default:"5"`
+	WebhookRetryDelay  int      `envconfig:"WEBHOOK_RETRY_DELAY" default:"10"`
 	Translate          Translate
 	Debug              bool
 }
@@ -31,6 +31,57 @@ type Translate struct {
 	ShowNoStandupTime          string
 	ShowStandupTime            string
 	WrongNArgs                 string
+	WrongUsername              string
+	SetStandupTimezone         string
+	WrongTimezone              string
+	SetWorkDays                string
+	WrongWorkDays              string
+	SetStandupSchedule         string
+	RemoveStandupSchedule      string
+	ShowStandupSchedule        string
+	WrongSchedule              string
+	AddHoliday                 string
+	RemoveHoliday              string
+	ListHolidays               string
+	ListNoHolidays             string
+	ImportHolidays             string
+	ImportHolidaysFailed       string
+	WrongDate                  string
+	AddAbsence                 string
+	RemoveAbsence              string
+	ListAbsences               string
+	ListNoAbsences             string
+	WrongAbsence               string
+	UserOnLeave                string
+	UserOnLeaveInChannel       string
+	AddStandupTemplate         string
+	ShowStandupTemplate        string
+	ShowNoStandupTemplate      string
+	RemoveStandupTemplate      string
+	WrongStandupTemplate       string
+	AddEscalation              string
+	ShowEscalation             string
+	ShowNoEscalation           string
+	RemoveEscalation           string
+	WrongEscalation            string
+	LinkProject                string
+	UnlinkProject              string
+	ListProjectLinks           string
+	ListNoProjectLinks         string
+	LinkUser                   string
+	UnlinkUser                 string
+	WrongProvider              string
+	AddAPIToken                string
+	ListAPITokens              string
+	ListNoAPITokens            string
+	RemoveAPIToken             string
+	AddWebhook                 string
+	ListWebhooks               string
+	ListWebhook                string
+	ListNoWebhooks             string
+	RemoveWebhook              string
+	RetryWebhook               string
+	WrongWebhook               string
 
 	NoWorklogs          string
 	NoCommits           string
@@ -44,6 +95,8 @@ type Translate struct {
 	NotifyManagerNotAll string
 	NotifyUsersWarning  string
 	NotifyDirectMessage string
+	NotifyEmptyToday    string
+	NotifyEscalation    string
 
 	ReportByProjectAndUser       string
 	ReportOnProjectHead          string
@@ -56,6 +109,20 @@ type Translate struct {
 	ReportIgnoredStandup         string
 	ReportShowChannel            string
 	ReportCollectorDataUser      string
+	ReportReviews                string
+	ReportBlockersHead           string
+	ReportBlockersFromUser       string
+	ReportNoBlockers             string
+	ReportWrongFormat            string
+	ReportUploaded               string
+	MarkdownProjectHead          string
+	MarkdownUserHead             string
+	MarkdownProjectAndUserHead   string
+	MarkdownBlockersHead         string
+	MarkdownNothingToReport      string
+	MarkdownColumns              string
+	MarkdownCollector            string
+	MarkdownCollectorData        string
 	UserDidNotStandup            string
 	UserDidStandup               string
 	UserDidNotStandupInChannel   string
@@ -110,11 +177,14 @@ func GetTranslation(lang string) (Translate, error) {
 		"noWorklogs", "noCommits", "noStandup", "hasWorklogs",
 		"hasCommits", "hasStandup", "isRook", "notifyAllDone",
 		"notifyNotAll", "notifyManagerNotAll", "notifyUsersWarning",
-		"notifyDirectMessage",
+		"notifyDirectMessage", "notifyEmptyToday", "notifyEscalation",
 		"reportByProjectAndUser", "reportOnProjectHead", "reportOnProjectCollectorData", "reportOnUserHead",
 		"reportOnProjectAndUserHead", "reportNoData", "reportDate",
 		"reportStandupFromUser", "reportIgnoredStandup", "reportShowChannel",
-		"reportCollectorDataUser",
+		"reportCollectorDataUser", "reportReviews", "reportBlockersHead", "reportBlockersFromUser", "reportNoBlockers",
+		"reportWrongFormat", "reportUploaded",
+		"markdownProjectHead", "markdownUserHead", "markdownProjectAndUserHead", "markdownBlockersHead",
+		"markdownNothingToReport", "markdownColumns", "markdownCollector", "markdownCollectorData",
 		"helloManager", "standupAccepted",
 		"p1", "p2", "p3",
 		"y1", "y2", "y3", "y4",
@@ -134,9 +204,23 @@ func GetTranslation(lang string) (Translate, error) {
 		"showNoStandupTime",
 		"showStandupTime",
 		"wrongNArgs",
+		"wrongUsername",
+		"setStandupTimezone", "wrongTimezone",
+		"setWorkDays", "wrongWorkDays", "addHoliday", "removeHoliday",
+		"setStandupSchedule", "removeStandupSchedule", "showStandupSchedule", "wrongSchedule",
+		"listHolidays", "listNoHolidays", "importHolidays", "importHolidaysFailed", "wrongDate",
+		"addAbsence", "removeAbsence", "listAbsences", "listNoAbsences", "wrongAbsence",
+		"addStandupTemplate", "showStandupTemplate", "showNoStandupTemplate",
+		"removeStandupTemplate", "wrongStandupTemplate",
+		"addEscalation", "showEscalation", "showNoEscalation", "removeEscalation", "wrongEscalation",
+		"linkProject", "unlinkProject", "listProjectLinks", "listNoProjectLinks",
+		"linkUser", "unlinkUser", "wrongProvider",
+		"addAPIToken", "listAPITokens", "listNoAPITokens", "removeAPIToken",
+		"addWebhook", "listWebhooks", "listWebhook", "listNoWebhooks", "removeWebhook", "retryWebhook", "wrongWebhook",
 		"dateError1", "dateError2",
 		"userDidNotStandup", "userDidStandup",
 		"userDidNotStandupInChannel", "userDidStandupInChannel",
+		"userOnLeave", "userOnLeaveInChannel",
 	}
 
 	for _, t := range r {
@@ -166,6 +250,55 @@ func GetTranslation(lang string) (Translate, error) {
 		ShowNoStandupTime:            m["showNoStandupTime"],
 		ShowStandupTime:              m["showStandupTime"],
 		WrongNArgs:                   m["wrongNArgs"],
+		WrongUsername:                m["wrongUsername"],
+		SetStandupTimezone:           m["setStandupTimezone"],
+		WrongTimezone:                m["wrongTimezone"],
+		SetWorkDays:                  m["setWorkDays"],
+		WrongWorkDays:                m["wrongWorkDays"],
+		SetStandupSchedule:           m["setStandupSchedule"],
+		RemoveStandupSchedule:        m["removeStandupSchedule"],
+		ShowStandupSchedule:          m["showStandupSchedule"],
+		WrongSchedule:                m["wrongSchedule"],
+		AddHoliday:                   m["addHoliday"],
+		RemoveHoliday:                m["removeHoliday"],
+		ListHolidays:                 m["listHolidays"],
+		ListNoHolidays:               m["listNoHolidays"],
+		ImportHolidays:               m["importHolidays"],
+		ImportHolidaysFailed:         m["importHolidaysFailed"],
+		WrongDate:                    m["wrongDate"],
+		AddAbsence:                   m["addAbsence"],
+		RemoveAbsence:                m["removeAbsence"],
+		ListAbsences:                 m["listAbsences"],
+		ListNoAbsences:               m["listNoAbsences"],
+		WrongAbsence:                 m["wrongAbsence"],
+		AddStandupTemplate:           m["addStandupTemplate"],
+		ShowStandupTemplate:          m["showStandupTemplate"],
+		ShowNoStandupTemplate:        m["showNoStandupTemplate"],
+		RemoveStandupTemplate:        m["removeStandupTemplate"],
+		WrongStandupTemplate:         m["wrongStandupTemplate"],
+		AddEscalation:                m["addEscalation"],
+		ShowEscalation:               m["showEscalation"],
+		ShowNoEscalation:             m["showNoEscalation"],
+		RemoveEscalation:             m["removeEscalation"],
+		WrongEscalation:              m["wrongEscalation"],
+		LinkProject:                  m["linkProject"],
+		UnlinkProject:                m["unlinkProject"],
+		ListProjectLinks:             m["listProjectLinks"],
+		ListNoProjectLinks:           m["listNoProjectLinks"],
+		LinkUser:                     m["linkUser"],
+		UnlinkUser:                   m["unlinkUser"],
+		WrongProvider:                m["wrongProvider"],
+		AddAPIToken:                  m["addAPIToken"],
+		ListAPITokens:                m["listAPITokens"],
+		ListNoAPITokens:              m["listNoAPITokens"],
+		RemoveAPIToken:               m["removeAPIToken"],
+		AddWebhook:                   m["addWebhook"],
+		ListWebhooks:                 m["listWebhooks"],
+		ListWebhook:                  m["listWebhook"],
+		ListNoWebhooks:               m["listNoWebhooks"],
+		RemoveWebhook:                m["removeWebhook"],
+		RetryWebhook:                 m["retryWebhook"],
+		WrongWebhook:                 m["wrongWebhook"],
 		NoWorklogs:                   m["noWorklogs"],
 		NoCommits:                    m["noCommits"],
 		NoStandup:                    m["noStandup"],
@@ -178,6 +311,8 @@ func GetTranslation(lang string) (Translate, error) {
 		NotifyManagerNotAll:          m["notifyManagerNotAll"],
 		NotifyUsersWarning:           m["notifyUsersWarning"],
 		NotifyDirectMessage:          m["notifyDirectMessage"],
+		NotifyEmptyToday:             m["notifyEmptyToday"],
+		NotifyEscalation:             m["notifyEscalation"],
 		ReportByProjectAndUser:       m["reportByProjectAndUser"],
 		ReportOnProjectHead:          m["reportOnProjectHead"],
 		ReportOnProjectCollectorData: m["reportOnProjectCollectorData"],
@@ -189,6 +324,20 @@ func GetTranslation(lang string) (Translate, error) {
 		ReportIgnoredStandup:         m["reportIgnoredStandup"],
 		ReportShowChannel:            m["reportShowChannel"],
 		ReportCollectorDataUser:      m["reportCollectorDataUser"],
+		ReportReviews:                m["reportReviews"],
+		ReportBlockersHead:           m["reportBlockersHead"],
+		ReportBlockersFromUser:       m["reportBlockersFromUser"],
+		ReportNoBlockers:             m["reportNoBlockers"],
+		ReportWrongFormat:            m["reportWrongFormat"],
+		ReportUploaded:               m["reportUploaded"],
+		MarkdownProjectHead:          m["markdownProjectHead"],
+		MarkdownUserHead:             m["markdownUserHead"],
+		MarkdownProjectAndUserHead:   m["markdownProjectAndUserHead"],
+		MarkdownBlockersHead:         m["markdownBlockersHead"],
+		MarkdownNothingToReport:      m["markdownNothingToReport"],
+		MarkdownColumns:              m["markdownColumns"],
+		MarkdownCollector:            m["markdownCollector"],
+		MarkdownCollectorData:        m["markdownCollectorData"],
 		DateError1:                   m["dateError1"],
 		DateError2:                   m["dateError2"],
 		HelloManager:                 m["helloManager"],
@@ -197,6 +346,8 @@ func GetTranslation(lang string) (Translate, error) {
 		UserDidStandup:               m["userDidStandup"],
 		UserDidNotStandupInChannel:   m["userDidNotStandupInChannel"],
 		UserDidStandupInChannel:      m["userDidStandupInChannel"],
+		UserOnLeave:                  m["userOnLeave"],
+		UserOnLeaveInChannel:         m["userOnLeaveInChannel"],
 
 		P1: m["p1"],
 		P2: m["p2"],
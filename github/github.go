@@ -0,0 +1,277 @@
+// Package github counts commits, merged pull requests and reviews of GitHub repositories
+// through GitHub REST API
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/maddevsio/comedian/collector"
+	"github.com/maddevsio/comedian/config"
+	"github.com/sirupsen/logrus"
+)
+
+// Name is the provider name used in channel and user links
+const Name = "github"
+
+const (
+	perPage    = 100
+	dateFormat = "2006-01-02"
+	// defaultMaxWait is the longest wait for rate limit reset before a request is given up
+	defaultMaxWait = time.Minute
+)
+
+// sleep waits for d or until ctx is done, it is replaced in tests
+var sleep = func(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+var nextLink = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+
+// RateLimitError is returned when rate limit is exceeded and resets later than client may wait
+type RateLimitError struct {
+	Reset time.Time
+}
+
+func (e RateLimitError) Error() string {
+	return fmt.Sprintf("github: rate limit exceeded until %v", e.Reset.Format(time.RFC3339))
+}
+
+// Client requests GitHub REST API v3, projects are repositories like "owner/repo"
+// and usernames are GitHub logins
+type Client struct {
+	URL   string
+	Token string
+	HTTP  *http.Client
+	// MaxWait is how long client waits for rate limit reset before it returns RateLimitError
+	MaxWait time.Duration
+}
+
+// New creates GitHub client from config
+func New(c config.Config) *Client {
+	return &Client{
+		URL:     strings.TrimRight(c.GithubURL, "/"),
+		Token:   c.GithubToken,
+		HTTP:    &http.Client{Timeout: time.Duration(c.CollectorTimeout) * time.Second},
+		MaxWait: defaultMaxWait,
+	}
+}
+
+type searchPage struct {
+	TotalCount int `json:"total_count"`
+	Items      []struct {
+		Number        int    `json:"number"`
+		RepositoryURL string `json:"repository_url"`
+	} `json:"items"`
+}
+
+type review struct {
+	User struct {
+		Login string `json:"login"`
+	} `json:"user"`
+	SubmittedAt *time.Time `json:"submitted_at"`
+}
+
+// Data returns commits authored, pull requests merged and reviews submitted in repositories
+// from the day of dateFrom to the day of dateTo, only work of username is counted if it is not empty
+func (c *Client) Data(ctx context.Context, projects []string, username string, dateFrom, dateTo time.Time) (collector.Data, error) {
+	var data collector.Data
+	from := day(dateFrom)
+	to := day(dateTo).AddDate(0, 0, 1)
+	for _, repo := range projects {
+		commits, err := c.commits(ctx, repo, username, from, to)
+		if err != nil {
+			return data, err
+		}
+		data.TotalCommits += commits
+	}
+	merges, err := c.merges(ctx, projects, username, from, to)
+	if err != nil {
+		return data, err
+	}
+	reviews, err := c.reviews(ctx, projects, username, from, to)
+	if err != nil {
+		return data, err
+	}
+	data.TotalMerges = merges
+	data.TotalReviews = reviews
+	return data, nil
+}
+
+// commits counts commits of default branch of repo authored in [from, to)
+func (c *Client) commits(ctx context.Context, repo, username string, from, to time.Time) (int, error) {
+	query := url.Values{}
+	query.Set("since", from.UTC().Format(time.RFC3339))
+	query.Set("until", to.UTC().Format(time.RFC3339))
+	query.Set("per_page", strconv.Itoa(perPage))
+	if username != "" {
+		query.Set("author", username)
+	}
+	total := 0
+	next := c.URL + "/repos/" + repo + "/commits?" + query.Encode()
+	for next != "" {
+		var commits []json.RawMessage
+		var err error
+		next, err = c.get(ctx, next, &commits)
+		if err != nil {
+			return 0, err
+		}
+		total += len(commits)
+	}
+	return total, nil
+}
+
+// merges counts pull requests merged in [from, to), search gives the number in one request
+func (c *Client) merges(ctx context.Context, repos []string, username string, from, to time.Time) (int, error) {
+	q := search(repos, "is:pr is:merged", "merged:"+period(from, to))
+	if username != "" {
+		q += " author:" + username
+	}
+	var page searchPage
+	_, err := c.get(ctx, c.URL+"/search/issues?"+url.Values{"q": {q}, "per_page": {"1"}}.Encode(), &page)
+	return page.TotalCount, err
+}
+
+// reviews counts reviews submitted in [from, to) to pull requests updated since from,
+// a pull request reviewed in the period may have been updated after it
+func (c *Client) reviews(ctx context.Context, repos []string, username string, from, to time.Time) (int, error) {
+	q := search(repos, "is:pr", "updated:>="+from.Format(dateFormat))
+	if username != "" {
+		q += " reviewed-by:" + username
+	}
+	total := 0
+	next := c.URL + "/search/issues?" + url.Values{"q": {q}, "per_page": {strconv.Itoa(perPage)}}.Encode()
+	for next != "" {
+		var page searchPage
+		var err error
+		next, err = c.get(ctx, next, &page)
+		if err != nil {
+			return 0, err
+		}
+		for _, pr := range page.Items {
+			n, err := c.pullReviews(ctx, pr.RepositoryURL, pr.Number, username, from, to)
+			if err != nil {
+				return 0, err
+			}
+			total += n
+		}
+	}
+	return total, nil
+}
+
+func (c *Client) pullReviews(ctx context.Context, repoURL string, number int, username string, from, to time.Time) (int, error) {
+	total := 0
+	next := fmt.Sprintf("%s/pulls/%d/reviews?per_page=%d", repoURL, number, perPage)
+	for next != "" {
+		var reviews []review
+		var err error
+		next, err = c.get(ctx, next, &reviews)
+		if err != nil {
+			return 0, err
+		}
+		for _, r := range reviews {
+			if r.SubmittedAt == nil || r.SubmittedAt.Before(from) || !r.SubmittedAt.Before(to) {
+				continue
+			}
+			if username == "" || strings.EqualFold(r.User.Login, username) {
+				total++
+			}
+		}
+	}
+	return total, nil
+}
+
+// search returns search query of repos with qualifier of dates, e.g. merged:2018-06-25..2018-06-26
+func search(repos []string, q, dates string) string {
+	for _, repo := range repos {
+		q += " repo:" + repo
+	}
+	return q + " " + dates
+}
+
+// period returns search range of dates in [from, to), e.g. 2018-06-25..2018-06-26
+func period(from, to time.Time) string {
+	return fmt.Sprintf("%s..%s", from.Format(dateFormat), to.AddDate(0, 0, -1).Format(dateFormat))
+}
+
+// get requests url and decodes response into v, it returns url of the next page if there is one.
+// When rate limit is exceeded the request is sent again after the limit resets
+func (c *Client) get(ctx context.Context, link string, v interface{}) (string, error) {
+	for {
+		req, err := http.NewRequest("GET", link, nil)
+		if err != nil {
+			return "", err
+		}
+		req = req.WithContext(ctx)
+		req.Header.Add("Authorization", "token "+c.Token)
+		req.Header.Add("Accept", "application/vnd.github.v3+json")
+		res, err := c.HTTP.Do(req)
+		if err != nil {
+			return "", err
+		}
+		if wait, limited := rateLimited(res); limited {
+			res.Body.Close()
+			if wait > c.MaxWait {
+				return "", RateLimitError{Reset: time.Now().Add(wait)}
+			}
+			if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+				return "", RateLimitError{Reset: time.Now().Add(wait)}
+			}
+			logrus.Warningf("github: rate limit exceeded, retrying in %v\n", wait)
+			if err := sleep(ctx, wait); err != nil {
+				return "", err
+			}
+			continue
+		}
+		defer res.Body.Close()
+		if res.StatusCode != http.StatusOK {
+			return "", collector.StatusError{Code: res.StatusCode}
+		}
+		next := ""
+		if m := nextLink.FindStringSubmatch(res.Header.Get("Link")); m != nil {
+			next = m[1]
+		}
+		return next, json.NewDecoder(res.Body).Decode(v)
+	}
+}
+
+// rateLimited checks if response is a rate limit error and returns how long to wait for the reset.
+// Primary limits set X-RateLimit-Remaining to 0, secondary limits set Retry-After
+func rateLimited(res *http.Response) (time.Duration, bool) {
+	if res.StatusCode != http.StatusForbidden && res.StatusCode != http.StatusTooManyRequests {
+		return 0, false
+	}
+	if s, err := strconv.Atoi(res.Header.Get("Retry-After")); err == nil {
+		return time.Duration(s) * time.Second, true
+	}
+	if res.Header.Get("X-RateLimit-Remaining") != "0" {
+		return 0, false
+	}
+	reset, err := strconv.ParseInt(res.Header.Get("X-RateLimit-Reset"), 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	wait := time.Until(time.Unix(reset, 0))
+	if wait < 0 {
+		wait = 0
+	}
+	return wait, true
+}
+
+func day(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,149 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/maddevsio/comedian/collector"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestData(t *testing.T) {
+	var s *httptest.Server
+	limited := 0
+	s = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "token ghtoken" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if limited > 0 {
+			limited--
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(30*time.Second).Unix(), 10))
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		q := r.URL.Query()
+		switch r.URL.Path {
+		case "/repos/maddevsio/comedian/commits":
+			if q.Get("page") == "" {
+				assert.Equal(t, "2018-06-25T00:00:00Z", q.Get("since"))
+				assert.Equal(t, "2018-06-27T00:00:00Z", q.Get("until"))
+				w.Header().Set("Link", fmt.Sprintf(`<%s/repos/maddevsio/comedian/commits?page=2>; rel="next", <%s/repos/maddevsio/comedian/commits?page=2>; rel="last"`, s.URL, s.URL))
+				fmt.Fprint(w, `[{"sha":"a"},{"sha":"b"}]`)
+				return
+			}
+			fmt.Fprint(w, `[{"sha":"c"}]`)
+		case "/repos/maddevsio/collector/commits":
+			if q.Get("author") == "anna" {
+				fmt.Fprint(w, `[]`)
+				return
+			}
+			fmt.Fprint(w, `[{"sha":"d"}]`)
+		case "/search/issues":
+			switch q.Get("q") {
+			case "is:pr is:merged repo:maddevsio/comedian repo:maddevsio/collector merged:2018-06-25..2018-06-26":
+				fmt.Fprint(w, `{"total_count":4,"items":[{"number":1}]}`)
+			case "is:pr is:merged repo:maddevsio/comedian repo:maddevsio/collector merged:2018-06-25..2018-06-26 author:anna":
+				fmt.Fprint(w, `{"total_count":1,"items":[{"number":1}]}`)
+			case "is:pr repo:maddevsio/comedian repo:maddevsio/collector updated:>=2018-06-25",
+				"is:pr repo:maddevsio/comedian repo:maddevsio/collector updated:>=2018-06-25 reviewed-by:anna":
+				fmt.Fprintf(w, `{"total_count":2,"items":[{"number":7,"repository_url":"%s/repos/maddevsio/comedian"},{"number":3,"repository_url":"%s/repos/maddevsio/collector"}]}`, s.URL, s.URL)
+			default:
+				t.Errorf("unexpected search %v", q.Get("q"))
+			}
+		case "/repos/maddevsio/comedian/pulls/7/reviews":
+			fmt.Fprint(w, `[
+				{"user":{"login":"Anna"},"submitted_at":"2018-06-25T10:00:00Z"},
+				{"user":{"login":"bob"},"submitted_at":"2018-06-26T10:00:00Z"},
+				{"user":{"login":"anna"},"submitted_at":"2018-06-27T10:00:00Z"}
+			]`)
+		case "/repos/maddevsio/collector/pulls/3/reviews":
+			fmt.Fprint(w, `[{"user":{"login":"anna"},"submitted_at":"2018-06-26T12:00:00Z"},{"user":{"login":"bob"}}]`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer s.Close()
+
+	waits := []time.Duration{}
+	defer func(s func(context.Context, time.Duration) error) { sleep = s }(sleep)
+	sleep = func(ctx context.Context, d time.Duration) error {
+		waits = append(waits, d)
+		return ctx.Err()
+	}
+
+	c := &Client{URL: s.URL, Token: "ghtoken", HTTP: s.Client(), MaxWait: time.Minute}
+	repos := []string{"maddevsio/comedian", "maddevsio/collector"}
+	from, to := time.Date(2018, 6, 25, 12, 0, 0, 0, time.UTC), time.Date(2018, 6, 26, 0, 0, 0, 0, time.UTC)
+	data, err := c.Data(context.Background(), repos, "", from, to)
+	assert.NoError(t, err)
+	assert.Equal(t, collector.Data{TotalCommits: 4, TotalMerges: 4, TotalReviews: 3}, data)
+
+	data, err = c.Data(context.Background(), repos, "anna", from, to)
+	assert.NoError(t, err)
+	assert.Equal(t, collector.Data{TotalCommits: 3, TotalMerges: 1, TotalReviews: 2}, data)
+	assert.Empty(t, waits)
+
+	// requests are sent again after rate limit resets
+	limited = 2
+	data, err = c.Data(context.Background(), repos, "anna", from, to)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, data.TotalCommits)
+	assert.Equal(t, 2, len(waits))
+	assert.True(t, waits[0] > 25*time.Second && waits[0] <= 30*time.Second)
+
+	// client does not wait longer than MaxWait
+	limited = 1
+	c.MaxWait = time.Second
+	_, err = c.Data(context.Background(), repos, "anna", from, to)
+	assert.IsType(t, RateLimitError{}, err)
+	assert.Equal(t, 2, len(waits))
+
+	// waiting for the reset stops when the caller gives up
+	limited = 1
+	c.MaxWait = time.Minute
+	sleep = func(ctx context.Context, d time.Duration) error {
+		waits = append(waits, d)
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+	_, err = c.Data(ctx, repos, "anna", from, to)
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, 3, len(waits))
+
+	_, err = c.Data(context.Background(), []string{"maddevsio/unknown"}, "", from, to)
+	assert.Equal(t, collector.StatusError{Code: http.StatusNotFound}, err)
+	c.Token = "wrong"
+	_, err = c.Data(context.Background(), repos, "", from, to)
+	assert.Equal(t, collector.StatusError{Code: http.StatusUnauthorized}, err)
+}
+
+func TestRateLimited(t *testing.T) {
+	res := &http.Response{StatusCode: http.StatusForbidden, Header: http.Header{}}
+	_, limited := rateLimited(res)
+	assert.False(t, limited)
+
+	res.Header.Set("Retry-After", "60")
+	wait, limited := rateLimited(res)
+	assert.True(t, limited)
+	assert.Equal(t, time.Minute, wait)
+
+	res = &http.Response{StatusCode: http.StatusForbidden, Header: http.Header{}}
+	res.Header.Set("X-RateLimit-Remaining", "0")
+	res.Header.Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(-time.Minute).Unix(), 10))
+	wait, limited = rateLimited(res)
+	assert.True(t, limited)
+	assert.Equal(t, time.Duration(0), wait)
+
+	res.StatusCode = http.StatusOK
+	_, limited = rateLimited(res)
+	assert.False(t, limited)
+}
@@ -0,0 +1,174 @@
+// Package gitlab counts commits and merges of GitLab projects through GitLab REST API
+package gitlab
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/maddevsio/comedian/collector"
+	"github.com/maddevsio/comedian/config"
+)
+
+// Name is the provider name used in channel and user links
+const Name = "gitlab"
+
+const perPage = 100
+
+// Client requests GitLab REST API v4, projects are paths like "group/project"
+type Client struct {
+	URL   string
+	Token string
+	HTTP  *http.Client
+}
+
+// New creates GitLab client from config
+func New(c config.Config) *Client {
+	return &Client{
+		URL:   c.GitlabURL,
+		Token: c.GitlabToken,
+		HTTP:  &http.Client{Timeout: time.Duration(c.CollectorTimeout) * time.Second},
+	}
+}
+
+type pushEvent struct {
+	AuthorUsername string `json:"author_username"`
+	PushData       struct {
+		CommitCount int `json:"commit_count"`
+	} `json:"push_data"`
+}
+
+type mergeRequest struct {
+	MergedAt *time.Time `json:"merged_at"`
+	Author   struct {
+		Username string `json:"username"`
+	} `json:"author"`
+}
+
+// Data returns commits pushed to projects and merge requests merged in them from the day
+// of dateFrom to the day of dateTo, only work of username is counted if it is not empty
+func (c *Client) Data(ctx context.Context, projects []string, username string, dateFrom, dateTo time.Time) (collector.Data, error) {
+	var data collector.Data
+	from := day(dateFrom)
+	to := day(dateTo).AddDate(0, 0, 1)
+	for _, project := range projects {
+		commits, err := c.commits(ctx, project, username, from, to)
+		if err != nil {
+			return data, err
+		}
+		merges, err := c.merges(ctx, project, username, from, to)
+		if err != nil {
+			return data, err
+		}
+		data.TotalCommits += commits
+		data.TotalMerges += merges
+	}
+	return data, nil
+}
+
+// commits sums commits of push events in [from, to), GitLab filters events by
+// dates exclusively, so after and before are a day wider than the period
+func (c *Client) commits(ctx context.Context, project, username string, from, to time.Time) (int, error) {
+	query := url.Values{}
+	query.Set("action", "pushed")
+	query.Set("after", from.AddDate(0, 0, -1).Format("2006-01-02"))
+	query.Set("before", to.Format("2006-01-02"))
+	total := 0
+	err := c.each(ctx, projectPath(project, "events"), query, func(body []byte) (int, error) {
+		var events []pushEvent
+		if err := json.Unmarshal(body, &events); err != nil {
+			return 0, err
+		}
+		for _, e := range events {
+			if username == "" || e.AuthorUsername == username {
+				total += e.PushData.CommitCount
+			}
+		}
+		return len(events), nil
+	})
+	return total, err
+}
+
+// merges counts merge requests merged in [from, to)
+func (c *Client) merges(ctx context.Context, project, username string, from, to time.Time) (int, error) {
+	query := url.Values{}
+	query.Set("state", "merged")
+	query.Set("updated_after", from.Format(time.RFC3339))
+	if username != "" {
+		query.Set("author_username", username)
+	}
+	total := 0
+	err := c.each(ctx, projectPath(project, "merge_requests"), query, func(body []byte) (int, error) {
+		var mrs []mergeRequest
+		if err := json.Unmarshal(body, &mrs); err != nil {
+			return 0, err
+		}
+		for _, mr := range mrs {
+			if mr.MergedAt == nil || mr.MergedAt.Before(from) || !mr.MergedAt.Before(to) {
+				continue
+			}
+			if username == "" || mr.Author.Username == username {
+				total++
+			}
+		}
+		return len(mrs), nil
+	})
+	return total, err
+}
+
+// each requests all pages of path and passes their bodies to page
+func (c *Client) each(ctx context.Context, path string, query url.Values, page func([]byte) (int, error)) error {
+	query.Set("per_page", strconv.Itoa(perPage))
+	next := "1"
+	for next != "" {
+		query.Set("page", next)
+		body, header, err := c.get(ctx, path+"?"+query.Encode())
+		if err != nil {
+			return err
+		}
+		n, err := page(body)
+		if err != nil {
+			return err
+		}
+		next = header.Get("X-Next-Page")
+		if n == 0 {
+			break
+		}
+	}
+	return nil
+}
+
+func (c *Client) get(ctx context.Context, path string) ([]byte, http.Header, error) {
+	req, err := http.NewRequest("GET", c.URL+path, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Add("PRIVATE-TOKEN", c.Token)
+	res, err := c.HTTP.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, nil, collector.StatusError{Code: res.StatusCode}
+	}
+	var body json.RawMessage
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		return nil, nil, err
+	}
+	return body, res.Header, nil
+}
+
+// projectPath returns API path of project resource, project path is escaped as GitLab requires
+func projectPath(project, resource string) string {
+	return fmt.Sprintf("/api/v4/projects/%s/%s", url.PathEscape(project), resource)
+}
+
+func day(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
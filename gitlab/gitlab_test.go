@@ -0,0 +1,64 @@
+package gitlab
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/maddevsio/comedian/collector"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestData(t *testing.T) {
+	requests := []string{}
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.EscapedPath()+" "+r.URL.Query().Get("page"))
+		if r.Header.Get("PRIVATE-TOKEN") != "gltoken" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		page := r.URL.Query().Get("page")
+		switch r.URL.EscapedPath() + " " + page {
+		case "/api/v4/projects/maddevs%2Fcomedian/events 1":
+			assert.Equal(t, "pushed", r.URL.Query().Get("action"))
+			assert.Equal(t, "2018-06-24", r.URL.Query().Get("after"))
+			assert.Equal(t, "2018-06-27", r.URL.Query().Get("before"))
+			w.Header().Set("X-Next-Page", "2")
+			fmt.Fprint(w, `[{"author_username":"anna","push_data":{"commit_count":3}},{"author_username":"bob","push_data":{"commit_count":1}}]`)
+		case "/api/v4/projects/maddevs%2Fcomedian/events 2":
+			fmt.Fprint(w, `[{"author_username":"anna","push_data":{"commit_count":2}}]`)
+		case "/api/v4/projects/maddevs%2Fcomedian/merge_requests 1":
+			assert.Equal(t, "merged", r.URL.Query().Get("state"))
+			fmt.Fprint(w, `[
+				{"merged_at":"2018-06-25T10:00:00Z","author":{"username":"anna"}},
+				{"merged_at":"2018-06-26T23:00:00Z","author":{"username":"bob"}},
+				{"merged_at":"2018-06-27T10:00:00Z","author":{"username":"anna"}}
+			]`)
+		case "/api/v4/projects/maddevs%2Fcollector/events 1", "/api/v4/projects/maddevs%2Fcollector/merge_requests 1":
+			fmt.Fprint(w, `[]`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer s.Close()
+
+	c := &Client{URL: s.URL, Token: "gltoken", HTTP: s.Client()}
+	from, to := time.Date(2018, 6, 25, 12, 0, 0, 0, time.UTC), time.Date(2018, 6, 26, 0, 0, 0, 0, time.UTC)
+	data, err := c.Data(context.Background(), []string{"maddevs/comedian", "maddevs/collector"}, "", from, to)
+	assert.NoError(t, err)
+	assert.Equal(t, collector.Data{TotalCommits: 6, TotalMerges: 2}, data)
+	assert.Equal(t, 5, len(requests))
+
+	data, err = c.Data(context.Background(), []string{"maddevs/comedian"}, "anna", from, to)
+	assert.NoError(t, err)
+	assert.Equal(t, collector.Data{TotalCommits: 5, TotalMerges: 1}, data)
+
+	_, err = c.Data(context.Background(), []string{"maddevs/unknown"}, "", from, to)
+	assert.Equal(t, collector.StatusError{Code: http.StatusNotFound}, err)
+	c.Token = "wrong"
+	_, err = c.Data(context.Background(), []string{"maddevs/comedian"}, "", from, to)
+	assert.Equal(t, collector.StatusError{Code: http.StatusUnauthorized}, err)
+}
@@ -0,0 +1,185 @@
+// Package jira sums time logged in JIRA projects through JIRA REST API
+package jira
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/maddevsio/comedian/collector"
+	"github.com/maddevsio/comedian/config"
+)
+
+// Name is the provider name used in channel and user links
+const Name = "jira"
+
+const (
+	pageSize   = 100
+	dateFormat = "2006-01-02"
+	// startedFormat is the format of worklog start time, e.g. 2018-06-25T10:00:00.000+0600
+	startedFormat = "2006-01-02T15:04:05.000-0700"
+)
+
+// Client requests JIRA REST API v2, projects are project keys and usernames are
+// names (JIRA Server) or account IDs (JIRA Cloud) of worklog authors
+type Client struct {
+	URL string
+	// User is set for JIRA Cloud, requests are sent with basic auth of user and API token,
+	// otherwise Token is a personal access token
+	User  string
+	Token string
+	HTTP  *http.Client
+}
+
+// New creates JIRA client from config
+func New(c config.Config) *Client {
+	return &Client{
+		URL:   strings.TrimRight(c.JiraURL, "/"),
+		User:  c.JiraUser,
+		Token: c.JiraToken,
+		HTTP:  &http.Client{Timeout: time.Duration(c.CollectorTimeout) * time.Second},
+	}
+}
+
+type searchPage struct {
+	Total  int `json:"total"`
+	Issues []struct {
+		Key string `json:"key"`
+	} `json:"issues"`
+}
+
+type worklogPage struct {
+	Total    int       `json:"total"`
+	Worklogs []worklog `json:"worklogs"`
+}
+
+type worklog struct {
+	Author struct {
+		Name      string `json:"name"`
+		AccountID string `json:"accountId"`
+	} `json:"author"`
+	Started          string `json:"started"`
+	TimeSpentSeconds int    `json:"timeSpentSeconds"`
+}
+
+// Data returns seconds logged in projects from the day of dateFrom to the day of dateTo,
+// only time of username is counted if it is not empty
+func (c *Client) Data(ctx context.Context, projects []string, username string, dateFrom, dateTo time.Time) (collector.Data, error) {
+	var data collector.Data
+	from := day(dateFrom)
+	to := day(dateTo).AddDate(0, 0, 1)
+	issues, err := c.issues(ctx, projects, username, from, to)
+	if err != nil {
+		return data, err
+	}
+	for _, key := range issues {
+		seconds, err := c.worklogs(ctx, key, username, from, to)
+		if err != nil {
+			return data, err
+		}
+		data.Worklogs += seconds
+	}
+	return data, nil
+}
+
+// issues finds issues of projects with time logged in [from, to)
+func (c *Client) issues(ctx context.Context, projects []string, username string, from, to time.Time) ([]string, error) {
+	keys := []string{}
+	for start := 0; ; {
+		query := url.Values{}
+		query.Set("jql", JQL(projects, username, from, to))
+		query.Set("fields", "key")
+		query.Set("startAt", strconv.Itoa(start))
+		query.Set("maxResults", strconv.Itoa(pageSize))
+		var page searchPage
+		if err := c.get(ctx, "/rest/api/2/search", query, &page); err != nil {
+			return nil, err
+		}
+		for _, issue := range page.Issues {
+			keys = append(keys, issue.Key)
+		}
+		// JIRA may return fewer results than asked for
+		start += len(page.Issues)
+		if len(page.Issues) == 0 || start >= page.Total {
+			return keys, nil
+		}
+	}
+}
+
+// worklogs sums seconds logged in issue in [from, to), JQL finds issues by dates
+// of any of their worklogs, so every worklog is checked again
+func (c *Client) worklogs(ctx context.Context, key, username string, from, to time.Time) (int, error) {
+	total := 0
+	for start := 0; ; {
+		query := url.Values{}
+		query.Set("startAt", strconv.Itoa(start))
+		query.Set("maxResults", strconv.Itoa(pageSize))
+		var page worklogPage
+		if err := c.get(ctx, "/rest/api/2/issue/"+url.PathEscape(key)+"/worklog", query, &page); err != nil {
+			return 0, err
+		}
+		for _, w := range page.Worklogs {
+			started, err := time.Parse(startedFormat, w.Started)
+			if err != nil {
+				return 0, err
+			}
+			started = started.In(from.Location())
+			if started.Before(from) || !started.Before(to) {
+				continue
+			}
+			if username == "" || w.Author.Name == username || w.Author.AccountID == username {
+				total += w.TimeSpentSeconds
+			}
+		}
+		start += len(page.Worklogs)
+		if len(page.Worklogs) == 0 || start >= page.Total {
+			return total, nil
+		}
+	}
+}
+
+// JQL returns query of issues in projects with time logged in [from, to) by username if it is set
+func JQL(projects []string, username string, from, to time.Time) string {
+	quoted := []string{}
+	for _, p := range projects {
+		quoted = append(quoted, strconv.Quote(p))
+	}
+	jql := fmt.Sprintf("project in (%s) AND worklogDate >= %q AND worklogDate < %q",
+		strings.Join(quoted, ", "), from.Format(dateFormat), to.Format(dateFormat))
+	if username != "" {
+		jql += fmt.Sprintf(" AND worklogAuthor = %q", username)
+	}
+	return jql + " ORDER BY key"
+}
+
+func (c *Client) get(ctx context.Context, path string, query url.Values, v interface{}) error {
+	req, err := http.NewRequest("GET", c.URL+path+"?"+query.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	if c.User != "" {
+		req.SetBasicAuth(c.User, c.Token)
+	} else {
+		req.Header.Add("Authorization", "Bearer "+c.Token)
+	}
+	req.Header.Add("Accept", "application/json")
+	res, err := c.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return collector.StatusError{Code: res.StatusCode}
+	}
+	return json.NewDecoder(res.Body).Decode(v)
+}
+
+func day(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
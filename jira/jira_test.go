@@ -0,0 +1,54 @@
+package jira
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/maddevsio/comedian/collector"
+	"github.com/maddevsio/comedian/jira/jiratest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestData(t *testing.T) {
+	interactions, err := jiratest.Load("testdata/worklogs.json")
+	require.NoError(t, err)
+	s := jiratest.NewServer("jiratoken", interactions)
+	defer s.Close()
+
+	c := &Client{URL: s.URL, Token: "jiratoken", HTTP: s.Client()}
+	from, to := time.Date(2018, 6, 25, 12, 0, 0, 0, time.UTC), time.Date(2018, 6, 26, 0, 0, 0, 0, time.UTC)
+	data, err := c.Data(context.Background(), []string{"CMD", "COL"}, "", from, to)
+	assert.NoError(t, err)
+	assert.Equal(t, collector.Data{Worklogs: 11700}, data)
+	assert.Equal(t, 4, s.Requests())
+
+	data, err = c.Data(context.Background(), []string{"CMD", "COL"}, "anna", from, to)
+	assert.NoError(t, err)
+	assert.Equal(t, collector.Data{Worklogs: 9000}, data)
+
+	// search results come in pages
+	data, err = c.Data(context.Background(), []string{"BIG"}, "", from, to)
+	assert.NoError(t, err)
+	assert.Equal(t, collector.Data{Worklogs: 6300}, data)
+
+	_, err = c.Data(context.Background(), []string{"NONE"}, "", from, to)
+	assert.Equal(t, collector.StatusError{Code: http.StatusBadRequest}, err)
+
+	// JIRA Cloud API tokens are sent with basic auth
+	c.User = "bot@maddevs.io"
+	data, err = c.Data(context.Background(), []string{"CMD", "COL"}, "anna", from, to)
+	assert.NoError(t, err)
+	assert.Equal(t, 9000, data.Worklogs)
+	c.Token = "wrong"
+	_, err = c.Data(context.Background(), []string{"CMD", "COL"}, "anna", from, to)
+	assert.Equal(t, collector.StatusError{Code: http.StatusUnauthorized}, err)
+}
+
+func TestJQL(t *testing.T) {
+	from, to := time.Date(2018, 6, 25, 0, 0, 0, 0, time.UTC), time.Date(2018, 6, 27, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, `project in ("CMD") AND worklogDate >= "2018-06-25" AND worklogDate < "2018-06-27" ORDER BY key`, JQL([]string{"CMD"}, "", from, to))
+	assert.Equal(t, `project in ("CMD", "COL") AND worklogDate >= "2018-06-25" AND worklogDate < "2018-06-27" AND worklogAuthor = "anna" ORDER BY key`, JQL([]string{"CMD", "COL"}, "anna", from, to))
+}
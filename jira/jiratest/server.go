@@ -0,0 +1,99 @@
+// Package jiratest provides a fake JIRA server replaying recorded API responses for tests
+package jiratest
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+)
+
+// Interaction is a recorded request and the response JIRA gave to it. Request matches
+// if its method and path are the same and it has all recorded query parameters
+type Interaction struct {
+	Method string            `json:"method"`
+	Path   string            `json:"path"`
+	Query  map[string]string `json:"query"`
+	Status int               `json:"status"`
+	Body   json.RawMessage   `json:"body"`
+}
+
+// Server answers JIRA API requests with recorded responses. Requests without
+// the token are unauthorized, requests that were not recorded are not found
+type Server struct {
+	*httptest.Server
+	Token string
+
+	mu           sync.Mutex
+	interactions []Interaction
+	requests     int
+}
+
+// NewServer starts a fake JIRA accepting token as a personal access token or
+// an API token of any user, it must be closed by the caller
+func NewServer(token string, interactions []Interaction) *Server {
+	s := &Server{Token: token, interactions: interactions}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+// Load reads interactions recorded in JSON file
+func Load(path string) ([]Interaction, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var interactions []Interaction
+	err = json.Unmarshal(b, &interactions)
+	return interactions, err
+}
+
+// Requests returns number of requests received
+func (s *Server) Requests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests++
+	s.mu.Unlock()
+
+	if !s.authorized(r) {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	for _, i := range s.interactions {
+		if i.Method != r.Method || i.Path != r.URL.Path || !matchQuery(i.Query, r) {
+			continue
+		}
+		status := i.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		w.Write(i.Body)
+		return
+	}
+	w.WriteHeader(http.StatusNotFound)
+}
+
+func (s *Server) authorized(r *http.Request) bool {
+	if _, token, ok := r.BasicAuth(); ok {
+		return token == s.Token
+	}
+	return r.Header.Get("Authorization") == "Bearer "+s.Token
+}
+
+func matchQuery(query map[string]string, r *http.Request) bool {
+	values := r.URL.Query()
+	for k, v := range query {
+		if values.Get(k) != v {
+			return false
+		}
+	}
+	return true
+}
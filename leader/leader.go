@@ -0,0 +1,94 @@
+// Package leader elects one of Comedian replicas sharing a database to run jobs
+// which must not be done twice, like standup reminders
+package leader
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/maddevsio/comedian/storage"
+	"github.com/sirupsen/logrus"
+)
+
+// lockName is the name of database lock held by the leader
+const lockName = "notifier"
+
+// Elector keeps a database lock while the replica is alive. The lock expires TTL after
+// the last renewal, so when the leader dies another replica takes it over
+type Elector struct {
+	DB  storage.Storage
+	ID  string
+	TTL time.Duration
+
+	mu     sync.RWMutex
+	leader bool
+	stop   chan struct{}
+}
+
+// NewElector creates elector of replica, an empty id is replaced with host name and process id
+func NewElector(db storage.Storage, id string, ttl time.Duration) *Elector {
+	if id == "" {
+		host, _ := os.Hostname()
+		id = fmt.Sprintf("%v-%v", host, os.Getpid())
+	}
+	return &Elector{DB: db, ID: id, TTL: ttl, stop: make(chan struct{})}
+}
+
+// Run campaigns for leadership until Stop is called, the lock is renewed three times per TTL
+func (e *Elector) Run() {
+	ticker := time.NewTicker(e.TTL / 3)
+	defer ticker.Stop()
+	for {
+		e.Campaign()
+		select {
+		case <-ticker.C:
+		case <-e.stop:
+			return
+		}
+	}
+}
+
+// Campaign tries to take or renew the lock once and returns if the replica is the leader
+func (e *Elector) Campaign() bool {
+	leader, err := e.DB.AcquireLock(lockName, e.ID, time.Now().Add(e.TTL))
+	if err != nil {
+		logrus.Errorf("leader: AcquireLock failed: %v\n", err)
+		// the lock may be lost already, better skip jobs than run them twice
+		leader = false
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if leader != e.leader {
+		if leader {
+			logrus.Infof("leader: %v became the leader\n", e.ID)
+		} else {
+			logrus.Infof("leader: %v is not the leader anymore\n", e.ID)
+		}
+	}
+	e.leader = leader
+	return leader
+}
+
+// IsLeader returns if the replica holds the lock
+func (e *Elector) IsLeader() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.leader
+}
+
+// Stop stops campaigning and releases the lock, so other replica becomes the leader
+// without waiting for the lock to expire
+func (e *Elector) Stop() {
+	close(e.stop)
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if !e.leader {
+		return
+	}
+	e.leader = false
+	if err := e.DB.ReleaseLock(lockName, e.ID); err != nil {
+		logrus.Errorf("leader: ReleaseLock failed: %v\n", err)
+	}
+}
@@ -0,0 +1,48 @@
+package leader
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bouk/monkey"
+	"github.com/maddevsio/comedian/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestElector(t *testing.T) {
+	d := time.Date(2018, 7, 9, 10, 0, 0, 0, time.UTC)
+	monkey.Patch(time.Now, func() time.Time { return d })
+	defer monkey.Unpatch(time.Now)
+
+	db := storage.NewMemory()
+	first := NewElector(db, "first", 30*time.Second)
+	second := NewElector(db, "second", 30*time.Second)
+
+	assert.True(t, first.Campaign())
+	assert.False(t, second.Campaign())
+	assert.True(t, first.IsLeader())
+	assert.False(t, second.IsLeader())
+
+	// the leader renews the lock, so it does not expire
+	d = d.Add(20 * time.Second)
+	assert.True(t, first.Campaign())
+	d = d.Add(20 * time.Second)
+	assert.False(t, second.Campaign())
+
+	// the leader dies, its lock expires
+	d = d.Add(31 * time.Second)
+	assert.True(t, second.Campaign())
+	assert.False(t, first.Campaign())
+
+	// stopped leader hands the lock over at once
+	second.Stop()
+	assert.False(t, second.IsLeader())
+	assert.True(t, first.Campaign())
+}
+
+func TestNewElector(t *testing.T) {
+	e := NewElector(storage.NewMemory(), "", time.Minute)
+	assert.NotEmpty(t, e.ID)
+	e = NewElector(storage.NewMemory(), "pod-1", time.Minute)
+	assert.Equal(t, "pod-1", e.ID)
+}
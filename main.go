@@ -1,10 +1,17 @@
 package main
 
 import (
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
 	"github.com/maddevsio/comedian/api"
 	"github.com/maddevsio/comedian/chat"
 	"github.com/maddevsio/comedian/config"
+	"github.com/maddevsio/comedian/leader"
 	"github.com/maddevsio/comedian/notifier"
+	"github.com/maddevsio/comedian/storage"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -13,22 +20,67 @@ func main() {
 	if err != nil {
 		log.Fatal(err)
 	}
-	api, err := api.NewRESTAPI(c)
+	db, err := storage.New(c)
+	if err != nil {
+		log.Fatal(err)
+	}
+	api, err := api.NewRESTAPI(c, db)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	go func() { log.Fatal(api.Start()) }()
-
-	slack, err := chat.NewSlack(c)
+	chats := chat.NewRegistry()
+	slack, err := chat.NewSlack(c, db)
 	if err != nil {
 		log.Fatal(err)
 	}
+	if c.SlackTransport == chat.TransportEvents {
+		api.AddHandler("/events", slack.EventsHandler())
+	}
+	chats.Register(chat.PlatformSlack, slack)
+
+	if c.TelegramToken != "" {
+		telegram, err := chat.NewTelegram(c, db)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if c.TelegramWebhookURL != "" {
+			api.AddHandler("/telegram", telegram.WebhookHandler())
+		}
+		telegram.HandleCommands(api.HandleCommand)
+		chats.Register(chat.PlatformTelegram, telegram)
+	}
+
+	if c.MattermostURL != "" {
+		mattermost, err := chat.NewMattermost(c, db)
+		if err != nil {
+			log.Fatal(err)
+		}
+		api.AddMattermostCommands(mattermost)
+		chats.Register(chat.PlatformMattermost, mattermost)
+	}
+
+	go func() { log.Fatal(api.Start()) }()
+
+	// replicas sharing the database elect one leader to send reminders
+	elector := leader.NewElector(db, c.ReplicaID, time.Duration(c.LeaderTTL)*time.Second)
+	elector.Campaign()
+	go elector.Run()
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-stop
+		elector.Stop()
+		os.Exit(0)
+	}()
 
-	notifier, err := notifier.NewNotifier(c, slack)
+	notifier, err := notifier.NewNotifier(c, chats, db)
 	if err != nil {
 		log.Fatal(err)
 	}
+	notifier.Leader = elector
 	go func() { log.Fatal(notifier.Start()) }()
-	slack.Run()
+	if err := chats.Run(); err != nil {
+		log.Fatal(err)
+	}
 }
@@ -0,0 +1,223 @@
+// Package metrics routes requests for commits, merges and worklogs to providers
+// channels and users are linked to, Collector is used for the rest
+package metrics
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/maddevsio/comedian/collector"
+	"github.com/maddevsio/comedian/config"
+	"github.com/maddevsio/comedian/github"
+	"github.com/maddevsio/comedian/gitlab"
+	"github.com/maddevsio/comedian/jira"
+	"github.com/maddevsio/comedian/storage"
+	"github.com/sirupsen/logrus"
+)
+
+// Sources is a collector.Client getting data from linked providers
+type Sources struct {
+	DB        storage.Storage
+	Collector collector.Client
+	Providers map[string]collector.Provider
+}
+
+// metric is a set of fields of collector.Data
+type metric uint8
+
+const (
+	commits metric = 1 << iota
+	merges
+	reviews
+	worklogs
+)
+
+// provided lists metrics counted by each provider, the rest are taken from Collector
+var provided = map[string]metric{
+	gitlab.Name: commits | merges,
+	github.Name: commits | merges | reviews,
+	jira.Name:   worklogs,
+}
+
+// New creates Sources with providers enabled in config
+func New(c config.Config, db storage.Storage) *Sources {
+	return &Sources{DB: db, Collector: collector.New(c), Providers: providers(c)}
+}
+
+func providers(c config.Config) map[string]collector.Provider {
+	p := map[string]collector.Provider{}
+	if c.GitlabToken != "" {
+		p[gitlab.Name] = gitlab.New(c)
+	}
+	if c.GithubToken != "" {
+		p[github.Name] = github.New(c)
+	}
+	if c.JiraURL != "" && c.JiraToken != "" {
+		p[jira.Name] = jira.New(c)
+	}
+	return p
+}
+
+// Names returns names of providers enabled in config, channels and users can be linked to them
+func Names(c config.Config) []string {
+	names := []string{}
+	for name := range providers(c) {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// UserData returns data on user in projects of user's channels, users without links are asked from Collector
+func (s *Sources) UserData(ctx context.Context, userID string, dateFrom, dateTo time.Time) (collector.Data, error) {
+	usernames, err := s.usernames(userID)
+	if err != nil || len(usernames) == 0 {
+		return s.Collector.UserData(ctx, userID, dateFrom, dateTo)
+	}
+	channels, err := s.DB.GetUserChannels(userID)
+	if err != nil {
+		return collector.Data{}, err
+	}
+	projects := map[string][]string{}
+	seen := map[string]bool{}
+	for _, channelID := range channels {
+		p, err := s.projects(channelID)
+		if err != nil {
+			return collector.Data{}, err
+		}
+		// projects shared by channels are counted once
+		for provider, list := range p {
+			for _, project := range list {
+				if seen[provider+"/"+project] {
+					continue
+				}
+				seen[provider+"/"+project] = true
+				projects[provider] = append(projects[provider], project)
+			}
+		}
+	}
+	data, got, err := s.sum(ctx, projects, usernames, true, dateFrom, dateTo)
+	if err != nil {
+		return data, err
+	}
+	return s.fill(data, got, func() (collector.Data, error) {
+		return s.Collector.UserData(ctx, userID, dateFrom, dateTo)
+	}), nil
+}
+
+// ProjectData returns data on projects linked to channel, channels without links are asked from Collector
+func (s *Sources) ProjectData(ctx context.Context, channelID, channelName string, dateFrom, dateTo time.Time) (collector.Data, error) {
+	projects, err := s.projects(channelID)
+	if err != nil || len(projects) == 0 {
+		return s.Collector.ProjectData(ctx, channelID, channelName, dateFrom, dateTo)
+	}
+	data, got, err := s.sum(ctx, projects, nil, false, dateFrom, dateTo)
+	if err != nil {
+		return data, err
+	}
+	return s.fill(data, got, func() (collector.Data, error) {
+		return s.Collector.ProjectData(ctx, channelID, channelName, dateFrom, dateTo)
+	}), nil
+}
+
+// ProjectUserData returns data on user in projects linked to channel,
+// channels without links are asked from Collector
+func (s *Sources) ProjectUserData(ctx context.Context, channelID, channelName, userID string, dateFrom, dateTo time.Time) (collector.Data, error) {
+	projects, err := s.projects(channelID)
+	if err != nil || len(projects) == 0 {
+		return s.Collector.ProjectUserData(ctx, channelID, channelName, userID, dateFrom, dateTo)
+	}
+	usernames, err := s.usernames(userID)
+	if err != nil {
+		return collector.Data{}, err
+	}
+	data, got, err := s.sum(ctx, projects, usernames, true, dateFrom, dateTo)
+	if err != nil {
+		return data, err
+	}
+	return s.fill(data, got, func() (collector.Data, error) {
+		return s.Collector.ProjectUserData(ctx, channelID, channelName, userID, dateFrom, dateTo)
+	}), nil
+}
+
+// sum adds up data of providers, if byUser is set providers user is not linked to are skipped.
+// Metrics counted by the providers asked are returned along with data
+func (s *Sources) sum(ctx context.Context, projects map[string][]string, usernames map[string]string, byUser bool, dateFrom, dateTo time.Time) (collector.Data, metric, error) {
+	var total collector.Data
+	var got metric
+	for name, list := range projects {
+		provider, ok := s.Providers[name]
+		if !ok {
+			logrus.Warningf("metrics: provider %v is not configured\n", name)
+			continue
+		}
+		username := usernames[name]
+		if byUser && username == "" {
+			continue
+		}
+		data, err := provider.Data(ctx, list, username, dateFrom, dateTo)
+		if err != nil {
+			return total, got, err
+		}
+		got |= provided[name]
+		total.TotalCommits += data.TotalCommits
+		total.TotalMerges += data.TotalMerges
+		total.TotalReviews += data.TotalReviews
+		total.Worklogs += data.Worklogs
+	}
+	return total, got, nil
+}
+
+// fill takes metrics no provider counted from Collector, Collector failures leave them empty
+func (s *Sources) fill(total collector.Data, got metric, fromCollector func() (collector.Data, error)) collector.Data {
+	if got == commits|merges|reviews|worklogs {
+		return total
+	}
+	data, err := fromCollector()
+	if err != nil {
+		logrus.Errorf("metrics: Collector failed: %v\n", err)
+		return total
+	}
+	if got&commits == 0 {
+		total.TotalCommits = data.TotalCommits
+	}
+	if got&merges == 0 {
+		total.TotalMerges = data.TotalMerges
+	}
+	if got&reviews == 0 {
+		total.TotalReviews = data.TotalReviews
+	}
+	if got&worklogs == 0 {
+		total.Worklogs = data.Worklogs
+	}
+	return total
+}
+
+// projects returns projects linked to channel grouped by provider
+func (s *Sources) projects(channelID string) (map[string][]string, error) {
+	links, err := s.DB.ListProjectLinks(channelID)
+	if err != nil {
+		logrus.Errorf("metrics: ListProjectLinks failed: %v\n", err)
+		return nil, err
+	}
+	projects := map[string][]string{}
+	for _, link := range links {
+		projects[link.Provider] = append(projects[link.Provider], link.Project)
+	}
+	return projects, nil
+}
+
+// usernames returns usernames of user by provider
+func (s *Sources) usernames(userID string) (map[string]string, error) {
+	links, err := s.DB.ListUserLinks(userID)
+	if err != nil {
+		logrus.Errorf("metrics: ListUserLinks failed: %v\n", err)
+		return nil, err
+	}
+	usernames := map[string]string{}
+	for _, link := range links {
+		usernames[link.Provider] = link.Username
+	}
+	return usernames, nil
+}
@@ -0,0 +1,117 @@
+package metrics
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/maddevsio/comedian/collector"
+	"github.com/maddevsio/comedian/config"
+	"github.com/maddevsio/comedian/model"
+	"github.com/maddevsio/comedian/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeProvider struct {
+	calls []string
+}
+
+func (p *fakeProvider) Data(ctx context.Context, projects []string, username string, dateFrom, dateTo time.Time) (collector.Data, error) {
+	p.calls = append(p.calls, strings.Join(projects, ",")+" "+username)
+	return collector.Data{TotalCommits: len(projects), TotalMerges: 1}, nil
+}
+
+type fakeCollector struct {
+	calls []string
+}
+
+func (c *fakeCollector) UserData(ctx context.Context, userID string, dateFrom, dateTo time.Time) (collector.Data, error) {
+	c.calls = append(c.calls, "users/"+userID)
+	return collector.Data{Worklogs: 3600}, nil
+}
+
+func (c *fakeCollector) ProjectData(ctx context.Context, channelID, channelName string, dateFrom, dateTo time.Time) (collector.Data, error) {
+	c.calls = append(c.calls, "projects/"+channelName)
+	return collector.Data{Worklogs: 7200}, nil
+}
+
+func (c *fakeCollector) ProjectUserData(ctx context.Context, channelID, channelName, userID string, dateFrom, dateTo time.Time) (collector.Data, error) {
+	c.calls = append(c.calls, "projects-users/"+channelName+"/"+userID)
+	return collector.Data{Worklogs: 1800}, nil
+}
+
+func TestSources(t *testing.T) {
+	db := storage.NewMemory()
+	provider, co := &fakeProvider{}, &fakeCollector{}
+	s := &Sources{DB: db, Collector: co, Providers: map[string]collector.Provider{"gitlab": provider}}
+	now := time.Now()
+
+	for _, u := range []model.StandupUser{
+		{SlackUserID: "U1", SlackName: "anna", ChannelID: "C1", Channel: "chan1"},
+		{SlackUserID: "U1", SlackName: "anna", ChannelID: "C2", Channel: "chan2"},
+		{SlackUserID: "U2", SlackName: "bob", ChannelID: "C1", Channel: "chan1"},
+	} {
+		_, err := db.CreateStandupUser(u)
+		require.NoError(t, err)
+	}
+	for _, l := range []model.ProjectLink{
+		{ChannelID: "C1", Provider: "gitlab", Project: "maddevs/comedian"},
+		{ChannelID: "C1", Provider: "gitlab", Project: "maddevs/collector"},
+		{ChannelID: "C2", Provider: "gitlab", Project: "maddevs/site"},
+		{ChannelID: "C2", Provider: "gitlab", Project: "maddevs/comedian"},
+		{ChannelID: "C2", Provider: "bitbucket", Project: "maddevs/app"},
+	} {
+		_, err := db.CreateProjectLink(l)
+		require.NoError(t, err)
+	}
+	_, err := db.CreateUserLink(model.UserLink{SlackUserID: "U1", Provider: "gitlab", Username: "anna.g"})
+	require.NoError(t, err)
+
+	// metrics providers do not count are taken from Collector
+	data, err := s.ProjectData(context.Background(), "C1", "chan1", now, now)
+	assert.NoError(t, err)
+	assert.Equal(t, collector.Data{TotalCommits: 2, TotalMerges: 1, Worklogs: 7200}, data)
+	assert.Equal(t, []string{"maddevs/collector,maddevs/comedian "}, provider.calls)
+
+	data, err = s.ProjectUserData(context.Background(), "C1", "chan1", "U1", now, now)
+	assert.NoError(t, err)
+	assert.Equal(t, collector.Data{TotalCommits: 2, TotalMerges: 1, Worklogs: 1800}, data)
+	assert.Equal(t, "maddevs/collector,maddevs/comedian anna.g", provider.calls[1])
+
+	// user without username is skipped in linked projects
+	data, err = s.ProjectUserData(context.Background(), "C1", "chan1", "U2", now, now)
+	assert.NoError(t, err)
+	assert.Equal(t, collector.Data{Worklogs: 1800}, data)
+	assert.Equal(t, 2, len(provider.calls))
+
+	// unconfigured providers are skipped, projects shared by channels are counted once
+	data, err = s.UserData(context.Background(), "U1", now, now)
+	assert.NoError(t, err)
+	assert.Equal(t, collector.Data{TotalCommits: 3, TotalMerges: 1, Worklogs: 3600}, data)
+	assert.Equal(t, 3, len(provider.calls))
+	assert.Equal(t, "maddevs/collector,maddevs/comedian,maddevs/site anna.g", provider.calls[2])
+	assert.Equal(t, []string{"projects/chan1", "projects-users/chan1/U1", "projects-users/chan1/U2", "users/U1"}, co.calls)
+	co.calls = nil
+
+	// channels and users without links are asked from Collector
+	data, err = s.ProjectData(context.Background(), "C3", "chan3", now, now)
+	assert.NoError(t, err)
+	assert.Equal(t, 7200, data.Worklogs)
+	data, err = s.UserData(context.Background(), "U2", now, now)
+	assert.NoError(t, err)
+	assert.Equal(t, 3600, data.Worklogs)
+	data, err = s.ProjectUserData(context.Background(), "C3", "chan3", "U2", now, now)
+	assert.NoError(t, err)
+	assert.Equal(t, 1800, data.Worklogs)
+	assert.Equal(t, []string{"projects/chan3", "users/U2", "projects-users/chan3/U2"}, co.calls)
+}
+
+func TestNames(t *testing.T) {
+	assert.Equal(t, []string{}, Names(config.Config{}))
+	assert.Equal(t, []string{"gitlab"}, Names(config.Config{GitlabToken: "gltoken"}))
+	assert.Equal(t, []string{}, Names(config.Config{JiraToken: "jiratoken"}))
+	assert.Equal(t, []string{"gitlab", "jira"}, Names(config.Config{GitlabToken: "gltoken", JiraURL: "https://maddevs.atlassian.net", JiraToken: "jiratoken"}))
+	assert.Equal(t, []string{"github"}, Names(config.Config{GithubToken: "ghtoken"}))
+}
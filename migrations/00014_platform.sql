@@ -0,0 +1,10 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+ALTER TABLE `standup` ADD `platform` VARCHAR (32) NOT NULL DEFAULT 'slack';
+ALTER TABLE `standup_users` ADD `platform` VARCHAR (32) NOT NULL DEFAULT 'slack';
+ALTER TABLE `standup_time` ADD `platform` VARCHAR (32) NOT NULL DEFAULT 'slack';
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+ALTER TABLE `standup` DROP `platform`;
+ALTER TABLE `standup_users` DROP `platform`;
+ALTER TABLE `standup_time` DROP `platform`;
@@ -0,0 +1,10 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+ALTER TABLE `standup` ADD `yesterday` TEXT NOT NULL;
+ALTER TABLE `standup` ADD `today` TEXT NOT NULL;
+ALTER TABLE `standup` ADD `problems` TEXT NOT NULL;
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+ALTER TABLE `standup` DROP `yesterday`;
+ALTER TABLE `standup` DROP `today`;
+ALTER TABLE `standup` DROP `problems`;
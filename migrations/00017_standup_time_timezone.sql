@@ -0,0 +1,6 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+ALTER TABLE `standup_time` ADD `timezone` VARCHAR (64) NOT NULL DEFAULT '';
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+ALTER TABLE `standup_time` DROP `timezone`;
@@ -0,0 +1,18 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+
+CREATE TABLE `holidays` (
+`id` INTEGER NOT NULL AUTO_INCREMENT PRIMARY KEY,
+`created` DATETIME NOT NULL,
+`channel_id` VARCHAR(255) NOT NULL,
+`date` VARCHAR(10) NOT NULL,
+`name` VARCHAR(255) NOT NULL,
+KEY (`channel_id`, `date`)
+);
+ALTER TABLE `standup_time` ADD `work_days` VARCHAR (32) NOT NULL DEFAULT '';
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+
+DROP TABLE `holidays`;
+ALTER TABLE `standup_time` DROP `work_days`;
@@ -0,0 +1,17 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+
+CREATE TABLE `absences` (
+`id` INTEGER NOT NULL AUTO_INCREMENT PRIMARY KEY,
+`created` DATETIME NOT NULL,
+`slack_user_id` VARCHAR(255) NOT NULL,
+`date_from` VARCHAR(10) NOT NULL,
+`date_to` VARCHAR(10) NOT NULL,
+`reason` VARCHAR(255) NOT NULL,
+KEY (`slack_user_id`, `date_from`)
+);
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+
+DROP TABLE `absences`;
@@ -0,0 +1,20 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+
+CREATE TABLE `reminder_runs` (
+`id` INTEGER NOT NULL AUTO_INCREMENT PRIMARY KEY,
+`created` DATETIME NOT NULL,
+`modified` DATETIME NOT NULL,
+`channel_id` VARCHAR(255) NOT NULL,
+`platform` VARCHAR(32) NOT NULL,
+`date` VARCHAR(10) NOT NULL,
+`stage` VARCHAR(32) NOT NULL,
+`repeats` INTEGER NOT NULL,
+`next_at` DATETIME NOT NULL,
+UNIQUE KEY (`channel_id`, `date`)
+);
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+
+DROP TABLE `reminder_runs`;
@@ -0,0 +1,13 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+
+CREATE TABLE `locks` (
+`name` VARCHAR(255) NOT NULL PRIMARY KEY,
+`holder` VARCHAR(255) NOT NULL,
+`expires` DATETIME NOT NULL
+);
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+
+DROP TABLE `locks`;
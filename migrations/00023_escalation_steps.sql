@@ -0,0 +1,17 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+
+CREATE TABLE `escalation_steps` (
+`id` INTEGER NOT NULL AUTO_INCREMENT PRIMARY KEY,
+`created` DATETIME NOT NULL,
+`channel_id` VARCHAR(255) NOT NULL,
+`action` VARCHAR(32) NOT NULL,
+`delay_minutes` INTEGER NOT NULL,
+`position` INTEGER NOT NULL,
+KEY (`channel_id`)
+);
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+
+DROP TABLE `escalation_steps`;
@@ -0,0 +1,26 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+
+CREATE TABLE `project_links` (
+`id` INTEGER NOT NULL AUTO_INCREMENT PRIMARY KEY,
+`created` DATETIME NOT NULL,
+`channel_id` VARCHAR(255) NOT NULL,
+`provider` VARCHAR(32) NOT NULL,
+`project` VARCHAR(255) NOT NULL,
+KEY (`channel_id`)
+);
+
+CREATE TABLE `user_links` (
+`id` INTEGER NOT NULL AUTO_INCREMENT PRIMARY KEY,
+`created` DATETIME NOT NULL,
+`slack_user_id` VARCHAR(255) NOT NULL,
+`provider` VARCHAR(32) NOT NULL,
+`username` VARCHAR(255) NOT NULL,
+KEY (`slack_user_id`)
+);
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+
+DROP TABLE `project_links`;
+DROP TABLE `user_links`;
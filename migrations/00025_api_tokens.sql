@@ -0,0 +1,16 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+
+CREATE TABLE `api_tokens` (
+`id` INTEGER NOT NULL AUTO_INCREMENT PRIMARY KEY,
+`created` DATETIME NOT NULL,
+`name` VARCHAR(255) NOT NULL,
+`token_hash` VARCHAR(64) NOT NULL,
+UNIQUE KEY (`name`),
+UNIQUE KEY (`token_hash`)
+);
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+
+DROP TABLE `api_tokens`;
@@ -0,0 +1,27 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+
+CREATE TABLE `webhooks` (
+`id` INTEGER NOT NULL AUTO_INCREMENT PRIMARY KEY,
+`created` DATETIME NOT NULL,
+`url` VARCHAR(2048) NOT NULL,
+`secret` VARCHAR(255) NOT NULL,
+`events` VARCHAR(1024) NOT NULL DEFAULT ''
+);
+
+CREATE TABLE `webhook_dead_letters` (
+`id` INTEGER NOT NULL AUTO_INCREMENT PRIMARY KEY,
+`created` DATETIME NOT NULL,
+`webhook_id` INTEGER NOT NULL,
+`event` VARCHAR(255) NOT NULL,
+`payload` TEXT NOT NULL,
+`attempts` INTEGER NOT NULL DEFAULT 0,
+`error` TEXT NOT NULL,
+KEY (`webhook_id`)
+);
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+
+DROP TABLE `webhook_dead_letters`;
+DROP TABLE `webhooks`;
@@ -0,0 +1,8 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+ALTER TABLE `standup` ADD `answers` TEXT NOT NULL;
+ALTER TABLE `standup_questions` ADD `role` VARCHAR(32) NOT NULL DEFAULT '';
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+ALTER TABLE `standup` DROP `answers`;
+ALTER TABLE `standup_questions` DROP `role`;
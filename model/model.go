@@ -1,8 +1,33 @@
 package model
 
 import (
+	"database/sql/driver"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"sync"
 	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Stages of reminder run, they follow each other in this order
+const (
+	ReminderStarted  = "started"
+	ReminderWarned   = "warned"
+	ReminderDeadline = "deadline"
+	ReminderDone     = "done"
+	// ReminderEscalated is the stage of runs going through escalation steps of channel,
+	// Repeats of such runs counts steps taken
+	ReminderEscalated = "escalated"
+)
+
+// Actions of escalation steps
+const (
+	EscalateUsers   = "dm"
+	EscalateChannel = "channel"
+	EscalateAdmins  = "admins"
+	EscalateGeneral = "general"
 )
 
 type (
@@ -17,8 +42,22 @@ type (
 		Username   string    `db:"username" json:"userName"`
 		Comment    string    `db:"comment" json:"comment"`
 		MessageTS  string    `db:"message_ts" json:"message_ts"`
+		Platform   string    `db:"platform" json:"platform"`
+		Yesterday  string    `db:"yesterday" json:"yesterday"`
+		Today      string    `db:"today" json:"today"`
+		Problems   string    `db:"problems" json:"problems"`
+		Answers    Answers   `db:"answers" json:"answers"`
+	}
+
+	// Answer is the answer of standup to a question of channel standup template
+	Answer struct {
+		Question string `json:"question"`
+		Text     string `json:"text"`
 	}
 
+	// Answers are answers of standup in order of template questions, they are stored as JSON
+	Answers []Answer
+
 	// StandupUser model used for serialization/deserialization stored standupUsers
 	StandupUser struct {
 		ID          int64     `db:"id" json:"id"`
@@ -29,6 +68,7 @@ type (
 		Channel     string    `db:"channel" json:"channel"`
 		ChannelID   string    `db:"channel_id" json:"channelId"`
 		Role        string    `db:"role" json:"role"`
+		Platform    string    `db:"platform" json:"platform"`
 	}
 
 	// StandupTime model used for serialization/deserialization stored standupTime
@@ -38,6 +78,124 @@ type (
 		Channel   string    `db:"channel" json:"channel"`
 		ChannelID string    `db:"channel_id" json:"channelId"`
 		Time      int64     `db:"standuptime" json:"time"`
+		Platform  string    `db:"platform" json:"platform"`
+		Timezone  string    `db:"timezone" json:"timezone"`
+		WorkDays  string    `db:"work_days" json:"workDays"`
+		Schedule  string    `db:"schedule" json:"schedule"`
+	}
+
+	// Holiday is a day off in channel, Date is formatted as 2006-01-02
+	Holiday struct {
+		ID        int64     `db:"id" json:"id"`
+		Created   time.Time `db:"created" json:"created"`
+		ChannelID string    `db:"channel_id" json:"channelId"`
+		Date      string    `db:"date" json:"date"`
+		Name      string    `db:"name" json:"name"`
+	}
+
+	// Absence is a vacation or another leave of a standuper in all channels,
+	// DateFrom and DateTo are inclusive and formatted as 2006-01-02
+	Absence struct {
+		ID          int64     `db:"id" json:"id"`
+		Created     time.Time `db:"created" json:"created"`
+		SlackUserID string    `db:"slack_user_id" json:"slack_user_id"`
+		DateFrom    string    `db:"date_from" json:"dateFrom"`
+		DateTo      string    `db:"date_to" json:"dateTo"`
+		Reason      string    `db:"reason" json:"reason"`
+	}
+
+	// ReminderRun keeps reminders sent in channel on a day, Date is formatted as 2006-01-02.
+	// Repeats counts reminders sent after the deadline, NextAt is the time of the next one
+	ReminderRun struct {
+		ID        int64     `db:"id" json:"id"`
+		Created   time.Time `db:"created" json:"created"`
+		Modified  time.Time `db:"modified" json:"modified"`
+		ChannelID string    `db:"channel_id" json:"channelId"`
+		Platform  string    `db:"platform" json:"platform"`
+		Date      string    `db:"date" json:"date"`
+		Stage     string    `db:"stage" json:"stage"`
+		Repeats   int       `db:"repeats" json:"repeats"`
+		NextAt    time.Time `db:"next_at" json:"nextAt"`
+	}
+
+	// Lock is a named lock owned by Holder until Expires, it is used to elect a leader among replicas
+	Lock struct {
+		Name    string    `db:"name" json:"name"`
+		Holder  string    `db:"holder" json:"holder"`
+		Expires time.Time `db:"expires" json:"expires"`
+	}
+
+	// StandupQuestion is a question of channel standup template, Pattern is a keyword
+	// or a regular expression in slashes which starts the answer
+	StandupQuestion struct {
+		ID        int64     `db:"id" json:"id"`
+		Created   time.Time `db:"created" json:"created"`
+		ChannelID string    `db:"channel_id" json:"channelId"`
+		Name      string    `db:"name" json:"name"`
+		Pattern   string    `db:"pattern" json:"pattern"`
+		Position  int       `db:"position" json:"position"`
+		// Role tells which standup section the answer fills: yesterday, today or problems.
+		// Reminders about plans read the today answer and blockers reports read the problems one
+		Role string `db:"role" json:"role"`
+	}
+
+	// EscalationStep is a step of channel escalation policy, it is taken Delay minutes after
+	// standup time if some standups are still missing. Steps are taken in order of Position
+	EscalationStep struct {
+		ID        int64     `db:"id" json:"id"`
+		Created   time.Time `db:"created" json:"created"`
+		ChannelID string    `db:"channel_id" json:"channelId"`
+		Action    string    `db:"action" json:"action"`
+		Delay     int       `db:"delay_minutes" json:"delay"`
+		Position  int       `db:"position" json:"position"`
+	}
+
+	// ProjectLink maps channel to a project of metrics provider, e.g. GitLab project path.
+	// A channel may be linked to several projects
+	ProjectLink struct {
+		ID        int64     `db:"id" json:"id"`
+		Created   time.Time `db:"created" json:"created"`
+		ChannelID string    `db:"channel_id" json:"channelId"`
+		Provider  string    `db:"provider" json:"provider"`
+		Project   string    `db:"project" json:"project"`
+	}
+
+	// UserLink maps standuper to username in metrics provider
+	UserLink struct {
+		ID          int64     `db:"id" json:"id"`
+		Created     time.Time `db:"created" json:"created"`
+		SlackUserID string    `db:"slack_user_id" json:"slack_user_id"`
+		Provider    string    `db:"provider" json:"provider"`
+		Username    string    `db:"username" json:"username"`
+	}
+
+	// APIToken grants access to JSON API, only SHA-256 hash of the token is stored
+	APIToken struct {
+		ID        int64     `db:"id" json:"id"`
+		Created   time.Time `db:"created" json:"created"`
+		Name      string    `db:"name" json:"name"`
+		TokenHash string    `db:"token_hash" json:"-"`
+	}
+
+	// Webhook subscribes URL to events, payloads are signed with Secret.
+	// Events are separated with commas, webhook without events receives all of them
+	Webhook struct {
+		ID      int64     `db:"id" json:"id"`
+		Created time.Time `db:"created" json:"created"`
+		URL     string    `db:"url" json:"url"`
+		Secret  string    `db:"secret" json:"-"`
+		Events  string    `db:"events" json:"events"`
+	}
+
+	// DeadLetter is an event which webhook did not accept after all attempts
+	DeadLetter struct {
+		ID        int64     `db:"id" json:"id"`
+		Created   time.Time `db:"created" json:"created"`
+		WebhookID int64     `db:"webhook_id" json:"webhookId"`
+		Event     string    `db:"event" json:"event"`
+		Payload   string    `db:"payload" json:"payload"`
+		Attempts  int       `db:"attempts" json:"attempts"`
+		Error     string    `db:"error" json:"error"`
 	}
 
 	// StandupEditHistory model used for serialization/deserialization stored standup edit history
@@ -58,6 +216,34 @@ func (c Standup) Validate() error {
 	return nil
 }
 
+// Value stores answers as JSON
+func (a Answers) Value() (driver.Value, error) {
+	if len(a) == 0 {
+		return "", nil
+	}
+	b, err := json.Marshal(a)
+	return string(b), err
+}
+
+// Scan reads answers stored as JSON, empty value means no answers
+func (a *Answers) Scan(src interface{}) error {
+	var b []byte
+	switch v := src.(type) {
+	case nil:
+	case []byte:
+		b = v
+	case string:
+		b = []byte(v)
+	default:
+		return fmt.Errorf("cannot scan %T into Answers", src)
+	}
+	if len(b) == 0 {
+		*a = nil
+		return nil
+	}
+	return json.Unmarshal(b, a)
+}
+
 // Validate validates StandupUser struct
 func (c StandupUser) Validate() error {
 	if c.SlackName == "" && c.SlackUserID == "" {
@@ -73,6 +259,80 @@ func (c StandupTime) Validate() error {
 		err := errors.New("Time cannot be empty")
 		return err
 	}
+	if c.Timezone != "" {
+		if _, err := loadLocation(c.Timezone); err != nil {
+			return fmt.Errorf("Unknown time zone %v", c.Timezone)
+		}
+	}
+	return nil
+}
+
+// Location returns time zone of standup time, standup times without
+// a time zone are in server local time
+func (c StandupTime) Location() *time.Location {
+	if c.Timezone == "" {
+		return time.Local
+	}
+	loc, err := loadLocation(c.Timezone)
+	if err != nil {
+		logrus.Warningf("model: time zone %v of channel %v is unknown, server local time is used: %v\n", c.Timezone, c.ChannelID, err)
+		return time.Local
+	}
+	return loc
+}
+
+// locations caches time zones by name, loading one reads the zone database
+var locations sync.Map
+
+func loadLocation(name string) (*time.Location, error) {
+	if loc, ok := locations.Load(name); ok {
+		return loc.(*time.Location), nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, err
+	}
+	locations.Store(name, loc)
+	return loc, nil
+}
+
+// Validate validates Holiday struct
+func (c Holiday) Validate() error {
+	if c.ChannelID == "" || c.Date == "" {
+		err := errors.New("Holiday cannot be empty")
+		return err
+	}
+	return nil
+}
+
+// Validate validates Absence struct
+func (c Absence) Validate() error {
+	if c.SlackUserID == "" || c.DateFrom == "" || c.DateTo == "" {
+		err := errors.New("Absence cannot be empty")
+		return err
+	}
+	if c.DateTo < c.DateFrom {
+		err := errors.New("Absence cannot end before it starts")
+		return err
+	}
+	return nil
+}
+
+// Validate validates ReminderRun struct
+func (c ReminderRun) Validate() error {
+	if c.ChannelID == "" || c.Date == "" || c.Stage == "" {
+		err := errors.New("Reminder run cannot be empty")
+		return err
+	}
+	return nil
+}
+
+// Validate validates StandupQuestion struct
+func (c StandupQuestion) Validate() error {
+	if c.ChannelID == "" || c.Name == "" || c.Pattern == "" {
+		err := errors.New("Question cannot be empty")
+		return err
+	}
 	return nil
 }
 
@@ -84,3 +344,63 @@ func (c StandupEditHistory) Validate() error {
 	}
 	return nil
 }
+
+// Validate validates EscalationStep struct
+func (c EscalationStep) Validate() error {
+	if c.ChannelID == "" || c.Action == "" {
+		err := errors.New("Escalation step cannot be empty")
+		return err
+	}
+	switch c.Action {
+	case EscalateUsers, EscalateChannel, EscalateAdmins, EscalateGeneral:
+	default:
+		err := errors.New("Unknown escalation action " + c.Action)
+		return err
+	}
+	return nil
+}
+
+// Validate validates ProjectLink struct
+func (c ProjectLink) Validate() error {
+	if c.ChannelID == "" || c.Provider == "" || c.Project == "" {
+		err := errors.New("Project link cannot be empty")
+		return err
+	}
+	return nil
+}
+
+// Validate validates UserLink struct
+func (c UserLink) Validate() error {
+	if c.SlackUserID == "" || c.Provider == "" || c.Username == "" {
+		err := errors.New("User link cannot be empty")
+		return err
+	}
+	return nil
+}
+
+// Validate validates APIToken struct
+func (c APIToken) Validate() error {
+	if c.Name == "" || c.TokenHash == "" {
+		err := errors.New("Token name and hash cannot be empty")
+		return err
+	}
+	return nil
+}
+
+// Validate validates Webhook struct
+func (c Webhook) Validate() error {
+	if c.URL == "" || c.Secret == "" {
+		err := errors.New("Webhook URL and secret cannot be empty")
+		return err
+	}
+	return nil
+}
+
+// Validate validates DeadLetter struct
+func (c DeadLetter) Validate() error {
+	if c.WebhookID == 0 || c.Event == "" || c.Payload == "" {
+		err := errors.New("Dead letter cannot be empty")
+		return err
+	}
+	return nil
+}
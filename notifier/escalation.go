@@ -0,0 +1,115 @@
+package notifier
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/maddevsio/comedian/chat"
+	"github.com/maddevsio/comedian/model"
+	"github.com/sirupsen/logrus"
+)
+
+// escalate takes escalation steps of channel one after another instead of the default
+// warning and reminders. Every step is taken Delay minutes after the deadline if someone
+// still did not write a standup, the run is done as soon as all standups arrive. Steps
+// taken are counted in Repeats of the reminder run, so they are not repeated after a restart
+func (n *Notifier) escalate(st model.StandupTime, steps []model.EscalationStep, deadline, now time.Time) {
+	start := sameMinute(now, stepTime(steps[0], deadline))
+	run, ok := n.reminderRun(st, now, start)
+	if !ok {
+		return
+	}
+	for run.Stage != model.ReminderDone {
+		next := run
+		var nonReporters []model.StandupUser
+		if run.Repeats < len(steps) {
+			if now.Before(stepTime(steps[run.Repeats], deadline)) {
+				return
+			}
+			var err error
+			nonReporters, err = n.getCurrentDayNonReporters(st.ChannelID)
+			if err != nil {
+				return
+			}
+		}
+		if run.Repeats >= len(steps) || len(nonReporters) == 0 {
+			next.Stage = model.ReminderDone
+		} else {
+			next.Stage = model.ReminderEscalated
+			next.Repeats++
+			next.NextAt = now
+			if next.Repeats < len(steps) {
+				next.NextAt = stepTime(steps[next.Repeats], deadline)
+			}
+		}
+		advanced, err := n.DB.AdvanceReminderRun(run, next)
+		if err != nil {
+			logrus.Errorf("notifier: AdvanceReminderRun failed: %v\n", err)
+			return
+		}
+		if !advanced {
+			return
+		}
+		if next.Stage == model.ReminderEscalated {
+			// the first step is where standups become missed
+			if run.Repeats == 0 {
+				n.publishMissed(st.ChannelID, nonReporters)
+			}
+			n.takeEscalationStep(st, steps[run.Repeats], nonReporters)
+		}
+		run = next
+	}
+}
+
+// takeEscalationStep reminds non reporters of channel the way escalation step says
+func (n *Notifier) takeEscalationStep(st model.StandupTime, step model.EscalationStep, nonReporters []model.StandupUser) {
+	ch, err := n.Chats.Get(st.Platform)
+	if err != nil {
+		logrus.Errorf("notifier: Chats.Get failed: %v\n", err)
+		return
+	}
+	mentions := []string{}
+	for _, user := range nonReporters {
+		mentions = append(mentions, "<@"+user.SlackUserID+">")
+	}
+	text := fmt.Sprintf(n.Config.Translate.NotifyEscalation, st.ChannelID, strings.Join(mentions, ", "))
+	logrus.Infof("notifier: escalation step %v in %v, non reporters: %v\n", step.Action, st.ChannelID, nonReporters)
+
+	switch step.Action {
+	case model.EscalateUsers:
+		for _, user := range nonReporters {
+			err := ch.SendUserMessage(user.SlackUserID, fmt.Sprintf(n.Config.Translate.NotifyDirectMessage, user.SlackName, user.ChannelID))
+			if err != nil {
+				logrus.Errorf("notifier: SendUserMessage failed: %v\n", err)
+			}
+		}
+	case model.EscalateChannel:
+		n.SendRepeatedNotification(st.Platform, st.ChannelID, nonReporters)
+	case model.EscalateAdmins:
+		admins, err := n.DB.ListChannelAdmins(st.ChannelID)
+		if err != nil {
+			logrus.Errorf("notifier: ListChannelAdmins failed: %v\n", err)
+			return
+		}
+		for _, admin := range admins {
+			if err := ch.SendUserMessage(admin.SlackUserID, text); err != nil {
+				logrus.Errorf("notifier: SendUserMessage failed: %v\n", err)
+			}
+		}
+	case model.EscalateGeneral:
+		// general channel is a Slack channel
+		slack, err := n.Chats.Get(chat.PlatformSlack)
+		if err != nil {
+			logrus.Errorf("notifier: Chats.Get failed: %v\n", err)
+			return
+		}
+		if err := slack.SendMessage(n.Config.ChanGeneral, text); err != nil {
+			logrus.Errorf("notifier: SendMessage failed: %v\n", err)
+		}
+	}
+}
+
+func stepTime(step model.EscalationStep, deadline time.Time) time.Time {
+	return deadline.Add(time.Duration(step.Delay) * time.Minute)
+}
@@ -1,46 +1,54 @@
 package notifier
 
 import (
-	"encoding/json"
+	"context"
 	"errors"
 	"fmt"
-	"io/ioutil"
-	"net/http"
 	"strings"
 	"time"
 
-	"github.com/cenkalti/backoff"
 	"github.com/maddevsio/comedian/model"
-	"github.com/maddevsio/comedian/reporting"
 
 	"github.com/jasonlvhit/gocron"
+	"github.com/maddevsio/comedian/calendar"
 	"github.com/maddevsio/comedian/chat"
+	"github.com/maddevsio/comedian/collector"
 	"github.com/maddevsio/comedian/config"
+	"github.com/maddevsio/comedian/metrics"
+	"github.com/maddevsio/comedian/parser"
 	"github.com/maddevsio/comedian/storage"
+	"github.com/maddevsio/comedian/webhook"
 	"github.com/sirupsen/logrus"
 )
 
 // Notifier struct is used to notify users about upcoming or skipped standups
 type Notifier struct {
-	Chat   chat.Chat
-	DB     storage.Storage
-	Config config.Config
+	Chats     *chat.Registry
+	DB        storage.Storage
+	Config    config.Config
+	Collector collector.Client
+	// Webhooks receive missed standups and rooks
+	Webhooks *webhook.Dispatcher
+	// Leader tells if this replica runs notifier jobs, all replicas run them if it is nil
+	Leader Leader
 }
 
-// NewNotifier creates a new notifier
-func NewNotifier(c config.Config, chat chat.Chat) (*Notifier, error) {
-	conn, err := storage.NewMySQL(c)
-	if err != nil {
-		return nil, err
-	}
-	notifier := &Notifier{Chat: chat, DB: conn, Config: c}
+// Leader is implemented by leader.Elector
+type Leader interface {
+	IsLeader() bool
+}
+
+// NewNotifier creates a new notifier, messages are sent through chats registered for platforms of channels
+func NewNotifier(c config.Config, chats *chat.Registry, db storage.Storage) (*Notifier, error) {
+	notifier := &Notifier{Chats: chats, DB: db, Config: c, Collector: metrics.New(c, db), Webhooks: webhook.New(c, db)}
 	return notifier, nil
 }
 
-// Start starts all notifier treads
+// Start starts all notifier treads. Every replica schedules the jobs, but only the leader runs them
 func (n *Notifier) Start() error {
-	gocron.Every(1).Day().At(n.Config.ReportTime).Do(n.RevealRooks)
-	gocron.Every(60).Seconds().Do(n.NotifyChannels)
+	n.ifLeader(n.ResumeReminders)()
+	gocron.Every(1).Day().At(n.Config.ReportTime).Do(n.ifLeader(n.RevealRooks))
+	gocron.Every(60).Seconds().Do(n.ifLeader(n.NotifyChannels))
 	channel := gocron.Start()
 	for {
 		report := <-channel
@@ -48,34 +56,60 @@ func (n *Notifier) Start() error {
 	}
 }
 
-// RevealRooks displays data about rooks in channel general
-func (n *Notifier) RevealRooks() {
-	// check if today is not saturday or sunday. During these days no notificatoins!
-	if int(time.Now().Weekday()) == 6 || int(time.Now().Weekday()) == 0 {
-		logrus.Info("It is Weekend!!! Do not disturb!!!")
-		return
-	}
-	timeFrom := time.Now().AddDate(0, 0, -1)
-	// if today is monday, check 3 days of performance for user
-	if int(time.Now().Weekday()) == 1 {
-		timeFrom = time.Now().AddDate(0, 0, -3)
+// ifLeader wraps job to run it only while the replica is the leader
+func (n *Notifier) ifLeader(job func()) func() {
+	return func() {
+		if n.Leader != nil && !n.Leader.IsLeader() {
+			return
+		}
+		job()
 	}
+}
+
+// RevealRooks displays data about rooks in channel general. Users are checked
+// on working days of their channels since the previous working day
+func (n *Notifier) RevealRooks() {
 	allUsers, err := n.DB.ListAllStandupUsers()
 	if err != nil {
 		logrus.Errorf("notifier: n.GetCurrentDayNonReporters failed: %v\n", err)
 		return
 	}
+	calendars := map[string]calendar.Calendar{}
 	text := ""
 	for _, user := range allUsers {
+		// general channel is a Slack channel, so only Slack users are shown there
+		if user.Platform != "" && user.Platform != chat.PlatformSlack {
+			continue
+		}
+		cal, ok := calendars[user.ChannelID]
+		if !ok {
+			cal = n.calendar(user.ChannelID)
+			calendars[user.ChannelID] = cal
+		}
+		if !cal.IsWorkday(time.Now()) {
+			continue
+		}
+		// after weekends and holidays all days since the last working day are checked
+		timeFrom := cal.PreviousWorkday(time.Now())
+		// users are excused if they were on leave on the reported working day
+		dayStart := time.Date(timeFrom.Year(), timeFrom.Month(), timeFrom.Day(), 0, 0, 0, 0, timeFrom.Location())
+		absent, err := n.DB.IsAbsent(user.SlackUserID, dayStart, dayStart.AddDate(0, 0, 1))
+		if err != nil {
+			logrus.Errorf("notifier: IsAbsent failed: %v\n", err)
+			continue
+		}
+		if absent {
+			continue
+		}
 		worklogs, commits, err := n.getCollectorData(user, timeFrom, time.Now())
 		if err != nil {
 			logrus.Errorf("notifier: getCollectorData failed: %v\n", err)
-			return
+			continue
 		}
 		isNonReporter, err := n.DB.IsNonReporter(user.SlackUserID, user.ChannelID, timeFrom, time.Now())
 		if err != nil {
 			logrus.Errorf("notifier: IsNonReporter failed: %v\n", err)
-			return
+			continue
 		}
 
 		if (worklogs < 8) || (commits == 0) || (isNonReporter == true) {
@@ -97,39 +131,55 @@ func (n *Notifier) RevealRooks() {
 			}
 
 			text += fmt.Sprintf(n.Config.Translate.IsRook, user.SlackUserID, user.ChannelID, fails)
+			n.Webhooks.Publish(webhook.RookReported, webhook.Rook{
+				UserID:    user.SlackUserID,
+				ChannelID: user.ChannelID,
+				Worklogs:  worklogs,
+				Commits:   commits,
+				Standup:   !isNonReporter,
+			})
 		}
 	}
 
-	n.Chat.SendMessage(n.Config.ChanGeneral, text)
+	if text == "" {
+		return
+	}
+	slack, err := n.Chats.Get(chat.PlatformSlack)
+	if err != nil {
+		logrus.Errorf("notifier: Chats.Get failed: %v\n", err)
+		return
+	}
+	slack.SendMessage(n.Config.ChanGeneral, text)
 
 }
 
-// NotifyChannels reminds users of channels about upcoming or missing standups
+// NotifyChannels reminds users of channels about upcoming or missing standups.
+// Standup times are checked in time zones of channels, days off and holidays are skipped.
+// Channels with a schedule are reminded only on days and at times of the schedule
 func (n *Notifier) NotifyChannels() {
-	if int(time.Now().Weekday()) == 6 || int(time.Now().Weekday()) == 0 {
-		logrus.Info("It is Weekend!!! No standups!!!")
-		return
-	}
 	standupTimes, err := n.DB.ListAllStandupTime()
 	if err != nil {
 		logrus.Errorf("notifier: ListAllStandupTime failed: %v\n", err)
 		return
 	}
-	// For each standup time, if standup time is now, start reminder
 	for _, st := range standupTimes {
-		standupTime := time.Unix(st.Time, 0)
-		warningTime := time.Unix(st.Time-n.Config.ReminderTime*60, 0)
-		if time.Now().Hour() == warningTime.Hour() && time.Now().Minute() == warningTime.Minute() {
-			n.SendWarning(st.ChannelID)
-		}
-		if time.Now().Hour() == standupTime.Hour() && time.Now().Minute() == standupTime.Minute() {
-			go n.SendChannelNotification(st.ChannelID)
+		now := time.Now().In(st.Location())
+		deadline, ok := n.calendar(st.ChannelID).Standup(now)
+		if !ok {
+			logrus.Infof("notifier: it is a day off in %v, no standups\n", st.ChannelID)
+			continue
 		}
+		n.remind(st, deadline, now)
 	}
 }
 
 // SendWarning reminds users in chat about upcoming standups
-func (n *Notifier) SendWarning(channelID string) {
+func (n *Notifier) SendWarning(platform, channelID string) {
+	ch, err := n.Chats.Get(platform)
+	if err != nil {
+		logrus.Errorf("notifier: Chats.Get failed: %v\n", err)
+		return
+	}
 	nonReporters, err := n.getCurrentDayNonReporters(channelID)
 	if err != nil {
 		logrus.Errorf("notifier: n.getCurrentDayNonReporters failed: %v\n", err)
@@ -143,16 +193,22 @@ func (n *Notifier) SendWarning(channelID string) {
 	for _, user := range nonReporters {
 		nonReportersIDs = append(nonReportersIDs, "<@"+user.SlackUserID+">")
 	}
-	err = n.Chat.SendMessage(channelID, fmt.Sprintf(n.Config.Translate.NotifyUsersWarning, strings.Join(nonReportersIDs, ", "), n.Config.ReminderTime))
+	err = ch.SendMessage(channelID, fmt.Sprintf(n.Config.Translate.NotifyUsersWarning, strings.Join(nonReportersIDs, ", "), n.Config.ReminderTime))
 	if err != nil {
-		logrus.Errorf("notifier: n.Chat.SendMessage failed: %v\n", err)
+		logrus.Errorf("notifier: SendMessage failed: %v\n", err)
 		return
 	}
 
 }
 
-//SendChannelNotification starts standup reminders and direct reminders to users
-func (n *Notifier) SendChannelNotification(channelID string) {
+//SendChannelNotification sends direct reminders to users who missed the deadline
+func (n *Notifier) SendChannelNotification(platform, channelID string) {
+	ch, err := n.Chats.Get(platform)
+	if err != nil {
+		logrus.Errorf("notifier: Chats.Get failed: %v\n", err)
+		return
+	}
+	n.notifyEmptyToday(ch, channelID)
 	nonReporters, err := n.getCurrentDayNonReporters(channelID)
 	if err != nil {
 		logrus.Errorf("notifier: n.getCurrentDayNonReporters failed: %v\n", err)
@@ -160,58 +216,88 @@ func (n *Notifier) SendChannelNotification(channelID string) {
 	}
 	// if everyone wrote their standups display all done message!
 	if len(nonReporters) == 0 {
-		err := n.Chat.SendMessage(channelID, n.Config.Translate.NotifyAllDone)
+		err := ch.SendMessage(channelID, n.Config.Translate.NotifyAllDone)
 		if err != nil {
 			logrus.Errorf("notifier: SendMessage failed: %v\n", err)
 		}
 		return
 	}
 
+	n.publishMissed(channelID, nonReporters)
 	// othervise Direct Message non reporters
 	for _, nonReporter := range nonReporters {
-		err := n.Chat.SendUserMessage(nonReporter.SlackUserID, fmt.Sprintf(n.Config.Translate.NotifyDirectMessage, nonReporter.SlackName, nonReporter.ChannelID))
+		err := ch.SendUserMessage(nonReporter.SlackUserID, fmt.Sprintf(n.Config.Translate.NotifyDirectMessage, nonReporter.SlackName, nonReporter.ChannelID))
 		if err != nil {
 			logrus.Errorf("notifier: SendMessage failed: %v\n", err)
 		}
 	}
+}
 
-	repeats := 0
-
-	// think about his code and refactor more!
-	notifyNotAll := func() error {
-		nonReporters, err := n.getCurrentDayNonReporters(channelID)
-		if err != nil {
-			logrus.Errorf("notifier: n.getCurrentDayNonReporters failed: %v\n", err)
-			return err
-		}
+// SendRepeatedNotification reminds users in channel who still did not write standups
+func (n *Notifier) SendRepeatedNotification(platform, channelID string, nonReporters []model.StandupUser) {
+	ch, err := n.Chats.Get(platform)
+	if err != nil {
+		logrus.Errorf("notifier: Chats.Get failed: %v\n", err)
+		return
+	}
+	nonReportersSlackIDs := []string{}
+	for _, nonReporter := range nonReporters {
+		nonReportersSlackIDs = append(nonReportersSlackIDs, fmt.Sprintf("<@%v>", nonReporter.SlackUserID))
+	}
+	logrus.Infof("notifier: Notifier non reporters: %v", nonReporters)
+	err = ch.SendMessage(channelID, fmt.Sprintf(n.Config.Translate.NotifyNotAll, strings.Join(nonReportersSlackIDs, ", ")))
+	if err != nil {
+		logrus.Errorf("notifier: SendMessage failed: %v\n", err)
+	}
+}
 
-		nonReportersSlackIDs := []string{}
-		for _, nonReporter := range nonReporters {
-			nonReportersSlackIDs = append(nonReportersSlackIDs, fmt.Sprintf("<@%v>", nonReporter.SlackUserID))
-		}
-		logrus.Infof("notifier: Notifier non reporters: %v", nonReporters)
+// publishMissed publishes standup.missed events of users who did not write standups by the deadline
+func (n *Notifier) publishMissed(channelID string, nonReporters []model.StandupUser) {
+	date := n.dayStart(channelID).Format(calendar.DateFormat)
+	for _, user := range nonReporters {
+		n.Webhooks.Publish(webhook.StandupMissed, webhook.Missed{
+			UserID:    user.SlackUserID,
+			UserName:  user.SlackName,
+			ChannelID: user.ChannelID,
+			Channel:   user.Channel,
+			Platform:  user.Platform,
+			Date:      date,
+		})
+	}
+}
 
-		if repeats < n.Config.ReminderRepeatsMax && len(nonReporters) > 0 {
-			n.Chat.SendMessage(channelID, fmt.Sprintf(n.Config.Translate.NotifyNotAll, strings.Join(nonReportersSlackIDs, ", ")))
-			repeats++
-			err := errors.New("Continue backoff")
-			return err
+// notifyEmptyToday asks users whose today standups have no plans for today to add them.
+// Channels whose template has no question about today plans are not checked
+func (n *Notifier) notifyEmptyToday(ch chat.Chat, channelID string) {
+	if !parser.HasRole(chat.ChannelQuestions(n.DB, n.Config.Translate, channelID), parser.Today) {
+		return
+	}
+	standups, err := n.DB.SelectStandupsByChannelIDForPeriod(channelID, n.dayStart(channelID), time.Now())
+	if err != nil {
+		logrus.Errorf("notifier: SelectStandupsByChannelIDForPeriod failed: %v\n", err)
+		return
+	}
+	users := []string{}
+	seen := map[string]bool{}
+	for _, standup := range standups {
+		if standup.Today != "" || seen[standup.UsernameID] {
+			continue
 		}
-		logrus.Info("Stop backoff")
-		return nil
+		seen[standup.UsernameID] = true
+		users = append(users, "<@"+standup.UsernameID+">")
 	}
-
-	b := backoff.NewConstantBackOff(time.Duration(n.Config.NotifierInterval) * time.Minute)
-	err = backoff.Retry(notifyNotAll, b)
+	if len(users) == 0 {
+		return
+	}
+	err = ch.SendMessage(channelID, fmt.Sprintf(n.Config.Translate.NotifyEmptyToday, strings.Join(users, ", ")))
 	if err != nil {
-		logrus.Errorf("notifier: backoff.Retry failed: %v\n", err)
+		logrus.Errorf("notifier: SendMessage failed: %v\n", err)
 	}
 }
 
 // getNonReporters returns a list of standupers that did not write standups
 func (n *Notifier) getCurrentDayNonReporters(channelID string) ([]model.StandupUser, error) {
-	timeFrom := time.Date(time.Now().Year(), time.Now().Month(), time.Now().Day(), 0, 0, 0, 0, time.UTC)
-	nonReporters, err := n.DB.GetNonReporters(channelID, timeFrom, time.Now())
+	nonReporters, err := n.DB.GetNonReporters(channelID, n.dayStart(channelID), time.Now())
 	if err != nil && err != errors.New("no rows in result set") {
 		logrus.Errorf("notifier: GetNonReporters failed: %v\n", err)
 		return nil, err
@@ -219,30 +305,36 @@ func (n *Notifier) getCurrentDayNonReporters(channelID string) ([]model.StandupU
 	return nonReporters, nil
 }
 
-func (n *Notifier) getCollectorData(user model.StandupUser, timeFrom, timeTo time.Time) (int, int, error) {
-	date := fmt.Sprintf("%d-%02d-%02d", timeTo.Year(), timeTo.Month(), timeTo.Day())
-	linkURL := fmt.Sprintf("%s/rest/api/v1/logger/%s/%s/%s/%s", n.Config.CollectorURL, "users", user.SlackUserID, date, date)
-
-	req, err := http.NewRequest("GET", linkURL, nil)
+// calendar returns working days of channel, channels without standup time
+// work from Monday to Friday in UTC
+func (n *Notifier) calendar(channelID string) calendar.Calendar {
+	st, err := n.DB.GetChannelStandupTime(channelID)
 	if err != nil {
-		logrus.Errorf("notifier: Get Request failed: %v\n", err)
-		return 0, 0, err
+		st = model.StandupTime{Timezone: "UTC"}
 	}
-	token := n.Config.CollectorToken
-	req.Header.Add("Authorization", fmt.Sprintf("Token %s", token))
-	res, err := http.DefaultClient.Do(req)
+	holidays, err := n.DB.ListHolidays(channelID)
 	if err != nil {
-		logrus.Errorf("notifier: Authorization failed: %v\n", err)
-		return 0, 0, err
+		logrus.Errorf("notifier: ListHolidays failed: %v\n", err)
+	}
+	return calendar.New(st, holidays)
+}
+
+// dayStart returns beginning of current day in time zone of channel standup time,
+// days of channels without standup time begin at UTC midnight
+func (n *Notifier) dayStart(channelID string) time.Time {
+	loc := time.UTC
+	if st, err := n.DB.GetChannelStandupTime(channelID); err == nil {
+		loc = st.Location()
 	}
-	defer res.Body.Close()
-	body, err := ioutil.ReadAll(res.Body)
+	now := time.Now().In(loc)
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+}
+
+// getCollectorData returns hours logged by user and number of commits on the day of timeTo
+func (n *Notifier) getCollectorData(user model.StandupUser, timeFrom, timeTo time.Time) (int, int, error) {
+	data, err := n.Collector.UserData(context.Background(), user.SlackUserID, timeTo, timeTo)
 	if err != nil {
-		logrus.Errorf("notifier: ioutil.ReadAll failed: %v\n", err)
 		return 0, 0, err
 	}
-	var collectorData reporting.CollectorData
-	json.Unmarshal(body, &collectorData)
-
-	return collectorData.Worklogs / 3600, collectorData.TotalCommits, nil
+	return data.Worklogs / 3600, data.TotalCommits, nil
 }
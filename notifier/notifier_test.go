@@ -1,19 +1,29 @@
 package notifier
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
 	"github.com/bouk/monkey"
+	"github.com/maddevsio/comedian/chat"
+	"github.com/maddevsio/comedian/collector"
 	"github.com/maddevsio/comedian/config"
 	"github.com/maddevsio/comedian/model"
+	"github.com/maddevsio/comedian/storage"
+	"github.com/maddevsio/comedian/webhook"
 	"github.com/stretchr/testify/assert"
 	httpmock "gopkg.in/jarcoal/httpmock.v1"
 )
 
 type ChatStub struct {
 	LastMessage string
+	Messages    []string
 }
 
 func (c *ChatStub) Run() error {
@@ -22,11 +32,13 @@ func (c *ChatStub) Run() error {
 
 func (c *ChatStub) SendMessage(chatID, message string) error {
 	c.LastMessage = fmt.Sprintf("CHAT: %s, MESSAGE: %s", chatID, message)
+	c.Messages = append(c.Messages, c.LastMessage)
 	return nil
 }
 
 func (c *ChatStub) SendUserMessage(userID, message string) error {
 	c.LastMessage = fmt.Sprintf("CHAT: %s, MESSAGE: %s", userID, message)
+	c.Messages = append(c.Messages, c.LastMessage)
 	return nil
 }
 
@@ -37,7 +49,11 @@ func TestNotifier(t *testing.T) {
 	c.NotifierInterval = 0
 	assert.NoError(t, err)
 	ch := &ChatStub{}
-	n, err := NewNotifier(c, ch)
+	db := storage.NewMemory()
+	assert.NoError(t, err)
+	chats := chat.NewRegistry()
+	chats.Register(chat.PlatformSlack, ch)
+	n, err := NewNotifier(c, chats, db)
 	assert.NoError(t, err)
 
 	channelID := "QWERTY123"
@@ -68,10 +84,10 @@ func TestNotifier(t *testing.T) {
 	assert.NotEmpty(t, nonReporters)
 	assert.Equal(t, 2, len(nonReporters))
 
-	n.SendWarning(channelID)
+	n.SendWarning(chat.PlatformSlack, channelID)
 	assert.Equal(t, "CHAT: QWERTY123, MESSAGE: Hey, <@userID1>, <@userID2>! 0 minutes to deadline and the team is still waiting for standups from you!", ch.LastMessage)
 
-	n.SendChannelNotification(channelID)
+	n.SendChannelNotification(chat.PlatformSlack, channelID)
 	assert.Equal(t, "CHAT: userID2, MESSAGE: Hello, <@user2>! You missed the standup deadline in <#QWERTY123> channel. Please, write you standup ASAP!", ch.LastMessage)
 
 	n.NotifyChannels()
@@ -109,7 +125,7 @@ func TestNotifier(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Empty(t, nonReporters)
 
-	n.SendChannelNotification(channelID)
+	n.SendChannelNotification(chat.PlatformSlack, channelID)
 	assert.Equal(t, "CHAT: QWERTY123, MESSAGE: Congradulations! Everybody wrote their standups today!", ch.LastMessage)
 
 	assert.NoError(t, n.DB.DeleteStandupUser(su.SlackName, su.ChannelID))
@@ -124,7 +140,11 @@ func TestCheckUser(t *testing.T) {
 	c.ChanGeneral = "XXXYYYZZZ"
 	assert.NoError(t, err)
 	ch := &ChatStub{}
-	n, err := NewNotifier(c, ch)
+	db := storage.NewMemory()
+	assert.NoError(t, err)
+	chats := chat.NewRegistry()
+	chats.Register(chat.PlatformSlack, ch)
+	n, err := NewNotifier(c, chats, db)
 	assert.NoError(t, err)
 
 	users, err := n.DB.ListAllStandupUsers()
@@ -225,3 +245,495 @@ func TestCheckUser(t *testing.T) {
 
 	assert.NoError(t, n.DB.DeleteStandupTime(st.ChannelID))
 }
+
+func TestNotifierPlatforms(t *testing.T) {
+	c, err := config.Get()
+	assert.NoError(t, err)
+	c.ReminderRepeatsMax = 0
+	db := storage.NewMemory()
+	slack, telegram := &ChatStub{}, &ChatStub{}
+	chats := chat.NewRegistry()
+	chats.Register(chat.PlatformSlack, slack)
+	chats.Register(chat.PlatformTelegram, telegram)
+	n, err := NewNotifier(c, chats, db)
+	assert.NoError(t, err)
+
+	d := time.Date(2018, 1, 2, 10, 0, 0, 0, time.UTC)
+	monkey.Patch(time.Now, func() time.Time { return d })
+	defer monkey.Unpatch(time.Now)
+
+	_, err = db.CreateStandupUser(model.StandupUser{SlackUserID: "42", SlackName: "ivan", ChannelID: "-100", Role: "user", Platform: chat.PlatformTelegram})
+	assert.NoError(t, err)
+	_, err = db.CreateStandupTime(model.StandupTime{ChannelID: "-100", Channel: "team", Time: d.Unix(), Platform: chat.PlatformTelegram})
+	assert.NoError(t, err)
+
+	n.SendWarning(chat.PlatformTelegram, "-100")
+	assert.Equal(t, "CHAT: -100, MESSAGE: Hey, <@42>! 5 minutes to deadline and the team is still waiting for standups from you!", telegram.LastMessage)
+	assert.Equal(t, "", slack.LastMessage)
+
+	n.SendChannelNotification(chat.PlatformTelegram, "-100")
+	assert.Equal(t, "CHAT: 42, MESSAGE: Hello, <@ivan>! You missed the standup deadline in <#-100> channel. Please, write you standup ASAP!", telegram.LastMessage)
+	assert.Equal(t, "", slack.LastMessage)
+
+	// nothing is sent for platforms which are not registered
+	telegram.LastMessage = ""
+	n.SendWarning(chat.PlatformMattermost, "-100")
+	assert.Equal(t, "", telegram.LastMessage)
+	assert.Equal(t, "", slack.LastMessage)
+}
+
+func TestNotifyEmptyToday(t *testing.T) {
+	c, err := config.Get()
+	assert.NoError(t, err)
+	db := storage.NewMemory()
+	slack := &ChatStub{}
+	chats := chat.NewRegistry()
+	chats.Register(chat.PlatformSlack, slack)
+	n, err := NewNotifier(c, chats, db)
+	assert.NoError(t, err)
+
+	d := time.Date(2018, 1, 2, 10, 0, 0, 0, time.UTC)
+	monkey.Patch(time.Now, func() time.Time { return d })
+	defer monkey.Unpatch(time.Now)
+
+	_, err = db.CreateStandup(model.Standup{ChannelID: "CHAN1", UsernameID: "user1", Comment: "standup", MessageTS: "1", Yesterday: "tests", Problems: "no"})
+	assert.NoError(t, err)
+	_, err = db.CreateStandup(model.Standup{ChannelID: "CHAN1", UsernameID: "user2", Comment: "standup", MessageTS: "2", Yesterday: "tests", Today: "review", Problems: "no"})
+	assert.NoError(t, err)
+
+	n.notifyEmptyToday(slack, "CHAN1")
+	assert.Equal(t, "CHAT: CHAN1, MESSAGE: <@user1>, your standups do not say what you are going to do today. Please, add your plans!", slack.LastMessage)
+
+	slack.LastMessage = ""
+	n.notifyEmptyToday(slack, "CHAN2")
+	assert.Equal(t, "", slack.LastMessage)
+
+	// template without a question about today plans
+	_, err = db.CreateStandupQuestion(model.StandupQuestion{ChannelID: "CHAN1", Name: "done", Pattern: "done"})
+	assert.NoError(t, err)
+	n.notifyEmptyToday(slack, "CHAN1")
+	assert.Equal(t, "", slack.LastMessage)
+
+	_, err = db.CreateStandupQuestion(model.StandupQuestion{ChannelID: "CHAN1", Name: "next", Pattern: "next", Position: 1, Role: "today"})
+	assert.NoError(t, err)
+	n.notifyEmptyToday(slack, "CHAN1")
+	assert.Equal(t, "CHAT: CHAN1, MESSAGE: <@user1>, your standups do not say what you are going to do today. Please, add your plans!", slack.LastMessage)
+}
+
+func TestNotifyChannelsTimezone(t *testing.T) {
+	c, err := config.Get()
+	assert.NoError(t, err)
+	c.ReminderTime = 10
+	db := storage.NewMemory()
+	slack := &ChatStub{}
+	chats := chat.NewRegistry()
+	chats.Register(chat.PlatformSlack, slack)
+	n, err := NewNotifier(c, chats, db)
+	assert.NoError(t, err)
+
+	// standup is at 09:30 in Berlin and in New York
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	assert.NoError(t, err)
+	newYork, err := time.LoadLocation("America/New_York")
+	assert.NoError(t, err)
+	_, err = db.CreateStandupTime(model.StandupTime{ChannelID: "BERLIN", Time: time.Date(2018, 1, 2, 9, 30, 0, 0, berlin).Unix(), Timezone: "Europe/Berlin"})
+	assert.NoError(t, err)
+	_, err = db.CreateStandupTime(model.StandupTime{ChannelID: "NEWYORK", Time: time.Date(2018, 1, 2, 9, 30, 0, 0, newYork).Unix(), Timezone: "America/New_York"})
+	assert.NoError(t, err)
+	for _, channelID := range []string{"BERLIN", "NEWYORK"} {
+		_, err = db.CreateStandupUser(model.StandupUser{SlackUserID: "user" + channelID, SlackName: "user", ChannelID: channelID, Role: "user"})
+		assert.NoError(t, err)
+	}
+
+	// 09:20 in Berlin, warning goes to Berlin channel only
+	d := time.Date(2018, 1, 3, 8, 20, 0, 0, time.UTC)
+	monkey.Patch(time.Now, func() time.Time { return d })
+	defer monkey.Unpatch(time.Now)
+	n.NotifyChannels()
+	assert.Equal(t, "CHAT: BERLIN, MESSAGE: Hey, <@userBERLIN>! 10 minutes to deadline and the team is still waiting for standups from you!", slack.LastMessage)
+
+	// 09:20 in New York
+	slack.LastMessage = ""
+	d = time.Date(2018, 1, 3, 14, 20, 0, 0, time.UTC)
+	n.NotifyChannels()
+	assert.Equal(t, "CHAT: NEWYORK, MESSAGE: Hey, <@userNEWYORK>! 10 minutes to deadline and the team is still waiting for standups from you!", slack.LastMessage)
+
+	// Saturday 09:20 in Berlin
+	slack.LastMessage = ""
+	d = time.Date(2018, 1, 6, 8, 20, 0, 0, time.UTC)
+	n.NotifyChannels()
+	assert.Equal(t, "", slack.LastMessage)
+}
+
+func TestNotifyChannelsHolidays(t *testing.T) {
+	c, err := config.Get()
+	assert.NoError(t, err)
+	c.ReminderTime = 10
+	db := storage.NewMemory()
+	slack := &ChatStub{}
+	chats := chat.NewRegistry()
+	chats.Register(chat.PlatformSlack, slack)
+	n, err := NewNotifier(c, chats, db)
+	assert.NoError(t, err)
+
+	// the channel works from Sunday to Thursday, New Year is a holiday
+	_, err = db.CreateStandupTime(model.StandupTime{ChannelID: "HOLIDAYS", Time: time.Date(2018, 1, 2, 9, 30, 0, 0, time.UTC).Unix(), Timezone: "UTC", WorkDays: "sun-thu"})
+	assert.NoError(t, err)
+	_, err = db.CreateHoliday(model.Holiday{ChannelID: "HOLIDAYS", Date: "2018-01-01", Name: "New Year"})
+	assert.NoError(t, err)
+	_, err = db.CreateStandupUser(model.StandupUser{SlackUserID: "userHOLIDAYS", SlackName: "user", ChannelID: "HOLIDAYS", Role: "user"})
+	assert.NoError(t, err)
+
+	d := time.Date(2018, 1, 1, 9, 20, 0, 0, time.UTC)
+	monkey.Patch(time.Now, func() time.Time { return d })
+	defer monkey.Unpatch(time.Now)
+	n.NotifyChannels()
+	assert.Equal(t, "", slack.LastMessage)
+
+	// Friday is a day off
+	d = time.Date(2018, 1, 5, 9, 20, 0, 0, time.UTC)
+	n.NotifyChannels()
+	assert.Equal(t, "", slack.LastMessage)
+
+	// Sunday is a working day
+	d = time.Date(2018, 1, 7, 9, 20, 0, 0, time.UTC)
+	n.NotifyChannels()
+	assert.Equal(t, "CHAT: HOLIDAYS, MESSAGE: Hey, <@userHOLIDAYS>! 10 minutes to deadline and the team is still waiting for standups from you!", slack.LastMessage)
+
+	// Tuesday after the holiday is checked since Sunday
+	cal := n.calendar("HOLIDAYS")
+	assert.Equal(t, time.Date(2017, 12, 31, 9, 0, 0, 0, time.UTC), cal.PreviousWorkday(time.Date(2018, 1, 2, 9, 0, 0, 0, time.UTC)))
+}
+
+func TestNotifyChannelsSchedule(t *testing.T) {
+	c, err := config.Get()
+	assert.NoError(t, err)
+	c.ReminderTime = 10
+	db := storage.NewMemory()
+	slack := &ChatStub{}
+	chats := chat.NewRegistry()
+	chats.Register(chat.PlatformSlack, slack)
+	n, err := NewNotifier(c, chats, db)
+	assert.NoError(t, err)
+
+	// standups are on Monday, Wednesday and Friday at 16:00, standup time is ignored
+	_, err = db.CreateStandupTime(model.StandupTime{ChannelID: "SCHEDULE", Time: time.Date(2018, 1, 2, 9, 30, 0, 0, time.UTC).Unix(), Timezone: "UTC", Schedule: "0 16 * * MON,WED,FRI"})
+	assert.NoError(t, err)
+	_, err = db.CreateStandupUser(model.StandupUser{SlackUserID: "userSCHEDULE", SlackName: "user", ChannelID: "SCHEDULE", Role: "user"})
+	assert.NoError(t, err)
+
+	// Monday 9:20 is not a standup time
+	d := time.Date(2018, 1, 8, 9, 20, 0, 0, time.UTC)
+	monkey.Patch(time.Now, func() time.Time { return d })
+	defer monkey.Unpatch(time.Now)
+	n.NotifyChannels()
+	assert.Equal(t, "", slack.LastMessage)
+
+	// Tuesday has no standup
+	d = time.Date(2018, 1, 9, 15, 50, 0, 0, time.UTC)
+	n.NotifyChannels()
+	assert.Equal(t, "", slack.LastMessage)
+
+	d = time.Date(2018, 1, 10, 15, 50, 0, 0, time.UTC)
+	n.NotifyChannels()
+	assert.Equal(t, "CHAT: SCHEDULE, MESSAGE: Hey, <@userSCHEDULE>! 10 minutes to deadline and the team is still waiting for standups from you!", slack.LastMessage)
+
+	// Wednesday is checked since Monday standup
+	cal := n.calendar("SCHEDULE")
+	assert.Equal(t, time.Date(2018, 1, 8, 16, 0, 0, 0, time.UTC), cal.PreviousWorkday(time.Date(2018, 1, 10, 16, 0, 0, 0, time.UTC)).UTC())
+}
+
+func TestNotifyChannelsAbsence(t *testing.T) {
+	c, err := config.Get()
+	assert.NoError(t, err)
+	c.ReminderTime = 10
+	db := storage.NewMemory()
+	slack := &ChatStub{}
+	chats := chat.NewRegistry()
+	chats.Register(chat.PlatformSlack, slack)
+	n, err := NewNotifier(c, chats, db)
+	assert.NoError(t, err)
+
+	_, err = db.CreateStandupTime(model.StandupTime{ChannelID: "ABSENCE", Time: time.Date(2018, 7, 2, 9, 30, 0, 0, time.UTC).Unix(), Timezone: "UTC"})
+	assert.NoError(t, err)
+	for _, id := range []string{"user1", "user2"} {
+		_, err = db.CreateStandupUser(model.StandupUser{SlackUserID: id, SlackName: id, ChannelID: "ABSENCE", Role: "user"})
+		assert.NoError(t, err)
+	}
+	_, err = db.CreateAbsence(model.Absence{SlackUserID: "user2", DateFrom: "2018-07-09", DateTo: "2018-07-13"})
+	assert.NoError(t, err)
+
+	d := time.Date(2018, 7, 9, 9, 20, 0, 0, time.UTC)
+	monkey.Patch(time.Now, func() time.Time { return d })
+	defer monkey.Unpatch(time.Now)
+	n.NotifyChannels()
+	assert.Equal(t, "CHAT: ABSENCE, MESSAGE: Hey, <@user1>! 10 minutes to deadline and the team is still waiting for standups from you!", slack.LastMessage)
+
+	// user2 is back on Monday
+	d = time.Date(2018, 7, 16, 9, 20, 0, 0, time.UTC)
+	n.NotifyChannels()
+	assert.Equal(t, "CHAT: ABSENCE, MESSAGE: Hey, <@user1>, <@user2>! 10 minutes to deadline and the team is still waiting for standups from you!", slack.LastMessage)
+}
+
+// absenceFailure fails to check absence of one user
+type absenceFailure struct {
+	storage.Storage
+	userID string
+}
+
+func (s absenceFailure) IsAbsent(slackUserID string, dateFrom, dateTo time.Time) (bool, error) {
+	if slackUserID == s.userID {
+		return false, errors.New("connection lost")
+	}
+	return s.Storage.IsAbsent(slackUserID, dateFrom, dateTo)
+}
+
+func TestRevealRooksAbsence(t *testing.T) {
+	c, err := config.Get()
+	assert.NoError(t, err)
+	c.ChanGeneral = "GENERAL"
+	db := storage.NewMemory()
+	slack := &ChatStub{}
+	chats := chat.NewRegistry()
+	chats.Register(chat.PlatformSlack, slack)
+	n, err := NewNotifier(c, chats, absenceFailure{Storage: db, userID: "broken"})
+	assert.NoError(t, err)
+	n.Collector = CollectorStub{Worklogs: 8 * 3600, TotalCommits: 2}
+
+	for _, id := range []string{"leaving", "away", "back", "broken"} {
+		_, err = db.CreateStandupUser(model.StandupUser{SlackUserID: id, SlackName: id, ChannelID: "CHAN1", Role: "user"})
+		assert.NoError(t, err)
+	}
+	// Wednesday report checks Tuesday, leave starting today or ended before does not excuse
+	for _, a := range []model.Absence{
+		{SlackUserID: "leaving", DateFrom: "2018-07-11", DateTo: "2018-07-13"},
+		{SlackUserID: "away", DateFrom: "2018-07-10", DateTo: "2018-07-10"},
+		{SlackUserID: "back", DateFrom: "2018-07-05", DateTo: "2018-07-09"},
+	} {
+		_, err = db.CreateAbsence(a)
+		assert.NoError(t, err)
+	}
+
+	d := time.Date(2018, 7, 11, 10, 0, 0, 0, time.UTC)
+	monkey.Patch(time.Now, func() time.Time { return d })
+	defer monkey.Unpatch(time.Now)
+	n.RevealRooks()
+	assert.Equal(t, "CHAT: GENERAL, MESSAGE: <@leaving> is a rook in <#CHAN1>! (Has enough worklogs: 8, enough commits: 2, and did not write standup!!!)\n"+
+		"<@back> is a rook in <#CHAN1>! (Has enough worklogs: 8, enough commits: 2, and did not write standup!!!)\n", slack.LastMessage)
+}
+
+func TestReminderRunSurvivesRestart(t *testing.T) {
+	c, err := config.Get()
+	assert.NoError(t, err)
+	c.ReminderTime = 10
+	c.ReminderRepeatsMax = 2
+	c.NotifierInterval = 5
+	db := storage.NewMemory()
+	slack := &ChatStub{}
+	chats := chat.NewRegistry()
+	chats.Register(chat.PlatformSlack, slack)
+	n, err := NewNotifier(c, chats, db)
+	assert.NoError(t, err)
+
+	_, err = db.CreateStandupTime(model.StandupTime{ChannelID: "RESTART", Time: time.Date(2018, 7, 2, 9, 30, 0, 0, time.UTC).Unix(), Timezone: "UTC"})
+	assert.NoError(t, err)
+	_, err = db.CreateStandupUser(model.StandupUser{SlackUserID: "user1", SlackName: "user1", ChannelID: "RESTART", Role: "user"})
+	assert.NoError(t, err)
+
+	d := time.Date(2018, 7, 9, 9, 20, 0, 0, time.UTC)
+	monkey.Patch(time.Now, func() time.Time { return d })
+	defer monkey.Unpatch(time.Now)
+	n.NotifyChannels()
+	// a restarted notifier runs during the same minute
+	n, err = NewNotifier(c, chats, db)
+	assert.NoError(t, err)
+	n.ResumeReminders()
+	n.NotifyChannels()
+	assert.Equal(t, []string{
+		"CHAT: RESTART, MESSAGE: Hey, <@user1>! 10 minutes to deadline and the team is still waiting for standups from you!",
+	}, slack.Messages)
+
+	// the notifier was down at the deadline, direct message and the first repeat are sent after restart
+	slack.Messages = nil
+	d = time.Date(2018, 7, 9, 9, 32, 0, 0, time.UTC)
+	n.ResumeReminders()
+	n.NotifyChannels()
+	assert.Equal(t, []string{
+		"CHAT: user1, MESSAGE: Hello, <@user1>! You missed the standup deadline in <#RESTART> channel. Please, write you standup ASAP!",
+		"CHAT: RESTART, MESSAGE: In this channel not all standupers wrote standup today, shame on you: <@user1>.",
+	}, slack.Messages)
+
+	slack.Messages = nil
+	d = time.Date(2018, 7, 9, 9, 35, 0, 0, time.UTC)
+	n.NotifyChannels()
+	assert.Equal(t, 0, len(slack.Messages))
+	d = time.Date(2018, 7, 9, 9, 37, 0, 0, time.UTC)
+	n.NotifyChannels()
+	n.NotifyChannels()
+	assert.Equal(t, []string{
+		"CHAT: RESTART, MESSAGE: In this channel not all standupers wrote standup today, shame on you: <@user1>.",
+	}, slack.Messages)
+
+	// repeats are over
+	slack.Messages = nil
+	d = time.Date(2018, 7, 9, 9, 45, 0, 0, time.UTC)
+	n.NotifyChannels()
+	assert.Equal(t, 0, len(slack.Messages))
+	run, err := db.GetReminderRun("RESTART", "2018-07-09")
+	assert.NoError(t, err)
+	assert.Equal(t, model.ReminderDone, run.Stage)
+	assert.Equal(t, 2, run.Repeats)
+}
+
+func TestEscalationPolicy(t *testing.T) {
+	c, err := config.Get()
+	assert.NoError(t, err)
+	c.ChanGeneral = "GENERAL"
+	db := storage.NewMemory()
+	slack := &ChatStub{}
+	chats := chat.NewRegistry()
+	chats.Register(chat.PlatformSlack, slack)
+	n, err := NewNotifier(c, chats, db)
+	assert.NoError(t, err)
+
+	_, err = db.CreateStandupTime(model.StandupTime{ChannelID: "ESCALATE", Time: time.Date(2018, 7, 2, 9, 30, 0, 0, time.UTC).Unix(), Timezone: "UTC"})
+	assert.NoError(t, err)
+	for _, u := range []model.StandupUser{
+		{SlackUserID: "user1", SlackName: "user1", ChannelID: "ESCALATE", Role: "user"},
+		{SlackUserID: "user2", SlackName: "user2", ChannelID: "ESCALATE", Role: "user"},
+		{SlackUserID: "admin1", SlackName: "admin1", ChannelID: "ESCALATE", Role: "admin"},
+	} {
+		_, err = db.CreateStandupUser(u)
+		assert.NoError(t, err)
+	}
+	for i, s := range []model.EscalationStep{{Action: model.EscalateUsers, Delay: 0}, {Action: model.EscalateChannel, Delay: 30}, {Action: model.EscalateAdmins, Delay: 60}, {Action: model.EscalateGeneral, Delay: 120}} {
+		s.ChannelID, s.Position = "ESCALATE", i
+		_, err = db.CreateEscalationStep(s)
+		assert.NoError(t, err)
+	}
+
+	// no warning before the deadline, the policy starts with direct messages
+	d := time.Date(2018, 7, 9, 9, 20, 0, 0, time.UTC)
+	monkey.Patch(time.Now, func() time.Time { return d })
+	defer monkey.Unpatch(time.Now)
+	n.NotifyChannels()
+	assert.Equal(t, 0, len(slack.Messages))
+	d = time.Date(2018, 7, 9, 9, 30, 0, 0, time.UTC)
+	n.NotifyChannels()
+	n.NotifyChannels()
+	assert.Equal(t, []string{
+		"CHAT: user1, MESSAGE: Hello, <@user1>! You missed the standup deadline in <#ESCALATE> channel. Please, write you standup ASAP!",
+		"CHAT: user2, MESSAGE: Hello, <@user2>! You missed the standup deadline in <#ESCALATE> channel. Please, write you standup ASAP!",
+	}, slack.Messages)
+
+	slack.Messages = nil
+	d = time.Date(2018, 7, 9, 9, 45, 0, 0, time.UTC)
+	_, err = db.CreateStandup(model.Standup{ChannelID: "ESCALATE", UsernameID: "user2", Comment: "standup", MessageTS: "1"})
+	assert.NoError(t, err)
+	n.NotifyChannels()
+	assert.Equal(t, 0, len(slack.Messages))
+	d = time.Date(2018, 7, 9, 10, 0, 0, 0, time.UTC)
+	n.NotifyChannels()
+	assert.Equal(t, []string{"CHAT: ESCALATE, MESSAGE: In this channel not all standupers wrote standup today, shame on you: <@user1>."}, slack.Messages)
+
+	// the admins step is taken after a restart of notifier
+	slack.Messages = nil
+	d = time.Date(2018, 7, 9, 10, 31, 0, 0, time.UTC)
+	n, err = NewNotifier(c, chats, db)
+	assert.NoError(t, err)
+	n.ResumeReminders()
+	n.NotifyChannels()
+	assert.Equal(t, []string{"CHAT: admin1, MESSAGE: Standups in <#ESCALATE> are still missing from <@user1>"}, slack.Messages)
+
+	// escalation stops when the last standup arrives
+	slack.Messages = nil
+	d = time.Date(2018, 7, 9, 11, 0, 0, 0, time.UTC)
+	_, err = db.CreateStandup(model.Standup{ChannelID: "ESCALATE", UsernameID: "user1", Comment: "standup", MessageTS: "2"})
+	assert.NoError(t, err)
+	d = time.Date(2018, 7, 9, 11, 30, 0, 0, time.UTC)
+	n.NotifyChannels()
+	assert.Equal(t, 0, len(slack.Messages))
+	run, err := db.GetReminderRun("ESCALATE", "2018-07-09")
+	assert.NoError(t, err)
+	assert.Equal(t, model.ReminderDone, run.Stage)
+	assert.Equal(t, 3, run.Repeats)
+}
+
+type LeaderStub bool
+
+func (l LeaderStub) IsLeader() bool {
+	return bool(l)
+}
+
+func TestNotifierLeader(t *testing.T) {
+	c, err := config.Get()
+	assert.NoError(t, err)
+	db := storage.NewMemory()
+	n, err := NewNotifier(c, chat.NewRegistry(), db)
+	assert.NoError(t, err)
+
+	runs := 0
+	job := n.ifLeader(func() { runs++ })
+	job()
+	assert.Equal(t, 1, runs)
+	n.Leader = LeaderStub(false)
+	job()
+	assert.Equal(t, 1, runs)
+	n.Leader = LeaderStub(true)
+	job()
+	assert.Equal(t, 2, runs)
+}
+
+type CollectorStub collector.Data
+
+func (c CollectorStub) UserData(ctx context.Context, userID string, dateFrom, dateTo time.Time) (collector.Data, error) {
+	return collector.Data(c), nil
+}
+
+func (c CollectorStub) ProjectData(ctx context.Context, channelID, channelName string, dateFrom, dateTo time.Time) (collector.Data, error) {
+	return collector.Data(c), nil
+}
+
+func (c CollectorStub) ProjectUserData(ctx context.Context, channelID, channelName, userID string, dateFrom, dateTo time.Time) (collector.Data, error) {
+	return collector.Data(c), nil
+}
+
+func TestWebhookEvents(t *testing.T) {
+	c, err := config.Get()
+	assert.NoError(t, err)
+	db := storage.NewMemory()
+	slack := &ChatStub{}
+	chats := chat.NewRegistry()
+	chats.Register(chat.PlatformSlack, slack)
+	n, err := NewNotifier(c, chats, db)
+	assert.NoError(t, err)
+	n.Collector = CollectorStub{Worklogs: 3 * 3600, TotalCommits: 2}
+
+	var events []webhook.Event
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var e webhook.Event
+		json.NewDecoder(r.Body).Decode(&e)
+		events = append(events, e)
+	}))
+	defer receiver.Close()
+	_, err = db.CreateWebhook(model.Webhook{URL: receiver.URL, Secret: "secret"})
+	assert.NoError(t, err)
+	_, err = db.CreateStandupUser(model.StandupUser{SlackUserID: "user1", SlackName: "user1", ChannelID: "CHAN1", Channel: "team"})
+	assert.NoError(t, err)
+
+	d := time.Date(2018, 1, 2, 10, 0, 0, 0, time.UTC)
+	monkey.Patch(time.Now, func() time.Time { return d })
+	defer monkey.Unpatch(time.Now)
+
+	n.SendChannelNotification(chat.PlatformSlack, "CHAN1")
+	n.Webhooks.Wait()
+	assert.Equal(t, 1, len(events))
+	assert.Equal(t, webhook.StandupMissed, events[0].Event)
+	assert.Equal(t, map[string]interface{}{"userId": "user1", "userName": "user1", "channelId": "CHAN1", "channel": "team", "platform": "", "date": "2018-01-02"}, events[0].Data)
+
+	n.RevealRooks()
+	n.Webhooks.Wait()
+	assert.Equal(t, 2, len(events))
+	assert.Equal(t, webhook.RookReported, events[1].Event)
+	assert.Equal(t, map[string]interface{}{"userId": "user1", "channelId": "CHAN1", "worklogs": float64(3), "commits": float64(2), "standup": false}, events[1].Data)
+}
@@ -0,0 +1,149 @@
+package notifier
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/maddevsio/comedian/calendar"
+	"github.com/maddevsio/comedian/model"
+	"github.com/sirupsen/logrus"
+)
+
+// ResumeReminders continues today reminder runs left unfinished by a stopped notifier,
+// so follow-up reminders are not lost after a restart
+func (n *Notifier) ResumeReminders() {
+	runs, err := n.DB.ListUnfinishedReminderRuns()
+	if err != nil {
+		logrus.Errorf("notifier: ListUnfinishedReminderRuns failed: %v\n", err)
+		return
+	}
+	for _, run := range runs {
+		st, err := n.DB.GetChannelStandupTime(run.ChannelID)
+		if err != nil {
+			continue
+		}
+		now := time.Now().In(st.Location())
+		// runs of previous days are stale
+		if run.Date != now.Format(calendar.DateFormat) {
+			continue
+		}
+		deadline, ok := n.calendar(st.ChannelID).Standup(now)
+		if !ok {
+			continue
+		}
+		logrus.Infof("notifier: resuming reminders in %v at stage %v\n", run.ChannelID, run.Stage)
+		n.remind(st, deadline, now)
+	}
+}
+
+// remind moves today reminder run of channel through its stages: a warning before the
+// deadline, direct messages at the deadline and repeated reminders after it. The run is
+// kept in database and every stage is saved before it is sent, so a restarted notifier
+// continues where the previous one stopped and never sends a stage twice. Channels
+// with an escalation policy go through its steps instead
+func (n *Notifier) remind(st model.StandupTime, deadline, now time.Time) {
+	steps, err := n.DB.ListEscalationSteps(st.ChannelID)
+	if err != nil {
+		logrus.Errorf("notifier: ListEscalationSteps failed: %v\n", err)
+		return
+	}
+	if len(steps) > 0 {
+		n.escalate(st, steps, deadline, now)
+		return
+	}
+	warning := deadline.Add(-time.Duration(n.Config.ReminderTime) * time.Minute)
+
+	// runs start at the warning or at the deadline, later they are only continued
+	start := sameMinute(now, warning) || sameMinute(now, deadline)
+	run, ok := n.reminderRun(st, now, start)
+	if !ok {
+		return
+	}
+	for {
+		next, nonReporters, ok := n.nextStage(run, now, warning, deadline)
+		if !ok {
+			return
+		}
+		advanced, err := n.DB.AdvanceReminderRun(run, next)
+		if err != nil {
+			logrus.Errorf("notifier: AdvanceReminderRun failed: %v\n", err)
+			return
+		}
+		if !advanced {
+			return
+		}
+		switch {
+		case next.Stage == model.ReminderWarned:
+			n.SendWarning(st.Platform, st.ChannelID)
+		case next.Stage == model.ReminderDeadline && run.Stage != model.ReminderDeadline:
+			n.SendChannelNotification(st.Platform, st.ChannelID)
+		case next.Stage == model.ReminderDeadline:
+			n.SendRepeatedNotification(st.Platform, st.ChannelID, nonReporters)
+		}
+		run = next
+	}
+}
+
+// nextStage returns the stage run should be moved to now. Non reporters are returned
+// for repeated reminders
+func (n *Notifier) nextStage(run model.ReminderRun, now, warning, deadline time.Time) (model.ReminderRun, []model.StandupUser, bool) {
+	next := run
+	switch {
+	case run.Stage == model.ReminderStarted && !now.Before(warning) && now.Before(deadline):
+		next.Stage = model.ReminderWarned
+	case (run.Stage == model.ReminderStarted || run.Stage == model.ReminderWarned) && !now.Before(deadline):
+		next.Stage = model.ReminderDeadline
+		next.NextAt = deadline
+	case run.Stage == model.ReminderDeadline && !now.Before(run.NextAt):
+		nonReporters, err := n.getCurrentDayNonReporters(run.ChannelID)
+		if err != nil {
+			return run, nil, false
+		}
+		if run.Repeats >= n.Config.ReminderRepeatsMax || len(nonReporters) == 0 {
+			next.Stage = model.ReminderDone
+			return next, nil, true
+		}
+		next.Repeats++
+		next.NextAt = now.Add(time.Duration(n.Config.NotifierInterval) * time.Minute)
+		return next, nonReporters, true
+	default:
+		return run, nil, false
+	}
+	return next, nil, true
+}
+
+// reminderRun returns today reminder run of channel, a new run is created if start is true
+func (n *Notifier) reminderRun(st model.StandupTime, now time.Time, start bool) (model.ReminderRun, bool) {
+	date := now.Format(calendar.DateFormat)
+	run, err := n.DB.GetReminderRun(st.ChannelID, date)
+	if err == nil {
+		return run, true
+	}
+	if err != sql.ErrNoRows {
+		logrus.Errorf("notifier: GetReminderRun failed: %v\n", err)
+		return run, false
+	}
+	if !start {
+		return run, false
+	}
+	run, err = n.DB.CreateReminderRun(model.ReminderRun{
+		ChannelID: st.ChannelID,
+		Platform:  st.Platform,
+		Date:      date,
+		Stage:     model.ReminderStarted,
+		NextAt:    now,
+	})
+	if err != nil {
+		// the run may have been created by another notifier meanwhile
+		run, err = n.DB.GetReminderRun(st.ChannelID, date)
+		if err != nil {
+			logrus.Errorf("notifier: CreateReminderRun failed: %v\n", err)
+			return run, false
+		}
+	}
+	return run, true
+}
+
+func sameMinute(a, b time.Time) bool {
+	return a.Hour() == b.Hour() && a.Minute() == b.Minute()
+}
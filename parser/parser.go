@@ -0,0 +1,222 @@
+// Package parser splits standup messages into sections like yesterday work,
+// today plans and problems
+package parser
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/maddevsio/comedian/config"
+)
+
+// Names of default standup sections
+const (
+	Yesterday = "yesterday"
+	Today     = "today"
+	Problems  = "problems"
+)
+
+// delimiters separate sentences and list items, a section starts after one of them
+const delimiters = "\n.;!?,"
+
+// bullets may precede section keyword in lists
+const bullets = " \t\r-*•"
+
+// headerMarks follow section keyword used as a header, e.g. "Yesterday: "
+const headerMarks = ":-–—\n"
+
+// Question is a standup section, it starts with a word containing one of keywords
+// or matching Regexp. Role is the default section the answer stands for, if any
+type Question struct {
+	Name     string
+	Role     string
+	Keywords []string
+	Regexp   *regexp.Regexp
+}
+
+// NewQuestion creates a question from template pattern, which is either a keyword
+// or a regular expression in slashes, e.g. /(?i)block|stuck/
+func NewQuestion(name, pattern string) (Question, error) {
+	if len(pattern) > 2 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+		re, err := regexp.Compile(pattern[1 : len(pattern)-1])
+		if err != nil {
+			return Question{}, err
+		}
+		return Question{Name: name, Regexp: re}, nil
+	}
+	return Question{Name: name, Keywords: []string{pattern}}, nil
+}
+
+// DefaultQuestions returns yesterday, today and problems questions,
+// keywords are word stems from translation
+func DefaultQuestions(t config.Translate) []Question {
+	return []Question{
+		{Name: Yesterday, Role: Yesterday, Keywords: []string{t.Y1, t.Y2, t.Y3, t.Y4}},
+		{Name: Today, Role: Today, Keywords: []string{t.T1, t.T2, t.T3}},
+		{Name: Problems, Role: Problems, Keywords: []string{t.P1, t.P2, t.P3}},
+	}
+}
+
+// IsRole checks if role is one of default sections
+func IsRole(role string) bool {
+	return role == Yesterday || role == Today || role == Problems
+}
+
+// HasRole checks if one of questions stands for the default section
+func HasRole(questions []Question, role string) bool {
+	for _, q := range questions {
+		if q.Role == role {
+			return true
+		}
+	}
+	return false
+}
+
+type section struct {
+	name      string
+	wordStart int
+	wordEnd   int
+	start     int
+}
+
+// Parse finds every question in text and returns answers by question name.
+// Text between one question keyword and the next one is the answer to the
+// first question. If some question is not mentioned, ok is false
+func Parse(text string, questions []Question) (answers map[string]string, ok bool) {
+	sections := []section{}
+	for _, q := range questions {
+		s, found := find(text, q)
+		if !found {
+			return nil, false
+		}
+		sections = append(sections, s)
+	}
+	sort.Slice(sections, func(i, j int) bool { return sections[i].wordStart < sections[j].wordStart })
+
+	prevEnd := 0
+	for i := range sections {
+		s := &sections[i]
+		// two questions found in one word, e.g. "todaystuck", cannot be told apart
+		if s.wordStart < prevEnd {
+			return nil, false
+		}
+		s.start = s.wordStart
+		// words before keyword belong to the section if they are in the same sentence
+		if d := strings.LastIndexAny(text[prevEnd:s.wordStart], delimiters); d >= 0 {
+			s.start = prevEnd + d + 1
+		} else if i == 0 {
+			s.start = 0
+		}
+		prevEnd = s.wordEnd
+	}
+
+	answers = map[string]string{}
+	for i, s := range sections {
+		end := len(text)
+		if i+1 < len(sections) {
+			end = sections[i+1].start
+		}
+		if end < s.wordEnd {
+			end = s.wordEnd
+		}
+		answer := strings.TrimLeft(text[s.start:end], bullets+"\n")
+		// "Yesterday: fixed tests" is answered with "fixed tests", "stuck with tests" is kept as is
+		if isHeader(text, s) {
+			answer = strings.TrimLeft(text[s.wordEnd:end], bullets+headerMarks)
+		}
+		answers[s.name] = strings.TrimRight(answer, " \t\r\n,;")
+	}
+	return answers, true
+}
+
+// find returns the first match of question starting a sentence, or the first
+// match anywhere if no sentence starts with it
+func find(text string, q Question) (section, bool) {
+	var first section
+	found := false
+	for _, m := range matches(text, q) {
+		s := section{name: q.Name, wordStart: wordStart(text, m[0]), wordEnd: wordEnd(text, m[1])}
+		if !found || before(text, s, first) {
+			first, found = s, true
+		}
+	}
+	return first, found
+}
+
+// before checks if section a is a better candidate than b: sentence starts
+// are preferred over matches in the middle of a sentence, then earlier matches
+func before(text string, a, b section) bool {
+	aStarts, bStarts := startsSentence(text, a.wordStart), startsSentence(text, b.wordStart)
+	if aStarts != bStarts {
+		return aStarts
+	}
+	return a.wordStart < b.wordStart
+}
+
+// matches returns positions of all keywords or regexp matches of question in text
+func matches(text string, q Question) [][]int {
+	if q.Regexp != nil {
+		result := [][]int{}
+		for _, m := range q.Regexp.FindAllStringIndex(text, -1) {
+			if m[1] > m[0] {
+				result = append(result, m)
+			}
+		}
+		return result
+	}
+	result := [][]int{}
+	for _, kw := range q.Keywords {
+		if kw == "" {
+			continue
+		}
+		for offset := 0; ; {
+			i := strings.Index(text[offset:], kw)
+			if i < 0 {
+				break
+			}
+			i += offset
+			offset = i + len(kw)
+			result = append(result, []int{i, offset})
+		}
+	}
+	return result
+}
+
+// isHeader checks if section keyword is the only word of a header like "- Today:"
+func isHeader(text string, s section) bool {
+	if !startsSentence(text, s.wordStart) {
+		return false
+	}
+	after := strings.TrimLeft(text[s.wordEnd:], " \t\r")
+	return after == "" || strings.ContainsAny(after[:1], headerMarks)
+}
+
+func startsSentence(text string, i int) bool {
+	before := strings.TrimRight(text[:i], bullets)
+	return before == "" || strings.ContainsAny(before[len(before)-1:], delimiters+":")
+}
+
+func wordStart(text string, i int) int {
+	for i > 0 {
+		r, size := utf8.DecodeLastRuneInString(text[:i])
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			break
+		}
+		i -= size
+	}
+	return i
+}
+
+func wordEnd(text string, i int) int {
+	for i < len(text) {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			break
+		}
+		i += size
+	}
+	return i
+}
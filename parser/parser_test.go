@@ -0,0 +1,67 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/maddevsio/comedian/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	c, err := config.Get()
+	assert.NoError(t, err)
+	questions := DefaultQuestions(c.Translate)
+
+	testCases := []struct {
+		title     string
+		text      string
+		ok        bool
+		yesterday string
+		today     string
+		problems  string
+	}{
+		{"not a standup", "hello, everyone", false, "", "", ""},
+		{"no problems", "Yesterday I fixed tests, today I will review PRs", false, "", "", ""},
+		{"headers", "Yesterday: fixed tests\nToday: review PRs\nProblems: CI is down", true, "fixed tests", "review PRs", "CI is down"},
+		{"sentences", "Yesterday I fixed tests. Today I am going to review PRs. No problems", true, "Yesterday I fixed tests.", "Today I am going to review PRs.", "No problems"},
+		{"other order", "problems: none; today: deploy; yesterday: tests", true, "tests", "deploy", "none"},
+		{"preamble", "Hi team!\nyesterday - tests\ntoday - deploy\nproblems - none", true, "tests", "deploy", "none"},
+		{"keyword inside answer", "Yesterday: updated the plan\nToday: review\nProblems: none", true, "updated the plan", "review", "none"},
+		{"bullets", "- yesterday: tests\n- today: deploy\n- stuck with docker", true, "tests", "deploy", "stuck with docker"},
+		{"questions in one word", "Yesterday I worked, todaystuck", false, "", "", ""},
+		{"one sentence", "I did tests and plan deploy without problems", true, "I did tests and", "plan deploy without", "problems"},
+	}
+	for _, tt := range testCases {
+		answers, ok := Parse(tt.text, questions)
+		assert.Equal(t, tt.ok, ok, tt.title)
+		if !tt.ok {
+			continue
+		}
+		assert.Equal(t, tt.yesterday, answers[Yesterday], tt.title)
+		assert.Equal(t, tt.today, answers[Today], tt.title)
+		assert.Equal(t, tt.problems, answers[Problems], tt.title)
+	}
+}
+
+func TestNewQuestion(t *testing.T) {
+	q, err := NewQuestion("blockers", "/(?i)block|stuck/")
+	assert.NoError(t, err)
+	assert.NotNil(t, q.Regexp)
+	q, err = NewQuestion("done", "done")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"done"}, q.Keywords)
+	_, err = NewQuestion("broken", "/(/")
+	assert.Error(t, err)
+
+	questions := []Question{}
+	for _, p := range [][]string{{"done", "one"}, {"next", "/(?i)next|will/"}, {"blockers", "/(?i)block/"}} {
+		q, err := NewQuestion(p[0], p[1])
+		assert.NoError(t, err)
+		questions = append(questions, q)
+	}
+	answers, ok := Parse("Done: release notes\nNext: deploy\nBlockers: none", questions)
+	assert.True(t, ok)
+	assert.Equal(t, map[string]string{"done": "release notes", "next": "deploy", "blockers": "none"}, answers)
+	_, ok = Parse("Done: release notes\nBlockers: none", questions)
+	assert.False(t, ok)
+}
@@ -0,0 +1,241 @@
+package reporting
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/maddevsio/comedian/collector"
+	"github.com/maddevsio/comedian/config"
+)
+
+// Formats reports are rendered in
+const (
+	FormatText     = "text"
+	FormatCSV      = "csv"
+	FormatJSON     = "json"
+	FormatMarkdown = "markdown"
+)
+
+// statusNoData marks days without data in tables
+const statusNoData = "no_data"
+
+// Renderer turns reports into documents of one format
+type Renderer interface {
+	Render(Report) ([]byte, error)
+	// Extension is the file name extension of documents
+	Extension() string
+}
+
+type (
+	// Text renders reports as Slack messages in language of Translate
+	Text struct {
+		Translate config.Translate
+	}
+
+	// CSV renders reports as tables with a row for every entry and every day without data,
+	// collector data is not included
+	CSV struct{}
+
+	// JSON renders reports as they are
+	JSON struct{}
+
+	// Markdown renders reports as a table for every day in language of Translate
+	Markdown struct {
+		Translate config.Translate
+	}
+)
+
+// Formats returns sorted names of formats reports are rendered in
+func (r *Reporter) Formats() []string {
+	formats := []string{}
+	for format := range r.Renderers {
+		formats = append(formats, format)
+	}
+	sort.Strings(formats)
+	return formats
+}
+
+// FileName returns name of file with report, e.g. "report_project_C123_2018-07-01_2018-07-31.csv"
+func (report Report) FileName(extension string) string {
+	parts := []string{"report", report.Kind}
+	for _, part := range []string{report.ChannelID, report.UserID, report.From, report.To} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, "_") + "." + extension
+}
+
+// Render renders report the way Slack commands answered before reports were rendered in other formats
+func (t Text) Render(report Report) ([]byte, error) {
+	var text string
+	switch report.Kind {
+	case KindProject:
+		text = fmt.Sprintf(t.Translate.ReportOnProjectHead, report.ChannelID)
+	case KindUser:
+		text = fmt.Sprintf(t.Translate.ReportOnUserHead, report.UserID)
+	case KindProjectUser:
+		text = fmt.Sprintf(t.Translate.ReportOnProjectAndUserHead, report.ChannelID, report.UserID)
+	case KindBlockers:
+		text = fmt.Sprintf(t.Translate.ReportBlockersHead, report.ChannelID)
+	}
+	for _, day := range report.Days {
+		text += fmt.Sprintf(t.Translate.ReportDate, day.Date)
+		switch {
+		case day.NoData && report.Kind == KindBlockers:
+			text += t.Translate.ReportNoBlockers
+		case day.NoData:
+			text += t.Translate.ReportNoData
+		case report.Kind == KindBlockers:
+			blockers := ""
+			for _, e := range day.Entries {
+				blockers += fmt.Sprintf(t.Translate.ReportBlockersFromUser, e.UserID, e.Text)
+			}
+			if blockers == "" {
+				blockers = t.Translate.ReportNoBlockers
+			}
+			text += blockers + "\n"
+		default:
+			for _, e := range day.Entries {
+				text += t.entry(e, report.Kind == KindUser)
+			}
+			if report.Kind != KindProjectUser {
+				text += "\n"
+			}
+		}
+	}
+	if report.Kind != KindBlockers {
+		text += t.collector(report.Collector)
+	}
+	return []byte(text), nil
+}
+
+// Extension of text reports
+func (t Text) Extension() string {
+	return "txt"
+}
+
+// entry tells what user did, inChannel mentions channel of entry
+func (t Text) entry(e Entry, inChannel bool) string {
+	switch {
+	case e.Status == StatusOnLeave && inChannel:
+		return fmt.Sprintf(t.Translate.UserOnLeaveInChannel, e.ChannelID, e.UserID)
+	case e.Status == StatusOnLeave:
+		return fmt.Sprintf(t.Translate.UserOnLeave, e.UserID)
+	case e.Status == StatusMissed && inChannel:
+		return fmt.Sprintf(t.Translate.UserDidNotStandupInChannel, e.ChannelID, e.UserID)
+	case e.Status == StatusMissed:
+		return fmt.Sprintf(t.Translate.UserDidNotStandup, e.UserID)
+	case inChannel:
+		return fmt.Sprintf(t.Translate.UserDidStandupInChannel, e.ChannelID, e.UserID) + fmt.Sprintf("%v \n", e.Text)
+	}
+	return fmt.Sprintf(t.Translate.UserDidStandup, e.UserID) + fmt.Sprintf("%v \n", e.Text)
+}
+
+// collector formats data from Collector, nothing is shown if Collector did not answer.
+// Reviews are shown only if a provider counted them
+func (t Text) collector(cd *collector.Data) string {
+	if cd == nil {
+		return ""
+	}
+	var text string
+	if cd.Worklogs != 0 {
+		text = fmt.Sprintf(t.Translate.ReportCollectorDataUser, cd.TotalCommits, cd.TotalMerges, cd.Worklogs/3600)
+	} else {
+		text = fmt.Sprintf(t.Translate.ReportOnProjectCollectorData, cd.TotalCommits, cd.TotalMerges)
+	}
+	if cd.TotalReviews != 0 {
+		text = strings.TrimRight(text, "\n") + fmt.Sprintf(t.Translate.ReportReviews, cd.TotalReviews)
+	}
+	return text
+}
+
+// Render writes header row "date,channel_id,user_id,status,text" and rows of report,
+// text is escaped so that spreadsheets do not take it for a formula
+func (CSV) Render(report Report) ([]byte, error) {
+	var b bytes.Buffer
+	w := csv.NewWriter(&b)
+	w.Write([]string{"date", "channel_id", "user_id", "status", "text"})
+	for _, day := range report.Days {
+		if day.NoData {
+			w.Write([]string{day.Date, report.ChannelID, report.UserID, statusNoData, ""})
+		}
+		for _, e := range day.Entries {
+			w.Write([]string{day.Date, e.ChannelID, e.UserID, e.Status, csvCell(e.Text)})
+		}
+	}
+	w.Flush()
+	return b.Bytes(), w.Error()
+}
+
+// Extension of CSV reports
+func (CSV) Extension() string {
+	return "csv"
+}
+
+// Render marshals report with indentation
+func (JSON) Render(report Report) ([]byte, error) {
+	return json.MarshalIndent(report, "", "  ")
+}
+
+// Extension of JSON reports
+func (JSON) Extension() string {
+	return "json"
+}
+
+// Render writes title, a section with a table for every day and collector data
+func (m Markdown) Render(report Report) ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteString("# ")
+	switch report.Kind {
+	case KindProject:
+		fmt.Fprintf(&b, m.Translate.MarkdownProjectHead, report.ChannelID)
+	case KindUser:
+		fmt.Fprintf(&b, m.Translate.MarkdownUserHead, report.UserID)
+	case KindProjectUser:
+		fmt.Fprintf(&b, m.Translate.MarkdownProjectAndUserHead, report.UserID, report.ChannelID)
+	case KindBlockers:
+		fmt.Fprintf(&b, m.Translate.MarkdownBlockersHead, report.ChannelID)
+	}
+	fmt.Fprintf(&b, ", %v — %v\n", report.From, report.To)
+	for _, day := range report.Days {
+		fmt.Fprintf(&b, "\n## %v\n\n", day.Date)
+		if day.NoData || len(day.Entries) == 0 {
+			b.WriteString(m.Translate.MarkdownNothingToReport + "\n")
+			continue
+		}
+		fmt.Fprintf(&b, "| %v |\n|---|---|---|---|\n", m.Translate.MarkdownColumns)
+		for _, e := range day.Entries {
+			fmt.Fprintf(&b, "| %v | %v | %v | %v |\n", markdownCell(e.ChannelID), markdownCell(e.UserID), e.Status, markdownCell(e.Text))
+		}
+	}
+	if cd := report.Collector; cd != nil {
+		fmt.Fprintf(&b, "\n## %v\n\n", m.Translate.MarkdownCollector)
+		fmt.Fprintf(&b, m.Translate.MarkdownCollectorData, cd.TotalCommits, cd.TotalMerges, cd.TotalReviews, cd.Worklogs/3600)
+	}
+	return b.Bytes(), nil
+}
+
+// Extension of Markdown reports
+func (Markdown) Extension() string {
+	return "md"
+}
+
+// csvCell prefixes text starting with =, +, - or @ with ' so that spreadsheets show it as text
+func csvCell(s string) string {
+	if s != "" && strings.ContainsAny(s[:1], "=+-@") {
+		return "'" + s
+	}
+	return s
+}
+
+// markdownCell escapes text for a table cell, line breaks become <br>
+func markdownCell(s string) string {
+	s = strings.Replace(s, "|", `\|`, -1)
+	s = strings.Replace(s, "\r\n", "\n", -1)
+	return strings.Replace(strings.TrimSpace(s), "\n", "<br>", -1)
+}
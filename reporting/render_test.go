@@ -0,0 +1,112 @@
+package reporting
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/bouk/monkey"
+	"github.com/maddevsio/comedian/collector"
+	"github.com/maddevsio/comedian/config"
+	"github.com/maddevsio/comedian/model"
+	"github.com/maddevsio/comedian/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderFormats(t *testing.T) {
+	d := time.Date(2018, 7, 10, 12, 0, 0, 0, time.UTC)
+	monkey.Patch(time.Now, func() time.Time { return d })
+	defer monkey.Unpatch(time.Now)
+
+	c, err := config.Get()
+	assert.NoError(t, err)
+	r, err := NewReporter(c, storage.NewMemory())
+	assert.NoError(t, err)
+
+	_, err = r.DB.CreateStandupUser(model.StandupUser{SlackUserID: "userID1", SlackName: "user1", ChannelID: "CHAN", Channel: "chanName"})
+	assert.NoError(t, err)
+	_, err = r.DB.CreateStandupUser(model.StandupUser{SlackUserID: "userID2", SlackName: "user2", ChannelID: "CHAN", Channel: "chanName"})
+	assert.NoError(t, err)
+	_, err = r.DB.CreateStandup(model.Standup{ChannelID: "CHAN", UsernameID: "userID1", Comment: "fixed bug, \"quoted\"\nreview | merge", MessageTS: "1"})
+	assert.NoError(t, err)
+	_, err = r.DB.CreateAbsence(model.Absence{SlackUserID: "userID2", DateFrom: "2018-07-09", DateTo: "2018-07-09"})
+	assert.NoError(t, err)
+
+	dateFrom := time.Date(2018, 7, 9, 0, 0, 0, 0, time.UTC)
+	report, err := r.ProjectReport("#CHAN", dateFrom, d, &collector.Data{TotalCommits: 3, TotalMerges: 1, Worklogs: 7200})
+	assert.NoError(t, err)
+	assert.Equal(t, "CHAN", report.ChannelID)
+	assert.Equal(t, 2, len(report.Days))
+	assert.Equal(t, []Entry{
+		{UserID: "userID1", ChannelID: "CHAN", Status: StatusMissed},
+		{UserID: "userID2", ChannelID: "CHAN", Status: StatusOnLeave},
+	}, report.Days[0].Entries)
+	assert.Equal(t, StatusSubmitted, report.Days[1].Entries[0].Status)
+	assert.Equal(t, "report_project_CHAN_2018-07-09_2018-07-10.csv", report.FileName("csv"))
+
+	doc, err := r.Render(report, FormatCSV)
+	assert.NoError(t, err)
+	assert.Equal(t, "date,channel_id,user_id,status,text\n"+
+		"2018-07-09,CHAN,userID1,missed,\n"+
+		"2018-07-09,CHAN,userID2,on_leave,\n"+
+		"2018-07-10,CHAN,userID1,submitted,\"fixed bug, \"\"quoted\"\"\nreview | merge\"\n"+
+		"2018-07-10,CHAN,userID2,missed,\n", string(doc))
+
+	doc, err = r.Render(report, FormatJSON)
+	assert.NoError(t, err)
+	var decoded Report
+	assert.NoError(t, json.Unmarshal(doc, &decoded))
+	assert.Equal(t, report, decoded)
+
+	doc, err = r.Render(report, FormatMarkdown)
+	assert.NoError(t, err)
+	assert.Equal(t, "# Report on channel CHAN, 2018-07-09 — 2018-07-10\n\n"+
+		"## 2018-07-09\n\n| Channel | User | Status | Text |\n|---|---|---|---|\n"+
+		"| CHAN | userID1 | missed |  |\n| CHAN | userID2 | on_leave |  |\n\n"+
+		"## 2018-07-10\n\n| Channel | User | Status | Text |\n|---|---|---|---|\n"+
+		"| CHAN | userID1 | submitted | fixed bug, \"quoted\"<br>review \\| merge |\n| CHAN | userID2 | missed |  |\n\n"+
+		"## Collector\n\n- Commits: 3\n- Merges: 1\n- Reviews: 0\n- Logged hours: 2\n", string(doc))
+
+	doc, err = r.Render(report, FormatText)
+	assert.NoError(t, err)
+	text, err := r.StandupReportByProject("CHAN", dateFrom, d, &collector.Data{TotalCommits: 3, TotalMerges: 1, Worklogs: 7200})
+	assert.NoError(t, err)
+	assert.Equal(t, text, string(doc))
+
+	_, err = r.Render(report, "xlsx")
+	assert.EqualError(t, err, "Unknown report format xlsx, formats are: csv, json, markdown, text")
+
+	report, err = r.BlockersReport("EMPTY", dateFrom, d)
+	assert.NoError(t, err)
+	doc, err = r.Render(report, FormatMarkdown)
+	assert.NoError(t, err)
+	assert.Equal(t, "# Blockers in channel EMPTY, 2018-07-09 — 2018-07-10\n\n## 2018-07-09\n\nNothing to report\n\n## 2018-07-10\n\nNothing to report\n", string(doc))
+
+	report, err = r.UserReport(model.StandupUser{SlackUserID: "nobody"}, dateFrom, dateFrom, nil)
+	assert.NoError(t, err)
+	doc, err = r.Render(report, FormatCSV)
+	assert.NoError(t, err)
+	assert.Equal(t, "date,channel_id,user_id,status,text\n2018-07-09,,nobody,no_data,\n", string(doc))
+	assert.Equal(t, "report_user_nobody_2018-07-09_2018-07-09.md", report.FileName(r.Renderers[FormatMarkdown].Extension()))
+}
+
+func TestRenderEscapesAndTranslates(t *testing.T) {
+	report := Report{Kind: KindProject, ChannelID: "CHAN", From: "2018-07-09", To: "2018-07-09", Days: []Day{{Date: "2018-07-09", Entries: []Entry{
+		{UserID: "U1", ChannelID: "CHAN", Status: StatusSubmitted, Text: "=HYPERLINK(\"http://example.com\")"},
+		{UserID: "U2", ChannelID: "CHAN", Status: StatusSubmitted, Text: "- fixed tests"},
+	}}}}
+	doc, err := CSV{}.Render(report)
+	assert.NoError(t, err)
+	assert.Equal(t, "date,channel_id,user_id,status,text\n"+
+		"2018-07-09,CHAN,U1,submitted,\"'=HYPERLINK(\"\"http://example.com\"\")\"\n"+
+		"2018-07-09,CHAN,U2,submitted,'- fixed tests\n", string(doc))
+
+	tr, err := config.GetTranslation("ru_RU")
+	assert.NoError(t, err)
+	report.Days = append(report.Days, Day{Date: "2018-07-10", NoData: true})
+	doc, err = Markdown{Translate: tr}.Render(report)
+	assert.NoError(t, err)
+	assert.Contains(t, string(doc), "# Отчёт по каналу CHAN")
+	assert.Contains(t, string(doc), "| Канал | Пользователь | Статус | Текст |")
+	assert.Contains(t, string(doc), "Нет данных за этот день")
+}
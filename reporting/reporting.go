@@ -1,175 +1,293 @@
 package reporting
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
 	"strings"
 	"time"
 
+	"github.com/maddevsio/comedian/collector"
 	"github.com/maddevsio/comedian/config"
 	"github.com/maddevsio/comedian/model"
 	"github.com/maddevsio/comedian/storage"
 )
 
+// Kinds of reports
+const (
+	KindProject     = "project"
+	KindUser        = "user"
+	KindProjectUser = "project_user"
+	KindBlockers    = "blockers"
+)
+
+// Statuses of report entries
+const (
+	StatusSubmitted = "submitted"
+	StatusMissed    = "missed"
+	StatusOnLeave   = "on_leave"
+	StatusBlocker   = "blocker"
+)
+
 //Reporter provides db and translation to functions
 type (
 	Reporter struct {
 		DB     storage.Storage
 		Config config.Config
+		// Renderers render reports by format name, other formats can be added to them
+		Renderers map[string]Renderer
+	}
+
+	// Report is a standup report for a period from From to To, it is built once
+	// and rendered in any format
+	Report struct {
+		Kind      string          `json:"kind"`
+		ChannelID string          `json:"channelId,omitempty"`
+		UserID    string          `json:"userId,omitempty"`
+		From      string          `json:"from"`
+		To        string          `json:"to"`
+		Days      []Day           `json:"days"`
+		Collector *collector.Data `json:"collector,omitempty"`
 	}
 
-	// CollectorData used to parse data on user from Collector
-	CollectorData struct {
-		TotalCommits int `json:"total_commits"`
-		TotalMerges  int `json:"total_merges"`
-		Worklogs     int `json:"worklogs"`
+	// Day is a day of report, NoData is set if there is nothing to report on the day
+	Day struct {
+		Date    string  `json:"date"`
+		NoData  bool    `json:"noData"`
+		Entries []Entry `json:"entries"`
+	}
+
+	// Entry tells what user did in channel on a day. Text is the standup of submitted
+	// entries and the problems section of the standup of blockers
+	Entry struct {
+		UserID    string `json:"userId"`
+		ChannelID string `json:"channelId"`
+		Status    string `json:"status"`
+		Text      string `json:"text,omitempty"`
 	}
 )
 
 //NewReporter creates new reporter instanse
-func NewReporter(c config.Config) (*Reporter, error) {
-	conn, err := storage.NewMySQL(c)
-	if err != nil {
-		return nil, err
+func NewReporter(c config.Config, db storage.Storage) (*Reporter, error) {
+	r := &Reporter{DB: db, Config: c}
+	r.Renderers = map[string]Renderer{
+		FormatText:     Text{Translate: c.Translate},
+		FormatCSV:      CSV{},
+		FormatJSON:     JSON{},
+		FormatMarkdown: Markdown{Translate: c.Translate},
 	}
-	r := &Reporter{DB: conn, Config: c}
 	return r, nil
 }
 
 // StandupReportByProject creates a standup report for a specified period of time
-func (r *Reporter) StandupReportByProject(channelID string, dateFrom, dateTo time.Time, collectorData []byte) (string, error) {
+func (r *Reporter) StandupReportByProject(channelID string, dateFrom, dateTo time.Time, collectorData *collector.Data) (string, error) {
+	report, err := r.ProjectReport(channelID, dateFrom, dateTo, collectorData)
+	if err != nil {
+		return "", err
+	}
+	return r.text(report)
+}
+
+// StandupReportByUser creates a standup report for a specified period of time
+func (r *Reporter) StandupReportByUser(user model.StandupUser, dateFrom, dateTo time.Time, collectorData *collector.Data) (string, error) {
+	report, err := r.UserReport(user, dateFrom, dateTo, collectorData)
+	if err != nil {
+		return "", err
+	}
+	return r.text(report)
+}
+
+// StandupReportByProjectAndUser creates a standup report for a specified period of time
+func (r *Reporter) StandupReportByProjectAndUser(channelID string, user model.StandupUser, dateFrom, dateTo time.Time, collectorData *collector.Data) (string, error) {
+	report, err := r.ProjectUserReport(channelID, user, dateFrom, dateTo, collectorData)
+	if err != nil {
+		return "", err
+	}
+	return r.text(report)
+}
+
+// StandupBlockersReport lists problems sections of standups written in channel for a specified period of time
+func (r *Reporter) StandupBlockersReport(channelID string, dateFrom, dateTo time.Time) (string, error) {
+	report, err := r.BlockersReport(channelID, dateFrom, dateTo)
+	if err != nil {
+		return "", err
+	}
+	return r.text(report)
+}
+
+// ProjectReport tells who of channel standupers submitted standups for a specified period of time
+func (r *Reporter) ProjectReport(channelID string, dateFrom, dateTo time.Time, collectorData *collector.Data) (Report, error) {
 	channel := strings.Replace(channelID, "#", "", -1)
-	report := fmt.Sprintf(r.Config.Translate.ReportOnProjectHead, channel)
+	report := newReport(KindProject, channel, "", dateFrom, dateTo, collectorData)
 
 	dateFromBegin, numberOfDays, err := r.setupDays(dateFrom, dateTo)
 	if err != nil {
-		return "", err
+		return report, err
 	}
+	loc := r.channelLocation(channel)
 
 	for day := 0; day <= numberOfDays; day++ {
-		dateFrom := dateFromBegin.Add(time.Duration(day*24) * time.Hour)
-		dateTo := dateFrom.Add(24 * time.Hour)
-		report += fmt.Sprintf(r.Config.Translate.ReportDate, dateFrom.Format("2006-01-02"))
+		dateFrom, dateTo := dayBounds(dateFromBegin.AddDate(0, 0, day), loc)
+		d := Day{Date: dateFrom.Format("2006-01-02"), Entries: []Entry{}}
 		standupers, err := r.DB.ListStandupUsersByChannelID(channel)
 		if err != nil || len(standupers) == 0 {
-			report += r.Config.Translate.ReportNoData
+			d.NoData = true
+			report.Days = append(report.Days, d)
 			continue
 		}
 		for _, user := range standupers {
-			userIsNonReporter, err := r.DB.IsNonReporter(user.SlackUserID, channel, dateFrom, dateTo)
-			if err != nil {
-				fmt.Println(err)
-				continue
-			}
-			if userIsNonReporter {
-				report += fmt.Sprintf(r.Config.Translate.UserDidNotStandup, user.SlackUserID)
-				continue
-			}
-			report += fmt.Sprintf(r.Config.Translate.UserDidStandup, user.SlackUserID)
-			standups, err := r.DB.SelectStandupsFiltered(user.SlackUserID, channel, dateFrom, dateTo)
+			entry, err := r.entry(user.SlackUserID, channel, dateFrom, dateTo)
 			if err != nil {
 				fmt.Println(err)
 				continue
 			}
-			report += fmt.Sprintf("%v \n", standups[0].Comment)
+			d.Entries = append(d.Entries, entry)
 		}
-		report += "\n"
+		report.Days = append(report.Days, d)
 	}
-
-	report += r.fetchCollectorData(collectorData)
 	return report, nil
 }
 
-// StandupReportByUser creates a standup report for a specified period of time
-func (r *Reporter) StandupReportByUser(user model.StandupUser, dateFrom, dateTo time.Time, collectorData []byte) (string, error) {
-	report := fmt.Sprintf(r.Config.Translate.ReportOnUserHead, user.SlackUserID)
+// UserReport tells if user submitted standups in all channels of the user for a specified period of time
+func (r *Reporter) UserReport(user model.StandupUser, dateFrom, dateTo time.Time, collectorData *collector.Data) (Report, error) {
+	report := newReport(KindUser, "", user.SlackUserID, dateFrom, dateTo, collectorData)
 
 	dateFromBegin, numberOfDays, err := r.setupDays(dateFrom, dateTo)
 	if err != nil {
-		return "", err
+		return report, err
 	}
 
 	for day := 0; day <= numberOfDays; day++ {
-		dateFrom := dateFromBegin.Add(time.Duration(day*24) * time.Hour)
-		dateTo := dateFrom.Add(24 * time.Hour)
-		report += fmt.Sprintf(r.Config.Translate.ReportDate, dateFrom.Format("2006-01-02"))
+		date := dateFromBegin.AddDate(0, 0, day)
+		d := Day{Date: date.Format("2006-01-02"), Entries: []Entry{}}
 		channels, err := r.DB.GetUserChannels(user.SlackUserID)
 		if err != nil || len(channels) == 0 {
-			report += r.Config.Translate.ReportNoData
+			d.NoData = true
+			report.Days = append(report.Days, d)
 			continue
 		}
 		for _, channel := range channels {
-			userIsNonReporter, err := r.DB.IsNonReporter(user.SlackUserID, channel, dateFrom, dateTo)
-			if err != nil {
-				fmt.Println(err)
-				continue
-			}
-			if userIsNonReporter {
-				report += fmt.Sprintf(r.Config.Translate.UserDidNotStandupInChannel, channel, user.SlackUserID)
-				continue
-			}
-			report += fmt.Sprintf(r.Config.Translate.UserDidStandupInChannel, channel, user.SlackUserID)
-			standups, err := r.DB.SelectStandupsFiltered(user.SlackUserID, channel, dateFrom, dateTo)
+			// the same day begins at different moments in channels of different time zones
+			dateFrom, dateTo := dayBounds(date, r.channelLocation(channel))
+			entry, err := r.entry(user.SlackUserID, channel, dateFrom, dateTo)
 			if err != nil {
 				fmt.Println(err)
 				continue
 			}
-			report += fmt.Sprintf("%v \n", standups[0].Comment)
+			d.Entries = append(d.Entries, entry)
 		}
-		report += "\n"
+		report.Days = append(report.Days, d)
 	}
-
-	report += r.fetchCollectorData(collectorData)
 	return report, nil
 }
 
-// StandupReportByProjectAndUser creates a standup report for a specified period of time
-func (r *Reporter) StandupReportByProjectAndUser(channelID string, user model.StandupUser, dateFrom, dateTo time.Time, collectorData []byte) (string, error) {
+// ProjectUserReport tells if user submitted standups in channel for a specified period of time
+func (r *Reporter) ProjectUserReport(channelID string, user model.StandupUser, dateFrom, dateTo time.Time, collectorData *collector.Data) (Report, error) {
 	channel := strings.Replace(channelID, "#", "", -1)
-	report := fmt.Sprintf(r.Config.Translate.ReportOnProjectAndUserHead, channel, user.SlackUserID)
+	report := newReport(KindProjectUser, channel, user.SlackUserID, dateFrom, dateTo, collectorData)
 
 	dateFromBegin, numberOfDays, err := r.setupDays(dateFrom, dateTo)
 	if err != nil {
-		return "", err
+		return report, err
 	}
+	loc := r.channelLocation(channel)
 
 	for day := 0; day <= numberOfDays; day++ {
-		dateFrom := dateFromBegin.Add(time.Duration(day*24) * time.Hour)
-		dateTo := dateFrom.Add(24 * time.Hour)
-		report += fmt.Sprintf(r.Config.Translate.ReportDate, dateFrom.Format("2006-01-02"))
-		userIsNonReporter, err := r.DB.IsNonReporter(user.SlackUserID, channel, dateFrom, dateTo)
+		dateFrom, dateTo := dayBounds(dateFromBegin.AddDate(0, 0, day), loc)
+		d := Day{Date: dateFrom.Format("2006-01-02"), Entries: []Entry{}}
+		entry, err := r.entry(user.SlackUserID, channel, dateFrom, dateTo)
 		if err != nil {
-			report += r.Config.Translate.ReportNoData
-			continue
+			d.NoData = true
+		} else {
+			d.Entries = append(d.Entries, entry)
 		}
-		if userIsNonReporter {
-			report += fmt.Sprintf(r.Config.Translate.UserDidNotStandup, user.SlackUserID)
-			continue
-		}
-		report += fmt.Sprintf(r.Config.Translate.UserDidStandup, user.SlackUserID)
-		standups, err := r.DB.SelectStandupsFiltered(user.SlackUserID, channel, dateFrom, dateTo)
+		report.Days = append(report.Days, d)
+	}
+	return report, nil
+}
+
+// BlockersReport lists problems sections of standups written in channel for a specified period of time
+func (r *Reporter) BlockersReport(channelID string, dateFrom, dateTo time.Time) (Report, error) {
+	channel := strings.Replace(channelID, "#", "", -1)
+	report := newReport(KindBlockers, channel, "", dateFrom, dateTo, nil)
+
+	dateFromBegin, numberOfDays, err := r.setupDays(dateFrom, dateTo)
+	if err != nil {
+		return report, err
+	}
+	loc := r.channelLocation(channel)
+
+	for day := 0; day <= numberOfDays; day++ {
+		dateFrom, dateTo := dayBounds(dateFromBegin.AddDate(0, 0, day), loc)
+		d := Day{Date: dateFrom.Format("2006-01-02"), Entries: []Entry{}}
+		standups, err := r.DB.SelectStandupsByChannelIDForPeriod(channel, dateFrom, dateTo)
 		if err != nil {
 			fmt.Println(err)
+			d.NoData = true
+			report.Days = append(report.Days, d)
 			continue
 		}
-		report += fmt.Sprintf("%v \n", standups[0].Comment)
+		for _, standup := range standups {
+			if standup.Problems == "" {
+				continue
+			}
+			d.Entries = append(d.Entries, Entry{UserID: standup.UsernameID, ChannelID: channel, Status: StatusBlocker, Text: standup.Problems})
+		}
+		report.Days = append(report.Days, d)
 	}
-
-	report += r.fetchCollectorData(collectorData)
 	return report, nil
 }
 
-func (r *Reporter) fetchCollectorData(data []byte) string {
-	var cd CollectorData
-	err := json.Unmarshal(data, &cd)
+// Render renders report in format, formats are names of Renderers
+func (r *Reporter) Render(report Report, format string) ([]byte, error) {
+	renderer, ok := r.Renderers[format]
+	if !ok {
+		return nil, fmt.Errorf(r.Config.Translate.ReportWrongFormat, format, strings.Join(r.Formats(), ", "))
+	}
+	return renderer.Render(report)
+}
+
+// text renders report as Slack message
+func (r *Reporter) text(report Report) (string, error) {
+	text, err := r.Render(report, FormatText)
+	return string(text), err
+}
+
+// entry tells if user submitted standup in channel between dateFrom and dateTo,
+// the error is returned if it cannot be checked
+func (r *Reporter) entry(slackUserID, channel string, dateFrom, dateTo time.Time) (Entry, error) {
+	entry := Entry{UserID: slackUserID, ChannelID: channel}
+	userIsNonReporter, err := r.DB.IsNonReporter(slackUserID, channel, dateFrom, dateTo)
 	if err != nil {
-		return ""
+		return entry, err
 	}
-	if cd.Worklogs != 0 {
-		return fmt.Sprintf(r.Config.Translate.ReportCollectorDataUser, cd.TotalCommits, cd.TotalMerges, cd.Worklogs/3600)
+	if userIsNonReporter {
+		entry.Status = StatusMissed
+		if r.isOnLeave(slackUserID, dateFrom, dateTo) {
+			entry.Status = StatusOnLeave
+		}
+		return entry, nil
+	}
+	entry.Status = StatusSubmitted
+	standups, err := r.DB.SelectStandupsFiltered(slackUserID, channel, dateFrom, dateTo)
+	if err != nil {
+		fmt.Println(err)
+		return entry, nil
+	}
+	if len(standups) != 0 {
+		entry.Text = standups[0].Comment
+	}
+	return entry, nil
+}
+
+// isOnLeave checks if user is absent on a report day, users whose absence
+// cannot be checked are reported as usual
+func (r *Reporter) isOnLeave(slackUserID string, dateFrom, dateTo time.Time) bool {
+	absent, err := r.DB.IsAbsent(slackUserID, dateFrom, dateTo)
+	if err != nil {
+		fmt.Println(err)
+		return false
 	}
-	return fmt.Sprintf(r.Config.Translate.ReportOnProjectCollectorData, cd.TotalCommits, cd.TotalMerges)
+	return absent
 }
 
 //setupDays gets dates and returns their differense in days
@@ -185,3 +303,32 @@ func (r *Reporter) setupDays(dateFrom, dateTo time.Time) (time.Time, int, error)
 	numberOfDays := int(dateToRounded.Sub(dateFromRounded).Hours() / 24)
 	return dateFromRounded, numberOfDays, nil
 }
+
+// channelLocation returns time zone of channel standup time,
+// days of channels without standup time are counted in UTC
+func (r *Reporter) channelLocation(channelID string) *time.Location {
+	st, err := r.DB.GetChannelStandupTime(channelID)
+	if err != nil {
+		return time.UTC
+	}
+	return st.Location()
+}
+
+// newReport creates report without days
+func newReport(kind, channelID, userID string, dateFrom, dateTo time.Time, collectorData *collector.Data) Report {
+	return Report{
+		Kind:      kind,
+		ChannelID: channelID,
+		UserID:    userID,
+		From:      dateFrom.Format("2006-01-02"),
+		To:        dateTo.Format("2006-01-02"),
+		Days:      []Day{},
+		Collector: collectorData,
+	}
+}
+
+// dayBounds returns beginning of the date and of the next day in time zone
+func dayBounds(date time.Time, loc *time.Location) (time.Time, time.Time) {
+	from := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, loc)
+	return from, from.AddDate(0, 0, 1)
+}
@@ -5,8 +5,10 @@ import (
 	"time"
 
 	"github.com/bouk/monkey"
+	"github.com/maddevsio/comedian/collector"
 	"github.com/maddevsio/comedian/config"
 	"github.com/maddevsio/comedian/model"
+	"github.com/maddevsio/comedian/storage"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -16,7 +18,9 @@ func TestStandupReportByProject(t *testing.T) {
 
 	c, err := config.Get()
 	assert.NoError(t, err)
-	r, err := NewReporter(c)
+	db := storage.NewMemory()
+	assert.NoError(t, err)
+	r, err := NewReporter(c, db)
 	assert.NoError(t, err)
 
 	channelID := "QWERTY123"
@@ -25,7 +29,7 @@ func TestStandupReportByProject(t *testing.T) {
 	dateTo := time.Now()
 	dateFrom := time.Now().AddDate(0, 0, -2)
 
-	data := []byte{}
+	var data *collector.Data
 
 	//First test when no data
 	actual, err := r.StandupReportByProject(channelID, dateFrom, dateTo, data)
@@ -108,7 +112,9 @@ func TestStandupReportByUser(t *testing.T) {
 	monkey.Patch(time.Now, func() time.Time { return d })
 	c, err := config.Get()
 	assert.NoError(t, err)
-	r, err := NewReporter(c)
+	db := storage.NewMemory()
+	assert.NoError(t, err)
+	r, err := NewReporter(c, db)
 	assert.NoError(t, err)
 
 	channelID := "QWERTY123"
@@ -128,7 +134,7 @@ func TestStandupReportByUser(t *testing.T) {
 	})
 	assert.NoError(t, err)
 
-	data := []byte{}
+	var data *collector.Data
 
 	_, err = r.StandupReportByUser(user, dateTo, dateFrom, data)
 	assert.Error(t, err)
@@ -163,7 +169,9 @@ func TestStandupReportByProjectAndUser(t *testing.T) {
 	monkey.Patch(time.Now, func() time.Time { return d })
 	c, err := config.Get()
 	assert.NoError(t, err)
-	r, err := NewReporter(c)
+	db := storage.NewMemory()
+	assert.NoError(t, err)
+	r, err := NewReporter(c, db)
 	assert.NoError(t, err)
 
 	channelID := "QWERTY123"
@@ -179,7 +187,7 @@ func TestStandupReportByProjectAndUser(t *testing.T) {
 		Channel:     channelName,
 	})
 
-	data := []byte{}
+	var data *collector.Data
 	actual, err := r.StandupReportByProjectAndUser(channelID, user1, dateFrom, dateTo, data)
 	assert.NoError(t, err)
 	expected := "Report on project: <#QWERTY123>, and user: <@userID1>\n\nReport for: 2018-06-03\n<@userID1> did not submit standup!Report for: 2018-06-04\n<@userID1> did not submit standup!Report for: 2018-06-05\n<@userID1> did not submit standup!"
@@ -202,3 +210,121 @@ func TestStandupReportByProjectAndUser(t *testing.T) {
 	assert.NoError(t, r.DB.DeleteStandup(standup1.ID))
 	assert.NoError(t, r.DB.DeleteStandupUser(user1.SlackName, user1.ChannelID))
 }
+
+func TestStandupBlockersReport(t *testing.T) {
+	d := time.Date(2018, 6, 5, 10, 0, 0, 0, time.UTC)
+	monkey.Patch(time.Now, func() time.Time { return d })
+
+	c, err := config.Get()
+	assert.NoError(t, err)
+	db := storage.NewMemory()
+	assert.NoError(t, err)
+	r, err := NewReporter(c, db)
+	assert.NoError(t, err)
+
+	channelID := "QWERTY123"
+	dateTo := time.Now()
+	dateFrom := time.Now().AddDate(0, 0, -1)
+
+	actual, err := r.StandupBlockersReport(channelID, dateFrom, dateTo)
+	assert.NoError(t, err)
+	expected := "Blockers on project <#QWERTY123>:\n\nReport for: 2018-06-04\nNo blockers for this day\n\nReport for: 2018-06-05\nNo blockers for this day\n\n"
+	assert.Equal(t, expected, actual)
+
+	standup1, err := r.DB.CreateStandup(model.Standup{
+		ChannelID:  channelID,
+		Comment:    "yesterday tests, today review, problems: CI is down",
+		UsernameID: "userID1",
+		MessageTS:  "123",
+		Problems:   "CI is down",
+	})
+	assert.NoError(t, err)
+	standup2, err := r.DB.CreateStandup(model.Standup{
+		ChannelID:  channelID,
+		Comment:    "yesterday tests, today review, problems",
+		UsernameID: "userID2",
+		MessageTS:  "1234",
+	})
+	assert.NoError(t, err)
+
+	actual, err = r.StandupBlockersReport(channelID, dateFrom, dateTo)
+	assert.NoError(t, err)
+	expected = "Blockers on project <#QWERTY123>:\n\nReport for: 2018-06-04\nNo blockers for this day\n\nReport for: 2018-06-05\n<@userID1>: CI is down\n\n"
+	assert.Equal(t, expected, actual)
+
+	assert.NoError(t, r.DB.DeleteStandup(standup1.ID))
+	assert.NoError(t, r.DB.DeleteStandup(standup2.ID))
+}
+
+func TestReportTimezone(t *testing.T) {
+	// 20:00 in UTC is 02:00 of the next day in Bishkek
+	d := time.Date(2018, 6, 4, 20, 0, 0, 0, time.UTC)
+	monkey.Patch(time.Now, func() time.Time { return d })
+	defer monkey.Unpatch(time.Now)
+
+	c, err := config.Get()
+	assert.NoError(t, err)
+	r, err := NewReporter(c, storage.NewMemory())
+	assert.NoError(t, err)
+
+	for _, channelID := range []string{"UTCCHAN", "BISHKEKCHAN"} {
+		_, err = r.DB.CreateStandup(model.Standup{ChannelID: channelID, Comment: "standup", UsernameID: "userID1", MessageTS: channelID, Problems: "CI is down"})
+		assert.NoError(t, err)
+	}
+	_, err = r.DB.CreateStandupTime(model.StandupTime{ChannelID: "BISHKEKCHAN", Time: d.Unix(), Timezone: "Asia/Bishkek"})
+	assert.NoError(t, err)
+
+	d = time.Date(2018, 6, 6, 0, 0, 0, 0, time.UTC)
+	dateFrom := time.Date(2018, 6, 4, 0, 0, 0, 0, time.UTC)
+	dateTo := time.Date(2018, 6, 5, 0, 0, 0, 0, time.UTC)
+
+	actual, err := r.StandupBlockersReport("UTCCHAN", dateFrom, dateTo)
+	assert.NoError(t, err)
+	assert.Equal(t, "Blockers on project <#UTCCHAN>:\n\nReport for: 2018-06-04\n<@userID1>: CI is down\n\nReport for: 2018-06-05\nNo blockers for this day\n\n", actual)
+
+	actual, err = r.StandupBlockersReport("BISHKEKCHAN", dateFrom, dateTo)
+	assert.NoError(t, err)
+	assert.Equal(t, "Blockers on project <#BISHKEKCHAN>:\n\nReport for: 2018-06-04\nNo blockers for this day\n\nReport for: 2018-06-05\n<@userID1>: CI is down\n\n", actual)
+}
+
+func TestReportOnLeave(t *testing.T) {
+	d := time.Date(2018, 7, 10, 12, 0, 0, 0, time.UTC)
+	monkey.Patch(time.Now, func() time.Time { return d })
+	defer monkey.Unpatch(time.Now)
+
+	c, err := config.Get()
+	assert.NoError(t, err)
+	r, err := NewReporter(c, storage.NewMemory())
+	assert.NoError(t, err)
+
+	user, err := r.DB.CreateStandupUser(model.StandupUser{SlackUserID: "userID1", SlackName: "user1", ChannelID: "LEAVECHAN", Channel: "chanName"})
+	assert.NoError(t, err)
+	_, err = r.DB.CreateAbsence(model.Absence{SlackUserID: "userID1", DateFrom: "2018-07-09", DateTo: "2018-07-09"})
+	assert.NoError(t, err)
+
+	dateFrom := time.Date(2018, 7, 9, 0, 0, 0, 0, time.UTC)
+	dateTo := time.Date(2018, 7, 10, 0, 0, 0, 0, time.UTC)
+
+	actual, err := r.StandupReportByProject("LEAVECHAN", dateFrom, dateTo, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "Full Report on project <#LEAVECHAN>:\n\nReport for: 2018-07-09\n<@userID1> is on leave\nReport for: 2018-07-10\n<@userID1> did not submit standup!\n", actual)
+
+	actual, err = r.StandupReportByUser(user, dateFrom, dateTo, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "Full Report on user <@userID1>:\n\nReport for: 2018-07-09\nIn <#LEAVECHAN> <@userID1> is on leave\nReport for: 2018-07-10\nIn <#LEAVECHAN> <@userID1> did not submit standup!\n", actual)
+
+	actual, err = r.StandupReportByProjectAndUser("LEAVECHAN", user, dateFrom, dateTo, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "Report on project: <#LEAVECHAN>, and user: <@userID1>\n\nReport for: 2018-07-09\n<@userID1> is on leaveReport for: 2018-07-10\n<@userID1> did not submit standup!", actual)
+}
+
+func TestFetchCollectorData(t *testing.T) {
+	c, err := config.Get()
+	assert.NoError(t, err)
+	r := Text{Translate: c.Translate}
+
+	assert.Equal(t, "", r.collector(nil))
+	assert.Equal(t, "\n\nCommits for period: 3 \nMerges for period: 1\n", r.collector(&collector.Data{TotalCommits: 3, TotalMerges: 1}))
+	assert.Equal(t, "\n\nCommits for period: 3 \nMerges for period: 1\nLogged Hours: 2", r.collector(&collector.Data{TotalCommits: 3, TotalMerges: 1, Worklogs: 7200}))
+	assert.Equal(t, "\n\nCommits for period: 3 \nMerges for period: 1\nReviews for period: 4", r.collector(&collector.Data{TotalCommits: 3, TotalMerges: 1, TotalReviews: 4}))
+}
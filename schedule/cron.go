@@ -0,0 +1,154 @@
+package schedule
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var macros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+}
+
+var monthNames = []string{"", "JAN", "FEB", "MAR", "APR", "MAY", "JUN", "JUL", "AUG", "SEP", "OCT", "NOV", "DEC"}
+
+var dayNames = []string{"SUN", "MON", "TUE", "WED", "THU", "FRI", "SAT"}
+
+// cron is a schedule of a cron expression, fields are kept as bit sets
+type cron struct {
+	minutes, hours, days, months, weekdays uint64
+	// as in cron, a day matches either day of month or weekday if both are restricted
+	anyDay, anyWeekday bool
+}
+
+func parseCron(expr string) (Schedule, error) {
+	if m, ok := macros[strings.ToLower(expr)]; ok {
+		expr = m
+	}
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("schedule: cron expression must have 5 fields, got %v", len(fields))
+	}
+	c := &cron{anyDay: fields[2] == "*", anyWeekday: fields[4] == "*"}
+	var err error
+	if c.minutes, err = parseField(fields[0], 0, 59, nil); err != nil {
+		return nil, err
+	}
+	if c.hours, err = parseField(fields[1], 0, 23, nil); err != nil {
+		return nil, err
+	}
+	if c.days, err = parseField(fields[2], 1, 31, nil); err != nil {
+		return nil, err
+	}
+	if c.months, err = parseField(fields[3], 1, 12, monthNames); err != nil {
+		return nil, err
+	}
+	// both 0 and 7 are Sunday
+	if c.weekdays, err = parseField(fields[4], 0, 7, dayNames); err != nil {
+		return nil, err
+	}
+	if c.weekdays&(1<<7) != 0 {
+		c.weekdays |= 1
+	}
+	// standups happen once a day, On could not tell which of several times is meant
+	if bits.OnesCount64(c.minutes) != 1 || bits.OnesCount64(c.hours) != 1 {
+		return nil, errors.New("schedule: standup happens once a day, give one minute and one hour")
+	}
+	return c, nil
+}
+
+// parseField parses comma separated values, ranges and steps, e.g. "1-5", "*/15" or "MON,WED"
+func parseField(field string, min, max int, names []string) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			s, err := strconv.Atoi(part[i+1:])
+			if err != nil || s <= 0 {
+				return 0, fmt.Errorf("schedule: wrong step in %q", part)
+			}
+			step = s
+			part = part[:i]
+		}
+		from, to := min, max
+		if part != "*" {
+			bounds := strings.SplitN(part, "-", 2)
+			var err error
+			if from, err = parseValue(bounds[0], names); err != nil {
+				return 0, err
+			}
+			to = from
+			if len(bounds) == 2 {
+				if to, err = parseValue(bounds[1], names); err != nil {
+					return 0, err
+				}
+			} else if step > 1 {
+				// "5/15" means from 5 to the end with step 15
+				to = max
+			}
+		}
+		if from < min || to > max || from > to {
+			return 0, fmt.Errorf("schedule: %q is out of range %v-%v", part, min, max)
+		}
+		for v := from; v <= to; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+func parseValue(s string, names []string) (int, error) {
+	for i, name := range names {
+		if name != "" && strings.EqualFold(s, name) {
+			return i, nil
+		}
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, errors.New("schedule: wrong value " + s)
+	}
+	return v, nil
+}
+
+// On returns time of the day matching expression
+func (c *cron) On(t time.Time) (time.Time, bool) {
+	if !c.matchesDay(t) {
+		return time.Time{}, false
+	}
+	for h := 0; h < 24; h++ {
+		if c.hours&(1<<uint(h)) == 0 {
+			continue
+		}
+		for m := 0; m < 60; m++ {
+			if c.minutes&(1<<uint(m)) != 0 {
+				return time.Date(t.Year(), t.Month(), t.Day(), h, m, 0, 0, t.Location()), true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+func (c *cron) matchesDay(t time.Time) bool {
+	if c.months&(1<<uint(t.Month())) == 0 {
+		return false
+	}
+	day := c.days&(1<<uint(t.Day())) != 0
+	weekday := c.weekdays&(1<<uint(t.Weekday())) != 0
+	switch {
+	case c.anyDay && c.anyWeekday:
+		return true
+	case c.anyDay:
+		return weekday
+	case c.anyWeekday:
+		return day
+	}
+	return day || weekday
+}
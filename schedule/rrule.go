@@ -0,0 +1,218 @@
+package schedule
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ruleDays = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+// rule is a schedule of iCalendar recurrence rule. Supported are DAILY, WEEKLY and MONTHLY
+// frequencies with INTERVAL, UNTIL, BYMONTH, BYMONTHDAY, BYDAY, BYHOUR and BYMINUTE parts
+type rule struct {
+	freq     string
+	interval int
+	start    time.Time
+	hasStart bool
+	until    time.Time
+	months   map[int]bool
+	days     map[int]bool
+	weekdays map[time.Weekday]bool
+	hour     int
+	minute   int
+}
+
+func parseRule(expr string) (Schedule, error) {
+	r := &rule{interval: 1, hour: -1}
+	var parts string
+	for _, line := range strings.Fields(expr) {
+		name, value := line, ""
+		if i := strings.Index(line, ":"); i >= 0 {
+			name, value = line[:i], line[i+1:]
+		}
+		name = strings.ToUpper(strings.SplitN(name, ";", 2)[0])
+		switch {
+		case name == "DTSTART":
+			start, err := parseRuleTime(value)
+			if err != nil {
+				return nil, err
+			}
+			r.start, r.hasStart = start, true
+		case name == "RRULE":
+			parts = value
+		case strings.HasPrefix(name, "FREQ="):
+			parts = line
+		default:
+			return nil, fmt.Errorf("schedule: unknown rule line %q", line)
+		}
+	}
+	if parts == "" {
+		return nil, errors.New("schedule: RRULE is missing")
+	}
+	for _, part := range strings.Split(parts, ";") {
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("schedule: wrong rule part %q", part)
+		}
+		if err := r.set(strings.ToUpper(kv[0]), strings.ToUpper(kv[1])); err != nil {
+			return nil, err
+		}
+	}
+	return r, r.validate()
+}
+
+func (r *rule) set(name, value string) error {
+	var err error
+	switch name {
+	case "FREQ":
+		if value != "DAILY" && value != "WEEKLY" && value != "MONTHLY" {
+			return fmt.Errorf("schedule: unsupported frequency %v", value)
+		}
+		r.freq = value
+	case "INTERVAL":
+		r.interval, err = strconv.Atoi(value)
+		if err != nil || r.interval < 1 {
+			return fmt.Errorf("schedule: wrong interval %v", value)
+		}
+	case "UNTIL":
+		r.until, err = parseRuleTime(value)
+	case "BYMONTH":
+		r.months, err = parseNumbers(value, 1, 12)
+	case "BYMONTHDAY":
+		r.days, err = parseNumbers(value, 1, 31)
+	case "BYDAY":
+		r.weekdays = map[time.Weekday]bool{}
+		for _, d := range strings.Split(value, ",") {
+			weekday, ok := ruleDays[d]
+			if !ok {
+				return fmt.Errorf("schedule: unsupported day %v", d)
+			}
+			r.weekdays[weekday] = true
+		}
+	case "BYHOUR":
+		r.hour, err = oneNumber(value, 0, 23)
+	case "BYMINUTE":
+		r.minute, err = oneNumber(value, 0, 59)
+	case "WKST":
+		// weeks always start on Monday
+	default:
+		return fmt.Errorf("schedule: unsupported rule part %v", name)
+	}
+	return err
+}
+
+func (r *rule) validate() error {
+	if r.freq == "" {
+		return errors.New("schedule: FREQ is missing")
+	}
+	if r.interval > 1 && !r.hasStart {
+		return errors.New("schedule: INTERVAL needs DTSTART")
+	}
+	if r.hour < 0 {
+		if !r.hasStart {
+			return errors.New("schedule: standup time is not set, use BYHOUR and BYMINUTE or DTSTART")
+		}
+		r.hour, r.minute = r.start.Hour(), r.start.Minute()
+	}
+	// rules repeat on the day of DTSTART if days are not given
+	if r.freq == "WEEKLY" && r.weekdays == nil {
+		if !r.hasStart {
+			return errors.New("schedule: weekly rule needs BYDAY or DTSTART")
+		}
+		r.weekdays = map[time.Weekday]bool{r.start.Weekday(): true}
+	}
+	if r.freq == "MONTHLY" && r.weekdays == nil && r.days == nil {
+		if !r.hasStart {
+			return errors.New("schedule: monthly rule needs BYMONTHDAY, BYDAY or DTSTART")
+		}
+		r.days = map[int]bool{r.start.Day(): true}
+	}
+	return nil
+}
+
+// On returns standup time of the day if the rule repeats on this day
+func (r *rule) On(t time.Time) (time.Time, bool) {
+	if !r.matchesDay(t) {
+		return time.Time{}, false
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), r.hour, r.minute, 0, 0, t.Location()), true
+}
+
+func (r *rule) matchesDay(t time.Time) bool {
+	if r.hasStart && date(t).Before(date(r.start)) {
+		return false
+	}
+	if !r.until.IsZero() && date(t).After(date(r.until)) {
+		return false
+	}
+	if r.months != nil && !r.months[int(t.Month())] {
+		return false
+	}
+	if r.days != nil && !r.days[t.Day()] {
+		return false
+	}
+	if r.weekdays != nil && !r.weekdays[t.Weekday()] {
+		return false
+	}
+	if !r.hasStart {
+		return true
+	}
+	switch r.freq {
+	case "DAILY":
+		return daysBetween(r.start, t)%r.interval == 0
+	case "WEEKLY":
+		return daysBetween(monday(r.start), monday(t))/7%r.interval == 0
+	}
+	months := (t.Year()-r.start.Year())*12 + int(t.Month()) - int(r.start.Month())
+	return months%r.interval == 0
+}
+
+// monday returns Monday of the week of t
+func monday(t time.Time) time.Time {
+	return t.AddDate(0, 0, -(int(t.Weekday())+6)%7)
+}
+
+// parseRuleTime parses DATE and DATE-TIME values, e.g. 20180702 or 20180702T093000Z
+func parseRuleTime(value string) (time.Time, error) {
+	for _, layout := range []string{"20060102T150405Z", "20060102T150405", "20060102"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("schedule: wrong date %v", value)
+}
+
+func parseNumbers(value string, min, max int) (map[int]bool, error) {
+	numbers := map[int]bool{}
+	for _, s := range strings.Split(value, ",") {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < min || n > max {
+			return nil, fmt.Errorf("schedule: %v is out of range %v-%v", s, min, max)
+		}
+		numbers[n] = true
+	}
+	return numbers, nil
+}
+
+// oneNumber parses a single number, standups happen once a day
+func oneNumber(value string, min, max int) (int, error) {
+	if strings.Contains(value, ",") {
+		return 0, fmt.Errorf("schedule: standup happens once a day, got %v", value)
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < min || n > max {
+		return 0, fmt.Errorf("schedule: %v is out of range %v-%v", value, min, max)
+	}
+	return n, nil
+}
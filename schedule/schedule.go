@@ -0,0 +1,73 @@
+// Package schedule parses standup schedules of channels written as cron expressions
+// or iCalendar recurrence rules
+package schedule
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// maxLookAhead limits search for the next standup
+const maxLookAhead = 366
+
+// Schedule tells on which days and at what time standups happen
+type Schedule interface {
+	// On returns time of standup on the day of t in the time zone of t,
+	// false if there is no standup that day
+	On(t time.Time) (time.Time, bool)
+}
+
+// Parse parses a cron expression with 5 fields, e.g. "30 9 * * MON,WED,FRI", or a recurrence
+// rule, e.g. "RRULE:FREQ=WEEKLY;BYDAY=FR;BYHOUR=16". A rule may be preceded by its
+// DTSTART, e.g. "DTSTART:20180702T093000 RRULE:FREQ=WEEKLY;INTERVAL=2"
+func Parse(expr string) (Schedule, error) {
+	expr = strings.TrimSpace(expr)
+	if expr == "" {
+		return nil, errors.New("schedule: empty schedule")
+	}
+	upper := strings.ToUpper(expr)
+	if strings.HasPrefix(upper, "RRULE") || strings.HasPrefix(upper, "DTSTART") || strings.HasPrefix(upper, "FREQ=") {
+		return parseRule(expr)
+	}
+	return parseCron(expr)
+}
+
+// Next returns the first standup after t, false if there is no standup within a year
+func Next(s Schedule, t time.Time) (time.Time, bool) {
+	for i := 0; i <= maxLookAhead; i++ {
+		day := t.AddDate(0, 0, i)
+		if at, ok := s.On(day); ok && at.After(t) {
+			return at, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// Upcoming checks that schedule has a standup within a year after t,
+// e.g. "0 9 31 2 *" is a valid expression, but never happens
+func Upcoming(s Schedule, t time.Time) error {
+	if _, ok := Next(s, t); !ok {
+		return errors.New("schedule: there is no standup within a year")
+	}
+	return nil
+}
+
+// Previous returns the last standup before the day of t, false if there is no standup within a year
+func Previous(s Schedule, t time.Time) (time.Time, bool) {
+	for i := 1; i <= maxLookAhead; i++ {
+		if at, ok := s.On(t.AddDate(0, 0, -i)); ok {
+			return at, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// date returns midnight of the day of t in UTC, it is used to count days between dates
+func date(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func daysBetween(from, to time.Time) int {
+	return int(date(to).Sub(date(from)).Hours() / 24)
+}
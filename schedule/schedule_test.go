@@ -0,0 +1,105 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	for _, expr := range []string{
+		"30 9 * * *",
+		"30 9 * * MON,WED,FRI",
+		"0 10 * * 5",
+		"15 9 1,15 * *",
+		"0 9 31 2 *",
+		"@weekly",
+		"RRULE:FREQ=WEEKLY;BYDAY=MO,WE,FR;BYHOUR=9;BYMINUTE=30",
+		"FREQ=DAILY;BYHOUR=9",
+		"DTSTART:20180702T093000 RRULE:FREQ=WEEKLY;INTERVAL=2",
+	} {
+		_, err := Parse(expr)
+		assert.NoError(t, err, expr)
+	}
+	for _, expr := range []string{
+		"",
+		"30 9 * *",
+		"61 9 * * *",
+		"30 9 * * FUNDAY",
+		"30 9 * * 5-1",
+		"* * * * *",
+		"30 9,14 * * *",
+		"*/15 9-17 1,15 * *",
+		"RRULE:FREQ=YEARLY;BYHOUR=9",
+		"RRULE:FREQ=WEEKLY;BYDAY=MO",
+		"RRULE:FREQ=WEEKLY;INTERVAL=2;BYDAY=MO;BYHOUR=9",
+		"RRULE:FREQ=WEEKLY;BYDAY=1MO;BYHOUR=9",
+		"RRULE:FREQ=DAILY;COUNT=3;BYHOUR=9",
+		"RRULE:FREQ=DAILY;BYHOUR=9,14",
+	} {
+		_, err := Parse(expr)
+		assert.Error(t, err, expr)
+	}
+}
+
+func TestOn(t *testing.T) {
+	// 2018-07-02 is Monday
+	day := func(d int) time.Time { return time.Date(2018, 7, d, 0, 0, 0, 0, time.UTC) }
+	at := func(d, h, m int) time.Time { return time.Date(2018, 7, d, h, m, 0, 0, time.UTC) }
+
+	testCases := []struct {
+		expr string
+		days map[int]time.Time
+	}{
+		{"30 9 * * MON,WED,FRI", map[int]time.Time{2: at(2, 9, 30), 3: {}, 4: at(4, 9, 30), 6: at(6, 9, 30), 7: {}, 8: {}}},
+		{"0 10 * * 0", map[int]time.Time{1: at(1, 10, 0), 7: {}, 8: at(8, 10, 0)}},
+		{"45 8 1-3 * *", map[int]time.Time{1: at(1, 8, 45), 3: at(3, 8, 45), 4: {}}},
+		// day of month or weekday
+		{"0 9 15 * FRI", map[int]time.Time{6: at(6, 9, 0), 15: at(15, 9, 0), 16: {}}},
+		{"RRULE:FREQ=WEEKLY;BYDAY=MO,WE,FR;BYHOUR=9;BYMINUTE=30", map[int]time.Time{2: at(2, 9, 30), 3: {}, 4: at(4, 9, 30)}},
+		{"DTSTART:20180702T093000 RRULE:FREQ=WEEKLY;INTERVAL=2", map[int]time.Time{1: {}, 2: at(2, 9, 30), 9: {}, 16: at(16, 9, 30), 17: {}}},
+		{"DTSTART:20180703T110000 RRULE:FREQ=DAILY;INTERVAL=3;UNTIL=20180710", map[int]time.Time{2: {}, 3: at(3, 11, 0), 6: at(6, 11, 0), 9: at(9, 11, 0), 12: {}}},
+		{"RRULE:FREQ=MONTHLY;BYMONTHDAY=1,15;BYHOUR=12", map[int]time.Time{1: at(1, 12, 0), 15: at(15, 12, 0), 16: {}}},
+	}
+	for _, tt := range testCases {
+		s, err := Parse(tt.expr)
+		assert.NoError(t, err, tt.expr)
+		for d, expected := range tt.days {
+			actual, ok := s.On(day(d))
+			assert.Equal(t, !expected.IsZero(), ok, "%v on %v", tt.expr, d)
+			if ok {
+				assert.Equal(t, expected, actual, "%v on %v", tt.expr, d)
+			}
+		}
+	}
+}
+
+func TestNextAndPrevious(t *testing.T) {
+	s, err := Parse("30 9 * * MON,WED,FRI")
+	assert.NoError(t, err)
+	bishkek, err := time.LoadLocation("Asia/Bishkek")
+	assert.NoError(t, err)
+
+	next, ok := Next(s, time.Date(2018, 7, 6, 10, 0, 0, 0, bishkek))
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2018, 7, 9, 9, 30, 0, 0, bishkek), next)
+	next, ok = Next(s, time.Date(2018, 7, 6, 9, 0, 0, 0, bishkek))
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2018, 7, 6, 9, 30, 0, 0, bishkek), next)
+
+	previous, ok := Previous(s, time.Date(2018, 7, 9, 9, 30, 0, 0, bishkek))
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2018, 7, 6, 9, 30, 0, 0, bishkek), previous)
+
+	s, err = Parse("DTSTART:20180702T093000 RRULE:FREQ=DAILY;UNTIL=20180703")
+	assert.NoError(t, err)
+	_, ok = Next(s, time.Date(2018, 7, 4, 0, 0, 0, 0, time.UTC))
+	assert.False(t, ok)
+	assert.Error(t, Upcoming(s, time.Date(2018, 7, 4, 0, 0, 0, 0, time.UTC)))
+	assert.NoError(t, Upcoming(s, time.Date(2018, 7, 1, 0, 0, 0, 0, time.UTC)))
+
+	s, err = Parse("0 9 31 2 *")
+	assert.NoError(t, err)
+	assert.Error(t, Upcoming(s, time.Date(2018, 7, 4, 0, 0, 0, 0, time.UTC)))
+}
@@ -0,0 +1,825 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/maddevsio/comedian/config"
+	"github.com/maddevsio/comedian/model"
+	"github.com/stretchr/testify/assert"
+)
+
+// conformanceTests describe behaviour every Storage implementation must have.
+// Each test gets an empty storage
+var conformanceTests = []struct {
+	name string
+	test func(*testing.T, Storage)
+}{
+	{"standups", testStandups},
+	{"standup users", testStandupUsers},
+	{"non reporters", testNonReporters},
+	{"standup time", testStandupTime},
+	{"standup edit history", testStandupEditHistory},
+	{"platforms", testPlatforms},
+	{"standup sections", testStandupSections},
+	{"standup questions", testStandupQuestions},
+	{"standup time zone", testStandupTimezone},
+	{"holidays", testHolidays},
+	{"absences", testAbsences},
+	{"reminder runs", testReminderRuns},
+	{"locks", testLocks},
+	{"standup schedule", testStandupSchedule},
+	{"escalation steps", testEscalationSteps},
+	{"links", testLinks},
+	{"select by id", testSelectByID},
+	{"api tokens", testAPITokens},
+	{"webhooks", testWebhooks},
+	{"concurrent access", testConcurrentAccess},
+}
+
+func runConformance(t *testing.T, newStorage func(t *testing.T) Storage) {
+	for _, tt := range conformanceTests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.test(t, newStorage(t))
+		})
+	}
+}
+
+func TestMemoryConformance(t *testing.T) {
+	runConformance(t, func(t *testing.T) Storage {
+		return NewMemory()
+	})
+}
+
+func TestSQLiteConformance(t *testing.T) {
+	dir, err := ioutil.TempDir("", "comedian")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	n := 0
+	runConformance(t, func(t *testing.T) Storage {
+		n++
+		db, err := NewSQLite(config.Config{DatabaseURL: "sqlite://" + filepath.Join(dir, fmt.Sprintf("comedian%d.db", n))})
+		assert.NoError(t, err)
+		return db
+	})
+}
+
+// MySQL and PostgreSQL suites need a running server, they are skipped
+// unless COMEDIAN_TEST_MYSQL or COMEDIAN_TEST_POSTGRES point to a database
+func TestMySQLConformance(t *testing.T) {
+	url := os.Getenv("COMEDIAN_TEST_MYSQL")
+	if url == "" {
+		t.Skip("COMEDIAN_TEST_MYSQL is not set")
+	}
+	runConformance(t, func(t *testing.T) Storage {
+		db, err := NewMySQL(config.Config{DatabaseURL: url})
+		assert.NoError(t, err)
+		truncate(t, db.conn)
+		return db
+	})
+}
+
+func TestPostgresConformance(t *testing.T) {
+	url := os.Getenv("COMEDIAN_TEST_POSTGRES")
+	if url == "" {
+		t.Skip("COMEDIAN_TEST_POSTGRES is not set")
+	}
+	runConformance(t, func(t *testing.T) Storage {
+		db, err := NewPostgres(config.Config{DatabaseURL: url})
+		assert.NoError(t, err)
+		truncate(t, db.conn)
+		return db
+	})
+}
+
+func truncate(t *testing.T, conn *sqlx.DB) {
+	for _, table := range []string{"standup", "standup_users", "standup_time", "standup_edit_history"} {
+		_, err := conn.Exec("DELETE FROM " + table)
+		assert.NoError(t, err)
+	}
+}
+
+func testStandups(t *testing.T, db Storage) {
+	s, err := db.CreateStandup(model.Standup{
+		ChannelID:  "QWERTY123",
+		Comment:    "work hard",
+		UsernameID: "userID1",
+		MessageTS:  "qweasdzxc",
+	})
+	assert.NoError(t, err)
+	assert.NotEqual(t, int64(0), s.ID)
+	assert.Equal(t, "work hard", s.Comment)
+
+	_, err = db.CreateStandup(model.Standup{ChannelID: "QWERTY123", UsernameID: "userID1"})
+	assert.Error(t, err)
+
+	s2, err := db.CreateStandup(model.Standup{
+		ChannelID:  "ASDF098",
+		Comment:    "stubComment",
+		UsernameID: "userID2",
+		MessageTS:  "you are not prepared",
+	})
+	assert.NoError(t, err)
+
+	dayAgo, dayAfter := time.Now().AddDate(0, 0, -1), time.Now().AddDate(0, 0, 1)
+	sps, err := db.SelectStandupsFiltered("userID1", "QWERTY123", dayAgo, dayAfter)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(sps))
+	sps, err = db.SelectStandupsFiltered("userID2", "QWERTY123", dayAgo, dayAfter)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(sps))
+	sps, err = db.SelectStandupsByChannelIDForPeriod("QWERTY123", dayAgo, dayAfter)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(sps))
+	sps, err = db.SelectStandupsByChannelIDForPeriod("QWERTY123", time.Now().AddDate(0, 0, -2), dayAgo)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(sps))
+
+	s.Comment = "Rest"
+	s.Answers = model.Answers{{Question: "done", Text: "tests"}, {Question: "blockers", Text: "none"}}
+	updated, err := db.UpdateStandup(s)
+	assert.NoError(t, err)
+	assert.Equal(t, s.ID, updated.ID)
+	assert.Equal(t, "Rest", updated.Comment)
+	assert.Equal(t, s.Answers, updated.Answers)
+	assert.Equal(t, "QWERTY123", updated.ChannelID)
+	assert.False(t, updated.Created.IsZero())
+	assert.False(t, updated.Modified.IsZero())
+
+	_, err = db.UpdateStandup(model.Standup{ID: s2.ID + 100, Comment: "missing"})
+	assert.Equal(t, sql.ErrNoRows, err)
+
+	selected, err := db.SelectStandupByMessageTS(s2.MessageTS)
+	assert.NoError(t, err)
+	assert.Equal(t, s2.ID, selected.ID)
+	assert.Equal(t, model.Answers(nil), selected.Answers)
+	_, err = db.SelectStandupByMessageTS("unknown")
+	assert.Equal(t, sql.ErrNoRows, err)
+
+	items, err := db.ListStandups()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(items))
+	assert.Equal(t, "Rest", items[0].Comment)
+
+	assert.NoError(t, db.DeleteStandup(s.ID))
+	assert.NoError(t, db.DeleteStandup(s2.ID))
+	items, err = db.ListStandups()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(items))
+}
+
+func testStandupUsers(t *testing.T, db Storage) {
+	su1, err := db.CreateStandupUser(model.StandupUser{SlackUserID: "userID1", SlackName: "user1", ChannelID: "123qwe", Channel: "channel1", Role: "user"})
+	assert.NoError(t, err)
+	assert.NotEqual(t, int64(0), su1.ID)
+	su2, err := db.CreateStandupUser(model.StandupUser{SlackUserID: "userID2", SlackName: "user2", ChannelID: "qwe123", Channel: "channel2", Role: "admin"})
+	assert.NoError(t, err)
+	_, err = db.CreateStandupUser(model.StandupUser{SlackUserID: "userID1", SlackName: "user1", ChannelID: "qwe123", Channel: "channel2"})
+	assert.NoError(t, err)
+	_, err = db.CreateStandupUser(model.StandupUser{})
+	assert.Error(t, err)
+
+	assert.False(t, db.IsAdmin(su1.SlackUserID, su1.ChannelID))
+	assert.True(t, db.IsAdmin(su2.SlackUserID, su2.ChannelID))
+	assert.False(t, db.IsAdmin(su2.SlackUserID, su1.ChannelID))
+	admins, err := db.ListChannelAdmins("qwe123")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(admins))
+	assert.Equal(t, "userID2", admins[0].SlackUserID)
+
+	user, err := db.FindStandupUserInChannelByUserID("userID2", "qwe123")
+	assert.NoError(t, err)
+	assert.Equal(t, "user2", user.SlackName)
+	assert.Equal(t, "channel2", user.Channel)
+	assert.Equal(t, "admin", user.Role)
+	_, err = db.FindStandupUserInChannelByUserID("userID2", "123qwe")
+	assert.Equal(t, sql.ErrNoRows, err)
+
+	user, err = db.FindStandupUser("user1")
+	assert.NoError(t, err)
+	assert.Equal(t, "userID1", user.SlackUserID)
+	_, err = db.FindStandupUser("nobody")
+	assert.Equal(t, sql.ErrNoRows, err)
+
+	// admins are not standupers
+	users, err := db.ListAllStandupUsers()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(users))
+	users, err = db.ListStandupUsersByChannelID("qwe123")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(users))
+	assert.Equal(t, "userID1", users[0].SlackUserID)
+
+	channels, err := db.GetAllChannels()
+	assert.NoError(t, err)
+	sort.Strings(channels)
+	assert.Equal(t, []string{"123qwe", "qwe123"}, channels)
+	channels, err = db.GetUserChannels("userID1")
+	assert.NoError(t, err)
+	sort.Strings(channels)
+	assert.Equal(t, []string{"123qwe", "qwe123"}, channels)
+
+	existed, err := db.HasExistedAlready("userID1", "123qwe", time.Now().Add(time.Second))
+	assert.NoError(t, err)
+	assert.True(t, existed)
+	existed, err = db.HasExistedAlready("userID1", "123qwe", time.Now().AddDate(0, 0, -1))
+	assert.NoError(t, err)
+	assert.False(t, existed)
+	existed, err = db.HasExistedAlready("userID3", "123qwe", time.Now().Add(time.Second))
+	assert.NoError(t, err)
+	assert.False(t, existed)
+
+	assert.NoError(t, db.DeleteStandupUser("user1", "123qwe"))
+	users, err = db.ListStandupUsersByChannelID("123qwe")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(users))
+	channels, err = db.GetUserChannels("userID1")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"qwe123"}, channels)
+}
+
+func testNonReporters(t *testing.T, db Storage) {
+	for _, u := range []model.StandupUser{
+		{SlackUserID: "userID1", SlackName: "user1", ChannelID: "chan1", Role: "user"},
+		{SlackUserID: "userID2", SlackName: "user2", ChannelID: "chan1"},
+		{SlackUserID: "userID3", SlackName: "user3", ChannelID: "chan1", Role: "admin"},
+		{SlackUserID: "userID4", SlackName: "user4", ChannelID: "chan2", Role: "user"},
+	} {
+		_, err := db.CreateStandupUser(u)
+		assert.NoError(t, err)
+	}
+	from, to := time.Now().AddDate(0, 0, -1), time.Now().AddDate(0, 0, 1)
+
+	nonReporters, err := db.GetNonReporters("chan1", from, to)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(nonReporters))
+
+	// standup in other channel does not count
+	_, err = db.CreateStandup(model.Standup{ChannelID: "chan2", UsernameID: "userID1", Comment: "standup", MessageTS: "1"})
+	assert.NoError(t, err)
+	isNonReporter, err := db.IsNonReporter("userID1", "chan1", from, to)
+	assert.NoError(t, err)
+	assert.True(t, isNonReporter)
+
+	_, err = db.CreateStandup(model.Standup{ChannelID: "chan1", UsernameID: "userID1", Comment: "standup", MessageTS: "2"})
+	assert.NoError(t, err)
+	isNonReporter, err = db.IsNonReporter("userID1", "chan1", from, to)
+	assert.NoError(t, err)
+	assert.False(t, isNonReporter)
+
+	nonReporters, err = db.GetNonReporters("chan1", from, to)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(nonReporters))
+	assert.Equal(t, "userID2", nonReporters[0].SlackUserID)
+
+	// standups outside of period do not count
+	nonReporters, err = db.GetNonReporters("chan1", time.Now().AddDate(0, 0, -2), from)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(nonReporters))
+	isNonReporter, err = db.IsNonReporter("userID1", "chan1", time.Now().AddDate(0, 0, -2), from)
+	assert.NoError(t, err)
+	assert.True(t, isNonReporter)
+}
+
+func testStandupTime(t *testing.T, db Storage) {
+	st, err := db.CreateStandupTime(model.StandupTime{ChannelID: "chanid", Channel: "chanName", Time: int64(12)})
+	assert.NoError(t, err)
+	assert.Equal(t, "chanid", st.ChannelID)
+	assert.Equal(t, int64(12), st.Time)
+
+	_, err = db.CreateStandupTime(model.StandupTime{})
+	assert.Error(t, err)
+
+	got, err := db.GetChannelStandupTime("chanid")
+	assert.NoError(t, err)
+	assert.Equal(t, st.ID, got.ID)
+	assert.Equal(t, "chanName", got.Channel)
+	assert.Equal(t, int64(12), got.Time)
+
+	_, err = db.CreateStandupTime(model.StandupTime{ChannelID: "chanid222", Channel: "chanName2", Time: int64(13)})
+	assert.NoError(t, err)
+	all, err := db.ListAllStandupTime()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(all))
+
+	assert.NoError(t, db.DeleteStandupTime("chanid"))
+	got, err = db.GetChannelStandupTime("chanid")
+	assert.Equal(t, sql.ErrNoRows, err)
+	assert.Equal(t, int64(0), got.Time)
+	all, err = db.ListAllStandupTime()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(all))
+}
+
+func testStandupEditHistory(t *testing.T, db Storage) {
+	h, err := db.AddToStandupHistory(model.StandupEditHistory{StandupID: 1, StandupText: "old text"})
+	assert.NoError(t, err)
+	assert.NotEqual(t, int64(0), h.ID)
+	assert.Equal(t, "old text", h.StandupText)
+
+	_, err = db.AddToStandupHistory(model.StandupEditHistory{StandupID: 1})
+	assert.Error(t, err)
+
+	_, err = db.AddToStandupHistory(model.StandupEditHistory{StandupID: 2, StandupText: "other standup"})
+	assert.NoError(t, err)
+	_, err = db.AddToStandupHistory(model.StandupEditHistory{StandupID: 1, StandupText: "newer text"})
+	assert.NoError(t, err)
+	history, err := db.ListStandupHistory(1)
+	assert.NoError(t, err)
+	if assert.Equal(t, 2, len(history)) {
+		assert.Equal(t, "old text", history[0].StandupText)
+		assert.Equal(t, "newer text", history[1].StandupText)
+		assert.Equal(t, int64(1), history[1].StandupID)
+		assert.False(t, history[0].Created.IsZero())
+	}
+	history, err = db.ListStandupHistory(3)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(history))
+}
+
+func testPlatforms(t *testing.T, db Storage) {
+	_, err := db.CreateStandup(model.Standup{ChannelID: "-100", UsernameID: "42", Comment: "standup", MessageTS: "-100:1", Platform: "telegram"})
+	assert.NoError(t, err)
+	standup, err := db.SelectStandupByMessageTS("-100:1")
+	assert.NoError(t, err)
+	assert.Equal(t, "telegram", standup.Platform)
+	standup.Comment = "edited standup"
+	standup, err = db.UpdateStandup(standup)
+	assert.NoError(t, err)
+	assert.Equal(t, "telegram", standup.Platform)
+
+	_, err = db.CreateStandupUser(model.StandupUser{SlackUserID: "42", SlackName: "ivan", ChannelID: "-100", Role: "user", Platform: "telegram"})
+	assert.NoError(t, err)
+	user, err := db.FindStandupUserInChannelByUserID("42", "-100")
+	assert.NoError(t, err)
+	assert.Equal(t, "telegram", user.Platform)
+
+	_, err = db.CreateStandupTime(model.StandupTime{ChannelID: "-100", Channel: "team", Time: 1535000000, Platform: "telegram"})
+	assert.NoError(t, err)
+	_, err = db.CreateStandupTime(model.StandupTime{ChannelID: "C1", Channel: "general", Time: 1535000000, Platform: "slack"})
+	assert.NoError(t, err)
+	all, err := db.ListAllStandupTime()
+	assert.NoError(t, err)
+	platforms := []string{}
+	for _, st := range all {
+		platforms = append(platforms, st.Platform)
+	}
+	sort.Strings(platforms)
+	assert.Equal(t, []string{"slack", "telegram"}, platforms)
+}
+
+func testStandupSections(t *testing.T, db Storage) {
+	s, err := db.CreateStandup(model.Standup{ChannelID: "QWERTY123", UsernameID: "userID1", Comment: "standup", MessageTS: "1",
+		Yesterday: "fixed tests", Today: "review", Problems: "no problems"})
+	assert.NoError(t, err)
+	standup, err := db.SelectStandupByMessageTS("1")
+	assert.NoError(t, err)
+	assert.Equal(t, s.ID, standup.ID)
+	assert.Equal(t, "fixed tests", standup.Yesterday)
+	assert.Equal(t, "review", standup.Today)
+	assert.Equal(t, "no problems", standup.Problems)
+
+	standup.Today = ""
+	standup.Problems = "CI is down"
+	standup, err = db.UpdateStandup(standup)
+	assert.NoError(t, err)
+	assert.Equal(t, "fixed tests", standup.Yesterday)
+	assert.Equal(t, "", standup.Today)
+	assert.Equal(t, "CI is down", standup.Problems)
+}
+
+func testStandupQuestions(t *testing.T, db Storage) {
+	_, err := db.CreateStandupQuestion(model.StandupQuestion{ChannelID: "QWERTY123", Name: "blockers", Pattern: "/block|stuck/", Position: 1, Role: "problems"})
+	assert.NoError(t, err)
+	_, err = db.CreateStandupQuestion(model.StandupQuestion{ChannelID: "QWERTY123", Name: "done", Pattern: "done", Position: 0})
+	assert.NoError(t, err)
+	_, err = db.CreateStandupQuestion(model.StandupQuestion{ChannelID: "OTHER", Name: "done", Pattern: "done", Position: 0})
+	assert.NoError(t, err)
+	_, err = db.CreateStandupQuestion(model.StandupQuestion{ChannelID: "QWERTY123", Name: "empty"})
+	assert.Error(t, err)
+
+	questions, err := db.ListStandupQuestions("QWERTY123")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(questions))
+	assert.Equal(t, "done", questions[0].Name)
+	assert.Equal(t, "blockers", questions[1].Name)
+	assert.Equal(t, "/block|stuck/", questions[1].Pattern)
+	assert.Equal(t, "problems", questions[1].Role)
+	assert.Equal(t, "", questions[0].Role)
+
+	assert.NoError(t, db.DeleteStandupQuestions("QWERTY123"))
+	questions, err = db.ListStandupQuestions("QWERTY123")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(questions))
+	questions, err = db.ListStandupQuestions("OTHER")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(questions))
+}
+
+func testStandupTimezone(t *testing.T, db Storage) {
+	_, err := db.UpdateStandupTime(model.StandupTime{ChannelID: "QWERTY123", Time: 1535000000, Timezone: "Asia/Bishkek"})
+	assert.Error(t, err)
+
+	_, err = db.CreateStandupTime(model.StandupTime{ChannelID: "QWERTY123", Channel: "chanName", Time: 1535000000, Timezone: "Europe/Berlin"})
+	assert.NoError(t, err)
+	st, err := db.GetChannelStandupTime("QWERTY123")
+	assert.NoError(t, err)
+	assert.Equal(t, "Europe/Berlin", st.Timezone)
+
+	st, err = db.UpdateStandupTime(model.StandupTime{ChannelID: "QWERTY123", Time: 1535010000, Timezone: "Asia/Bishkek"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1535010000), st.Time)
+	assert.Equal(t, "Asia/Bishkek", st.Timezone)
+	assert.Equal(t, "chanName", st.Channel)
+
+	// unknown time zones are not stored
+	_, err = db.UpdateStandupTime(model.StandupTime{ChannelID: "QWERTY123", Time: 1535010000, Timezone: "Mars/Olympus"})
+	assert.Error(t, err)
+	_, err = db.CreateStandupTime(model.StandupTime{ChannelID: "QWERTY124", Channel: "chanName", Time: 1535000000, Timezone: "Mars/Olympus"})
+	assert.Error(t, err)
+	st, err = db.GetChannelStandupTime("QWERTY123")
+	assert.NoError(t, err)
+	assert.Equal(t, "Asia/Bishkek", st.Timezone)
+}
+
+func testHolidays(t *testing.T, db Storage) {
+	_, err := db.CreateHoliday(model.Holiday{ChannelID: "QWERTY123", Date: "2018-12-31", Name: "New Year eve"})
+	assert.NoError(t, err)
+	_, err = db.CreateHoliday(model.Holiday{ChannelID: "QWERTY123", Date: "2018-03-08"})
+	assert.NoError(t, err)
+	_, err = db.CreateHoliday(model.Holiday{ChannelID: "OTHER", Date: "2018-05-01"})
+	assert.NoError(t, err)
+	_, err = db.CreateHoliday(model.Holiday{ChannelID: "QWERTY123"})
+	assert.Error(t, err)
+
+	holidays, err := db.ListHolidays("QWERTY123")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(holidays))
+	assert.Equal(t, "2018-03-08", holidays[0].Date)
+	assert.Equal(t, "2018-12-31", holidays[1].Date)
+	assert.Equal(t, "New Year eve", holidays[1].Name)
+
+	assert.NoError(t, db.DeleteHoliday("QWERTY123", "2018-03-08"))
+	holidays, err = db.ListHolidays("QWERTY123")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(holidays))
+	holidays, err = db.ListHolidays("OTHER")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(holidays))
+
+	_, err = db.CreateStandupTime(model.StandupTime{ChannelID: "QWERTY123", Channel: "chanName", Time: 1535000000, WorkDays: "mon-thu"})
+	assert.NoError(t, err)
+	st, err := db.GetChannelStandupTime("QWERTY123")
+	assert.NoError(t, err)
+	assert.Equal(t, "mon-thu", st.WorkDays)
+	st.WorkDays = "sun-thu"
+	st, err = db.UpdateStandupTime(st)
+	assert.NoError(t, err)
+	assert.Equal(t, "sun-thu", st.WorkDays)
+}
+
+func testAbsences(t *testing.T, db Storage) {
+	_, err := db.CreateAbsence(model.Absence{SlackUserID: "userID1", DateFrom: "2018-07-09", DateTo: "2018-07-13", Reason: "vacation"})
+	assert.NoError(t, err)
+	_, err = db.CreateAbsence(model.Absence{SlackUserID: "userID1", DateFrom: "2018-06-01", DateTo: "2018-06-01"})
+	assert.NoError(t, err)
+	_, err = db.CreateAbsence(model.Absence{SlackUserID: "userID1", DateFrom: "2018-08-02", DateTo: "2018-08-01"})
+	assert.Error(t, err)
+	_, err = db.CreateAbsence(model.Absence{SlackUserID: "userID1"})
+	assert.Error(t, err)
+
+	absences, err := db.ListAbsences("userID1")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(absences))
+	assert.Equal(t, "2018-06-01", absences[0].DateFrom)
+	assert.Equal(t, "2018-07-13", absences[1].DateTo)
+	assert.Equal(t, "vacation", absences[1].Reason)
+
+	day := func(d int) time.Time { return time.Date(2018, 7, d, 10, 0, 0, 0, time.UTC) }
+	for _, tt := range []struct {
+		from, to time.Time
+		absent   bool
+	}{
+		{day(8), day(8), false},
+		{day(9), day(9), true},
+		{day(13), day(13), true},
+		{day(14), day(16), false},
+		{day(6), day(9), true},
+	} {
+		absent, err := db.IsAbsent("userID1", tt.from, tt.to)
+		assert.NoError(t, err)
+		assert.Equal(t, tt.absent, absent, tt.from.String())
+	}
+	absent, err := db.IsAbsent("userID2", day(10), day(10))
+	assert.NoError(t, err)
+	assert.False(t, absent)
+
+	// days of reports end at midnight of the next day, which is not included
+	midnight := func(d int) time.Time { return time.Date(2018, 7, d, 0, 0, 0, 0, time.UTC) }
+	for _, tt := range []struct {
+		day    int
+		absent bool
+	}{
+		{8, false},
+		{9, true},
+		{13, true},
+		{14, false},
+	} {
+		absent, err := db.IsAbsent("userID1", midnight(tt.day), midnight(tt.day+1))
+		assert.NoError(t, err)
+		assert.Equal(t, tt.absent, absent, "day %v", tt.day)
+	}
+
+	// users on leave are not non reporters
+	for _, id := range []string{"userID1", "userID2"} {
+		_, err = db.CreateStandupUser(model.StandupUser{SlackUserID: id, SlackName: id, ChannelID: "QWERTY123", Channel: "chanName"})
+		assert.NoError(t, err)
+	}
+	nonReporters, err := db.GetNonReporters("QWERTY123", day(10).Add(-time.Hour), day(10))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(nonReporters))
+	assert.Equal(t, "userID2", nonReporters[0].SlackUserID)
+	nonReporters, err = db.GetNonReporters("QWERTY123", day(16).Add(-time.Hour), day(16))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(nonReporters))
+	nonReporters, err = db.GetNonReporters("QWERTY123", midnight(8), midnight(9))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(nonReporters))
+
+	assert.NoError(t, db.DeleteAbsence("userID1", "2018-07-09"))
+	absences, err = db.ListAbsences("userID1")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(absences))
+}
+
+func testReminderRuns(t *testing.T, db Storage) {
+	nextAt := time.Date(2018, 7, 9, 10, 0, 0, 0, time.UTC)
+	run, err := db.CreateReminderRun(model.ReminderRun{ChannelID: "QWERTY123", Platform: "slack", Date: "2018-07-09", Stage: model.ReminderStarted, NextAt: nextAt})
+	assert.NoError(t, err)
+	_, err = db.CreateReminderRun(model.ReminderRun{ChannelID: "QWERTY123", Date: "2018-07-09", Stage: model.ReminderStarted, NextAt: nextAt})
+	assert.Error(t, err)
+	_, err = db.CreateReminderRun(model.ReminderRun{ChannelID: "QWERTY123"})
+	assert.Error(t, err)
+	_, err = db.GetReminderRun("QWERTY123", "2018-07-10")
+	assert.Equal(t, sql.ErrNoRows, err)
+
+	next := run
+	next.Stage = model.ReminderDeadline
+	next.Repeats = 1
+	next.NextAt = nextAt.Add(time.Hour)
+	advanced, err := db.AdvanceReminderRun(run, next)
+	assert.NoError(t, err)
+	assert.True(t, advanced)
+	// the same stage is not saved twice
+	advanced, err = db.AdvanceReminderRun(run, next)
+	assert.NoError(t, err)
+	assert.False(t, advanced)
+
+	run, err = db.GetReminderRun("QWERTY123", "2018-07-09")
+	assert.NoError(t, err)
+	assert.Equal(t, "slack", run.Platform)
+	assert.Equal(t, model.ReminderDeadline, run.Stage)
+	assert.Equal(t, 1, run.Repeats)
+	assert.True(t, run.NextAt.Equal(nextAt.Add(time.Hour)), run.NextAt.String())
+
+	runs, err := db.ListUnfinishedReminderRuns()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(runs))
+	done := run
+	done.Stage = model.ReminderDone
+	advanced, err = db.AdvanceReminderRun(run, done)
+	assert.NoError(t, err)
+	assert.True(t, advanced)
+	runs, err = db.ListUnfinishedReminderRuns()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(runs))
+}
+
+func testLocks(t *testing.T, db Storage) {
+	expires := time.Now().Add(time.Minute)
+	ok, err := db.AcquireLock("notifier", "replica1", expires)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = db.AcquireLock("notifier", "replica2", expires)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	// the holder prolongs its lock
+	ok, err = db.AcquireLock("notifier", "replica1", expires.Add(time.Minute))
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = db.AcquireLock("other", "replica2", expires)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	// only the holder releases the lock
+	assert.NoError(t, db.ReleaseLock("notifier", "replica2"))
+	ok, err = db.AcquireLock("notifier", "replica2", expires)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	assert.NoError(t, db.ReleaseLock("notifier", "replica1"))
+	ok, err = db.AcquireLock("notifier", "replica2", time.Now().Add(-time.Second))
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	// expired lock is taken over
+	ok, err = db.AcquireLock("notifier", "replica1", expires)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func testStandupSchedule(t *testing.T, db Storage) {
+	_, err := db.CreateStandupTime(model.StandupTime{ChannelID: "QWERTY123", Channel: "chanName", Time: 1535000000, Schedule: "30 9 * * MON,WED,FRI"})
+	assert.NoError(t, err)
+	st, err := db.GetChannelStandupTime("QWERTY123")
+	assert.NoError(t, err)
+	assert.Equal(t, "30 9 * * MON,WED,FRI", st.Schedule)
+	st.Schedule = ""
+	st, err = db.UpdateStandupTime(st)
+	assert.NoError(t, err)
+	assert.Equal(t, "", st.Schedule)
+}
+
+func testEscalationSteps(t *testing.T, db Storage) {
+	_, err := db.CreateEscalationStep(model.EscalationStep{ChannelID: "QWERTY123", Action: model.EscalateAdmins, Delay: 60, Position: 1})
+	assert.NoError(t, err)
+	_, err = db.CreateEscalationStep(model.EscalationStep{ChannelID: "QWERTY123", Action: model.EscalateUsers, Delay: 0, Position: 0})
+	assert.NoError(t, err)
+	_, err = db.CreateEscalationStep(model.EscalationStep{ChannelID: "QWERTY123", Action: "sms", Delay: 90, Position: 2})
+	assert.Error(t, err)
+	_, err = db.CreateEscalationStep(model.EscalationStep{ChannelID: "OTHER", Action: model.EscalateGeneral, Delay: 30})
+	assert.NoError(t, err)
+
+	steps, err := db.ListEscalationSteps("QWERTY123")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(steps))
+	assert.Equal(t, model.EscalateUsers, steps[0].Action)
+	assert.Equal(t, model.EscalateAdmins, steps[1].Action)
+	assert.Equal(t, 60, steps[1].Delay)
+
+	assert.NoError(t, db.DeleteEscalationSteps("QWERTY123"))
+	steps, err = db.ListEscalationSteps("QWERTY123")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(steps))
+	steps, err = db.ListEscalationSteps("OTHER")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(steps))
+}
+
+func testLinks(t *testing.T, db Storage) {
+	_, err := db.CreateProjectLink(model.ProjectLink{ChannelID: "QWERTY123", Provider: "gitlab", Project: "maddevs/comedian"})
+	assert.NoError(t, err)
+	_, err = db.CreateProjectLink(model.ProjectLink{ChannelID: "QWERTY123", Provider: "gitlab", Project: "maddevs/collector"})
+	assert.NoError(t, err)
+	_, err = db.CreateProjectLink(model.ProjectLink{ChannelID: "QWERTY123", Provider: "gitlab"})
+	assert.Error(t, err)
+	projects, err := db.ListProjectLinks("QWERTY123")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(projects))
+	assert.Equal(t, "maddevs/comedian", projects[1].Project)
+	assert.NoError(t, db.DeleteProjectLink("QWERTY123", "gitlab", "maddevs/comedian"))
+	projects, err = db.ListProjectLinks("QWERTY123")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(projects))
+	assert.Equal(t, "maddevs/collector", projects[0].Project)
+
+	_, err = db.CreateUserLink(model.UserLink{SlackUserID: "userID1", Provider: "gitlab", Username: "user1"})
+	assert.NoError(t, err)
+	_, err = db.CreateUserLink(model.UserLink{SlackUserID: "userID1", Provider: "gitlab"})
+	assert.Error(t, err)
+	users, err := db.ListUserLinks("userID1")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(users))
+	assert.Equal(t, "user1", users[0].Username)
+	assert.NoError(t, db.DeleteUserLink("userID1", "gitlab"))
+	users, err = db.ListUserLinks("userID1")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(users))
+}
+
+func testSelectByID(t *testing.T, db Storage) {
+	s, err := db.CreateStandup(model.Standup{ChannelID: "QWERTY123", UsernameID: "userID1", Comment: "standup", MessageTS: "1"})
+	assert.NoError(t, err)
+	selected, err := db.SelectStandup(s.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "standup", selected.Comment)
+	_, err = db.SelectStandup(s.ID + 100)
+	assert.Error(t, err)
+
+	u, err := db.CreateStandupUser(model.StandupUser{SlackUserID: "userID1", SlackName: "user1", ChannelID: "QWERTY123", Channel: "chanName"})
+	assert.NoError(t, err)
+	user, err := db.SelectStandupUser(u.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "user1", user.SlackName)
+	user.SlackName = "user2"
+	user.Role = "admin"
+	user, err = db.UpdateStandupUser(user)
+	assert.NoError(t, err)
+	assert.Equal(t, "user2", user.SlackName)
+	assert.Equal(t, "admin", user.Role)
+	assert.Equal(t, "userID1", user.SlackUserID)
+	assert.True(t, db.IsAdmin("userID1", "QWERTY123"))
+	_, err = db.UpdateStandupUser(model.StandupUser{ID: u.ID})
+	assert.Error(t, err)
+	_, err = db.SelectStandupUser(u.ID + 100)
+	assert.Error(t, err)
+}
+
+func testAPITokens(t *testing.T, db Storage) {
+	_, err := db.CreateAPIToken(model.APIToken{Name: "dashboard", TokenHash: "hash1"})
+	assert.NoError(t, err)
+	_, err = db.CreateAPIToken(model.APIToken{Name: "scripts", TokenHash: "hash2"})
+	assert.NoError(t, err)
+	_, err = db.CreateAPIToken(model.APIToken{Name: "dashboard", TokenHash: "hash3"})
+	assert.Error(t, err)
+	_, err = db.CreateAPIToken(model.APIToken{Name: "empty"})
+	assert.Error(t, err)
+
+	token, err := db.SelectAPIToken("hash2")
+	assert.NoError(t, err)
+	assert.Equal(t, "scripts", token.Name)
+	_, err = db.SelectAPIToken("hash3")
+	assert.Error(t, err)
+	tokens, err := db.ListAPITokens()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(tokens))
+	assert.Equal(t, "dashboard", tokens[0].Name)
+
+	assert.NoError(t, db.DeleteAPIToken("dashboard"))
+	_, err = db.SelectAPIToken("hash1")
+	assert.Error(t, err)
+	tokens, err = db.ListAPITokens()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(tokens))
+}
+
+func testWebhooks(t *testing.T, db Storage) {
+	first, err := db.CreateWebhook(model.Webhook{URL: "https://example.com/hooks", Secret: "secret1"})
+	assert.NoError(t, err)
+	second, err := db.CreateWebhook(model.Webhook{URL: "https://example.org/hooks", Secret: "secret2", Events: "standup.created,standup.updated"})
+	assert.NoError(t, err)
+	_, err = db.CreateWebhook(model.Webhook{URL: "https://example.net/hooks"})
+	assert.Error(t, err)
+	webhooks, err := db.ListWebhooks()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(webhooks))
+	assert.Equal(t, first.ID, webhooks[0].ID)
+	assert.Equal(t, "secret2", webhooks[1].Secret)
+	assert.Equal(t, "standup.created,standup.updated", webhooks[1].Events)
+
+	letter, err := db.CreateDeadLetter(model.DeadLetter{WebhookID: first.ID, Event: "standup.created", Payload: "{}", Attempts: 5, Error: "status 500"})
+	assert.NoError(t, err)
+	_, err = db.CreateDeadLetter(model.DeadLetter{WebhookID: first.ID, Event: "standup.missed", Payload: "{}", Attempts: 5, Error: "status 502"})
+	assert.NoError(t, err)
+	_, err = db.CreateDeadLetter(model.DeadLetter{WebhookID: second.ID, Event: "rook.reported", Payload: "{}", Attempts: 5, Error: "timeout"})
+	assert.NoError(t, err)
+	_, err = db.CreateDeadLetter(model.DeadLetter{WebhookID: second.ID})
+	assert.Error(t, err)
+	letters, err := db.ListDeadLetters(first.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(letters))
+	assert.Equal(t, "status 500", letters[0].Error)
+	assert.Equal(t, 5, letters[0].Attempts)
+
+	assert.NoError(t, db.DeleteDeadLetter(letter.ID))
+	letters, err = db.ListDeadLetters(first.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(letters))
+	assert.Equal(t, "standup.missed", letters[0].Event)
+
+	assert.NoError(t, db.DeleteWebhook(first.ID))
+	letters, err = db.ListDeadLetters(first.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(letters))
+	letters, err = db.ListDeadLetters(second.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(letters))
+	webhooks, err = db.ListWebhooks()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(webhooks))
+}
+
+func testConcurrentAccess(t *testing.T, db Storage) {
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, err := db.CreateStandup(model.Standup{ChannelID: "chan", UsernameID: "user", Comment: "standup", MessageTS: fmt.Sprint(i)})
+			assert.NoError(t, err)
+			_, err = db.ListStandups()
+			assert.NoError(t, err)
+		}(i)
+	}
+	wg.Wait()
+	items, err := db.ListStandups()
+	assert.NoError(t, err)
+	assert.Equal(t, 10, len(items))
+}
@@ -0,0 +1,1005 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/maddevsio/comedian/model"
+)
+
+// Memory keeps all entries in process memory. It mirrors MySQL behaviour
+// and is used in tests and demo mode (DATABASE=memory://)
+type Memory struct {
+	mu        sync.RWMutex
+	lastID    int64
+	standups  []model.Standup
+	users     []model.StandupUser
+	times     []model.StandupTime
+	history   []model.StandupEditHistory
+	questions []model.StandupQuestion
+	holidays  []model.Holiday
+	absences  []model.Absence
+	runs      []model.ReminderRun
+	locks     map[string]model.Lock
+	steps     []model.EscalationStep
+	projects  []model.ProjectLink
+	accounts  []model.UserLink
+	tokens    []model.APIToken
+	webhooks  []model.Webhook
+	letters   []model.DeadLetter
+}
+
+// NewMemory creates a new empty in-memory storage
+func NewMemory() *Memory {
+	return &Memory{}
+}
+
+// now returns current time with the same precision MySQL DATETIME column has
+func (m *Memory) now() time.Time {
+	return time.Now().UTC().Truncate(time.Second)
+}
+
+func (m *Memory) nextID() int64 {
+	m.lastID++
+	return m.lastID
+}
+
+func between(t, from, to time.Time) bool {
+	return !t.Before(from) && !t.After(to)
+}
+
+// CreateStandup creates standup entry in database
+func (m *Memory) CreateStandup(s model.Standup) (model.Standup, error) {
+	err := s.Validate()
+	if err != nil {
+		return s, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s.ID = m.nextID()
+	m.standups = append(m.standups, model.Standup{
+		ID:         s.ID,
+		Created:    m.now(),
+		Modified:   m.now(),
+		ChannelID:  s.ChannelID,
+		UsernameID: s.UsernameID,
+		Comment:    s.Comment,
+		MessageTS:  s.MessageTS,
+		Platform:   s.Platform,
+		Yesterday:  s.Yesterday,
+		Today:      s.Today,
+		Problems:   s.Problems,
+		Answers:    s.Answers,
+	})
+	return s, nil
+}
+
+// UpdateStandup updates standup entry in database
+func (m *Memory) UpdateStandup(s model.Standup) (model.Standup, error) {
+	err := s.Validate()
+	if err != nil {
+		return s, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, standup := range m.standups {
+		if standup.ID != s.ID {
+			continue
+		}
+		standup.Modified = m.now()
+		standup.UsernameID = s.UsernameID
+		standup.Comment = s.Comment
+		standup.ChannelID = s.ChannelID
+		standup.MessageTS = s.MessageTS
+		standup.Yesterday = s.Yesterday
+		standup.Today = s.Today
+		standup.Problems = s.Problems
+		standup.Answers = s.Answers
+		m.standups[i] = standup
+		return standup, nil
+	}
+	return model.Standup{}, sql.ErrNoRows
+}
+
+// SelectStandupByMessageTS selects standup entry from database filtered by MessageTS parameter
+func (m *Memory) SelectStandupByMessageTS(messageTS string) (model.Standup, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, standup := range m.standups {
+		if standup.MessageTS == messageTS {
+			return standup, nil
+		}
+	}
+	return model.Standup{}, sql.ErrNoRows
+}
+
+// SelectStandupsByChannelIDForPeriod selects standup entrys by channel ID and time period from database
+func (m *Memory) SelectStandupsByChannelIDForPeriod(channelID string, dateStart, dateEnd time.Time) ([]model.Standup, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	items := []model.Standup{}
+	for _, standup := range m.standups {
+		if standup.ChannelID == channelID && between(standup.Created, dateStart, dateEnd) {
+			items = append(items, standup)
+		}
+	}
+	return items, nil
+}
+
+// SelectStandupsFiltered selects standup entrys by channel ID and time period from database
+func (m *Memory) SelectStandupsFiltered(slackUserID, channelID string, dateStart, dateEnd time.Time) ([]model.Standup, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	items := []model.Standup{}
+	for _, standup := range m.standups {
+		if standup.ChannelID == channelID && standup.UsernameID == slackUserID && between(standup.Created, dateStart, dateEnd) {
+			items = append(items, standup)
+		}
+	}
+	return items, nil
+}
+
+// SelectStandup selects standup entry by ID from database
+func (m *Memory) SelectStandup(id int64) (model.Standup, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, standup := range m.standups {
+		if standup.ID == id {
+			return standup, nil
+		}
+	}
+	return model.Standup{}, sql.ErrNoRows
+}
+
+// DeleteStandup deletes standup entry from database
+func (m *Memory) DeleteStandup(id int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	items := m.standups[:0]
+	for _, standup := range m.standups {
+		if standup.ID != id {
+			items = append(items, standup)
+		}
+	}
+	m.standups = items
+	return nil
+}
+
+// CreateStandupUser creates comedian entry in database
+func (m *Memory) CreateStandupUser(s model.StandupUser) (model.StandupUser, error) {
+	err := s.Validate()
+	if err != nil {
+		return s, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s.ID = m.nextID()
+	m.users = append(m.users, model.StandupUser{
+		ID:          s.ID,
+		Created:     m.now(),
+		Modified:    m.now(),
+		SlackUserID: s.SlackUserID,
+		SlackName:   s.SlackName,
+		Channel:     s.Channel,
+		ChannelID:   s.ChannelID,
+		Role:        s.Role,
+		Platform:    s.Platform,
+	})
+	return s, nil
+}
+
+// SelectStandupUser selects standupUser entry by ID from database
+func (m *Memory) SelectStandupUser(id int64) (model.StandupUser, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, user := range m.users {
+		if user.ID == id {
+			return user, nil
+		}
+	}
+	return model.StandupUser{}, sql.ErrNoRows
+}
+
+// UpdateStandupUser updates name, channel name and role of standupUser in database
+func (m *Memory) UpdateStandupUser(s model.StandupUser) (model.StandupUser, error) {
+	err := s.Validate()
+	if err != nil {
+		return s, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, user := range m.users {
+		if user.ID != s.ID {
+			continue
+		}
+		user.Modified = m.now()
+		user.SlackName = s.SlackName
+		user.Channel = s.Channel
+		user.Role = s.Role
+		m.users[i] = user
+		return user, nil
+	}
+	return model.StandupUser{}, sql.ErrNoRows
+}
+
+// FindStandupUserInChannelByUserID finds user in channel
+func (m *Memory) FindStandupUserInChannelByUserID(usernameID, channelID string) (model.StandupUser, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, user := range m.users {
+		if user.SlackUserID == usernameID && user.ChannelID == channelID {
+			return user, nil
+		}
+	}
+	return model.StandupUser{}, sql.ErrNoRows
+}
+
+// FindStandupUser finds user in
+func (m *Memory) FindStandupUser(username string) (model.StandupUser, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, user := range m.users {
+		if user.SlackName == username {
+			return user, nil
+		}
+	}
+	return model.StandupUser{}, sql.ErrNoRows
+}
+
+// ListAllStandupUsers returns array of standup entries from database
+func (m *Memory) ListAllStandupUsers() ([]model.StandupUser, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	items := []model.StandupUser{}
+	for _, user := range m.users {
+		if user.Role != "admin" {
+			items = append(items, user)
+		}
+	}
+	return items, nil
+}
+
+// ListChannelAdmins returns admins of channel
+func (m *Memory) ListChannelAdmins(channelID string) ([]model.StandupUser, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	items := []model.StandupUser{}
+	for _, user := range m.users {
+		if user.ChannelID == channelID && user.Role == "admin" {
+			items = append(items, user)
+		}
+	}
+	return items, nil
+}
+
+// GetNonReporters returns a list of non reporters in selected time period, users on leave are not listed
+func (m *Memory) GetNonReporters(channelID string, dateFrom, dateTo time.Time) ([]model.StandupUser, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	reporters := map[string]bool{}
+	for _, standup := range m.standups {
+		if standup.ChannelID == channelID && between(standup.Created, dateFrom, dateTo) {
+			reporters[standup.UsernameID] = true
+		}
+	}
+	nonReporters := []model.StandupUser{}
+	for _, user := range m.users {
+		if user.ChannelID == channelID && user.Role != "admin" && !reporters[user.SlackUserID] && !m.isAbsent(user.SlackUserID, dateFrom, dateTo) {
+			nonReporters = append(nonReporters, user)
+		}
+	}
+	return nonReporters, nil
+}
+
+// IsNonReporter returns true if user did not submit standup in time period, false othervise
+func (m *Memory) IsNonReporter(slackUserID, channelID string, dateFrom, dateTo time.Time) (bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, standup := range m.standups {
+		if standup.ChannelID == channelID && standup.UsernameID == slackUserID && between(standup.Created, dateFrom, dateTo) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// HasExistedAlready returns true if user existed already and therefore could submit standup
+func (m *Memory) HasExistedAlready(slackUserID, channelID string, dateFrom time.Time) (bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, user := range m.users {
+		if user.ChannelID == channelID && user.SlackUserID == slackUserID && !user.Created.After(dateFrom) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// IsAdmin checks if user in channel is of a role admin
+func (m *Memory) IsAdmin(slackUserID, channelID string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, user := range m.users {
+		if user.ChannelID == channelID && user.SlackUserID == slackUserID && user.Role == "admin" {
+			return true
+		}
+	}
+	return false
+}
+
+// ListStandupUsersByChannelID returns array of standup entries from database
+func (m *Memory) ListStandupUsersByChannelID(channelID string) ([]model.StandupUser, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	items := []model.StandupUser{}
+	for _, user := range m.users {
+		if user.ChannelID == channelID && user.Role != "admin" {
+			items = append(items, user)
+		}
+	}
+	return items, nil
+}
+
+// DeleteStandupUser deletes standup_users entry from database
+func (m *Memory) DeleteStandupUser(username, channelID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	items := m.users[:0]
+	for _, user := range m.users {
+		if user.SlackName != username || user.ChannelID != channelID {
+			items = append(items, user)
+		}
+	}
+	m.users = items
+	return nil
+}
+
+// CreateStandupTime creates time entry in database
+func (m *Memory) CreateStandupTime(s model.StandupTime) (model.StandupTime, error) {
+	err := s.Validate()
+	if err != nil {
+		return s, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s.ID = m.nextID()
+	m.times = append(m.times, model.StandupTime{
+		ID:        s.ID,
+		Created:   m.now(),
+		Channel:   s.Channel,
+		ChannelID: s.ChannelID,
+		Time:      s.Time,
+		Platform:  s.Platform,
+		Timezone:  s.Timezone,
+		WorkDays:  s.WorkDays,
+		Schedule:  s.Schedule,
+	})
+	return s, nil
+}
+
+// UpdateStandupTime updates standup time, time zone, work days and schedule of channel in database
+func (m *Memory) UpdateStandupTime(s model.StandupTime) (model.StandupTime, error) {
+	err := s.Validate()
+	if err != nil {
+		return s, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, st := range m.times {
+		if st.ChannelID != s.ChannelID {
+			continue
+		}
+		st.Time = s.Time
+		st.Timezone = s.Timezone
+		st.WorkDays = s.WorkDays
+		st.Schedule = s.Schedule
+		m.times[i] = st
+		return st, nil
+	}
+	return s, sql.ErrNoRows
+}
+
+// GetChannelStandupTime returns standup time entry from database
+func (m *Memory) GetChannelStandupTime(channelID string) (model.StandupTime, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, st := range m.times {
+		if st.ChannelID == channelID {
+			return st, nil
+		}
+	}
+	return model.StandupTime{}, sql.ErrNoRows
+}
+
+// ListAllStandupTime returns standup time entry for all channels from database
+func (m *Memory) ListAllStandupTime() ([]model.StandupTime, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	reminders := make([]model.StandupTime, len(m.times))
+	copy(reminders, m.times)
+	return reminders, nil
+}
+
+// CreateAbsence creates absence entry in database
+func (m *Memory) CreateAbsence(a model.Absence) (model.Absence, error) {
+	err := a.Validate()
+	if err != nil {
+		return a, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	a.ID = m.nextID()
+	a.Created = m.now()
+	m.absences = append(m.absences, a)
+	return a, nil
+}
+
+// ListAbsences returns absences of user ordered by start date
+func (m *Memory) ListAbsences(slackUserID string) ([]model.Absence, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	absences := []model.Absence{}
+	for _, a := range m.absences {
+		if a.SlackUserID == slackUserID {
+			absences = append(absences, a)
+		}
+	}
+	sort.SliceStable(absences, func(i, j int) bool { return absences[i].DateFrom < absences[j].DateFrom })
+	return absences, nil
+}
+
+// DeleteAbsence deletes absence of user by start date
+func (m *Memory) DeleteAbsence(slackUserID, dateFrom string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	items := m.absences[:0]
+	for _, a := range m.absences {
+		if a.SlackUserID != slackUserID || a.DateFrom != dateFrom {
+			items = append(items, a)
+		}
+	}
+	m.absences = items
+	return nil
+}
+
+// IsAbsent returns true if user is on leave on some day of time period
+func (m *Memory) IsAbsent(slackUserID string, dateFrom, dateTo time.Time) (bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.isAbsent(slackUserID, dateFrom, dateTo), nil
+}
+
+func (m *Memory) isAbsent(slackUserID string, dateFrom, dateTo time.Time) bool {
+	from, to := absenceDays(dateFrom, dateTo)
+	for _, a := range m.absences {
+		if a.SlackUserID == slackUserID && a.DateFrom <= to && a.DateTo >= from {
+			return true
+		}
+	}
+	return false
+}
+
+// CreateReminderRun creates reminder run entry in database
+func (m *Memory) CreateReminderRun(r model.ReminderRun) (model.ReminderRun, error) {
+	err := r.Validate()
+	if err != nil {
+		return r, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, run := range m.runs {
+		if run.ChannelID == r.ChannelID && run.Date == r.Date {
+			return r, errors.New("storage: reminder run already exists")
+		}
+	}
+	r.ID = m.nextID()
+	r.Created = m.now()
+	r.Modified = r.Created
+	m.runs = append(m.runs, r)
+	return r, nil
+}
+
+// GetReminderRun returns reminder run of channel on date
+func (m *Memory) GetReminderRun(channelID, date string) (model.ReminderRun, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, run := range m.runs {
+		if run.ChannelID == channelID && run.Date == date {
+			return run, nil
+		}
+	}
+	return model.ReminderRun{}, sql.ErrNoRows
+}
+
+// ListUnfinishedReminderRuns returns reminder runs which are not done yet
+func (m *Memory) ListUnfinishedReminderRuns() ([]model.ReminderRun, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	runs := []model.ReminderRun{}
+	for _, run := range m.runs {
+		if run.Stage != model.ReminderDone {
+			runs = append(runs, run)
+		}
+	}
+	return runs, nil
+}
+
+// AdvanceReminderRun moves reminder run from stage and repeats it has in database to the next ones
+func (m *Memory) AdvanceReminderRun(from, to model.ReminderRun) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, run := range m.runs {
+		if run.ID != from.ID || run.Stage != from.Stage || run.Repeats != from.Repeats {
+			continue
+		}
+		m.runs[i].Modified = m.now()
+		m.runs[i].Stage = to.Stage
+		m.runs[i].Repeats = to.Repeats
+		m.runs[i].NextAt = to.NextAt
+		return true, nil
+	}
+	return false, nil
+}
+
+// AcquireLock takes named lock for holder or prolongs it till expiration time
+func (m *Memory) AcquireLock(name, holder string, expires time.Time) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.locks == nil {
+		m.locks = map[string]model.Lock{}
+	}
+	lock, ok := m.locks[name]
+	if ok && lock.Holder != holder && !lock.Expires.Before(time.Now()) {
+		return false, nil
+	}
+	m.locks[name] = model.Lock{Name: name, Holder: holder, Expires: expires}
+	return true, nil
+}
+
+// ReleaseLock frees named lock if it is owned by holder
+func (m *Memory) ReleaseLock(name, holder string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if lock, ok := m.locks[name]; ok && lock.Holder == holder {
+		delete(m.locks, name)
+	}
+	return nil
+}
+
+// DeleteStandupTime deletes standup_time entry for channel from database
+func (m *Memory) DeleteStandupTime(channelID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	items := m.times[:0]
+	for _, st := range m.times {
+		if st.ChannelID != channelID {
+			items = append(items, st)
+		}
+	}
+	m.times = items
+	return nil
+}
+
+// CreateHoliday creates holiday entry in database
+func (m *Memory) CreateHoliday(h model.Holiday) (model.Holiday, error) {
+	err := h.Validate()
+	if err != nil {
+		return h, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	h.ID = m.nextID()
+	h.Created = m.now()
+	m.holidays = append(m.holidays, h)
+	return h, nil
+}
+
+// ListHolidays returns holidays of channel ordered by date
+func (m *Memory) ListHolidays(channelID string) ([]model.Holiday, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	holidays := []model.Holiday{}
+	for _, h := range m.holidays {
+		if h.ChannelID == channelID {
+			holidays = append(holidays, h)
+		}
+	}
+	sort.SliceStable(holidays, func(i, j int) bool { return holidays[i].Date < holidays[j].Date })
+	return holidays, nil
+}
+
+// DeleteHoliday deletes holiday of channel by date
+func (m *Memory) DeleteHoliday(channelID, date string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	items := m.holidays[:0]
+	for _, h := range m.holidays {
+		if h.ChannelID != channelID || h.Date != date {
+			items = append(items, h)
+		}
+	}
+	m.holidays = items
+	return nil
+}
+
+// CreateStandupQuestion creates standup template question entry in database
+func (m *Memory) CreateStandupQuestion(q model.StandupQuestion) (model.StandupQuestion, error) {
+	err := q.Validate()
+	if err != nil {
+		return q, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	q.ID = m.nextID()
+	q.Created = m.now()
+	m.questions = append(m.questions, q)
+	return q, nil
+}
+
+// ListStandupQuestions returns standup template questions of channel ordered by position
+func (m *Memory) ListStandupQuestions(channelID string) ([]model.StandupQuestion, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	questions := []model.StandupQuestion{}
+	for _, q := range m.questions {
+		if q.ChannelID == channelID {
+			questions = append(questions, q)
+		}
+	}
+	sort.SliceStable(questions, func(i, j int) bool { return questions[i].Position < questions[j].Position })
+	return questions, nil
+}
+
+// DeleteStandupQuestions deletes standup template questions of channel from database
+func (m *Memory) DeleteStandupQuestions(channelID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	items := m.questions[:0]
+	for _, q := range m.questions {
+		if q.ChannelID != channelID {
+			items = append(items, q)
+		}
+	}
+	m.questions = items
+	return nil
+}
+
+// CreateEscalationStep creates escalation step entry in database
+func (m *Memory) CreateEscalationStep(s model.EscalationStep) (model.EscalationStep, error) {
+	err := s.Validate()
+	if err != nil {
+		return s, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s.ID = m.nextID()
+	s.Created = m.now()
+	m.steps = append(m.steps, s)
+	return s, nil
+}
+
+// ListEscalationSteps returns escalation steps of channel ordered by position
+func (m *Memory) ListEscalationSteps(channelID string) ([]model.EscalationStep, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	steps := []model.EscalationStep{}
+	for _, s := range m.steps {
+		if s.ChannelID == channelID {
+			steps = append(steps, s)
+		}
+	}
+	sort.SliceStable(steps, func(i, j int) bool { return steps[i].Position < steps[j].Position })
+	return steps, nil
+}
+
+// DeleteEscalationSteps deletes escalation steps of channel from database
+func (m *Memory) DeleteEscalationSteps(channelID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	items := m.steps[:0]
+	for _, s := range m.steps {
+		if s.ChannelID != channelID {
+			items = append(items, s)
+		}
+	}
+	m.steps = items
+	return nil
+}
+
+// CreateProjectLink creates project link entry in database
+func (m *Memory) CreateProjectLink(l model.ProjectLink) (model.ProjectLink, error) {
+	err := l.Validate()
+	if err != nil {
+		return l, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	l.ID = m.nextID()
+	l.Created = m.now()
+	m.projects = append(m.projects, l)
+	return l, nil
+}
+
+// ListProjectLinks returns project links of channel ordered by provider and project
+func (m *Memory) ListProjectLinks(channelID string) ([]model.ProjectLink, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	links := []model.ProjectLink{}
+	for _, l := range m.projects {
+		if l.ChannelID == channelID {
+			links = append(links, l)
+		}
+	}
+	sort.SliceStable(links, func(i, j int) bool {
+		if links[i].Provider != links[j].Provider {
+			return links[i].Provider < links[j].Provider
+		}
+		return links[i].Project < links[j].Project
+	})
+	return links, nil
+}
+
+// DeleteProjectLink deletes link of channel to project of provider
+func (m *Memory) DeleteProjectLink(channelID, provider, project string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	items := m.projects[:0]
+	for _, l := range m.projects {
+		if l.ChannelID != channelID || l.Provider != provider || l.Project != project {
+			items = append(items, l)
+		}
+	}
+	m.projects = items
+	return nil
+}
+
+// CreateUserLink creates user link entry in database
+func (m *Memory) CreateUserLink(l model.UserLink) (model.UserLink, error) {
+	err := l.Validate()
+	if err != nil {
+		return l, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	l.ID = m.nextID()
+	l.Created = m.now()
+	m.accounts = append(m.accounts, l)
+	return l, nil
+}
+
+// ListUserLinks returns links of user ordered by provider
+func (m *Memory) ListUserLinks(slackUserID string) ([]model.UserLink, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	links := []model.UserLink{}
+	for _, l := range m.accounts {
+		if l.SlackUserID == slackUserID {
+			links = append(links, l)
+		}
+	}
+	sort.SliceStable(links, func(i, j int) bool { return links[i].Provider < links[j].Provider })
+	return links, nil
+}
+
+// DeleteUserLink deletes link of user to provider
+func (m *Memory) DeleteUserLink(slackUserID, provider string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	items := m.accounts[:0]
+	for _, l := range m.accounts {
+		if l.SlackUserID != slackUserID || l.Provider != provider {
+			items = append(items, l)
+		}
+	}
+	m.accounts = items
+	return nil
+}
+
+// CreateAPIToken creates API token entry in database
+func (m *Memory) CreateAPIToken(t model.APIToken) (model.APIToken, error) {
+	err := t.Validate()
+	if err != nil {
+		return t, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, token := range m.tokens {
+		if token.Name == t.Name || token.TokenHash == t.TokenHash {
+			return t, errors.New("storage: api token already exists")
+		}
+	}
+	t.ID = m.nextID()
+	t.Created = m.now()
+	m.tokens = append(m.tokens, t)
+	return t, nil
+}
+
+// SelectAPIToken selects API token by hash of the token
+func (m *Memory) SelectAPIToken(hash string) (model.APIToken, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, t := range m.tokens {
+		if t.TokenHash == hash {
+			return t, nil
+		}
+	}
+	return model.APIToken{}, sql.ErrNoRows
+}
+
+// ListAPITokens returns API tokens ordered by name
+func (m *Memory) ListAPITokens() ([]model.APIToken, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	tokens := append([]model.APIToken{}, m.tokens...)
+	sort.SliceStable(tokens, func(i, j int) bool { return tokens[i].Name < tokens[j].Name })
+	return tokens, nil
+}
+
+// DeleteAPIToken deletes API token by name
+func (m *Memory) DeleteAPIToken(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	items := m.tokens[:0]
+	for _, t := range m.tokens {
+		if t.Name != name {
+			items = append(items, t)
+		}
+	}
+	m.tokens = items
+	return nil
+}
+
+// CreateWebhook creates webhook subscription in database
+func (m *Memory) CreateWebhook(w model.Webhook) (model.Webhook, error) {
+	err := w.Validate()
+	if err != nil {
+		return w, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	w.ID = m.nextID()
+	w.Created = m.now()
+	m.webhooks = append(m.webhooks, w)
+	return w, nil
+}
+
+// ListWebhooks returns webhook subscriptions ordered by ID
+func (m *Memory) ListWebhooks() ([]model.Webhook, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return append([]model.Webhook{}, m.webhooks...), nil
+}
+
+// DeleteWebhook deletes webhook subscription with its dead letters
+func (m *Memory) DeleteWebhook(id int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	webhooks := m.webhooks[:0]
+	for _, w := range m.webhooks {
+		if w.ID != id {
+			webhooks = append(webhooks, w)
+		}
+	}
+	m.webhooks = webhooks
+	letters := m.letters[:0]
+	for _, d := range m.letters {
+		if d.WebhookID != id {
+			letters = append(letters, d)
+		}
+	}
+	m.letters = letters
+	return nil
+}
+
+// CreateDeadLetter saves event which webhook did not accept
+func (m *Memory) CreateDeadLetter(d model.DeadLetter) (model.DeadLetter, error) {
+	err := d.Validate()
+	if err != nil {
+		return d, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	d.ID = m.nextID()
+	d.Created = m.now()
+	m.letters = append(m.letters, d)
+	return d, nil
+}
+
+// ListDeadLetters returns dead letters of webhook ordered by ID
+func (m *Memory) ListDeadLetters(webhookID int64) ([]model.DeadLetter, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	letters := []model.DeadLetter{}
+	for _, d := range m.letters {
+		if d.WebhookID == webhookID {
+			letters = append(letters, d)
+		}
+	}
+	return letters, nil
+}
+
+// DeleteDeadLetter deletes dead letter by ID
+func (m *Memory) DeleteDeadLetter(id int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	letters := m.letters[:0]
+	for _, d := range m.letters {
+		if d.ID != id {
+			letters = append(letters, d)
+		}
+	}
+	m.letters = letters
+	return nil
+}
+
+// AddToStandupHistory creates backup standup entry in standup_edit_history database
+func (m *Memory) AddToStandupHistory(s model.StandupEditHistory) (model.StandupEditHistory, error) {
+	err := s.Validate()
+	if err != nil {
+		return s, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s.ID = m.nextID()
+	m.history = append(m.history, model.StandupEditHistory{
+		ID:          s.ID,
+		Created:     m.now(),
+		StandupID:   s.StandupID,
+		StandupText: s.StandupText,
+	})
+	return s, nil
+}
+
+// ListStandupHistory returns previous texts of standup, the oldest first
+func (m *Memory) ListStandupHistory(standupID int64) ([]model.StandupEditHistory, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	items := []model.StandupEditHistory{}
+	for _, h := range m.history {
+		if h.StandupID == standupID {
+			items = append(items, h)
+		}
+	}
+	return items, nil
+}
+
+// GetAllChannels returns list of unique channels
+func (m *Memory) GetAllChannels() ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	channels := []string{}
+	seen := map[string]bool{}
+	for _, user := range m.users {
+		if !seen[user.ChannelID] {
+			seen[user.ChannelID] = true
+			channels = append(channels, user.ChannelID)
+		}
+	}
+	return channels, nil
+}
+
+// GetUserChannels returns list of user's channels
+func (m *Memory) GetUserChannels(slackUserID string) ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	channels := []string{}
+	for _, user := range m.users {
+		if user.SlackUserID == slackUserID {
+			channels = append(channels, user.ChannelID)
+		}
+	}
+	return channels, nil
+}
+
+// ListStandups returns array of standup entries from database
+// Helper function for testing
+func (m *Memory) ListStandups() ([]model.Standup, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	items := make([]model.Standup, len(m.standups))
+	copy(items, m.standups)
+	return items, nil
+}
@@ -0,0 +1,38 @@
+package storage
+
+import (
+	"github.com/jmoiron/sqlx"
+)
+
+// migrate applies schema statements that were not applied to the database yet.
+// Applied versions are kept in schema_migrations table, so new statements
+// must only be appended to the list, never edited or reordered
+func migrate(conn *sqlx.DB, migrations []string) error {
+	_, err := conn.Exec("CREATE TABLE IF NOT EXISTS schema_migrations (version INTEGER NOT NULL PRIMARY KEY)")
+	if err != nil {
+		return err
+	}
+	var version int
+	err = conn.Get(&version, "SELECT COALESCE(MAX(version), 0) FROM schema_migrations")
+	if err != nil {
+		return err
+	}
+	for i := version; i < len(migrations); i++ {
+		tx, err := conn.Beginx()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(migrations[i]); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if _, err := tx.Exec(tx.Rebind("INSERT INTO schema_migrations (version) VALUES (?)"), i+1); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -8,14 +8,27 @@ import (
 	"github.com/maddevsio/comedian/config"
 	"github.com/maddevsio/comedian/model"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
-func TestCRUDLStandup(t *testing.T) {
-
+// newTestMySQL connects to MySQL from config, tests are skipped when
+// Comedian is configured to use other storage or MySQL is not running
+func newTestMySQL(t *testing.T) *MySQL {
 	c, err := config.Get()
-	assert.NoError(t, err)
+	require.NoError(t, err)
+	if scheme, _ := splitDatabaseURL(c.DatabaseURL); scheme != "" && scheme != "mysql" {
+		t.Skipf("storage is not MySQL: %v", c.DatabaseURL)
+	}
 	db, err := NewMySQL(c)
-	assert.NoError(t, err)
+	require.NoError(t, err)
+	if err := db.conn.Ping(); err != nil {
+		t.Skipf("MySQL is not available: %v", err)
+	}
+	return db
+}
+
+func TestCRUDLStandup(t *testing.T) {
+	db := newTestMySQL(t)
 
 	// clean up table before tests
 	standups, _ := db.ListStandups()
@@ -100,10 +113,7 @@ func TestCRUDLStandup(t *testing.T) {
 }
 
 func TestCRUDStandupUser(t *testing.T) {
-	c, err := config.Get()
-	assert.NoError(t, err)
-	db, err := NewMySQL(c)
-	assert.NoError(t, err)
+	db := newTestMySQL(t)
 
 	// clean up table before tests
 	standupUsers, _ := db.ListAllStandupUsers()
@@ -193,10 +203,7 @@ func TestCRUDStandupUser(t *testing.T) {
 }
 
 func TestCRUDStandupTime(t *testing.T) {
-	c, err := config.Get()
-	assert.NoError(t, err)
-	db, err := NewMySQL(c)
-	assert.NoError(t, err)
+	db := newTestMySQL(t)
 
 	//clean up table before tests
 	ast, err := db.ListAllStandupTime()
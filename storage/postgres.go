@@ -0,0 +1,801 @@
+package storage
+
+import (
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/maddevsio/comedian/config"
+	// This line is must for working PostgreSQL database
+	_ "github.com/lib/pq"
+	"github.com/maddevsio/comedian/model"
+)
+
+// postgresMigrations describes PostgreSQL schema, it is applied on start
+var postgresMigrations = []string{
+	`CREATE TABLE standup (
+		id BIGSERIAL PRIMARY KEY,
+		created TIMESTAMP NOT NULL,
+		modified TIMESTAMP NOT NULL,
+		channel_id VARCHAR(255) NOT NULL,
+		username_id VARCHAR(255) NOT NULL,
+		comment TEXT NOT NULL,
+		message_ts VARCHAR(255) NOT NULL
+	);
+	CREATE INDEX standup_created ON standup (created);
+	CREATE TABLE standup_users (
+		id BIGSERIAL PRIMARY KEY,
+		created TIMESTAMP NOT NULL,
+		modified TIMESTAMP NOT NULL,
+		username VARCHAR(255) NOT NULL,
+		channel VARCHAR(255) NOT NULL,
+		channel_id VARCHAR(255) NOT NULL,
+		slack_user_id VARCHAR(255) NOT NULL,
+		role VARCHAR(255) NOT NULL
+	);
+	CREATE TABLE standup_time (
+		id BIGSERIAL PRIMARY KEY,
+		created TIMESTAMP NOT NULL,
+		channel VARCHAR(255) NOT NULL,
+		channel_id VARCHAR(255) NOT NULL,
+		standuptime BIGINT NOT NULL
+	);
+	CREATE TABLE standup_edit_history (
+		id BIGSERIAL PRIMARY KEY,
+		created TIMESTAMP NOT NULL,
+		standup_id BIGINT NOT NULL,
+		standup_text TEXT NOT NULL
+	);`,
+	`ALTER TABLE standup ADD COLUMN platform VARCHAR(32) NOT NULL DEFAULT 'slack';
+	ALTER TABLE standup_users ADD COLUMN platform VARCHAR(32) NOT NULL DEFAULT 'slack';
+	ALTER TABLE standup_time ADD COLUMN platform VARCHAR(32) NOT NULL DEFAULT 'slack';`,
+	`ALTER TABLE standup ADD COLUMN yesterday TEXT NOT NULL DEFAULT '';
+	ALTER TABLE standup ADD COLUMN today TEXT NOT NULL DEFAULT '';
+	ALTER TABLE standup ADD COLUMN problems TEXT NOT NULL DEFAULT '';`,
+	`CREATE TABLE standup_questions (
+		id BIGSERIAL PRIMARY KEY,
+		created TIMESTAMP NOT NULL,
+		channel_id VARCHAR(255) NOT NULL,
+		name VARCHAR(255) NOT NULL,
+		pattern VARCHAR(255) NOT NULL,
+		position INTEGER NOT NULL
+	);`,
+	`ALTER TABLE standup_time ADD COLUMN timezone VARCHAR(64) NOT NULL DEFAULT '';`,
+	`CREATE TABLE holidays (
+		id BIGSERIAL PRIMARY KEY,
+		created TIMESTAMP NOT NULL,
+		channel_id VARCHAR(255) NOT NULL,
+		date VARCHAR(10) NOT NULL,
+		name VARCHAR(255) NOT NULL
+	);
+	ALTER TABLE standup_time ADD COLUMN work_days VARCHAR(32) NOT NULL DEFAULT '';`,
+	`CREATE TABLE absences (
+		id BIGSERIAL PRIMARY KEY,
+		created TIMESTAMP NOT NULL,
+		slack_user_id VARCHAR(255) NOT NULL,
+		date_from VARCHAR(10) NOT NULL,
+		date_to VARCHAR(10) NOT NULL,
+		reason VARCHAR(255) NOT NULL
+	);`,
+	`CREATE TABLE reminder_runs (
+		id BIGSERIAL PRIMARY KEY,
+		created TIMESTAMP NOT NULL,
+		modified TIMESTAMP NOT NULL,
+		channel_id VARCHAR(255) NOT NULL,
+		platform VARCHAR(32) NOT NULL,
+		date VARCHAR(10) NOT NULL,
+		stage VARCHAR(32) NOT NULL,
+		repeats INTEGER NOT NULL,
+		next_at TIMESTAMP NOT NULL,
+		UNIQUE (channel_id, date)
+	);`,
+	`CREATE TABLE locks (
+		name VARCHAR(255) NOT NULL PRIMARY KEY,
+		holder VARCHAR(255) NOT NULL,
+		expires TIMESTAMP NOT NULL
+	);`,
+	`ALTER TABLE standup_time ADD COLUMN schedule VARCHAR(255) NOT NULL DEFAULT '';`,
+	`CREATE TABLE escalation_steps (
+		id BIGSERIAL PRIMARY KEY,
+		created TIMESTAMP NOT NULL,
+		channel_id VARCHAR(255) NOT NULL,
+		action VARCHAR(32) NOT NULL,
+		delay_minutes INTEGER NOT NULL,
+		position INTEGER NOT NULL
+	);`,
+	`CREATE TABLE project_links (
+		id BIGSERIAL PRIMARY KEY,
+		created TIMESTAMP NOT NULL,
+		channel_id VARCHAR(255) NOT NULL,
+		provider VARCHAR(32) NOT NULL,
+		project VARCHAR(255) NOT NULL
+	);
+	CREATE TABLE user_links (
+		id BIGSERIAL PRIMARY KEY,
+		created TIMESTAMP NOT NULL,
+		slack_user_id VARCHAR(255) NOT NULL,
+		provider VARCHAR(32) NOT NULL,
+		username VARCHAR(255) NOT NULL
+	);`,
+	`CREATE TABLE api_tokens (
+		id BIGSERIAL PRIMARY KEY,
+		created TIMESTAMP NOT NULL,
+		name VARCHAR(255) NOT NULL UNIQUE,
+		token_hash VARCHAR(64) NOT NULL UNIQUE
+	);`,
+	`CREATE TABLE webhooks (
+		id BIGSERIAL PRIMARY KEY,
+		created TIMESTAMP NOT NULL,
+		url VARCHAR(2048) NOT NULL,
+		secret VARCHAR(255) NOT NULL,
+		events VARCHAR(1024) NOT NULL DEFAULT ''
+	);`,
+	`CREATE TABLE webhook_dead_letters (
+		id BIGSERIAL PRIMARY KEY,
+		created TIMESTAMP NOT NULL,
+		webhook_id BIGINT NOT NULL,
+		event VARCHAR(255) NOT NULL,
+		payload TEXT NOT NULL,
+		attempts INTEGER NOT NULL DEFAULT 0,
+		error TEXT NOT NULL
+	);`,
+	`ALTER TABLE standup ADD COLUMN answers TEXT NOT NULL DEFAULT '';
+	ALTER TABLE standup_questions ADD COLUMN role VARCHAR(32) NOT NULL DEFAULT '';`,
+}
+
+// Postgres provides api for work with postgresql database
+type Postgres struct {
+	conn *sqlx.DB
+}
+
+// NewPostgres creates a new instance of database API
+func NewPostgres(c config.Config) (*Postgres, error) {
+	conn, err := sqlx.Open("postgres", c.DatabaseURL)
+	if err != nil {
+		return nil, err
+	}
+	if err := migrate(conn, postgresMigrations); err != nil {
+		return nil, err
+	}
+	m := &Postgres{}
+	m.conn = conn
+
+	return m, nil
+}
+
+// CreateStandup creates standup entry in database
+func (m *Postgres) CreateStandup(s model.Standup) (model.Standup, error) {
+	err := s.Validate()
+	if err != nil {
+		return s, err
+	}
+	err = m.conn.Get(&s.ID,
+		"INSERT INTO standup (created, modified, comment, channel_id, username_id, message_ts, platform, yesterday, today, problems, answers) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) RETURNING id",
+		time.Now().UTC(), time.Now().UTC(), s.Comment, s.ChannelID, s.UsernameID, s.MessageTS, s.Platform, s.Yesterday, s.Today, s.Problems, s.Answers,
+	)
+	if err != nil {
+		return s, err
+	}
+
+	return s, nil
+}
+
+// UpdateStandup updates standup entry in database
+func (m *Postgres) UpdateStandup(s model.Standup) (model.Standup, error) {
+	err := s.Validate()
+	if err != nil {
+		return s, err
+	}
+	_, err = m.conn.Exec(
+		"UPDATE standup SET modified=$1, username_id=$2, comment=$3, channel_id=$4, message_ts=$5, yesterday=$6, today=$7, problems=$8, answers=$9 WHERE id=$10",
+		time.Now().UTC(), s.UsernameID, s.Comment, s.ChannelID, s.MessageTS, s.Yesterday, s.Today, s.Problems, s.Answers, s.ID,
+	)
+	if err != nil {
+		return s, err
+	}
+	var i model.Standup
+	err = m.conn.Get(&i, "SELECT * FROM standup WHERE id=$1", s.ID)
+
+	return i, err
+}
+
+// SelectStandupByMessageTS selects standup entry from database filtered by MessageTS parameter
+func (m *Postgres) SelectStandupByMessageTS(messageTS string) (model.Standup, error) {
+	var s model.Standup
+	err := m.conn.Get(&s, "SELECT * FROM standup WHERE message_ts=$1", messageTS)
+
+	return s, err
+}
+
+// SelectStandupsByChannelIDForPeriod selects standup entrys by channel ID and time period from database
+func (m *Postgres) SelectStandupsByChannelIDForPeriod(channelID string, dateStart, dateEnd time.Time) ([]model.Standup, error) {
+	items := []model.Standup{}
+	err := m.conn.Select(&items, "SELECT * FROM standup WHERE channel_id=$1 AND created BETWEEN $2 AND $3",
+		channelID, dateStart.UTC(), dateEnd.UTC())
+	return items, err
+}
+
+// SelectStandupsFiltered selects standup entrys by channel ID and time period from database
+func (m *Postgres) SelectStandupsFiltered(slackUserID, channelID string, dateStart, dateEnd time.Time) ([]model.Standup, error) {
+	items := []model.Standup{}
+	err := m.conn.Select(&items, "SELECT * FROM standup WHERE channel_id=$1 AND username_id =$2 AND created BETWEEN $3 AND $4",
+		channelID, slackUserID, dateStart.UTC(), dateEnd.UTC())
+	return items, err
+}
+
+// SelectStandup selects standup entry by ID from database
+func (m *Postgres) SelectStandup(id int64) (model.Standup, error) {
+	var s model.Standup
+	err := m.conn.Get(&s, "SELECT * FROM standup WHERE id=$1", id)
+	return s, err
+}
+
+// DeleteStandup deletes standup entry from database
+func (m *Postgres) DeleteStandup(id int64) error {
+	_, err := m.conn.Exec("DELETE FROM standup WHERE id=$1", id)
+	return err
+}
+
+// CreateStandupUser creates comedian entry in database
+func (m *Postgres) CreateStandupUser(s model.StandupUser) (model.StandupUser, error) {
+	err := s.Validate()
+	if err != nil {
+		return s, err
+	}
+	err = m.conn.Get(&s.ID,
+		"INSERT INTO standup_users (created, modified, slack_user_id, username, channel_id, channel, role, platform) VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id",
+		time.Now().UTC(), time.Now().UTC(), s.SlackUserID, s.SlackName, s.ChannelID, s.Channel, s.Role, s.Platform)
+	if err != nil {
+		return s, err
+	}
+
+	return s, nil
+}
+
+// SelectStandupUser selects standupUser entry by ID from database
+func (m *Postgres) SelectStandupUser(id int64) (model.StandupUser, error) {
+	var u model.StandupUser
+	err := m.conn.Get(&u, "SELECT * FROM standup_users WHERE id=$1", id)
+	return u, err
+}
+
+// UpdateStandupUser updates name, channel name and role of standupUser in database
+func (m *Postgres) UpdateStandupUser(s model.StandupUser) (model.StandupUser, error) {
+	err := s.Validate()
+	if err != nil {
+		return s, err
+	}
+	_, err = m.conn.Exec(
+		"UPDATE standup_users SET modified=$1, username=$2, channel=$3, role=$4 WHERE id=$5",
+		time.Now().UTC(), s.SlackName, s.Channel, s.Role, s.ID,
+	)
+	if err != nil {
+		return s, err
+	}
+	var u model.StandupUser
+	err = m.conn.Get(&u, "SELECT * FROM standup_users WHERE id=$1", s.ID)
+	return u, err
+}
+
+// FindStandupUserInChannelByUserID finds user in channel
+func (m *Postgres) FindStandupUserInChannelByUserID(usernameID, channelID string) (model.StandupUser, error) {
+	var u model.StandupUser
+	err := m.conn.Get(&u, "SELECT * FROM standup_users WHERE slack_user_id=$1 AND channel_id=$2", usernameID, channelID)
+	return u, err
+}
+
+// FindStandupUser finds user in
+func (m *Postgres) FindStandupUser(username string) (model.StandupUser, error) {
+	var u model.StandupUser
+	err := m.conn.Get(&u, "SELECT * FROM standup_users WHERE username=$1", username)
+	return u, err
+}
+
+// ListAllStandupUsers returns array of standup entries from database
+func (m *Postgres) ListAllStandupUsers() ([]model.StandupUser, error) {
+	items := []model.StandupUser{}
+	err := m.conn.Select(&items, "SELECT * FROM standup_users where role!='admin'")
+	return items, err
+}
+
+// ListChannelAdmins returns admins of channel
+func (m *Postgres) ListChannelAdmins(channelID string) ([]model.StandupUser, error) {
+	items := []model.StandupUser{}
+	err := m.conn.Select(&items, "SELECT * FROM standup_users where channel_id=$1 AND role='admin'", channelID)
+	return items, err
+}
+
+// GetNonReporters returns a list of non reporters in selected time period, users on leave are not listed
+func (m *Postgres) GetNonReporters(channelID string, dateFrom, dateTo time.Time) ([]model.StandupUser, error) {
+	from, to := absenceDays(dateFrom, dateTo)
+	nonReporters := []model.StandupUser{}
+	err := m.conn.Select(&nonReporters, `SELECT * FROM standup_users where channel_id=$1 and role!='admin' AND slack_user_id NOT IN (SELECT username_id FROM standup where channel_id=$2 and created BETWEEN $3 AND $4) AND slack_user_id NOT IN (SELECT slack_user_id FROM absences where date_from<=$5 AND date_to>=$6)`, channelID, channelID, dateFrom.UTC(), dateTo.UTC(), to, from)
+	return nonReporters, err
+}
+
+// IsNonReporter returns true if user did not submit standup in time period, false othervise
+func (m *Postgres) IsNonReporter(slackUserID, channelID string, dateFrom, dateTo time.Time) (bool, error) {
+	var id int
+	err := m.conn.Get(&id, `SELECT id FROM standup where channel_id=$1 and username_id=$2 and created between $3 and $4`, channelID, slackUserID, dateFrom.UTC(), dateTo.UTC())
+	if err != nil && err.Error() != "sql: no rows in result set" {
+		return true, err
+	}
+	if id != 0 {
+		return false, nil
+	}
+	return true, nil
+}
+
+// HasExistedAlready returns true if user existed already and therefore could submit standup
+func (m *Postgres) HasExistedAlready(slackUserID, channelID string, dateFrom time.Time) (bool, error) {
+	var id int
+	err := m.conn.Get(&id, `SELECT id FROM standup_users where channel_id=$1 and slack_user_id=$2 and created <=$3`, channelID, slackUserID, dateFrom.UTC())
+	if err != nil && err.Error() != "sql: no rows in result set" {
+		return false, err
+	}
+	if id != 0 {
+		return true, nil
+	}
+	return false, nil
+}
+
+// IsAdmin checks if user in channel is of a role admin
+func (m *Postgres) IsAdmin(slackUserID, channelID string) bool {
+	var u model.StandupUser
+	err := m.conn.Get(&u, `SELECT * FROM standup_users where channel_id = $1 and slack_user_id = $2 and role = $3`, channelID, slackUserID, "admin")
+	if err != nil {
+		return false
+	}
+	return true
+}
+
+// ListStandupUsersByChannelID returns array of standup entries from database
+func (m *Postgres) ListStandupUsersByChannelID(channelID string) ([]model.StandupUser, error) {
+	items := []model.StandupUser{}
+	err := m.conn.Select(&items, "SELECT * FROM standup_users WHERE channel_id=$1 AND role!='admin'", channelID)
+	return items, err
+}
+
+// DeleteStandupUser deletes standup_users entry from database
+func (m *Postgres) DeleteStandupUser(username, channelID string) error {
+	_, err := m.conn.Exec("DELETE FROM standup_users WHERE username=$1 AND channel_id=$2", username, channelID)
+	return err
+}
+
+// CreateStandupTime creates time entry in database
+func (m *Postgres) CreateStandupTime(s model.StandupTime) (model.StandupTime, error) {
+	err := s.Validate()
+	if err != nil {
+		return s, err
+	}
+	err = m.conn.Get(&s.ID,
+		"INSERT INTO standup_time (created, channel_id, channel, standuptime, platform, timezone, work_days, schedule) VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id",
+		time.Now().UTC(), s.ChannelID, s.Channel, s.Time, s.Platform, s.Timezone, s.WorkDays, s.Schedule)
+	if err != nil {
+		return s, err
+	}
+
+	return s, nil
+}
+
+// UpdateStandupTime updates standup time, time zone, work days and schedule of channel in database
+func (m *Postgres) UpdateStandupTime(s model.StandupTime) (model.StandupTime, error) {
+	err := s.Validate()
+	if err != nil {
+		return s, err
+	}
+	_, err = m.conn.Exec(
+		"UPDATE standup_time SET standuptime=$1, timezone=$2, work_days=$3, schedule=$4 WHERE channel_id=$5",
+		s.Time, s.Timezone, s.WorkDays, s.Schedule, s.ChannelID)
+	if err != nil {
+		return s, err
+	}
+	return m.GetChannelStandupTime(s.ChannelID)
+}
+
+// GetChannelStandupTime returns standup time entry from database
+func (m *Postgres) GetChannelStandupTime(channelID string) (model.StandupTime, error) {
+	var time model.StandupTime
+	err := m.conn.Get(&time, "SELECT * FROM standup_time WHERE channel_id=$1", channelID)
+	return time, err
+}
+
+// ListAllStandupTime returns standup time entry for all channels from database
+func (m *Postgres) ListAllStandupTime() ([]model.StandupTime, error) {
+	reminders := []model.StandupTime{}
+	err := m.conn.Select(&reminders, "SELECT * FROM standup_time")
+	return reminders, err
+}
+
+// CreateHoliday creates holiday entry in database
+func (m *Postgres) CreateHoliday(h model.Holiday) (model.Holiday, error) {
+	err := h.Validate()
+	if err != nil {
+		return h, err
+	}
+	err = m.conn.Get(&h.ID,
+		"INSERT INTO holidays (created, channel_id, date, name) VALUES ($1, $2, $3, $4) RETURNING id",
+		time.Now().UTC(), h.ChannelID, h.Date, h.Name)
+	if err != nil {
+		return h, err
+	}
+
+	return h, nil
+}
+
+// ListHolidays returns holidays of channel ordered by date
+func (m *Postgres) ListHolidays(channelID string) ([]model.Holiday, error) {
+	holidays := []model.Holiday{}
+	err := m.conn.Select(&holidays, "SELECT * FROM holidays WHERE channel_id=$1 ORDER BY date", channelID)
+	return holidays, err
+}
+
+// DeleteHoliday deletes holiday of channel by date
+func (m *Postgres) DeleteHoliday(channelID, date string) error {
+	_, err := m.conn.Exec("DELETE FROM holidays WHERE channel_id=$1 AND date=$2", channelID, date)
+	return err
+}
+
+// CreateAbsence creates absence entry in database
+func (m *Postgres) CreateAbsence(a model.Absence) (model.Absence, error) {
+	err := a.Validate()
+	if err != nil {
+		return a, err
+	}
+	err = m.conn.Get(&a.ID,
+		"INSERT INTO absences (created, slack_user_id, date_from, date_to, reason) VALUES ($1, $2, $3, $4, $5) RETURNING id",
+		time.Now().UTC(), a.SlackUserID, a.DateFrom, a.DateTo, a.Reason)
+	if err != nil {
+		return a, err
+	}
+
+	return a, nil
+}
+
+// ListAbsences returns absences of user ordered by start date
+func (m *Postgres) ListAbsences(slackUserID string) ([]model.Absence, error) {
+	absences := []model.Absence{}
+	err := m.conn.Select(&absences, "SELECT * FROM absences WHERE slack_user_id=$1 ORDER BY date_from", slackUserID)
+	return absences, err
+}
+
+// DeleteAbsence deletes absence of user by start date
+func (m *Postgres) DeleteAbsence(slackUserID, dateFrom string) error {
+	_, err := m.conn.Exec("DELETE FROM absences WHERE slack_user_id=$1 AND date_from=$2", slackUserID, dateFrom)
+	return err
+}
+
+// IsAbsent returns true if user is on leave on some day of time period
+func (m *Postgres) IsAbsent(slackUserID string, dateFrom, dateTo time.Time) (bool, error) {
+	from, to := absenceDays(dateFrom, dateTo)
+	var count int
+	err := m.conn.Get(&count, "SELECT COUNT(*) FROM absences WHERE slack_user_id=$1 AND date_from<=$2 AND date_to>=$3", slackUserID, to, from)
+	return count > 0, err
+}
+
+// CreateReminderRun creates reminder run entry in database
+func (m *Postgres) CreateReminderRun(r model.ReminderRun) (model.ReminderRun, error) {
+	err := r.Validate()
+	if err != nil {
+		return r, err
+	}
+	err = m.conn.Get(&r.ID,
+		"INSERT INTO reminder_runs (created, modified, channel_id, platform, date, stage, repeats, next_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id",
+		time.Now().UTC(), time.Now().UTC(), r.ChannelID, r.Platform, r.Date, r.Stage, r.Repeats, r.NextAt.UTC())
+	if err != nil {
+		return r, err
+	}
+
+	return r, nil
+}
+
+// GetReminderRun returns reminder run of channel on date
+func (m *Postgres) GetReminderRun(channelID, date string) (model.ReminderRun, error) {
+	var r model.ReminderRun
+	err := m.conn.Get(&r, "SELECT * FROM reminder_runs WHERE channel_id=$1 AND date=$2", channelID, date)
+	return r, err
+}
+
+// ListUnfinishedReminderRuns returns reminder runs which are not done yet
+func (m *Postgres) ListUnfinishedReminderRuns() ([]model.ReminderRun, error) {
+	runs := []model.ReminderRun{}
+	err := m.conn.Select(&runs, "SELECT * FROM reminder_runs WHERE stage!=$1", model.ReminderDone)
+	return runs, err
+}
+
+// AdvanceReminderRun moves reminder run from stage and repeats it has in database to the next ones
+func (m *Postgres) AdvanceReminderRun(from, to model.ReminderRun) (bool, error) {
+	res, err := m.conn.Exec(
+		"UPDATE reminder_runs SET modified=$1, stage=$2, repeats=$3, next_at=$4 WHERE id=$5 AND stage=$6 AND repeats=$7",
+		time.Now().UTC(), to.Stage, to.Repeats, to.NextAt.UTC(), from.ID, from.Stage, from.Repeats)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+// AcquireLock takes named lock for holder or prolongs it till expiration time
+func (m *Postgres) AcquireLock(name, holder string, expires time.Time) (bool, error) {
+	_, err := m.conn.Exec("UPDATE locks SET holder=$1, expires=$2 WHERE name=$3 AND (holder=$4 OR expires<$5)",
+		holder, expires.UTC(), name, holder, time.Now().UTC())
+	if err != nil {
+		return false, err
+	}
+	_, err = m.conn.Exec("INSERT INTO locks (name, holder, expires) VALUES ($1, $2, $3) ON CONFLICT DO NOTHING", name, holder, expires.UTC())
+	if err != nil {
+		return false, err
+	}
+	var owner string
+	err = m.conn.Get(&owner, "SELECT holder FROM locks WHERE name=$1", name)
+	return owner == holder, err
+}
+
+// ReleaseLock frees named lock if it is owned by holder
+func (m *Postgres) ReleaseLock(name, holder string) error {
+	_, err := m.conn.Exec("DELETE FROM locks WHERE name=$1 AND holder=$2", name, holder)
+	return err
+}
+
+// DeleteStandupTime deletes standup_time entry for channel from database
+func (m *Postgres) DeleteStandupTime(channelID string) error {
+	_, err := m.conn.Exec("DELETE FROM standup_time WHERE channel_id=$1", channelID)
+	return err
+}
+
+// CreateStandupQuestion creates standup template question entry in database
+func (m *Postgres) CreateStandupQuestion(q model.StandupQuestion) (model.StandupQuestion, error) {
+	err := q.Validate()
+	if err != nil {
+		return q, err
+	}
+	err = m.conn.Get(&q.ID,
+		"INSERT INTO standup_questions (created, channel_id, name, pattern, position, role) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id",
+		time.Now().UTC(), q.ChannelID, q.Name, q.Pattern, q.Position, q.Role)
+	if err != nil {
+		return q, err
+	}
+
+	return q, nil
+}
+
+// ListStandupQuestions returns standup template questions of channel ordered by position
+func (m *Postgres) ListStandupQuestions(channelID string) ([]model.StandupQuestion, error) {
+	questions := []model.StandupQuestion{}
+	err := m.conn.Select(&questions, "SELECT * FROM standup_questions WHERE channel_id=$1 ORDER BY position", channelID)
+	return questions, err
+}
+
+// DeleteStandupQuestions deletes standup template questions of channel from database
+func (m *Postgres) DeleteStandupQuestions(channelID string) error {
+	_, err := m.conn.Exec("DELETE FROM standup_questions WHERE channel_id=$1", channelID)
+	return err
+}
+
+// CreateEscalationStep creates escalation step entry in database
+func (m *Postgres) CreateEscalationStep(s model.EscalationStep) (model.EscalationStep, error) {
+	err := s.Validate()
+	if err != nil {
+		return s, err
+	}
+	err = m.conn.Get(&s.ID,
+		"INSERT INTO escalation_steps (created, channel_id, action, delay_minutes, position) VALUES ($1, $2, $3, $4, $5) RETURNING id",
+		time.Now().UTC(), s.ChannelID, s.Action, s.Delay, s.Position)
+	if err != nil {
+		return s, err
+	}
+
+	return s, nil
+}
+
+// ListEscalationSteps returns escalation steps of channel ordered by position
+func (m *Postgres) ListEscalationSteps(channelID string) ([]model.EscalationStep, error) {
+	steps := []model.EscalationStep{}
+	err := m.conn.Select(&steps, "SELECT * FROM escalation_steps WHERE channel_id=$1 ORDER BY position", channelID)
+	return steps, err
+}
+
+// DeleteEscalationSteps deletes escalation steps of channel from database
+func (m *Postgres) DeleteEscalationSteps(channelID string) error {
+	_, err := m.conn.Exec("DELETE FROM escalation_steps WHERE channel_id=$1", channelID)
+	return err
+}
+
+// CreateProjectLink creates project link entry in database
+func (m *Postgres) CreateProjectLink(l model.ProjectLink) (model.ProjectLink, error) {
+	err := l.Validate()
+	if err != nil {
+		return l, err
+	}
+	err = m.conn.Get(&l.ID,
+		"INSERT INTO project_links (created, channel_id, provider, project) VALUES ($1, $2, $3, $4) RETURNING id",
+		time.Now().UTC(), l.ChannelID, l.Provider, l.Project)
+	if err != nil {
+		return l, err
+	}
+
+	return l, nil
+}
+
+// ListProjectLinks returns project links of channel ordered by provider and project
+func (m *Postgres) ListProjectLinks(channelID string) ([]model.ProjectLink, error) {
+	links := []model.ProjectLink{}
+	err := m.conn.Select(&links, "SELECT * FROM project_links WHERE channel_id=$1 ORDER BY provider, project", channelID)
+	return links, err
+}
+
+// DeleteProjectLink deletes link of channel to project of provider
+func (m *Postgres) DeleteProjectLink(channelID, provider, project string) error {
+	_, err := m.conn.Exec("DELETE FROM project_links WHERE channel_id=$1 AND provider=$2 AND project=$3", channelID, provider, project)
+	return err
+}
+
+// CreateUserLink creates user link entry in database
+func (m *Postgres) CreateUserLink(l model.UserLink) (model.UserLink, error) {
+	err := l.Validate()
+	if err != nil {
+		return l, err
+	}
+	err = m.conn.Get(&l.ID,
+		"INSERT INTO user_links (created, slack_user_id, provider, username) VALUES ($1, $2, $3, $4) RETURNING id",
+		time.Now().UTC(), l.SlackUserID, l.Provider, l.Username)
+	if err != nil {
+		return l, err
+	}
+
+	return l, nil
+}
+
+// ListUserLinks returns links of user ordered by provider
+func (m *Postgres) ListUserLinks(slackUserID string) ([]model.UserLink, error) {
+	links := []model.UserLink{}
+	err := m.conn.Select(&links, "SELECT * FROM user_links WHERE slack_user_id=$1 ORDER BY provider", slackUserID)
+	return links, err
+}
+
+// DeleteUserLink deletes link of user to provider
+func (m *Postgres) DeleteUserLink(slackUserID, provider string) error {
+	_, err := m.conn.Exec("DELETE FROM user_links WHERE slack_user_id=$1 AND provider=$2", slackUserID, provider)
+	return err
+}
+
+// CreateAPIToken creates API token entry in database
+func (m *Postgres) CreateAPIToken(t model.APIToken) (model.APIToken, error) {
+	err := t.Validate()
+	if err != nil {
+		return t, err
+	}
+	err = m.conn.Get(&t.ID,
+		"INSERT INTO api_tokens (created, name, token_hash) VALUES ($1, $2, $3) RETURNING id",
+		time.Now().UTC(), t.Name, t.TokenHash)
+	if err != nil {
+		return t, err
+	}
+
+	return t, nil
+}
+
+// SelectAPIToken selects API token by hash of the token
+func (m *Postgres) SelectAPIToken(hash string) (model.APIToken, error) {
+	var t model.APIToken
+	err := m.conn.Get(&t, "SELECT * FROM api_tokens WHERE token_hash=$1", hash)
+	return t, err
+}
+
+// ListAPITokens returns API tokens ordered by name
+func (m *Postgres) ListAPITokens() ([]model.APIToken, error) {
+	tokens := []model.APIToken{}
+	err := m.conn.Select(&tokens, "SELECT * FROM api_tokens ORDER BY name")
+	return tokens, err
+}
+
+// DeleteAPIToken deletes API token by name
+func (m *Postgres) DeleteAPIToken(name string) error {
+	_, err := m.conn.Exec("DELETE FROM api_tokens WHERE name=$1", name)
+	return err
+}
+
+// CreateWebhook creates webhook subscription in database
+func (m *Postgres) CreateWebhook(w model.Webhook) (model.Webhook, error) {
+	err := w.Validate()
+	if err != nil {
+		return w, err
+	}
+	err = m.conn.Get(&w.ID,
+		"INSERT INTO webhooks (created, url, secret, events) VALUES ($1, $2, $3, $4) RETURNING id",
+		time.Now().UTC(), w.URL, w.Secret, w.Events)
+	if err != nil {
+		return w, err
+	}
+
+	return w, nil
+}
+
+// ListWebhooks returns webhook subscriptions ordered by ID
+func (m *Postgres) ListWebhooks() ([]model.Webhook, error) {
+	webhooks := []model.Webhook{}
+	err := m.conn.Select(&webhooks, "SELECT * FROM webhooks ORDER BY id")
+	return webhooks, err
+}
+
+// DeleteWebhook deletes webhook subscription with its dead letters
+func (m *Postgres) DeleteWebhook(id int64) error {
+	if _, err := m.conn.Exec("DELETE FROM webhook_dead_letters WHERE webhook_id=$1", id); err != nil {
+		return err
+	}
+	_, err := m.conn.Exec("DELETE FROM webhooks WHERE id=$1", id)
+	return err
+}
+
+// CreateDeadLetter saves event which webhook did not accept
+func (m *Postgres) CreateDeadLetter(d model.DeadLetter) (model.DeadLetter, error) {
+	err := d.Validate()
+	if err != nil {
+		return d, err
+	}
+	err = m.conn.Get(&d.ID,
+		"INSERT INTO webhook_dead_letters (created, webhook_id, event, payload, attempts, error) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id",
+		time.Now().UTC(), d.WebhookID, d.Event, d.Payload, d.Attempts, d.Error)
+	if err != nil {
+		return d, err
+	}
+
+	return d, nil
+}
+
+// ListDeadLetters returns dead letters of webhook ordered by ID
+func (m *Postgres) ListDeadLetters(webhookID int64) ([]model.DeadLetter, error) {
+	letters := []model.DeadLetter{}
+	err := m.conn.Select(&letters, "SELECT * FROM webhook_dead_letters WHERE webhook_id=$1 ORDER BY id", webhookID)
+	return letters, err
+}
+
+// DeleteDeadLetter deletes dead letter by ID
+func (m *Postgres) DeleteDeadLetter(id int64) error {
+	_, err := m.conn.Exec("DELETE FROM webhook_dead_letters WHERE id=$1", id)
+	return err
+}
+
+// AddToStandupHistory creates backup standup entry in standup_edit_history database
+func (m *Postgres) AddToStandupHistory(s model.StandupEditHistory) (model.StandupEditHistory, error) {
+	err := s.Validate()
+	if err != nil {
+		return s, err
+	}
+	err = m.conn.Get(&s.ID,
+		"INSERT INTO standup_edit_history (created, standup_id, standup_text) VALUES ($1, $2, $3) RETURNING id",
+		time.Now().UTC(), s.StandupID, s.StandupText)
+	if err != nil {
+		return s, err
+	}
+
+	return s, nil
+}
+
+// ListStandupHistory returns previous texts of standup, the oldest first
+func (m *Postgres) ListStandupHistory(standupID int64) ([]model.StandupEditHistory, error) {
+	items := []model.StandupEditHistory{}
+	err := m.conn.Select(&items, "SELECT id, created, standup_id, standup_text AS standuptext FROM standup_edit_history WHERE standup_id=$1 ORDER BY id", standupID)
+	return items, err
+}
+
+// GetAllChannels returns list of unique channels
+func (m *Postgres) GetAllChannels() ([]string, error) {
+	channels := []string{}
+	err := m.conn.Select(&channels, "SELECT DISTINCT channel_id FROM standup_users")
+	return channels, err
+}
+
+// GetUserChannels returns list of user's channels
+func (m *Postgres) GetUserChannels(slackUserID string) ([]string, error) {
+	channels := []string{}
+	err := m.conn.Select(&channels, "SELECT channel_id FROM standup_users where slack_user_id=$1", slackUserID)
+	return channels, err
+}
+
+// ListStandups returns array of standup entries from database
+// Helper function for testing
+func (m *Postgres) ListStandups() ([]model.Standup, error) {
+	items := []model.Standup{}
+	err := m.conn.Select(&items, "SELECT * FROM standup")
+	return items, err
+}
@@ -0,0 +1,875 @@
+package storage
+
+import (
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/maddevsio/comedian/config"
+	"github.com/maddevsio/comedian/model"
+	// This line is must for working SQLite database
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// sqliteMigrations describes SQLite schema. SQLite database is usually a local
+// file without goose migrations run against it, so schema is applied on start
+var sqliteMigrations = []string{
+	`CREATE TABLE standup (
+		id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+		created DATETIME NOT NULL,
+		modified DATETIME NOT NULL,
+		channel_id VARCHAR(255) NOT NULL,
+		username_id VARCHAR(255) NOT NULL,
+		comment TEXT NOT NULL,
+		message_ts VARCHAR(255) NOT NULL
+	);
+	CREATE INDEX standup_created ON standup (created);
+	CREATE TABLE standup_users (
+		id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+		created DATETIME NOT NULL,
+		modified DATETIME NOT NULL,
+		username VARCHAR(255) NOT NULL,
+		channel VARCHAR(255) NOT NULL,
+		channel_id VARCHAR(255) NOT NULL,
+		slack_user_id VARCHAR(255) NOT NULL,
+		role VARCHAR(255) NOT NULL
+	);
+	CREATE TABLE standup_time (
+		id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+		created DATETIME NOT NULL,
+		channel VARCHAR(255) NOT NULL,
+		channel_id VARCHAR(255) NOT NULL,
+		standuptime BIGINT NOT NULL
+	);
+	CREATE TABLE standup_edit_history (
+		id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+		created DATETIME NOT NULL,
+		standup_id INTEGER NOT NULL,
+		standup_text TEXT NOT NULL
+	);`,
+	`ALTER TABLE standup ADD COLUMN platform VARCHAR(32) NOT NULL DEFAULT 'slack';
+	ALTER TABLE standup_users ADD COLUMN platform VARCHAR(32) NOT NULL DEFAULT 'slack';
+	ALTER TABLE standup_time ADD COLUMN platform VARCHAR(32) NOT NULL DEFAULT 'slack';`,
+	`ALTER TABLE standup ADD COLUMN yesterday TEXT NOT NULL DEFAULT '';
+	ALTER TABLE standup ADD COLUMN today TEXT NOT NULL DEFAULT '';
+	ALTER TABLE standup ADD COLUMN problems TEXT NOT NULL DEFAULT '';`,
+	`CREATE TABLE standup_questions (
+		id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+		created DATETIME NOT NULL,
+		channel_id VARCHAR(255) NOT NULL,
+		name VARCHAR(255) NOT NULL,
+		pattern VARCHAR(255) NOT NULL,
+		position INTEGER NOT NULL
+	);`,
+	`ALTER TABLE standup_time ADD COLUMN timezone VARCHAR(64) NOT NULL DEFAULT '';`,
+	`CREATE TABLE holidays (
+		id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+		created DATETIME NOT NULL,
+		channel_id VARCHAR(255) NOT NULL,
+		date VARCHAR(10) NOT NULL,
+		name VARCHAR(255) NOT NULL
+	);
+	ALTER TABLE standup_time ADD COLUMN work_days VARCHAR(32) NOT NULL DEFAULT '';`,
+	`CREATE TABLE absences (
+		id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+		created DATETIME NOT NULL,
+		slack_user_id VARCHAR(255) NOT NULL,
+		date_from VARCHAR(10) NOT NULL,
+		date_to VARCHAR(10) NOT NULL,
+		reason VARCHAR(255) NOT NULL
+	);`,
+	`CREATE TABLE reminder_runs (
+		id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+		created DATETIME NOT NULL,
+		modified DATETIME NOT NULL,
+		channel_id VARCHAR(255) NOT NULL,
+		platform VARCHAR(32) NOT NULL,
+		date VARCHAR(10) NOT NULL,
+		stage VARCHAR(32) NOT NULL,
+		repeats INTEGER NOT NULL,
+		next_at DATETIME NOT NULL,
+		UNIQUE (channel_id, date)
+	);`,
+	`CREATE TABLE locks (
+		name VARCHAR(255) NOT NULL PRIMARY KEY,
+		holder VARCHAR(255) NOT NULL,
+		expires DATETIME NOT NULL
+	);`,
+	`ALTER TABLE standup_time ADD COLUMN schedule VARCHAR(255) NOT NULL DEFAULT '';`,
+	`CREATE TABLE escalation_steps (
+		id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+		created DATETIME NOT NULL,
+		channel_id VARCHAR(255) NOT NULL,
+		action VARCHAR(32) NOT NULL,
+		delay_minutes INTEGER NOT NULL,
+		position INTEGER NOT NULL
+	);`,
+	`CREATE TABLE project_links (
+		id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+		created DATETIME NOT NULL,
+		channel_id VARCHAR(255) NOT NULL,
+		provider VARCHAR(32) NOT NULL,
+		project VARCHAR(255) NOT NULL
+	);
+	CREATE TABLE user_links (
+		id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+		created DATETIME NOT NULL,
+		slack_user_id VARCHAR(255) NOT NULL,
+		provider VARCHAR(32) NOT NULL,
+		username VARCHAR(255) NOT NULL
+	);`,
+	`CREATE TABLE api_tokens (
+		id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+		created DATETIME NOT NULL,
+		name VARCHAR(255) NOT NULL UNIQUE,
+		token_hash VARCHAR(64) NOT NULL UNIQUE
+	);`,
+	`CREATE TABLE webhooks (
+		id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+		created DATETIME NOT NULL,
+		url VARCHAR(2048) NOT NULL,
+		secret VARCHAR(255) NOT NULL,
+		events VARCHAR(1024) NOT NULL DEFAULT ''
+	);`,
+	`CREATE TABLE webhook_dead_letters (
+		id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+		created DATETIME NOT NULL,
+		webhook_id INTEGER NOT NULL,
+		event VARCHAR(255) NOT NULL,
+		payload TEXT NOT NULL,
+		attempts INTEGER NOT NULL DEFAULT 0,
+		error TEXT NOT NULL
+	);`,
+	`ALTER TABLE standup ADD COLUMN answers TEXT NOT NULL DEFAULT '';
+	ALTER TABLE standup_questions ADD COLUMN role VARCHAR(32) NOT NULL DEFAULT '';`,
+}
+
+// SQLite provides api for work with sqlite database
+type SQLite struct {
+	conn *sqlx.DB
+}
+
+// NewSQLite creates a new instance of database API backed by sqlite file
+func NewSQLite(c config.Config) (*SQLite, error) {
+	_, path := splitDatabaseURL(c.DatabaseURL)
+	conn, err := sqlx.Open("sqlite3", path)
+	if err != nil {
+		return nil, err
+	}
+	// sqlite does not support concurrent writers
+	conn.SetMaxOpenConns(1)
+	if err := migrate(conn, sqliteMigrations); err != nil {
+		return nil, err
+	}
+	m := &SQLite{}
+	m.conn = conn
+
+	return m, nil
+}
+
+// CreateStandup creates standup entry in database
+func (m *SQLite) CreateStandup(s model.Standup) (model.Standup, error) {
+	err := s.Validate()
+	if err != nil {
+		return s, err
+	}
+	res, err := m.conn.Exec(
+		"INSERT INTO standup (created, modified, comment, channel_id, username_id, message_ts, platform, yesterday, today, problems, answers) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		time.Now().UTC(), time.Now().UTC(), s.Comment, s.ChannelID, s.UsernameID, s.MessageTS, s.Platform, s.Yesterday, s.Today, s.Problems, s.Answers,
+	)
+	if err != nil {
+		return s, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return s, err
+	}
+	s.ID = id
+
+	return s, nil
+}
+
+// UpdateStandup updates standup entry in database
+func (m *SQLite) UpdateStandup(s model.Standup) (model.Standup, error) {
+	err := s.Validate()
+	if err != nil {
+		return s, err
+	}
+	_, err = m.conn.Exec(
+		"UPDATE standup SET modified=?, username_id=?, comment=?, channel_id=?, message_ts=?, yesterday=?, today=?, problems=?, answers=? WHERE id=?",
+		time.Now().UTC(), s.UsernameID, s.Comment, s.ChannelID, s.MessageTS, s.Yesterday, s.Today, s.Problems, s.Answers, s.ID,
+	)
+	if err != nil {
+		return s, err
+	}
+	var i model.Standup
+	err = m.conn.Get(&i, "SELECT * FROM standup WHERE id=?", s.ID)
+
+	return i, err
+}
+
+// SelectStandupByMessageTS selects standup entry from database filtered by MessageTS parameter
+func (m *SQLite) SelectStandupByMessageTS(messageTS string) (model.Standup, error) {
+	var s model.Standup
+	err := m.conn.Get(&s, "SELECT * FROM standup WHERE message_ts=?", messageTS)
+
+	return s, err
+}
+
+// SelectStandupsByChannelIDForPeriod selects standup entrys by channel ID and time period from database
+func (m *SQLite) SelectStandupsByChannelIDForPeriod(channelID string, dateStart, dateEnd time.Time) ([]model.Standup, error) {
+	items := []model.Standup{}
+	err := m.conn.Select(&items, "SELECT * FROM standup WHERE channel_id=? AND created BETWEEN ? AND ?",
+		channelID, dateStart.UTC(), dateEnd.UTC())
+	return items, err
+}
+
+// SelectStandupsFiltered selects standup entrys by channel ID and time period from database
+func (m *SQLite) SelectStandupsFiltered(slackUserID, channelID string, dateStart, dateEnd time.Time) ([]model.Standup, error) {
+	items := []model.Standup{}
+	err := m.conn.Select(&items, "SELECT * FROM standup WHERE channel_id=? AND username_id =? AND created BETWEEN ? AND ?",
+		channelID, slackUserID, dateStart.UTC(), dateEnd.UTC())
+	return items, err
+}
+
+// SelectStandup selects standup entry by ID from database
+func (m *SQLite) SelectStandup(id int64) (model.Standup, error) {
+	var s model.Standup
+	err := m.conn.Get(&s, "SELECT * FROM standup WHERE id=?", id)
+	return s, err
+}
+
+// DeleteStandup deletes standup entry from database
+func (m *SQLite) DeleteStandup(id int64) error {
+	_, err := m.conn.Exec("DELETE FROM standup WHERE id=?", id)
+	return err
+}
+
+// CreateStandupUser creates comedian entry in database
+func (m *SQLite) CreateStandupUser(s model.StandupUser) (model.StandupUser, error) {
+	err := s.Validate()
+	if err != nil {
+		return s, err
+	}
+	res, err := m.conn.Exec(
+		"INSERT INTO standup_users (created, modified, slack_user_id, username, channel_id, channel, role, platform) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+		time.Now().UTC(), time.Now().UTC(), s.SlackUserID, s.SlackName, s.ChannelID, s.Channel, s.Role, s.Platform)
+	if err != nil {
+		return s, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return s, err
+	}
+	s.ID = id
+
+	return s, nil
+}
+
+// SelectStandupUser selects standupUser entry by ID from database
+func (m *SQLite) SelectStandupUser(id int64) (model.StandupUser, error) {
+	var u model.StandupUser
+	err := m.conn.Get(&u, "SELECT * FROM standup_users WHERE id=?", id)
+	return u, err
+}
+
+// UpdateStandupUser updates name, channel name and role of standupUser in database
+func (m *SQLite) UpdateStandupUser(s model.StandupUser) (model.StandupUser, error) {
+	err := s.Validate()
+	if err != nil {
+		return s, err
+	}
+	_, err = m.conn.Exec(
+		"UPDATE standup_users SET modified=?, username=?, channel=?, role=? WHERE id=?",
+		time.Now().UTC(), s.SlackName, s.Channel, s.Role, s.ID,
+	)
+	if err != nil {
+		return s, err
+	}
+	var u model.StandupUser
+	err = m.conn.Get(&u, "SELECT * FROM standup_users WHERE id=?", s.ID)
+	return u, err
+}
+
+// FindStandupUserInChannelByUserID finds user in channel
+func (m *SQLite) FindStandupUserInChannelByUserID(usernameID, channelID string) (model.StandupUser, error) {
+	var u model.StandupUser
+	err := m.conn.Get(&u, "SELECT * FROM standup_users WHERE slack_user_id=? AND channel_id=?", usernameID, channelID)
+	return u, err
+}
+
+// FindStandupUser finds user in
+func (m *SQLite) FindStandupUser(username string) (model.StandupUser, error) {
+	var u model.StandupUser
+	err := m.conn.Get(&u, "SELECT * FROM standup_users WHERE username=?", username)
+	return u, err
+}
+
+// ListAllStandupUsers returns array of standup entries from database
+func (m *SQLite) ListAllStandupUsers() ([]model.StandupUser, error) {
+	items := []model.StandupUser{}
+	err := m.conn.Select(&items, "SELECT * FROM standup_users where role!='admin'")
+	return items, err
+}
+
+// ListChannelAdmins returns admins of channel
+func (m *SQLite) ListChannelAdmins(channelID string) ([]model.StandupUser, error) {
+	items := []model.StandupUser{}
+	err := m.conn.Select(&items, "SELECT * FROM standup_users where channel_id=? AND role='admin'", channelID)
+	return items, err
+}
+
+// GetNonReporters returns a list of non reporters in selected time period, users on leave are not listed
+func (m *SQLite) GetNonReporters(channelID string, dateFrom, dateTo time.Time) ([]model.StandupUser, error) {
+	from, to := absenceDays(dateFrom, dateTo)
+	nonReporters := []model.StandupUser{}
+	err := m.conn.Select(&nonReporters, `SELECT * FROM standup_users where channel_id=? and role!='admin' AND slack_user_id NOT IN (SELECT username_id FROM standup where channel_id=? and created BETWEEN ? AND ?) AND slack_user_id NOT IN (SELECT slack_user_id FROM absences where date_from<=? AND date_to>=?)`, channelID, channelID, dateFrom.UTC(), dateTo.UTC(), to, from)
+	return nonReporters, err
+}
+
+// IsNonReporter returns true if user did not submit standup in time period, false othervise
+func (m *SQLite) IsNonReporter(slackUserID, channelID string, dateFrom, dateTo time.Time) (bool, error) {
+	var id int
+	err := m.conn.Get(&id, `SELECT id FROM standup where channel_id=? and username_id=? and created between ? and ?`, channelID, slackUserID, dateFrom.UTC(), dateTo.UTC())
+	if err != nil && err.Error() != "sql: no rows in result set" {
+		return true, err
+	}
+	if id != 0 {
+		return false, nil
+	}
+	return true, nil
+}
+
+// HasExistedAlready returns true if user existed already and therefore could submit standup
+func (m *SQLite) HasExistedAlready(slackUserID, channelID string, dateFrom time.Time) (bool, error) {
+	var id int
+	err := m.conn.Get(&id, `SELECT id FROM standup_users where channel_id=? and slack_user_id=? and created <=?`, channelID, slackUserID, dateFrom.UTC())
+	if err != nil && err.Error() != "sql: no rows in result set" {
+		return false, err
+	}
+	if id != 0 {
+		return true, nil
+	}
+	return false, nil
+}
+
+// IsAdmin checks if user in channel is of a role admin
+func (m *SQLite) IsAdmin(slackUserID, channelID string) bool {
+	var u model.StandupUser
+	err := m.conn.Get(&u, `SELECT * FROM standup_users where channel_id = ? and slack_user_id = ? and role = ?`, channelID, slackUserID, "admin")
+	if err != nil {
+		return false
+	}
+	return true
+}
+
+// ListStandupUsersByChannelID returns array of standup entries from database
+func (m *SQLite) ListStandupUsersByChannelID(channelID string) ([]model.StandupUser, error) {
+	items := []model.StandupUser{}
+	err := m.conn.Select(&items, "SELECT * FROM standup_users WHERE channel_id=? AND role!='admin'", channelID)
+	return items, err
+}
+
+// DeleteStandupUser deletes standup_users entry from database
+func (m *SQLite) DeleteStandupUser(username, channelID string) error {
+	_, err := m.conn.Exec("DELETE FROM standup_users WHERE username=? AND channel_id=?", username, channelID)
+	return err
+}
+
+// CreateStandupTime creates time entry in database
+func (m *SQLite) CreateStandupTime(s model.StandupTime) (model.StandupTime, error) {
+	err := s.Validate()
+	if err != nil {
+		return s, err
+	}
+	res, err := m.conn.Exec(
+		"INSERT INTO standup_time (created, channel_id, channel, standuptime, platform, timezone, work_days, schedule) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+		time.Now().UTC(), s.ChannelID, s.Channel, s.Time, s.Platform, s.Timezone, s.WorkDays, s.Schedule)
+	if err != nil {
+		return s, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return s, err
+	}
+	s.ID = id
+
+	return s, nil
+}
+
+// UpdateStandupTime updates standup time, time zone, work days and schedule of channel in database
+func (m *SQLite) UpdateStandupTime(s model.StandupTime) (model.StandupTime, error) {
+	err := s.Validate()
+	if err != nil {
+		return s, err
+	}
+	_, err = m.conn.Exec(
+		"UPDATE standup_time SET standuptime=?, timezone=?, work_days=?, schedule=? WHERE channel_id=?",
+		s.Time, s.Timezone, s.WorkDays, s.Schedule, s.ChannelID)
+	if err != nil {
+		return s, err
+	}
+	return m.GetChannelStandupTime(s.ChannelID)
+}
+
+// GetChannelStandupTime returns standup time entry from database
+func (m *SQLite) GetChannelStandupTime(channelID string) (model.StandupTime, error) {
+	var time model.StandupTime
+	err := m.conn.Get(&time, "SELECT * FROM standup_time WHERE channel_id=?", channelID)
+	return time, err
+}
+
+// ListAllStandupTime returns standup time entry for all channels from database
+func (m *SQLite) ListAllStandupTime() ([]model.StandupTime, error) {
+	reminders := []model.StandupTime{}
+	err := m.conn.Select(&reminders, "SELECT * FROM standup_time")
+	return reminders, err
+}
+
+// CreateHoliday creates holiday entry in database
+func (m *SQLite) CreateHoliday(h model.Holiday) (model.Holiday, error) {
+	err := h.Validate()
+	if err != nil {
+		return h, err
+	}
+	res, err := m.conn.Exec(
+		"INSERT INTO holidays (created, channel_id, date, name) VALUES (?, ?, ?, ?)",
+		time.Now().UTC(), h.ChannelID, h.Date, h.Name)
+	if err != nil {
+		return h, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return h, err
+	}
+	h.ID = id
+
+	return h, nil
+}
+
+// ListHolidays returns holidays of channel ordered by date
+func (m *SQLite) ListHolidays(channelID string) ([]model.Holiday, error) {
+	holidays := []model.Holiday{}
+	err := m.conn.Select(&holidays, "SELECT * FROM holidays WHERE channel_id=? ORDER BY date", channelID)
+	return holidays, err
+}
+
+// DeleteHoliday deletes holiday of channel by date
+func (m *SQLite) DeleteHoliday(channelID, date string) error {
+	_, err := m.conn.Exec("DELETE FROM holidays WHERE channel_id=? AND date=?", channelID, date)
+	return err
+}
+
+// CreateAbsence creates absence entry in database
+func (m *SQLite) CreateAbsence(a model.Absence) (model.Absence, error) {
+	err := a.Validate()
+	if err != nil {
+		return a, err
+	}
+	res, err := m.conn.Exec(
+		"INSERT INTO absences (created, slack_user_id, date_from, date_to, reason) VALUES (?, ?, ?, ?, ?)",
+		time.Now().UTC(), a.SlackUserID, a.DateFrom, a.DateTo, a.Reason)
+	if err != nil {
+		return a, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return a, err
+	}
+	a.ID = id
+
+	return a, nil
+}
+
+// ListAbsences returns absences of user ordered by start date
+func (m *SQLite) ListAbsences(slackUserID string) ([]model.Absence, error) {
+	absences := []model.Absence{}
+	err := m.conn.Select(&absences, "SELECT * FROM absences WHERE slack_user_id=? ORDER BY date_from", slackUserID)
+	return absences, err
+}
+
+// DeleteAbsence deletes absence of user by start date
+func (m *SQLite) DeleteAbsence(slackUserID, dateFrom string) error {
+	_, err := m.conn.Exec("DELETE FROM absences WHERE slack_user_id=? AND date_from=?", slackUserID, dateFrom)
+	return err
+}
+
+// IsAbsent returns true if user is on leave on some day of time period
+func (m *SQLite) IsAbsent(slackUserID string, dateFrom, dateTo time.Time) (bool, error) {
+	from, to := absenceDays(dateFrom, dateTo)
+	var count int
+	err := m.conn.Get(&count, "SELECT COUNT(*) FROM absences WHERE slack_user_id=? AND date_from<=? AND date_to>=?", slackUserID, to, from)
+	return count > 0, err
+}
+
+// CreateReminderRun creates reminder run entry in database
+func (m *SQLite) CreateReminderRun(r model.ReminderRun) (model.ReminderRun, error) {
+	err := r.Validate()
+	if err != nil {
+		return r, err
+	}
+	res, err := m.conn.Exec(
+		"INSERT INTO reminder_runs (created, modified, channel_id, platform, date, stage, repeats, next_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+		time.Now().UTC(), time.Now().UTC(), r.ChannelID, r.Platform, r.Date, r.Stage, r.Repeats, r.NextAt.UTC())
+	if err != nil {
+		return r, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return r, err
+	}
+	r.ID = id
+
+	return r, nil
+}
+
+// GetReminderRun returns reminder run of channel on date
+func (m *SQLite) GetReminderRun(channelID, date string) (model.ReminderRun, error) {
+	var r model.ReminderRun
+	err := m.conn.Get(&r, "SELECT * FROM reminder_runs WHERE channel_id=? AND date=?", channelID, date)
+	return r, err
+}
+
+// ListUnfinishedReminderRuns returns reminder runs which are not done yet
+func (m *SQLite) ListUnfinishedReminderRuns() ([]model.ReminderRun, error) {
+	runs := []model.ReminderRun{}
+	err := m.conn.Select(&runs, "SELECT * FROM reminder_runs WHERE stage!=?", model.ReminderDone)
+	return runs, err
+}
+
+// AdvanceReminderRun moves reminder run from stage and repeats it has in database to the next ones
+func (m *SQLite) AdvanceReminderRun(from, to model.ReminderRun) (bool, error) {
+	res, err := m.conn.Exec(
+		"UPDATE reminder_runs SET modified=?, stage=?, repeats=?, next_at=? WHERE id=? AND stage=? AND repeats=?",
+		time.Now().UTC(), to.Stage, to.Repeats, to.NextAt.UTC(), from.ID, from.Stage, from.Repeats)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+// AcquireLock takes named lock for holder or prolongs it till expiration time
+func (m *SQLite) AcquireLock(name, holder string, expires time.Time) (bool, error) {
+	_, err := m.conn.Exec("UPDATE locks SET holder=?, expires=? WHERE name=? AND (holder=? OR expires<?)",
+		holder, expires.UTC(), name, holder, time.Now().UTC())
+	if err != nil {
+		return false, err
+	}
+	_, err = m.conn.Exec("INSERT OR IGNORE INTO locks (name, holder, expires) VALUES (?, ?, ?)", name, holder, expires.UTC())
+	if err != nil {
+		return false, err
+	}
+	var owner string
+	err = m.conn.Get(&owner, "SELECT holder FROM locks WHERE name=?", name)
+	return owner == holder, err
+}
+
+// ReleaseLock frees named lock if it is owned by holder
+func (m *SQLite) ReleaseLock(name, holder string) error {
+	_, err := m.conn.Exec("DELETE FROM locks WHERE name=? AND holder=?", name, holder)
+	return err
+}
+
+// DeleteStandupTime deletes standup_time entry for channel from database
+func (m *SQLite) DeleteStandupTime(channelID string) error {
+	_, err := m.conn.Exec("DELETE FROM standup_time WHERE channel_id=?", channelID)
+	return err
+}
+
+// CreateStandupQuestion creates standup template question entry in database
+func (m *SQLite) CreateStandupQuestion(q model.StandupQuestion) (model.StandupQuestion, error) {
+	err := q.Validate()
+	if err != nil {
+		return q, err
+	}
+	res, err := m.conn.Exec(
+		"INSERT INTO standup_questions (created, channel_id, name, pattern, position, role) VALUES (?, ?, ?, ?, ?, ?)",
+		time.Now().UTC(), q.ChannelID, q.Name, q.Pattern, q.Position, q.Role)
+	if err != nil {
+		return q, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return q, err
+	}
+	q.ID = id
+
+	return q, nil
+}
+
+// ListStandupQuestions returns standup template questions of channel ordered by position
+func (m *SQLite) ListStandupQuestions(channelID string) ([]model.StandupQuestion, error) {
+	questions := []model.StandupQuestion{}
+	err := m.conn.Select(&questions, "SELECT * FROM standup_questions WHERE channel_id=? ORDER BY position", channelID)
+	return questions, err
+}
+
+// DeleteStandupQuestions deletes standup template questions of channel from database
+func (m *SQLite) DeleteStandupQuestions(channelID string) error {
+	_, err := m.conn.Exec("DELETE FROM standup_questions WHERE channel_id=?", channelID)
+	return err
+}
+
+// CreateEscalationStep creates escalation step entry in database
+func (m *SQLite) CreateEscalationStep(s model.EscalationStep) (model.EscalationStep, error) {
+	err := s.Validate()
+	if err != nil {
+		return s, err
+	}
+	res, err := m.conn.Exec(
+		"INSERT INTO escalation_steps (created, channel_id, action, delay_minutes, position) VALUES (?, ?, ?, ?, ?)",
+		time.Now().UTC(), s.ChannelID, s.Action, s.Delay, s.Position)
+	if err != nil {
+		return s, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return s, err
+	}
+	s.ID = id
+
+	return s, nil
+}
+
+// ListEscalationSteps returns escalation steps of channel ordered by position
+func (m *SQLite) ListEscalationSteps(channelID string) ([]model.EscalationStep, error) {
+	steps := []model.EscalationStep{}
+	err := m.conn.Select(&steps, "SELECT * FROM escalation_steps WHERE channel_id=? ORDER BY position", channelID)
+	return steps, err
+}
+
+// DeleteEscalationSteps deletes escalation steps of channel from database
+func (m *SQLite) DeleteEscalationSteps(channelID string) error {
+	_, err := m.conn.Exec("DELETE FROM escalation_steps WHERE channel_id=?", channelID)
+	return err
+}
+
+// CreateProjectLink creates project link entry in database
+func (m *SQLite) CreateProjectLink(l model.ProjectLink) (model.ProjectLink, error) {
+	err := l.Validate()
+	if err != nil {
+		return l, err
+	}
+	res, err := m.conn.Exec(
+		"INSERT INTO project_links (created, channel_id, provider, project) VALUES (?, ?, ?, ?)",
+		time.Now().UTC(), l.ChannelID, l.Provider, l.Project)
+	if err != nil {
+		return l, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return l, err
+	}
+	l.ID = id
+
+	return l, nil
+}
+
+// ListProjectLinks returns project links of channel ordered by provider and project
+func (m *SQLite) ListProjectLinks(channelID string) ([]model.ProjectLink, error) {
+	links := []model.ProjectLink{}
+	err := m.conn.Select(&links, "SELECT * FROM project_links WHERE channel_id=? ORDER BY provider, project", channelID)
+	return links, err
+}
+
+// DeleteProjectLink deletes link of channel to project of provider
+func (m *SQLite) DeleteProjectLink(channelID, provider, project string) error {
+	_, err := m.conn.Exec("DELETE FROM project_links WHERE channel_id=? AND provider=? AND project=?", channelID, provider, project)
+	return err
+}
+
+// CreateUserLink creates user link entry in database
+func (m *SQLite) CreateUserLink(l model.UserLink) (model.UserLink, error) {
+	err := l.Validate()
+	if err != nil {
+		return l, err
+	}
+	res, err := m.conn.Exec(
+		"INSERT INTO user_links (created, slack_user_id, provider, username) VALUES (?, ?, ?, ?)",
+		time.Now().UTC(), l.SlackUserID, l.Provider, l.Username)
+	if err != nil {
+		return l, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return l, err
+	}
+	l.ID = id
+
+	return l, nil
+}
+
+// ListUserLinks returns links of user ordered by provider
+func (m *SQLite) ListUserLinks(slackUserID string) ([]model.UserLink, error) {
+	links := []model.UserLink{}
+	err := m.conn.Select(&links, "SELECT * FROM user_links WHERE slack_user_id=? ORDER BY provider", slackUserID)
+	return links, err
+}
+
+// DeleteUserLink deletes link of user to provider
+func (m *SQLite) DeleteUserLink(slackUserID, provider string) error {
+	_, err := m.conn.Exec("DELETE FROM user_links WHERE slack_user_id=? AND provider=?", slackUserID, provider)
+	return err
+}
+
+// CreateAPIToken creates API token entry in database
+func (m *SQLite) CreateAPIToken(t model.APIToken) (model.APIToken, error) {
+	err := t.Validate()
+	if err != nil {
+		return t, err
+	}
+	res, err := m.conn.Exec(
+		"INSERT INTO api_tokens (created, name, token_hash) VALUES (?, ?, ?)",
+		time.Now().UTC(), t.Name, t.TokenHash)
+	if err != nil {
+		return t, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return t, err
+	}
+	t.ID = id
+
+	return t, nil
+}
+
+// SelectAPIToken selects API token by hash of the token
+func (m *SQLite) SelectAPIToken(hash string) (model.APIToken, error) {
+	var t model.APIToken
+	err := m.conn.Get(&t, "SELECT * FROM api_tokens WHERE token_hash=?", hash)
+	return t, err
+}
+
+// ListAPITokens returns API tokens ordered by name
+func (m *SQLite) ListAPITokens() ([]model.APIToken, error) {
+	tokens := []model.APIToken{}
+	err := m.conn.Select(&tokens, "SELECT * FROM api_tokens ORDER BY name")
+	return tokens, err
+}
+
+// DeleteAPIToken deletes API token by name
+func (m *SQLite) DeleteAPIToken(name string) error {
+	_, err := m.conn.Exec("DELETE FROM api_tokens WHERE name=?", name)
+	return err
+}
+
+// CreateWebhook creates webhook subscription in database
+func (m *SQLite) CreateWebhook(w model.Webhook) (model.Webhook, error) {
+	err := w.Validate()
+	if err != nil {
+		return w, err
+	}
+	res, err := m.conn.Exec(
+		"INSERT INTO webhooks (created, url, secret, events) VALUES (?, ?, ?, ?)",
+		time.Now().UTC(), w.URL, w.Secret, w.Events)
+	if err != nil {
+		return w, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return w, err
+	}
+	w.ID = id
+
+	return w, nil
+}
+
+// ListWebhooks returns webhook subscriptions ordered by ID
+func (m *SQLite) ListWebhooks() ([]model.Webhook, error) {
+	webhooks := []model.Webhook{}
+	err := m.conn.Select(&webhooks, "SELECT * FROM webhooks ORDER BY id")
+	return webhooks, err
+}
+
+// DeleteWebhook deletes webhook subscription with its dead letters
+func (m *SQLite) DeleteWebhook(id int64) error {
+	if _, err := m.conn.Exec("DELETE FROM webhook_dead_letters WHERE webhook_id=?", id); err != nil {
+		return err
+	}
+	_, err := m.conn.Exec("DELETE FROM webhooks WHERE id=?", id)
+	return err
+}
+
+// CreateDeadLetter saves event which webhook did not accept
+func (m *SQLite) CreateDeadLetter(d model.DeadLetter) (model.DeadLetter, error) {
+	err := d.Validate()
+	if err != nil {
+		return d, err
+	}
+	res, err := m.conn.Exec(
+		"INSERT INTO webhook_dead_letters (created, webhook_id, event, payload, attempts, error) VALUES (?, ?, ?, ?, ?, ?)",
+		time.Now().UTC(), d.WebhookID, d.Event, d.Payload, d.Attempts, d.Error)
+	if err != nil {
+		return d, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return d, err
+	}
+	d.ID = id
+
+	return d, nil
+}
+
+// ListDeadLetters returns dead letters of webhook ordered by ID
+func (m *SQLite) ListDeadLetters(webhookID int64) ([]model.DeadLetter, error) {
+	letters := []model.DeadLetter{}
+	err := m.conn.Select(&letters, "SELECT * FROM webhook_dead_letters WHERE webhook_id=? ORDER BY id", webhookID)
+	return letters, err
+}
+
+// DeleteDeadLetter deletes dead letter by ID
+func (m *SQLite) DeleteDeadLetter(id int64) error {
+	_, err := m.conn.Exec("DELETE FROM webhook_dead_letters WHERE id=?", id)
+	return err
+}
+
+// AddToStandupHistory creates backup standup entry in standup_edit_history database
+func (m *SQLite) AddToStandupHistory(s model.StandupEditHistory) (model.StandupEditHistory, error) {
+	err := s.Validate()
+	if err != nil {
+		return s, err
+	}
+	res, err := m.conn.Exec(
+		"INSERT INTO standup_edit_history (created, standup_id, standup_text) VALUES (?, ?, ?)",
+		time.Now().UTC(), s.StandupID, s.StandupText)
+	if err != nil {
+		return s, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return s, err
+	}
+	s.ID = id
+
+	return s, nil
+}
+
+// ListStandupHistory returns previous texts of standup, the oldest first
+func (m *SQLite) ListStandupHistory(standupID int64) ([]model.StandupEditHistory, error) {
+	items := []model.StandupEditHistory{}
+	err := m.conn.Select(&items, "SELECT id, created, standup_id, standup_text AS standuptext FROM standup_edit_history WHERE standup_id=? ORDER BY id", standupID)
+	return items, err
+}
+
+// GetAllChannels returns list of unique channels
+func (m *SQLite) GetAllChannels() ([]string, error) {
+	channels := []string{}
+	err := m.conn.Select(&channels, "SELECT DISTINCT channel_id FROM standup_users")
+	return channels, err
+}
+
+// GetUserChannels returns list of user's channels
+func (m *SQLite) GetUserChannels(slackUserID string) ([]string, error) {
+	channels := []string{}
+	err := m.conn.Select(&channels, "SELECT channel_id FROM standup_users where slack_user_id=?", slackUserID)
+	return channels, err
+}
+
+// ListStandups returns array of standup entries from database
+// Helper function for testing
+func (m *SQLite) ListStandups() ([]model.Standup, error) {
+	items := []model.Standup{}
+	err := m.conn.Select(&items, "SELECT * FROM standup")
+	return items, err
+}
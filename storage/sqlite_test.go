@@ -0,0 +1,129 @@
+package storage
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/maddevsio/comedian/config"
+	"github.com/maddevsio/comedian/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestSQLite(t *testing.T) (*SQLite, func()) {
+	dir, err := ioutil.TempDir("", "comedian")
+	assert.NoError(t, err)
+	db, err := NewSQLite(config.Config{DatabaseURL: "sqlite://" + filepath.Join(dir, "comedian.db")})
+	assert.NoError(t, err)
+	return db, func() { os.RemoveAll(dir) }
+}
+
+func TestSQLiteMigrateTwice(t *testing.T) {
+	dir, err := ioutil.TempDir("", "comedian")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	c := config.Config{DatabaseURL: "sqlite://" + filepath.Join(dir, "comedian.db")}
+
+	db, err := NewSQLite(c)
+	assert.NoError(t, err)
+	_, err = db.CreateStandupTime(model.StandupTime{ChannelID: "chanid", Channel: "chanName", Time: 12})
+	assert.NoError(t, err)
+
+	// reopening the same file must keep data and skip applied migrations
+	db, err = NewSQLite(c)
+	assert.NoError(t, err)
+	st, err := db.GetChannelStandupTime("chanid")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(12), st.Time)
+}
+
+func TestSQLiteStandup(t *testing.T) {
+	db, cleanup := newTestSQLite(t)
+	defer cleanup()
+
+	s, err := db.CreateStandup(model.Standup{
+		ChannelID:  "QWERTY123",
+		Comment:    "work hard",
+		UsernameID: "userID1",
+		MessageTS:  "qweasdzxc",
+	})
+	assert.NoError(t, err)
+	assert.NotEqual(t, int64(0), s.ID)
+
+	_, err = db.CreateStandup(model.Standup{ChannelID: "QWERTY123", UsernameID: "userID1"})
+	assert.Error(t, err)
+
+	_, err = db.AddToStandupHistory(model.StandupEditHistory{StandupID: s.ID, StandupText: s.Comment})
+	assert.NoError(t, err)
+
+	sps, err := db.SelectStandupsFiltered("userID1", "QWERTY123", time.Now().AddDate(0, 0, -1), time.Now().AddDate(0, 0, 1))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(sps))
+
+	sps, err = db.SelectStandupsByChannelIDForPeriod("QWERTY123", time.Now().AddDate(0, 0, -2), time.Now().AddDate(0, 0, -1))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(sps))
+
+	s.Comment = "Rest"
+	s, err = db.UpdateStandup(s)
+	assert.NoError(t, err)
+	assert.Equal(t, "Rest", s.Comment)
+
+	selected, err := db.SelectStandupByMessageTS("qweasdzxc")
+	assert.NoError(t, err)
+	assert.Equal(t, s.ID, selected.ID)
+
+	assert.NoError(t, db.DeleteStandup(s.ID))
+	items, err := db.ListStandups()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(items))
+}
+
+func TestSQLiteStandupUser(t *testing.T) {
+	db, cleanup := newTestSQLite(t)
+	defer cleanup()
+
+	su1, err := db.CreateStandupUser(model.StandupUser{SlackUserID: "userID1", SlackName: "user1", ChannelID: "123qwe", Channel: "channel1", Role: "user"})
+	assert.NoError(t, err)
+	su2, err := db.CreateStandupUser(model.StandupUser{SlackUserID: "userID2", SlackName: "user2", ChannelID: "qwe123", Channel: "channel2", Role: "admin"})
+	assert.NoError(t, err)
+	_, err = db.CreateStandupUser(model.StandupUser{SlackUserID: "userID3", SlackName: "user3", ChannelID: "123qwe", Channel: "channel1"})
+	assert.NoError(t, err)
+
+	assert.False(t, db.IsAdmin(su1.SlackUserID, su1.ChannelID))
+	assert.True(t, db.IsAdmin(su2.SlackUserID, su2.ChannelID))
+
+	_, err = db.CreateStandup(model.Standup{ChannelID: "123qwe", UsernameID: "userID1", Comment: "yesterday, today, problems", MessageTS: "1"})
+	assert.NoError(t, err)
+
+	nonReporters, err := db.GetNonReporters("123qwe", time.Now().AddDate(0, 0, -1), time.Now().AddDate(0, 0, 1))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(nonReporters))
+	assert.Equal(t, "userID3", nonReporters[0].SlackUserID)
+
+	isNonReporter, err := db.IsNonReporter("userID1", "123qwe", time.Now().AddDate(0, 0, -1), time.Now().AddDate(0, 0, 1))
+	assert.NoError(t, err)
+	assert.False(t, isNonReporter)
+
+	existed, err := db.HasExistedAlready("userID1", "123qwe", time.Now().AddDate(0, 0, 1))
+	assert.NoError(t, err)
+	assert.True(t, existed)
+	existed, err = db.HasExistedAlready("userID1", "123qwe", time.Now().AddDate(0, 0, -1))
+	assert.NoError(t, err)
+	assert.False(t, existed)
+
+	users, err := db.ListAllStandupUsers()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(users))
+
+	channels, err := db.GetAllChannels()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(channels))
+
+	assert.NoError(t, db.DeleteStandupUser("user1", "123qwe"))
+	users, err = db.ListStandupUsersByChannelID("123qwe")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(users))
+}
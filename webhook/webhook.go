@@ -0,0 +1,254 @@
+// Package webhook posts signed JSON events of standup lifecycle to subscribed URLs
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/maddevsio/comedian/config"
+	"github.com/maddevsio/comedian/model"
+	"github.com/maddevsio/comedian/storage"
+	"github.com/sirupsen/logrus"
+)
+
+// Events webhooks may subscribe to
+const (
+	StandupCreated = "standup.created"
+	StandupUpdated = "standup.updated"
+	StandupMissed  = "standup.missed"
+	RookReported   = "rook.reported"
+)
+
+// Events lists all events, a webhook without events receives all of them
+var Events = []string{StandupCreated, StandupUpdated, StandupMissed, RookReported}
+
+// sleep is replaced in tests
+var sleep = time.Sleep
+
+type (
+	// Event is the body of webhook requests. ID is the same in all attempts to deliver
+	// the event, so receivers can skip events they already handled
+	Event struct {
+		ID      string      `json:"id"`
+		Event   string      `json:"event"`
+		Created time.Time   `json:"created"`
+		Data    interface{} `json:"data"`
+	}
+
+	// Missed is data of standup.missed event, Date is the day of channel standup
+	Missed struct {
+		UserID    string `json:"userId"`
+		UserName  string `json:"userName"`
+		ChannelID string `json:"channelId"`
+		Channel   string `json:"channel"`
+		Platform  string `json:"platform"`
+		Date      string `json:"date"`
+	}
+
+	// Rook is data of rook.reported event, Worklogs are in hours
+	Rook struct {
+		UserID    string `json:"userId"`
+		ChannelID string `json:"channelId"`
+		Worklogs  int    `json:"worklogs"`
+		Commits   int    `json:"commits"`
+		Standup   bool   `json:"standup"`
+	}
+)
+
+// Dispatcher delivers events to webhooks kept in database. Failed requests are retried
+// Attempts times waiting Delay before the first retry and twice as long before every next one,
+// events not delivered after all attempts are saved as dead letters
+type Dispatcher struct {
+	DB       storage.Storage
+	HTTP     *http.Client
+	Attempts int
+	Delay    time.Duration
+	wg       sync.WaitGroup
+}
+
+// New creates dispatcher from config
+func New(c config.Config, db storage.Storage) *Dispatcher {
+	attempts := c.WebhookAttempts
+	if attempts < 1 {
+		attempts = 1
+	}
+	return &Dispatcher{
+		DB:       db,
+		HTTP:     &http.Client{Timeout: time.Duration(c.WebhookTimeout) * time.Second},
+		Attempts: attempts,
+		Delay:    time.Duration(c.WebhookRetryDelay) * time.Second,
+	}
+}
+
+// Publish sends event with data to webhooks subscribed to it in background.
+// Nil dispatcher publishes nothing
+func (d *Dispatcher) Publish(event string, data interface{}) {
+	if d == nil {
+		return
+	}
+	webhooks, err := d.DB.ListWebhooks()
+	if err != nil {
+		logrus.Errorf("webhook: ListWebhooks failed: %v\n", err)
+		return
+	}
+	var payload []byte
+	for _, w := range webhooks {
+		if !Subscribed(w, event) {
+			continue
+		}
+		if payload == nil {
+			id, err := newID()
+			if err != nil {
+				logrus.Errorf("webhook: newID failed: %v\n", err)
+				return
+			}
+			payload, err = json.Marshal(Event{ID: id, Event: event, Created: time.Now().UTC(), Data: data})
+			if err != nil {
+				logrus.Errorf("webhook: Marshal failed: %v\n", err)
+				return
+			}
+		}
+		d.wg.Add(1)
+		go func(w model.Webhook) {
+			defer d.wg.Done()
+			d.deliver(w, event, payload)
+		}(w)
+	}
+}
+
+// Redeliver sends dead letters of webhook once more in background and returns their number.
+// Letters are removed and saved again if webhook still does not accept them
+func (d *Dispatcher) Redeliver(w model.Webhook) (int, error) {
+	letters, err := d.DB.ListDeadLetters(w.ID)
+	if err != nil {
+		logrus.Errorf("webhook: ListDeadLetters failed: %v\n", err)
+		return 0, err
+	}
+	for _, l := range letters {
+		if err := d.DB.DeleteDeadLetter(l.ID); err != nil {
+			logrus.Errorf("webhook: DeleteDeadLetter failed: %v\n", err)
+			return 0, err
+		}
+		d.wg.Add(1)
+		go func(l model.DeadLetter) {
+			defer d.wg.Done()
+			d.deliver(w, l.Event, []byte(l.Payload))
+		}(l)
+	}
+	return len(letters), nil
+}
+
+// Wait waits until deliveries in progress succeed or become dead letters
+func (d *Dispatcher) Wait() {
+	d.wg.Wait()
+}
+
+// deliver posts payload to webhook until it is accepted or attempts are over
+func (d *Dispatcher) deliver(w model.Webhook, event string, payload []byte) {
+	delay := d.Delay
+	var err error
+	for attempt := 1; attempt <= d.Attempts; attempt++ {
+		if err = d.post(w, event, payload); err == nil {
+			logrus.Infof("webhook: %v delivered to %v\n", event, w.URL)
+			return
+		}
+		logrus.Warningf("webhook: attempt %v to deliver %v to %v failed: %v\n", attempt, event, w.URL, err)
+		if attempt < d.Attempts {
+			sleep(delay)
+			delay *= 2
+		}
+	}
+	_, err = d.DB.CreateDeadLetter(model.DeadLetter{
+		WebhookID: w.ID,
+		Event:     event,
+		Payload:   string(payload),
+		Attempts:  d.Attempts,
+		Error:     err.Error(),
+	})
+	if err != nil {
+		logrus.Errorf("webhook: CreateDeadLetter failed: %v\n", err)
+	}
+}
+
+// post sends signed payload, any status other than 2xx is an error
+func (d *Dispatcher) post(w model.Webhook, event string, payload []byte) error {
+	req, err := http.NewRequest("POST", w.URL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Comedian-Event", event)
+	req.Header.Set("X-Comedian-Request-Timestamp", timestamp)
+	req.Header.Set("X-Comedian-Signature", Signature(w.Secret, timestamp, payload))
+	res, err := d.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	io.Copy(ioutil.Discard, res.Body)
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("status %v", res.StatusCode)
+	}
+	return nil
+}
+
+// Signature calculates X-Comedian-Signature of body, it is made the same way as
+// Slack signs its requests, so receivers can check it like Slack signatures
+func Signature(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "v0:%s:%s", timestamp, body)
+	return "v0=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Subscribed tells if webhook receives event
+func Subscribed(w model.Webhook, event string) bool {
+	if w.Events == "" {
+		return true
+	}
+	for _, e := range strings.Split(w.Events, ",") {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseEvents checks event names and joins them for Webhook.Events
+func ParseEvents(events []string) (string, error) {
+	for _, e := range events {
+		known := false
+		for _, name := range Events {
+			known = known || e == name
+		}
+		if !known {
+			return "", fmt.Errorf("unknown event %v, events are %v", e, strings.Join(Events, ", "))
+		}
+	}
+	return strings.Join(events, ","), nil
+}
+
+// NewSecret returns a random secret to sign payloads
+func NewSecret() (string, error) {
+	return newID()
+}
+
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
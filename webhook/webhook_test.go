@@ -0,0 +1,157 @@
+package webhook
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/maddevsio/comedian/config"
+	"github.com/maddevsio/comedian/model"
+	"github.com/maddevsio/comedian/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// receiver answers with statuses in turn and records accepted events
+type receiver struct {
+	mu       sync.Mutex
+	statuses []int
+	requests int
+	events   []Event
+	headers  []http.Header
+	bodies   [][]byte
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	body, _ := ioutil.ReadAll(req.Body)
+	status := http.StatusOK
+	if r.requests < len(r.statuses) {
+		status = r.statuses[r.requests]
+	}
+	r.requests++
+	if status == http.StatusOK {
+		var e Event
+		json.Unmarshal(body, &e)
+		r.events = append(r.events, e)
+		r.headers = append(r.headers, req.Header)
+		r.bodies = append(r.bodies, body)
+	}
+	w.WriteHeader(status)
+}
+
+func newDispatcher(t *testing.T) (*Dispatcher, storage.Storage, *[]time.Duration) {
+	db := storage.NewMemory()
+	d := New(config.Config{WebhookTimeout: 5, WebhookAttempts: 3, WebhookRetryDelay: 10}, db)
+	waits := &[]time.Duration{}
+	var mu sync.Mutex
+	sleep = func(d time.Duration) {
+		mu.Lock()
+		defer mu.Unlock()
+		*waits = append(*waits, d)
+	}
+	t.Cleanup(func() { sleep = time.Sleep })
+	return d, db, waits
+}
+
+func TestPublish(t *testing.T) {
+	d, db, waits := newDispatcher(t)
+	all := &receiver{}
+	created := &receiver{}
+	allServer := httptest.NewServer(all)
+	defer allServer.Close()
+	createdServer := httptest.NewServer(created)
+	defer createdServer.Close()
+	_, err := db.CreateWebhook(model.Webhook{URL: allServer.URL, Secret: "secret1"})
+	require.NoError(t, err)
+	_, err = db.CreateWebhook(model.Webhook{URL: createdServer.URL, Secret: "secret2", Events: StandupCreated})
+	require.NoError(t, err)
+
+	d.Publish(StandupCreated, model.Standup{ChannelID: "C1", UsernameID: "U1", Comment: "standup"})
+	d.Publish(StandupMissed, Missed{UserID: "U2", ChannelID: "C1", Date: "2018-01-02"})
+	d.Wait()
+
+	assert.Equal(t, 2, len(all.events))
+	require.Equal(t, 1, len(created.events))
+	e := created.events[0]
+	assert.Equal(t, StandupCreated, e.Event)
+	assert.Equal(t, 32, len(e.ID))
+	assert.Equal(t, "C1", e.Data.(map[string]interface{})["channelId"])
+	assert.Equal(t, StandupCreated, created.headers[0].Get("X-Comedian-Event"))
+	assert.Equal(t, "application/json", created.headers[0].Get("Content-Type"))
+	timestamp := created.headers[0].Get("X-Comedian-Request-Timestamp")
+	assert.Equal(t, Signature("secret2", timestamp, created.bodies[0]), created.headers[0].Get("X-Comedian-Signature"))
+	assert.NotEqual(t, Signature("secret1", timestamp, created.bodies[0]), created.headers[0].Get("X-Comedian-Signature"))
+	assert.Equal(t, 0, len(*waits))
+}
+
+func TestRetriesAndDeadLetters(t *testing.T) {
+	d, db, waits := newDispatcher(t)
+	flaky := &receiver{statuses: []int{http.StatusInternalServerError, http.StatusBadGateway}}
+	server := httptest.NewServer(flaky)
+	defer server.Close()
+	w, err := db.CreateWebhook(model.Webhook{URL: server.URL, Secret: "secret"})
+	require.NoError(t, err)
+
+	// the third attempt succeeds
+	d.Publish(RookReported, Rook{UserID: "U1", ChannelID: "C1", Worklogs: 3})
+	d.Wait()
+	assert.Equal(t, 3, flaky.requests)
+	assert.Equal(t, 1, len(flaky.events))
+	assert.Equal(t, []time.Duration{10 * time.Second, 20 * time.Second}, *waits)
+	letters, err := db.ListDeadLetters(w.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 0, len(letters))
+
+	// all attempts fail
+	flaky.statuses = []int{http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusServiceUnavailable}
+	flaky.requests = 0
+	d.Publish(StandupUpdated, model.Standup{ChannelID: "C1", Comment: "edited"})
+	d.Wait()
+	assert.Equal(t, 3, flaky.requests)
+	letters, err = db.ListDeadLetters(w.ID)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(letters))
+	assert.Equal(t, StandupUpdated, letters[0].Event)
+	assert.Equal(t, 3, letters[0].Attempts)
+	assert.Equal(t, "status 503", letters[0].Error)
+
+	// webhook is fixed and dead letters are sent again with the same event ID
+	var failed Event
+	require.NoError(t, json.Unmarshal([]byte(letters[0].Payload), &failed))
+	n, err := d.Redeliver(w)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	d.Wait()
+	assert.Equal(t, 2, len(flaky.events))
+	assert.Equal(t, failed.ID, flaky.events[1].ID)
+	letters, err = db.ListDeadLetters(w.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 0, len(letters))
+}
+
+func TestNilDispatcher(t *testing.T) {
+	var d *Dispatcher
+	d.Publish(StandupCreated, model.Standup{})
+}
+
+func TestParseEvents(t *testing.T) {
+	events, err := ParseEvents([]string{StandupCreated, RookReported})
+	assert.NoError(t, err)
+	assert.Equal(t, "standup.created,rook.reported", events)
+	events, err = ParseEvents(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "", events)
+	_, err = ParseEvents([]string{"standup.deleted"})
+	assert.Error(t, err)
+
+	assert.True(t, Subscribed(model.Webhook{}, StandupMissed))
+	assert.True(t, Subscribed(model.Webhook{Events: events}, StandupMissed))
+	assert.True(t, Subscribed(model.Webhook{Events: "standup.created,standup.missed"}, StandupMissed))
+	assert.False(t, Subscribed(model.Webhook{Events: "standup.created"}, StandupMissed))
+}